@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"dklbreitling/goDNS/internal/config"
+)
+
+// settingFlags maps the command-line flags that override configuration
+// keys to those keys
+var settingFlags = map[string]string{
+	"server":    "name_server",
+	"protocol":  "protocol",
+	"timeout":   "timeout",
+	"dnssec":    "dnssec",
+	"hosts":     "hosts_file",
+	"debug":     "debug",
+	"dump":      "dump_files",
+	"dump-dir":  "dump_dir",
+	"log-level": "log_level",
+}
+
+// defineSettingFlags defines the flags of settingFlags with the defaults
+// of config.DefaultConfig. Their values are read back with flag.Visit.
+func defineSettingFlags() {
+	defaults := config.DefaultConfig()
+	flag.String("server", defaults.NameServer, "name server `address` (host:port)")
+	flag.String("protocol", defaults.Protocol, "transport protocol, udp or tcp")
+	flag.Duration("timeout", defaults.Timeout, "query timeout")
+	flag.Bool("dnssec", defaults.DNSSEC, "request DNSSEC records and validate the response")
+	flag.String("hosts", "", "answer from a hosts `file` before querying, such as "+config.DefaultHostsPath)
+	flag.Bool("debug", defaults.Debug, "log at debug level with source locations and dump each query and response to stderr")
+	flag.Bool("dump", defaults.DumpFiles, "write annotated hex dumps of each query and response to files")
+	flag.String("dump-dir", defaults.DumpDir, "`directory` of the dump files (default the working directory)")
+	flag.String("log-level", defaults.LogLevel, "log `level`: debug, info, warn or error")
+}
+
+// loadConfig builds the configuration from, in increasing precedence, the
+// defaults, the configuration file, GODNS_* environment variables, a
+// resolv.conf file and the flags set on the command line
+func loadConfig(path, resolvConf string) (*config.Config, error) {
+	loader := config.NewLoader()
+	if path != "" {
+		if err := loader.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := loader.LoadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if resolvConf != "" {
+		if err := loader.LoadResolvConf(resolvConf); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := settingFlags[f.Name]; ok && err == nil {
+			err = loader.Set(key, f.Value.String(), "flag -"+f.Name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loader.Config()
+}
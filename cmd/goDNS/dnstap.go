@@ -0,0 +1,18 @@
+package main
+
+import (
+	"dklbreitling/goDNS/pkg/client"
+	"dklbreitling/goDNS/pkg/dnstap"
+)
+
+// startDnstap makes the client log its queries and responses to a dnstap
+// file or unix:/path socket and returns the function ending the stream
+func startDnstap(dnsClient *client.Client, target string) (func(), error) {
+	logger, err := dnstap.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	logger.Version = []byte("goDNS")
+	dnsClient.SetDnstap(logger)
+	return func() { logger.Close() }, nil
+}
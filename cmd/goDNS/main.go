@@ -2,6 +2,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
@@ -9,20 +11,60 @@ import (
 	"dklbreitling/goDNS/internal/config"
 	"dklbreitling/goDNS/pkg/client"
 	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnssec"
 )
 
 // main is the entry point for the goDNS application
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
-	cfg := config.DefaultConfig()
 
-	if len(os.Args) < 2 {
-		logger.Error("Usage: goDNS <domain>")
+	// Zone tools are subcommands; anything else is a query
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "keygen":
+			run = runKeygen
+		case "sign":
+			run = runSign
+		case "pcap":
+			run = runPcap
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				logger.Error("Command failed", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	reverse := flag.Bool("x", false, "reverse lookup of an IP address or CIDR range")
+	capture := flag.String("pcap", "", "write the exchanged packets to a pcap `file`")
+	tap := flag.String("dnstap", "", "log queries and responses to a dnstap `file` or unix:/path socket")
+	trace := flag.Bool("trace", false, "print the timing of dialing, writing and reading for the query to stderr")
+	metricsAddr := flag.String("metrics", "", "serve Prometheus metrics at /metrics on `addr` after the query, until interrupted")
+	configFile := flag.String("config", os.Getenv(config.EnvPrefix+"CONFIG"), "configuration `file` (JSON or key = value lines)")
+	resolvConf := flag.String("resolvconf", "", "use the name servers, search list and options of a resolv.conf `file`, such as "+config.DefaultResolvConfPath)
+	defineSettingFlags()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: goDNS [flags] <domain>\n       goDNS -x <address|prefix>\n       goDNS keygen|sign|pcap [flags] ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	domain := os.Args[1]
-	
+	domain := flag.Arg(0)
+	cfg, err := loadConfig(*configFile, *resolvConf)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger = cfg.NewLogger(os.Stderr)
+
 	// Create DNS client
 	dnsClient, err := client.New(cfg, logger)
 	if err != nil {
@@ -30,12 +72,84 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Exit once the capture and dnstap outputs are closed by the deferred
+	// calls below
+	exitCode := 0
+	defer func() { os.Exit(exitCode) }()
+
+	// The metrics are served last, once the outputs below are closed
+	if *metricsAddr != "" {
+		serveMetrics, err := startMetrics(dnsClient, *metricsAddr, logger)
+		if err != nil {
+			logger.Error("Failed to listen for metrics", "error", err)
+			exitCode = 1
+			return
+		}
+		defer serveMetrics()
+	}
+
+	if *capture != "" {
+		closeCapture, err := startCapture(dnsClient, *capture)
+		if err != nil {
+			logger.Error("Failed to create capture file", "error", err)
+			exitCode = 1
+			return
+		}
+		defer closeCapture()
+	}
+	if *tap != "" {
+		closeDnstap, err := startDnstap(dnsClient, *tap)
+		if err != nil {
+			logger.Error("Failed to open dnstap output", "error", err)
+			exitCode = 1
+			return
+		}
+		defer closeDnstap()
+	}
+
+	if *reverse {
+		if err := runReverse(dnsClient, domain); err != nil {
+			logger.Error("Reverse lookup failed", "error", err)
+			exitCode = 1
+		}
+		return
+	}
+
 	// Query for A records
-	result, err := dnsClient.Query(domain, dns.TypeA)
+	ctx := context.Background()
+	if *trace {
+		ctx = client.WithTrace(ctx, newTimingTrace(os.Stderr))
+	}
+	result, err := dnsClient.QueryContext(ctx, domain, dns.TypeA)
 	if err != nil {
 		logger.Error("DNS query failed", "error", err)
-		os.Exit(1)
+		exitCode = 1
+		return
 	}
 
 	fmt.Println("DNS Query Result:\n", result.String())
+
+	if cfg.DNSSEC {
+		if err := printValidation(dnsClient, cfg.TrustAnchors, result); err != nil {
+			logger.Error("Invalid trust anchors", "error", err)
+			exitCode = 1
+		}
+	}
+}
+
+// printValidation validates the response from the root trust anchor and
+// prints its DNSSEC status. An error is returned for invalid trust
+// anchors.
+func printValidation(dnsClient *client.Client, trustAnchors []string, result *dns.Message) error {
+	anchors, err := dnssec.ParseTrustAnchors(trustAnchors)
+	if err != nil {
+		return err
+	}
+
+	validation := dnssec.NewChainValidator(dnsClient, anchors).Validate(result)
+	fmt.Println("DNSSEC:", validation.Status)
+	if err := validation.Err(); err != nil {
+		fmt.Println("DNSSEC error:", err)
+	}
+	return nil
 }
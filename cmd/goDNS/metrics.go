@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"dklbreitling/goDNS/pkg/client"
+	"dklbreitling/goDNS/pkg/metrics"
+)
+
+// startMetrics makes the client record its metrics and serves them at
+// /metrics on addr. The returned function keeps serving until the process
+// is interrupted, so that the metrics of the query can be scraped, then
+// stops the server.
+func startMetrics(dnsClient *client.Client, addr string, logger *slog.Logger) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := metrics.NewRegistry()
+	dnsClient.SetMetrics(client.NewMetrics(registry))
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	return func() {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		logger.Info("Serving metrics until interrupted", "address", "http://"+listener.Addr().String()+"/metrics")
+		<-ctx.Done()
+		server.Close()
+	}, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/client"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/pcap"
+)
+
+// runPcap prints the DNS messages of a pcap or pcapng capture that match
+// the filter flags, optionally writing them to a new capture
+func runPcap(args []string) error {
+	fs := flag.NewFlagSet("pcap", flag.ExitOnError)
+	port := fs.Uint("port", pcap.DefaultPort, "DNS server port")
+	name := fs.String("name", "", "only messages asking for this `domain` or a subdomain")
+	qtype := fs.String("type", "", "only messages asking for this record `type`")
+	rcode := fs.String("rcode", "", "only responses with this `rcode`, such as NXDOMAIN")
+	queries := fs.Bool("queries", false, "only queries")
+	responses := fs.Bool("responses", false, "only responses")
+	verbose := fs.Bool("v", false, "print each message in full")
+	output := fs.String("w", "", "also write the matching messages to a pcap `file`")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goDNS pcap [flags] <capture>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *port > 65535 || (*queries && *responses) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filter := captureFilter{queries: *queries, responses: *responses}
+	if *name != "" {
+		var err error
+		if filter.name, err = dns.ParseName(*name); err != nil {
+			return fmt.Errorf("invalid name: %w", err)
+		}
+	}
+	if *qtype != "" {
+		t, err := dns.ParseQType(*qtype)
+		if err != nil {
+			return err
+		}
+		filter.qtype = &t
+	}
+	if *rcode != "" {
+		rc, err := parseRcode(*rcode)
+		if err != nil {
+			return err
+		}
+		filter.rcode = &rc
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := pcap.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	dnsReader := pcap.NewDNSReader(reader)
+	dnsReader.Port = uint16(*port)
+
+	var writer *pcap.Writer
+	if *output != "" {
+		out, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if writer, err = pcap.NewWriter(out, pcap.LinkTypeRaw); err != nil {
+			return err
+		}
+	}
+
+	for {
+		m, err := dnsReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(0), err)
+		}
+
+		msg, err := client.ParseMessage(m.Data)
+		if err != nil {
+			if filter.empty() {
+				fmt.Printf("%s\t; malformed: %v\n", captureSummary(m), err)
+			}
+			continue
+		}
+		if !filter.match(msg) {
+			continue
+		}
+
+		fmt.Printf("%s\t%s\n", captureSummary(m), messageSummary(msg))
+		if *verbose {
+			fmt.Println(msg.String())
+		}
+		if writer != nil {
+			if err := writer.WriteDNS(m.Timestamp, m.Network, m.Src, m.Dst, m.Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if dnsReader.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, "; skipped %d packets that are not UDP or TCP over IP\n", dnsReader.Skipped)
+	}
+	return nil
+}
+
+// captureFilter selects messages by their first question and header
+type captureFilter struct {
+	name      dns.Name
+	qtype     *dns.QType
+	rcode     *dns.Rcode
+	queries   bool
+	responses bool
+}
+
+// empty reports whether the filter matches every message
+func (f *captureFilter) empty() bool {
+	return f.name == nil && f.qtype == nil && f.rcode == nil && !f.queries && !f.responses
+}
+
+// match reports whether a message passes the filter
+func (f *captureFilter) match(msg *dns.Message) bool {
+	if f.queries && msg.Header.IsResponse() || f.responses && !msg.Header.IsResponse() {
+		return false
+	}
+	if f.rcode != nil && (!msg.Header.IsResponse() || msg.Rcode() != *f.rcode) {
+		return false
+	}
+	if f.name == nil && f.qtype == nil {
+		return true
+	}
+	if len(msg.Question) == 0 {
+		return false
+	}
+	q := msg.Question[0]
+	if f.name != nil && !q.Name.IsSubdomainOf(f.name) {
+		return false
+	}
+	return f.qtype == nil || q.Type == *f.qtype
+}
+
+// parseRcode returns the RCODE with the given mnemonic or number
+func parseRcode(s string) (dns.Rcode, error) {
+	if n, err := strconv.ParseUint(s, 10, 12); err == nil {
+		return dns.Rcode(n), nil
+	}
+	for rc := dns.Rcode(0); rc <= dns.RcodeBadVers; rc++ {
+		if name := rc.String(); name == strings.ToUpper(s) && name != "UNKNOWN" {
+			return rc, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rcode %q", s)
+}
+
+// captureSummary returns the time, network and endpoints of a message
+func captureSummary(m *pcap.Message) string {
+	return fmt.Sprintf("%s %s %s > %s", m.Timestamp.Format("2006-01-02T15:04:05.000000Z"), m.Network, m.Src, m.Dst)
+}
+
+// messageSummary returns a one-line summary of a message: its kind, ID,
+// RCODE, first question and section sizes
+func messageSummary(msg *dns.Message) string {
+	var b strings.Builder
+	if msg.Header.IsResponse() {
+		fmt.Fprintf(&b, "response %04X %s", msg.Header.ID, msg.Rcode())
+	} else {
+		fmt.Fprintf(&b, "query %04X", msg.Header.ID)
+	}
+	if len(msg.Question) > 0 {
+		q := msg.Question[0]
+		fmt.Fprintf(&b, " %s. %s", q.Name, q.Type)
+	}
+	if msg.Header.IsResponse() {
+		fmt.Fprintf(&b, " (%d answers, %d authority, %d additional)", len(msg.Answer), len(msg.Authority), len(msg.Additional))
+	}
+	return b.String()
+}
+
+// startCapture makes the client write its exchanges to a new pcap file and
+// returns the function closing it
+func startCapture(dnsClient *client.Client, path string) (func(), error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := pcap.NewWriter(file, pcap.LinkTypeRaw)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	dnsClient.SetCapture(writer)
+	return func() { file.Close() }, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/client"
+)
+
+// runReverse prints the PTR targets of an address, or of every address
+// of a CIDR range
+func runReverse(dnsClient *client.Client, arg string) error {
+	if !strings.Contains(arg, "/") {
+		ip := net.ParseIP(arg)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address: %s", arg)
+		}
+		names, err := dnsClient.ReverseLookup(ip)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name.String() + ".")
+		}
+		return nil
+	}
+
+	prefix, err := netip.ParsePrefix(arg)
+	if err != nil {
+		return err
+	}
+	results, err := dnsClient.ReverseLookupPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			fmt.Printf("%s\t; %v\n", result.Addr, result.Err)
+		case len(result.Names) == 0:
+			fmt.Printf("%s\t; no PTR records\n", result.Addr)
+		}
+		for _, name := range result.Names {
+			fmt.Printf("%s\t%s.\n", result.Addr, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"dklbreitling/goDNS/pkg/client"
+)
+
+// newTimingTrace returns a trace printing how long dialing, writing the
+// query and reading the response took, one line per step
+func newTimingTrace(w io.Writer) *client.Trace {
+	var last time.Time
+	elapsed := func() time.Duration {
+		now := time.Now()
+		d := now.Sub(last)
+		last = now
+		return d
+	}
+
+	return &client.Trace{
+		DialStart: func(network, server string) {
+			last = time.Now()
+			fmt.Fprintf(w, ";; dial %s %s\n", network, server)
+		},
+		DialDone: func(network string, local, remote net.Addr, err error) {
+			if err != nil {
+				fmt.Fprintf(w, ";;   dial failed after %v: %v\n", elapsed(), err)
+				return
+			}
+			fmt.Fprintf(w, ";;   connected to %s from %s in %v\n", remote, local, elapsed())
+		},
+		WroteQuery: func(data []byte, err error) {
+			if err != nil {
+				fmt.Fprintf(w, ";;   write failed after %v: %v\n", elapsed(), err)
+				return
+			}
+			fmt.Fprintf(w, ";;   wrote %d bytes in %v\n", len(data), elapsed())
+		},
+		ReadResponse: func(data []byte, err error) {
+			if err != nil {
+				fmt.Fprintf(w, ";;   read failed after %v: %v\n", elapsed(), err)
+				return
+			}
+			fmt.Fprintf(w, ";;   read %d bytes in %v\n", len(data), elapsed())
+		},
+	}
+}
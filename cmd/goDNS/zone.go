@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnssec"
+	"dklbreitling/goDNS/pkg/records"
+	"dklbreitling/goDNS/pkg/zone"
+)
+
+// runKeygen generates a DNSSEC key pair, writes the private key as PEM and
+// prints the DNSKEY record
+func runKeygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	algorithm := fs.Uint("algorithm", uint(records.AlgorithmECDSAP256SHA256), "DNSSEC algorithm number (8, 10, 13, 14 or 15)")
+	ksk := fs.Bool("ksk", false, "generate a key signing key (SEP flag)")
+	output := fs.String("o", "", "private key file (default K<zone>+<alg>+<tag>.pem)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goDNS keygen [flags] <zone>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	flags := records.DNSKEYFlagZone
+	if *ksk {
+		flags |= records.DNSKEYFlagSEP
+	}
+	key, err := dnssec.GenerateKey(uint8(*algorithm), flags)
+	if err != nil {
+		return err
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	owner := strings.TrimSuffix(fs.Arg(0), ".")
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("K%s.+%03d+%05d.pem", owner, *algorithm, key.DNSKEY.KeyTag())
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s.\tIN\tDNSKEY\t%s\n", owner, key.DNSKEY)
+	return nil
+}
+
+// runSign signs a zone file with one or more private keys
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	origin := fs.String("origin", "", "zone origin (required)")
+	keyFiles := fs.String("keys", "", "comma-separated private key files (required)")
+	nsec3 := fs.Bool("nsec3", false, "build an NSEC3 chain instead of NSEC")
+	iterations := fs.Uint("iterations", 0, "additional NSEC3 hash iterations")
+	salt := fs.String("salt", "", "NSEC3 salt in hex")
+	optOut := fs.Bool("optout", false, "NSEC3 opt-out for insecure delegations")
+	output := fs.String("o", "", "signed zone file (default <zonefile>.signed)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goDNS sign -origin <zone> -keys <files> [flags] <zonefile>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *origin == "" || *keyFiles == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var keys []*dnssec.PrivateKey
+	for _, path := range strings.Split(*keyFiles, ",") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key, err := dnssec.ParsePrivateKey(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if *iterations > dnssec.MaxNSEC3Iterations {
+		return fmt.Errorf("NSEC3 iterations must not exceed %d", dnssec.MaxNSEC3Iterations)
+	}
+	opts := zone.SignOptions{NSEC3: *nsec3, Iterations: uint16(*iterations), OptOut: *optOut}
+	if *salt != "" && *salt != "-" {
+		var err error
+		if opts.Salt, err = hex.DecodeString(*salt); err != nil {
+			return fmt.Errorf("invalid salt: %w", err)
+		}
+	}
+
+	input := fs.Arg(0)
+	file, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	unsigned, err := zone.Parse(file, *origin)
+	if err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+	signed, err := zone.Sign(unsigned, keys, opts)
+	if err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = input + ".signed"
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := signed.WriteTo(out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Signed %s with %d keys: %d records written to %s\n",
+		dns.LabelsToString(signed.Origin), len(keys), len(signed.Records), path)
+	return nil
+}
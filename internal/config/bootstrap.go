@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// BootstrapResolver resolves the hostnames of name servers. *net.Resolver
+// implements it, so net.DefaultResolver uses the system resolver.
+type BootstrapResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// ResolveServer returns the addresses (ip:port) of a name server given as
+// host:port, looking up a hostname with resolver. A nil resolver uses
+// net.DefaultResolver. Servers given by IP address are returned as is
+// without any lookup.
+func ResolveServer(ctx context.Context, resolver BootstrapResolver, server string) ([]string, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name server format: %w", err)
+	}
+	if isIP(host) {
+		return []string{server}, nil
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve name server hostname %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("name server hostname %s has no addresses", host)
+	}
+
+	servers := make([]string, len(addrs))
+	for i, addr := range addrs {
+		servers[i] = net.JoinHostPort(addr.Unmap().String(), port)
+	}
+	return servers, nil
+}
+
+// Resolve looks up the name servers given by hostname through Bootstrap
+// and returns the addresses of all name servers in order. Unlike Validate
+// it may use the network; clients otherwise resolve hostnames lazily when
+// they first query a server.
+func (c *Config) Resolve(ctx context.Context) ([]string, error) {
+	var servers []string
+	for _, server := range c.NameServers() {
+		addrs, err := ResolveServer(ctx, c.Bootstrap, server)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, addrs...)
+	}
+	return servers, nil
+}
+
+// isIP reports whether host is an IP address, with an optional IPv6 zone
+func isIP(host string) bool {
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
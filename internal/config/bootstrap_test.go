@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// fakeBootstrap resolves hostnames from a map and counts the lookups
+type fakeBootstrap struct {
+	hosts   map[string][]netip.Addr
+	lookups int
+}
+
+func (f *fakeBootstrap) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	f.lookups++
+	addrs, ok := f.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestResolveServer(t *testing.T) {
+	bootstrap := &fakeBootstrap{hosts: map[string][]netip.Addr{
+		"ns.example":    {netip.MustParseAddr("192.0.2.53"), netip.MustParseAddr("2001:db8::53")},
+		"empty.example": nil,
+	}}
+
+	servers, err := ResolveServer(context.Background(), bootstrap, "ns.example:5353")
+	if err != nil {
+		t.Fatalf("ResolveServer() returned error: %v", err)
+	}
+	if strings.Join(servers, " ") != "192.0.2.53:5353 [2001:db8::53]:5353" {
+		t.Errorf("ResolveServer() = %v", servers)
+	}
+
+	// Addresses need no lookup
+	if servers, err := ResolveServer(context.Background(), bootstrap, "[2001:db8::1]:53"); err != nil || servers[0] != "[2001:db8::1]:53" {
+		t.Errorf("ResolveServer() = %v, %v, want the address unchanged", servers, err)
+	}
+	if bootstrap.lookups != 1 {
+		t.Errorf("bootstrap resolver called %d times, want 1", bootstrap.lookups)
+	}
+
+	for _, server := range []string{"missing.example:53", "empty.example:53", "ns.example"} {
+		if _, err := ResolveServer(context.Background(), bootstrap, server); err == nil {
+			t.Errorf("ResolveServer(%q) should return error", server)
+		}
+	}
+}
+
+func TestConfigResolve(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NameServer = "ns.example:53"
+	cfg.FallbackServers = []string{"192.0.2.1:53"}
+	cfg.Bootstrap = &fakeBootstrap{hosts: map[string][]netip.Addr{"ns.example": {netip.MustParseAddr("192.0.2.53")}}}
+
+	servers, err := cfg.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve() returned error: %v", err)
+	}
+	if strings.Join(servers, " ") != "192.0.2.53:53 192.0.2.1:53" {
+		t.Errorf("Resolve() = %v", servers)
+	}
+
+	cfg.NameServer = "unknown.example:53"
+	if _, err := cfg.Resolve(context.Background()); err == nil {
+		t.Error("Resolve() should return error for an unknown hostname")
+	}
+}
+
+func TestValidateOffline(t *testing.T) {
+	// Hostnames are checked syntactically, never resolved
+	cfg := DefaultConfig()
+	cfg.NameServer = "ns.does-not-exist.invalid:53"
+	cfg.Bootstrap = &fakeBootstrap{}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+	if cfg.Bootstrap.(*fakeBootstrap).lookups != 0 {
+		t.Error("Validate() should not resolve name server hostnames")
+	}
+
+	for _, server := range []string{"bad_host:53", "ns.example:", "ns.example:http", "192.0.2.1:0", "192.0.2.1:65536"} {
+		cfg.NameServer = server
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() should reject name server %q", server)
+		}
+	}
+}
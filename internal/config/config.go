@@ -3,24 +3,47 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
+	"strconv"
 	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
 )
 
 // Config holds the DNS client configuration
 type Config struct {
 	// Network settings
-	NameServer string        // DNS server address (host:port)
-	Protocol   string        // "udp" or "tcp"
-	Timeout    time.Duration // Query timeout
+	NameServer      string        // DNS server address (host:port)
+	FallbackServers []string      // Servers tried in order when NameServer fails
+	Rotate          bool          // Spread queries round-robin over all servers
+	Protocol        string        // "udp" or "tcp"
+	Timeout         time.Duration // Query timeout
+
+	// Bootstrap resolves name server hostnames at query time; nil uses
+	// the system resolver. It is not read from files or the environment.
+	Bootstrap BootstrapResolver
 
 	// Query settings
 	RecursionDesired bool // Set RD bit in queries
 	RetryCount       int  // Number of retries on failure
+	RcodeErrors      bool // Return errors for NXDOMAIN, SERVFAIL, etc. responses
+	EDNS             bool // Advertise EDNS(0) support in queries
+
+	// Name resolution settings
+	Search    []string // Search list for relative names
+	NDots     int      // Names with fewer dots are tried with the search list first
+	HostsFile string   // Hosts file consulted before the network; empty disables it
+
+	// DNSSEC settings
+	DNSSEC       bool     // Request DNSSEC records (EDNS DO bit)
+	TrustAnchors []string // Root DS records in presentation format; empty uses the IANA root anchors
 
 	// Debug settings
-	Debug     bool   // Enable debug output
-	DumpFiles bool   // Enable hex dump files
+	Debug     bool   // Log at debug level and write annotated hex dumps of each exchange to stderr
+	DumpFiles bool   // Write annotated hex dumps of each exchange to DumpDir
+	DumpDir   string // Directory of the dump files; empty is the working directory
 	LogLevel  string // Log level (debug, info, warn, error)
 }
 
@@ -32,64 +55,116 @@ func DefaultConfig() *Config {
 		Timeout:          5 * time.Second,
 		RecursionDesired: true,
 		RetryCount:       3,
+		NDots:            1,
 		Debug:            false,
 		DumpFiles:        false,
 		LogLevel:         "info",
 	}
 }
 
-// Validate checks if the configuration is valid
+// Validate checks if the configuration is valid. The checks are purely
+// syntactic: name server hostnames are not resolved (see Resolve). Errors
+// are *KeyError values naming the offending key.
 func (c *Config) Validate() error {
-	// Validate name server
+	// Validate name servers
 	if c.NameServer == "" {
-		return fmt.Errorf("name server cannot be empty")
+		return keyError("name_server", "name server cannot be empty")
 	}
-	
-	host, port, err := net.SplitHostPort(c.NameServer)
-	if err != nil {
-		return fmt.Errorf("invalid name server format: %w", err)
+	if err := validateServer(c.NameServer); err != nil {
+		return &KeyError{Key: "name_server", Err: err}
 	}
-	
-	if net.ParseIP(host) == nil {
-		// Try to resolve hostname
-		if _, err := net.ResolveIPAddr("ip", host); err != nil {
-			return fmt.Errorf("cannot resolve name server hostname %s: %w", host, err)
+	for _, server := range c.FallbackServers {
+		if err := validateServer(server); err != nil {
+			return &KeyError{Key: "fallback_servers", Err: err}
 		}
 	}
 	
-	if port == "" {
-		return fmt.Errorf("name server port is required")
-	}
-	
 	// Validate protocol
 	if c.Protocol != "udp" && c.Protocol != "tcp" {
-		return fmt.Errorf("protocol must be 'udp' or 'tcp', got '%s'", c.Protocol)
+		return keyError("protocol", "protocol must be 'udp' or 'tcp', got '%s'", c.Protocol)
 	}
 	
 	// Validate timeout
 	if c.Timeout <= 0 {
-		return fmt.Errorf("timeout must be positive, got %v", c.Timeout)
+		return keyError("timeout", "timeout must be positive, got %v", c.Timeout)
 	}
 	
 	// Validate retry count
 	if c.RetryCount < 0 {
-		return fmt.Errorf("retry count cannot be negative, got %d", c.RetryCount)
+		return keyError("retry_count", "retry count cannot be negative, got %d", c.RetryCount)
+	}
+	
+	if c.NDots < 0 {
+		return keyError("ndots", "ndots cannot be negative, got %d", c.NDots)
 	}
 	
 	// Validate log level
-	validLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
+	if _, ok := logLevels[c.LogLevel]; !ok {
+		return keyError("log_level", "invalid log level '%s', must be one of: debug, info, warn, error", c.LogLevel)
+	}
+	
+	return nil
+}
+
+// keyError returns a *KeyError for key with a formatted message
+func keyError(key, format string, args ...any) error {
+	return &KeyError{Key: key, Err: fmt.Errorf(format, args...)}
+}
+
+// logLevels maps the LogLevel names to slog levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Level returns the slog level selected by LogLevel, or debug if Debug is
+// set
+func (c *Config) Level() slog.Level {
+	if c.Debug {
+		return slog.LevelDebug
 	}
-	if !validLevels[c.LogLevel] {
-		return fmt.Errorf("invalid log level '%s', must be one of: debug, info, warn, error", c.LogLevel)
+	return logLevels[c.LogLevel]
+}
+
+// NewLogger returns a text logger writing to w at the configured level.
+// With Debug set, records include their source location.
+func (c *Config) NewLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level:     c.Level(),
+		AddSource: c.Debug,
+	}))
+}
+
+// validateServer checks a name server address of the form host:port,
+// where host is an IP address or a hostname
+func validateServer(server string) error {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return fmt.Errorf("invalid name server format: %w", err)
 	}
 	
+	if !isIP(host) {
+		if err := dns.HostnamePolicy.Validate(host); err != nil {
+			return fmt.Errorf("invalid name server hostname: %w", err)
+		}
+	}
+	
+	if port == "" {
+		return fmt.Errorf("name server port is required")
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("invalid name server port %q", port)
+	}
 	return nil
 }
 
+// NameServers returns NameServer followed by the fallback servers
+func (c *Config) NameServers() []string {
+	return append([]string{c.NameServer}, c.FallbackServers...)
+}
+
 // GetMaxMessageSize returns the maximum message size for the configured protocol
 func (c *Config) GetMaxMessageSize() int {
 	switch c.Protocol {
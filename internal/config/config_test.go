@@ -1,6 +1,10 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -65,6 +69,16 @@ func TestConfigValidation(t *testing.T) {
 			config:      &Config{NameServer: "8.8.8.8:53", Protocol: "udp", Timeout: 5 * time.Second, RetryCount: -1, LogLevel: "info"},
 			expectError: true,
 		},
+		{
+			name:        "invalid fallback server",
+			config:      &Config{NameServer: "8.8.8.8:53", FallbackServers: []string{"8.8.4.4"}, Protocol: "udp", Timeout: 5 * time.Second, RetryCount: 3, LogLevel: "info"},
+			expectError: true,
+		},
+		{
+			name:        "negative ndots",
+			config:      &Config{NameServer: "8.8.8.8:53", Protocol: "udp", Timeout: 5 * time.Second, RetryCount: 3, NDots: -1, LogLevel: "info"},
+			expectError: true,
+		},
 		{
 			name:        "invalid log level",
 			config:      &Config{NameServer: "8.8.8.8:53", Protocol: "udp", Timeout: 5 * time.Second, RetryCount: 3, LogLevel: "invalid"},
@@ -104,3 +118,42 @@ func TestGetMaxMessageSize(t *testing.T) {
 		}
 	}
 }
+
+func TestNameServers(t *testing.T) {
+	cfg := &Config{NameServer: "192.0.2.1:53", FallbackServers: []string{"192.0.2.2:53", "[2001:db8::1]:53"}}
+	expected := []string{"192.0.2.1:53", "192.0.2.2:53", "[2001:db8::1]:53"}
+	if result := cfg.NameServers(); strings.Join(result, " ") != strings.Join(expected, " ") {
+		t.Errorf("NameServers() = %v, want %v", result, expected)
+	}
+}
+
+func TestValidateKeyError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FallbackServers = []string{"192.0.2.2"}
+
+	var keyErr *KeyError
+	if err := cfg.Validate(); !errors.As(err, &keyErr) || keyErr.Key != "fallback_servers" {
+		t.Errorf("Validate() error = %v, want *KeyError for fallback_servers", err)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Level() != slog.LevelInfo {
+		t.Errorf("Level() = %v, want INFO", cfg.Level())
+	}
+	cfg.LogLevel = "error"
+	if cfg.Level() != slog.LevelError {
+		t.Errorf("Level() = %v, want ERROR", cfg.Level())
+	}
+	cfg.Debug = true
+	if cfg.Level() != slog.LevelDebug {
+		t.Errorf("Level() with Debug = %v, want DEBUG", cfg.Level())
+	}
+
+	var buf bytes.Buffer
+	cfg.NewLogger(&buf).Debug("visible")
+	if !strings.Contains(buf.String(), "visible") || !strings.Contains(buf.String(), "source=") {
+		t.Errorf("debug logger output = %q, want the message with its source", buf.String())
+	}
+}
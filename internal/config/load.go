@@ -0,0 +1,405 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables read by
+// Loader.LoadEnv, followed by the upper-case key, as in GODNS_NAME_SERVER
+const EnvPrefix = "GODNS_"
+
+// KeyError reports an invalid setting together with where it was set
+type KeyError struct {
+	Key    string // Configuration key, such as "timeout"
+	Source string // File and line, environment variable or flag; empty if unknown
+	Err    error
+}
+
+func (e *KeyError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Source, e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// value is a setting as read from a file, the environment or a flag.
+// Lists are either given as list or as comma-separated text.
+type value struct {
+	text   string
+	list   []string
+	isList bool
+}
+
+// setters maps each configuration key to the function that applies a value
+var setters = map[string]func(c *Config, v value) error{
+	"name_server":       stringSetter(func(c *Config) *string { return &c.NameServer }),
+	"fallback_servers":  listSetter(func(c *Config) *[]string { return &c.FallbackServers }),
+	"rotate":            boolSetter(func(c *Config) *bool { return &c.Rotate }),
+	"protocol":          stringSetter(func(c *Config) *string { return &c.Protocol }),
+	"timeout":           durationSetter(func(c *Config) *time.Duration { return &c.Timeout }),
+	"recursion_desired": boolSetter(func(c *Config) *bool { return &c.RecursionDesired }),
+	"retry_count":       intSetter(func(c *Config) *int { return &c.RetryCount }),
+	"rcode_errors":      boolSetter(func(c *Config) *bool { return &c.RcodeErrors }),
+	"edns":              boolSetter(func(c *Config) *bool { return &c.EDNS }),
+	"search":            listSetter(func(c *Config) *[]string { return &c.Search }),
+	"ndots":             intSetter(func(c *Config) *int { return &c.NDots }),
+	"hosts_file":        stringSetter(func(c *Config) *string { return &c.HostsFile }),
+	"dnssec":            boolSetter(func(c *Config) *bool { return &c.DNSSEC }),
+	"trust_anchors":     listSetter(func(c *Config) *[]string { return &c.TrustAnchors }),
+	"debug":             boolSetter(func(c *Config) *bool { return &c.Debug }),
+	"dump_files":        boolSetter(func(c *Config) *bool { return &c.DumpFiles }),
+	"dump_dir":          stringSetter(func(c *Config) *string { return &c.DumpDir }),
+	"log_level":         stringSetter(func(c *Config) *string { return &c.LogLevel }),
+}
+
+// Keys returns the configuration keys in alphabetical order
+func Keys() []string {
+	keys := make([]string, 0, len(setters))
+	for key := range setters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Loader builds a configuration from layered sources. Each source
+// overrides the keys it sets, so sources are applied from lowest to
+// highest precedence: defaults, file, environment, flags.
+type Loader struct {
+	cfg     *Config
+	sources map[string]string // Where each key was last set
+}
+
+// NewLoader returns a loader starting from DefaultConfig
+func NewLoader() *Loader {
+	return &Loader{cfg: DefaultConfig(), sources: make(map[string]string)}
+}
+
+// Set applies a textual value to a key, as from a command-line flag.
+// Lists are comma-separated.
+func (l *Loader) Set(key, text, source string) error {
+	return l.set(key, value{text: text}, source)
+}
+
+// set applies a value and records its source
+func (l *Loader) set(key string, v value, source string) error {
+	key = normalizeKey(key)
+	setter, ok := setters[key]
+	if !ok {
+		return &KeyError{Key: key, Source: source, Err: errors.New("unknown key")}
+	}
+	if err := setter(l.cfg, v); err != nil {
+		return &KeyError{Key: key, Source: source, Err: err}
+	}
+	l.sources[key] = source
+	return nil
+}
+
+// LoadFile applies a configuration file. Files ending in ".json" or
+// starting with "{" are JSON objects; others use a TOML-like syntax of
+// "key = value" lines. Keys are those of Keys, with "-" accepted for "_".
+func (l *Loader) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return l.loadJSON(path, data)
+	}
+	return l.loadTOML(path, data)
+}
+
+// loadJSON applies a JSON object of strings, numbers, booleans and arrays
+// of strings
+func (l *Loader) loadJSON(path string, data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Apply keys in a fixed order so errors are reproducible
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v, err := jsonValue(fields[key])
+		if err != nil {
+			return &KeyError{Key: normalizeKey(key), Source: path, Err: err}
+		}
+		if err := l.set(key, v, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonValue converts a JSON value to a setting value
+func jsonValue(raw json.RawMessage) (value, error) {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return value{list: list, isList: true}, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return value{text: text}, nil
+	}
+	var scalar any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&scalar); err != nil {
+		return value{}, err
+	}
+	switch scalar := scalar.(type) {
+	case bool, json.Number:
+		return value{text: fmt.Sprint(scalar)}, nil
+	default:
+		return value{}, fmt.Errorf("unsupported value %s", raw)
+	}
+}
+
+// loadTOML applies "key = value" lines. Values are quoted strings,
+// booleans, numbers, bare words or single-line arrays of quoted strings;
+// "#" starts a comment outside quotes.
+func (l *Loader) loadTOML(path string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		source := fmt.Sprintf("%s:%d", path, lineNum)
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		key, text, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s: expected key = value", source)
+		}
+		key = strings.TrimSpace(key)
+		v, err := tomlValue(strings.TrimSpace(text))
+		if err != nil {
+			return &KeyError{Key: normalizeKey(key), Source: source, Err: err}
+		}
+		if err := l.set(key, v, source); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// tomlValue parses the value of a "key = value" line
+func tomlValue(text string) (value, error) {
+	switch {
+	case text == "":
+		return value{}, errors.New("missing value")
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return value{}, errors.New("unterminated array")
+		}
+		list := []string{}
+		for _, item := range splitArray(text[1 : len(text)-1]) {
+			s, err := strconv.Unquote(item)
+			if err != nil {
+				return value{}, fmt.Errorf("array items must be quoted strings, got %s", item)
+			}
+			list = append(list, s)
+		}
+		return value{list: list, isList: true}, nil
+	case strings.HasPrefix(text, `"`):
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return value{}, fmt.Errorf("invalid string %s", text)
+		}
+		return value{text: s}, nil
+	default:
+		return value{text: text}, nil
+	}
+}
+
+// splitArray splits the items of an array at commas outside quotes,
+// ignoring a trailing comma
+func splitArray(s string) []string {
+	var items []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+// stripComment removes a "#" comment that is not inside a quoted string
+func stripComment(line string) string {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quoted:
+			i++
+		case line[i] == '"':
+			quoted = !quoted
+		case line[i] == '#' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// LoadEnv applies the environment variables named EnvPrefix followed by
+// an upper-case key, looked up with lookup (normally os.LookupEnv). Lists
+// are comma-separated.
+func (l *Loader) LoadEnv(lookup func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		name := EnvPrefix + strings.ToUpper(key)
+		if text, ok := lookup(name); ok {
+			if err := l.set(key, value{text: text}, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadResolvConf applies the settings of a resolv.conf file; see
+// ResolvConf.Apply
+func (l *Loader) LoadResolvConf(path string) error {
+	rc, err := LoadResolvConf(path)
+	if err != nil {
+		return err
+	}
+	rc.Apply(l.cfg)
+	for _, key := range []string{"name_server", "fallback_servers", "search", "ndots", "timeout", "retry_count", "rotate", "edns"} {
+		l.sources[key] = path
+	}
+	return nil
+}
+
+// Config validates and returns the configuration. Validation errors name
+// the source of the offending key.
+func (l *Loader) Config() (*Config, error) {
+	if err := l.cfg.Validate(); err != nil {
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) && keyErr.Source == "" {
+			keyErr.Source = l.sources[keyErr.Key]
+		}
+		return nil, err
+	}
+	return l.cfg, nil
+}
+
+// Load returns the defaults overridden by the file at path, if not empty,
+// and by GODNS_* environment variables
+func Load(path string) (*Config, error) {
+	l := NewLoader()
+	if path != "" {
+		if err := l.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.LoadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return l.Config()
+}
+
+// normalizeKey returns the canonical form of a key
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+// stringSetter returns a setter for a string field
+func stringSetter(field func(*Config) *string) func(*Config, value) error {
+	return func(c *Config, v value) error {
+		if v.isList {
+			return errors.New("expected a single value, got a list")
+		}
+		*field(c) = v.text
+		return nil
+	}
+}
+
+// boolSetter returns a setter for a boolean field
+func boolSetter(field func(*Config) *bool) func(*Config, value) error {
+	return func(c *Config, v value) error {
+		b, err := strconv.ParseBool(v.text)
+		if err != nil || v.isList {
+			return fmt.Errorf("invalid boolean %q", v.text)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// intSetter returns a setter for an integer field
+func intSetter(field func(*Config) *int) func(*Config, value) error {
+	return func(c *Config, v value) error {
+		n, err := strconv.Atoi(v.text)
+		if err != nil || v.isList {
+			return fmt.Errorf("invalid integer %q", v.text)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// durationSetter returns a setter for a duration field, given as a Go
+// duration such as "1500ms" or as a number of seconds
+func durationSetter(field func(*Config) *time.Duration) func(*Config, value) error {
+	return func(c *Config, v value) error {
+		if v.isList {
+			return errors.New("expected a duration, got a list")
+		}
+		if seconds, err := strconv.ParseFloat(v.text, 64); err == nil {
+			*field(c) = time.Duration(seconds * float64(time.Second))
+			return nil
+		}
+		d, err := time.ParseDuration(v.text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v.text)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// listSetter returns a setter for a list field. Text values are split at
+// commas; an empty text clears the list.
+func listSetter(field func(*Config) *[]string) func(*Config, value) error {
+	return func(c *Config, v value) error {
+		list := v.list
+		if !v.isList {
+			list = nil
+			for _, item := range strings.Split(v.text, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+		}
+		*field(c) = append([]string(nil), list...)
+		return nil
+	}
+}
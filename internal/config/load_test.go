@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to a file named name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns a lookup function over the given variables
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+const testConfigTOML = `# goDNS configuration
+name_server = "192.0.2.53:53"
+fallback-servers = ["192.0.2.54:53", "[2001:db8::53]:53",]
+protocol = tcp   # bare words are strings
+timeout = "2500ms"
+retry_count = 1
+search = ["example.com"]
+dnssec = true
+log_level = "debug"
+`
+
+const testConfigJSON = `{
+	"name_server": "192.0.2.53:53",
+	"fallback_servers": ["192.0.2.54:53", "[2001:db8::53]:53"],
+	"protocol": "tcp",
+	"timeout": 2.5,
+	"retry_count": 1,
+	"search": ["example.com"],
+	"dnssec": true,
+	"log_level": "debug"
+}`
+
+func TestLoaderLoadFile(t *testing.T) {
+	for name, content := range map[string]string{"goDNS.toml": testConfigTOML, "goDNS.json": testConfigJSON} {
+		t.Run(name, func(t *testing.T) {
+			loader := NewLoader()
+			if err := loader.LoadFile(writeFile(t, name, content)); err != nil {
+				t.Fatalf("LoadFile() returned error: %v", err)
+			}
+			cfg, err := loader.Config()
+			if err != nil {
+				t.Fatalf("Config() returned error: %v", err)
+			}
+
+			if cfg.NameServer != "192.0.2.53:53" || strings.Join(cfg.FallbackServers, " ") != "192.0.2.54:53 [2001:db8::53]:53" {
+				t.Errorf("servers = %s %v", cfg.NameServer, cfg.FallbackServers)
+			}
+			if cfg.Protocol != "tcp" || cfg.Timeout != 2500*time.Millisecond || cfg.RetryCount != 1 {
+				t.Errorf("protocol = %s, timeout = %v, retry count = %d", cfg.Protocol, cfg.Timeout, cfg.RetryCount)
+			}
+			if len(cfg.Search) != 1 || !cfg.DNSSEC || cfg.LogLevel != "debug" {
+				t.Errorf("search = %v, dnssec = %t, log level = %s", cfg.Search, cfg.DNSSEC, cfg.LogLevel)
+			}
+			// Keys missing from the file keep their defaults
+			if !cfg.RecursionDesired || cfg.NDots != 1 {
+				t.Errorf("defaults overwritten: recursion desired = %t, ndots = %d", cfg.RecursionDesired, cfg.NDots)
+			}
+		})
+	}
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	loader := NewLoader()
+	if err := loader.LoadFile(writeFile(t, "goDNS.conf", testConfigTOML)); err != nil {
+		t.Fatalf("LoadFile() returned error: %v", err)
+	}
+	err := loader.LoadEnv(env(map[string]string{
+		"GODNS_PROTOCOL":     "udp",
+		"GODNS_TIMEOUT":      "3",
+		"GODNS_SEARCH":       "a.example, b.example",
+		"GODNS_RCODE_ERRORS": "true",
+	}))
+	if err != nil {
+		t.Fatalf("LoadEnv() returned error: %v", err)
+	}
+	if err := loader.Set("timeout", "1s", "flag -timeout"); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+
+	cfg, err := loader.Config()
+	if err != nil {
+		t.Fatalf("Config() returned error: %v", err)
+	}
+	if cfg.NameServer != "192.0.2.53:53" {
+		t.Errorf("NameServer = %s, want the file's", cfg.NameServer)
+	}
+	if cfg.Protocol != "udp" || !cfg.RcodeErrors || strings.Join(cfg.Search, " ") != "a.example b.example" {
+		t.Errorf("protocol = %s, rcode errors = %t, search = %v, want the environment's", cfg.Protocol, cfg.RcodeErrors, cfg.Search)
+	}
+	if cfg.Timeout != time.Second {
+		t.Errorf("Timeout = %v, want the flag's 1s", cfg.Timeout)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		load   func(l *Loader) error
+		key    string
+		source string
+	}{
+		{"unknown key", func(l *Loader) error {
+			return l.LoadFile(writeFile(t, "c.toml", "# header\nname_sever = \"x\"\n"))
+		}, "name_sever", "c.toml:2"},
+		{"bad duration", func(l *Loader) error {
+			return l.LoadFile(writeFile(t, "c.toml", "timeout = 5x\n"))
+		}, "timeout", "c.toml:1"},
+		{"bad array", func(l *Loader) error {
+			return l.LoadFile(writeFile(t, "c.toml", "search = [example.com]\n"))
+		}, "search", "c.toml:1"},
+		{"list for string", func(l *Loader) error {
+			return l.LoadFile(writeFile(t, "c.json", `{"protocol": ["udp"]}`))
+		}, "protocol", "c.json"},
+		{"object value", func(l *Loader) error {
+			return l.LoadFile(writeFile(t, "c.json", `{"timeout": {"seconds": 5}}`))
+		}, "timeout", "c.json"},
+		{"bad boolean", func(l *Loader) error {
+			return l.LoadEnv(env(map[string]string{"GODNS_DNSSEC": "maybe"}))
+		}, "dnssec", "GODNS_DNSSEC"},
+		{"invalid value", func(l *Loader) error {
+			if err := l.LoadEnv(env(map[string]string{"GODNS_RETRY_COUNT": "-2"})); err != nil {
+				return err
+			}
+			_, err := l.Config()
+			return err
+		}, "retry_count", "GODNS_RETRY_COUNT"},
+		{"invalid flag", func(l *Loader) error {
+			if err := l.Set("log-level", "verbose", "flag -log-level"); err != nil {
+				return err
+			}
+			_, err := l.Config()
+			return err
+		}, "log_level", "flag -log-level"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.load(NewLoader())
+			var keyErr *KeyError
+			if !errors.As(err, &keyErr) {
+				t.Fatalf("error = %v, want *KeyError", err)
+			}
+			if keyErr.Key != test.key || filepath.Base(keyErr.Source) != test.source {
+				t.Errorf("KeyError = %q at %q, want %q at %q", keyErr.Key, keyErr.Source, test.key, test.source)
+			}
+		})
+	}
+
+	if err := NewLoader().LoadFile(writeFile(t, "c.toml", "timeout 5\n")); err == nil {
+		t.Error("LoadFile() should reject lines without =")
+	}
+}
+
+func TestLoaderLoadResolvConf(t *testing.T) {
+	loader := NewLoader()
+	if err := loader.LoadResolvConf(writeFile(t, "resolv.conf", "nameserver 192.0.2.1\noptions timeout:2\n")); err != nil {
+		t.Fatalf("LoadResolvConf() returned error: %v", err)
+	}
+	cfg, err := loader.Config()
+	if err != nil {
+		t.Fatalf("Config() returned error: %v", err)
+	}
+	if cfg.NameServer != "192.0.2.1:53" || cfg.Timeout != 2*time.Second {
+		t.Errorf("Config() = %s, %v, want 192.0.2.1:53, 2s", cfg.NameServer, cfg.Timeout)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, "goDNS.toml", "protocol = \"tcp\"\nndots = 2\n")
+	t.Setenv("GODNS_NDOTS", "3")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if cfg.Protocol != "tcp" || cfg.NDots != 3 {
+		t.Errorf("Load() = protocol %s, ndots %d, want tcp, 3", cfg.Protocol, cfg.NDots)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("Load() should return error for a missing file")
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default locations of the system resolver files
+const (
+	DefaultResolvConfPath = "/etc/resolv.conf"
+	DefaultHostsPath      = "/etc/hosts"
+)
+
+// Limits applied by the system resolver (resolv.conf(5))
+const (
+	maxResolvNameServers = 3
+	maxResolvNDots       = 15
+	maxResolvTimeout     = 30 * time.Second
+	maxResolvAttempts    = 5
+)
+
+// ResolvConf holds the settings of a resolv.conf file
+type ResolvConf struct {
+	NameServers []string      // Server addresses (host:port)
+	Search      []string      // Search list, from "search" or "domain"
+	NDots       int           // options ndots:n
+	Timeout     time.Duration // options timeout:n
+	Attempts    int           // options attempts:n
+	Rotate      bool          // options rotate
+	EDNS0       bool          // options edns0
+}
+
+// ParseResolvConf parses a resolv.conf file. Unknown keywords and options
+// are ignored like the system resolver does; without name servers the
+// local host is used.
+func ParseResolvConf(r io.Reader) (*ResolvConf, error) {
+	rc := &ResolvConf{NDots: 1, Timeout: 5 * time.Second, Attempts: 2}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "nameserver":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: nameserver needs an address", lineNum)
+			}
+			// Scoped IPv6 addresses keep their zone
+			host, _, _ := strings.Cut(fields[1], "%")
+			if net.ParseIP(host) == nil {
+				return nil, fmt.Errorf("line %d: invalid nameserver address %q", lineNum, fields[1])
+			}
+			if len(rc.NameServers) < maxResolvNameServers {
+				rc.NameServers = append(rc.NameServers, net.JoinHostPort(fields[1], "53"))
+			}
+		case "domain":
+			if len(fields) > 1 {
+				rc.Search = []string{strings.TrimSuffix(fields[1], ".")}
+			}
+		case "search":
+			rc.Search = nil
+			for _, domain := range fields[1:] {
+				rc.Search = append(rc.Search, strings.TrimSuffix(domain, "."))
+			}
+		case "options":
+			if err := rc.parseOptions(fields[1:]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(rc.NameServers) == 0 {
+		rc.NameServers = []string{"127.0.0.1:53", "[::1]:53"}
+	}
+	return rc, nil
+}
+
+// parseOptions applies the values of an options line, capping them at the
+// limits of the system resolver
+func (rc *ResolvConf) parseOptions(options []string) error {
+	for _, option := range options {
+		name, value, hasValue := strings.Cut(option, ":")
+		number := 0
+		if hasValue {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value in option %q", option)
+			}
+			number = n
+		}
+
+		switch name {
+		case "ndots":
+			rc.NDots = min(number, maxResolvNDots)
+		case "timeout":
+			rc.Timeout = min(time.Duration(max(number, 1))*time.Second, maxResolvTimeout)
+		case "attempts":
+			rc.Attempts = min(max(number, 1), maxResolvAttempts)
+		case "rotate":
+			rc.Rotate = true
+		case "edns0":
+			rc.EDNS0 = true
+		}
+	}
+	return nil
+}
+
+// LoadResolvConf reads and parses the resolv.conf file at path
+func LoadResolvConf(path string) (*ResolvConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rc, err := ParseResolvConf(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rc, nil
+}
+
+// Apply sets the name servers, search list and resolver options of cfg.
+// Each attempt is one retry round over all servers.
+func (rc *ResolvConf) Apply(cfg *Config) {
+	cfg.NameServer = rc.NameServers[0]
+	cfg.FallbackServers = append([]string(nil), rc.NameServers[1:]...)
+	cfg.Search = append([]string(nil), rc.Search...)
+	cfg.NDots = rc.NDots
+	cfg.Timeout = rc.Timeout
+	cfg.RetryCount = rc.Attempts - 1
+	cfg.Rotate = rc.Rotate
+	cfg.EDNS = rc.EDNS0
+}
+
+// SystemConfig returns the default configuration with the settings of the
+// resolv.conf file at resolvConfPath applied and hostsPath as hosts file
+func SystemConfig(resolvConfPath, hostsPath string) (*Config, error) {
+	rc, err := LoadResolvConf(resolvConfPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg := DefaultConfig()
+	rc.Apply(cfg)
+	cfg.HostsFile = hostsPath
+	return cfg, nil
+}
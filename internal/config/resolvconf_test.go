@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testResolvConf = `# Generated by NetworkManager
+domain corp.example
+search example.com. example.net ; overrides domain
+nameserver 192.0.2.1
+nameserver 2001:db8::53
+nameserver fe80::1%eth0
+nameserver 192.0.2.4
+options ndots:2 timeout:3 attempts:9 rotate edns0 single-request
+sortlist 130.155.160.0/255.255.240.0
+`
+
+func TestParseResolvConf(t *testing.T) {
+	rc, err := ParseResolvConf(strings.NewReader(testResolvConf))
+	if err != nil {
+		t.Fatalf("ParseResolvConf() returned error: %v", err)
+	}
+
+	// Only the first three name servers are used
+	servers := []string{"192.0.2.1:53", "[2001:db8::53]:53", "[fe80::1%eth0]:53"}
+	if strings.Join(rc.NameServers, " ") != strings.Join(servers, " ") {
+		t.Errorf("NameServers = %v, want %v", rc.NameServers, servers)
+	}
+	if search := []string{"example.com", "example.net"}; strings.Join(rc.Search, " ") != strings.Join(search, " ") {
+		t.Errorf("Search = %v, want %v", rc.Search, search)
+	}
+	if rc.NDots != 2 || rc.Timeout != 3*time.Second || rc.Attempts != 5 || !rc.Rotate || !rc.EDNS0 {
+		t.Errorf("options = ndots:%d timeout:%v attempts:%d rotate:%t edns0:%t, want 2 3s 5 true true",
+			rc.NDots, rc.Timeout, rc.Attempts, rc.Rotate, rc.EDNS0)
+	}
+}
+
+func TestParseResolvConfDefaults(t *testing.T) {
+	rc, err := ParseResolvConf(strings.NewReader("; empty\n"))
+	if err != nil {
+		t.Fatalf("ParseResolvConf() returned error: %v", err)
+	}
+
+	if servers := []string{"127.0.0.1:53", "[::1]:53"}; strings.Join(rc.NameServers, " ") != strings.Join(servers, " ") {
+		t.Errorf("NameServers = %v, want %v", rc.NameServers, servers)
+	}
+	if rc.Search != nil || rc.NDots != 1 || rc.Timeout != 5*time.Second || rc.Attempts != 2 || rc.Rotate || rc.EDNS0 {
+		t.Errorf("ParseResolvConf() defaults = %+v", rc)
+	}
+
+	// The last of domain and search wins
+	rc, err = ParseResolvConf(strings.NewReader("search a.example b.example\ndomain c.example\n"))
+	if err != nil || strings.Join(rc.Search, " ") != "c.example" {
+		t.Errorf("Search = %v, %v, want [c.example]", rc.Search, err)
+	}
+
+	rc, err = ParseResolvConf(strings.NewReader("options ndots:20 timeout:0\n"))
+	if err != nil || rc.NDots != 15 || rc.Timeout != time.Second {
+		t.Errorf("capped options = ndots:%d timeout:%v, %v, want 15 1s", rc.NDots, rc.Timeout, err)
+	}
+}
+
+func TestParseResolvConfErrors(t *testing.T) {
+	for _, input := range []string{
+		"nameserver\n",
+		"nameserver dns.example\n",
+		"options ndots:x\n",
+		"options timeout:-1\n",
+	} {
+		if _, err := ParseResolvConf(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseResolvConf(%q) should return error", input)
+		}
+	}
+}
+
+func TestSystemConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	if err := os.WriteFile(path, []byte(testResolvConf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := SystemConfig(path, "/tmp/hosts")
+	if err != nil {
+		t.Fatalf("SystemConfig() returned error: %v", err)
+	}
+	if cfg.NameServer != "192.0.2.1:53" || len(cfg.FallbackServers) != 2 {
+		t.Errorf("servers = %s %v, want 192.0.2.1:53 and 2 fallbacks", cfg.NameServer, cfg.FallbackServers)
+	}
+	if cfg.NDots != 2 || cfg.Timeout != 3*time.Second || cfg.RetryCount != 4 || !cfg.Rotate || !cfg.EDNS {
+		t.Errorf("SystemConfig() options = %+v", cfg)
+	}
+	if cfg.HostsFile != "/tmp/hosts" || len(cfg.Search) != 2 {
+		t.Errorf("HostsFile = %q, Search = %v", cfg.HostsFile, cfg.Search)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	if _, err := SystemConfig(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("SystemConfig() should return error for a missing file")
+	}
+}
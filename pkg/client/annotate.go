@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// annotateBytesPerLine is the number of bytes shown on each line of an
+// annotated dump
+const annotateBytesPerLine = 8
+
+// AnnotateMessage returns a hex dump of a wire-format message with one
+// field per line, each prefixed by its offset: the header fields, every
+// label and compression pointer of the names, and the fixed fields and
+// RDATA of each record. Names inside NS, CNAME, DNAME, PTR, MX, SRV and
+// SOA RDATA are broken down too. Bytes from the first malformed field on
+// are dumped without annotations.
+func AnnotateMessage(data []byte) string {
+	a := &annotator{data: data}
+	if err := a.message(); err != nil {
+		a.comment("malformed: %v", err)
+		if a.off < len(data) {
+			a.field(len(data)-a.off, "unparsed")
+		}
+	}
+	return a.out.String()
+}
+
+// annotator writes the annotated dump of data, field by field
+type annotator struct {
+	data []byte
+	off  int
+	out  strings.Builder
+}
+
+// message annotates the header and all sections
+func (a *annotator) message() error {
+	if len(a.data) < 12 {
+		return fmt.Errorf("header truncated: %d bytes", len(a.data))
+	}
+	header := dns.Header{Flags: dns.HeaderBitfield(binary.BigEndian.Uint16(a.data[2:4]))}
+
+	a.comment("header")
+	a.field(2, "ID 0x%04X", binary.BigEndian.Uint16(a.data[0:2]))
+	a.field(2, "flags 0x%04X: %s", uint16(header.Flags), header.FlagsString())
+	counts := make([]int, 4)
+	for i, name := range []string{"QDCOUNT", "ANCOUNT", "NSCOUNT", "ARCOUNT"} {
+		counts[i] = int(binary.BigEndian.Uint16(a.data[a.off : a.off+2]))
+		a.field(2, "%s %d", name, counts[i])
+	}
+
+	for i := 0; i < counts[0]; i++ {
+		a.comment("question %d", i+1)
+		if err := a.name("name"); err != nil {
+			return err
+		}
+		if err := a.need(4); err != nil {
+			return err
+		}
+		a.field(2, "type %s", dns.QType(a.uint16()))
+		a.field(2, "class %s", dns.QClass(a.uint16()))
+	}
+
+	for section, name := range []string{"answer", "authority", "additional"} {
+		for i := 0; i < counts[section+1]; i++ {
+			a.comment("%s %d", name, i+1)
+			if err := a.record(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a.off < len(a.data) {
+		a.comment("trailing data")
+		a.field(len(a.data)-a.off, "%d bytes", len(a.data)-a.off)
+	}
+	return nil
+}
+
+// record annotates a resource record
+func (a *annotator) record() error {
+	start := a.off
+	if err := a.name("owner"); err != nil {
+		return err
+	}
+	if err := a.need(10); err != nil {
+		return err
+	}
+
+	rrType := dns.QType(a.uint16())
+	a.field(2, "type %s", rrType)
+	if rrType == dns.TypeOPT {
+		a.field(2, "UDP payload size %d", a.uint16())
+		a.field(4, "extended RCODE %d, version %d, flags 0x%04X", a.data[a.off], a.data[a.off+1], binary.BigEndian.Uint16(a.data[a.off+2:a.off+4]))
+	} else {
+		a.field(2, "class %s", dns.QClass(a.uint16()))
+		a.field(4, "TTL %d", binary.BigEndian.Uint32(a.data[a.off:a.off+4]))
+	}
+	length := int(a.uint16())
+	a.field(2, "RDLENGTH %d", length)
+	if err := a.need(length); err != nil {
+		return err
+	}
+	end := a.off + length
+
+	// The parsed record summarizes the RDATA
+	summary := "RDATA"
+	if rr, _, err := parseResourceRecord(a.data, start); err == nil && rr.RData != nil {
+		summary = fmt.Sprintf("RDATA %s", rr.RData.String())
+	}
+	a.comment("%s", summary)
+
+	if err := a.rdata(rrType, end); err != nil {
+		return err
+	}
+	if a.off != end {
+		return fmt.Errorf("RDATA of %s ends at offset %d, RDLENGTH says %d", rrType, a.off, end)
+	}
+	return nil
+}
+
+// rdata annotates the RDATA of a record ending at end. Names are broken
+// down for the types that contain them; other RDATA is dumped as a whole.
+func (a *annotator) rdata(rrType dns.QType, end int) error {
+	fixed := func(sizes ...int) error {
+		for _, size := range sizes {
+			if a.off+size > end {
+				return fmt.Errorf("RDATA of %s truncated", rrType)
+			}
+			value := uint32(0)
+			for _, b := range a.data[a.off : a.off+size] {
+				value = value<<8 | uint32(b)
+			}
+			a.field(size, "%d", value)
+		}
+		return nil
+	}
+
+	switch rrType {
+	case dns.TypeNS, dns.TypeCNAME, dns.TypeDNAME, dns.TypePTR:
+		return a.name("target")
+	case dns.TypeMX:
+		if err := fixed(2); err != nil {
+			return err
+		}
+		return a.name("exchange")
+	case dns.TypeSRV:
+		if err := fixed(2, 2, 2); err != nil {
+			return err
+		}
+		return a.name("target")
+	case dns.TypeSOA:
+		if err := a.name("mname"); err != nil {
+			return err
+		}
+		if err := a.name("rname"); err != nil {
+			return err
+		}
+		return fixed(4, 4, 4, 4, 4)
+	default:
+		if end > a.off {
+			a.field(end-a.off, "%d bytes", end-a.off)
+		}
+		return nil
+	}
+}
+
+// name annotates each label of a name and its terminating root label or
+// compression pointer
+func (a *annotator) name(role string) error {
+	for {
+		if err := a.need(1); err != nil {
+			return err
+		}
+		length := a.data[a.off]
+		switch {
+		case length&0xC0 == 0xC0:
+			if err := a.need(2); err != nil {
+				return err
+			}
+			pointer := int(binary.BigEndian.Uint16(a.data[a.off:a.off+2]) & 0x3FFF)
+			target, _, err := parseLabels(a.data, a.off)
+			if err != nil {
+				return fmt.Errorf("%s: %w", role, err)
+			}
+			a.field(2, "%s: pointer to 0x%04X (%s.)", role, pointer, target.String())
+			return nil
+		case length&0xC0 != 0:
+			return fmt.Errorf("%s: unsupported label type %#02x", role, length&0xC0)
+		case length == 0:
+			a.field(1, "%s: root label", role)
+			return nil
+		default:
+			if err := a.need(1 + int(length)); err != nil {
+				return err
+			}
+			label := dns.Name{{Length: length, Data: a.data[a.off+1 : a.off+1+int(length)]}}
+			a.field(1+int(length), "%s: label %q", role, label.String())
+		}
+	}
+}
+
+// need fails if fewer than n bytes are left
+func (a *annotator) need(n int) error {
+	if a.off+n > len(a.data) {
+		return fmt.Errorf("truncated at offset %d: need %d bytes, have %d", a.off, n, len(a.data)-a.off)
+	}
+	return nil
+}
+
+// uint16 returns the big-endian value at the current offset without
+// consuming it
+func (a *annotator) uint16() uint16 {
+	return binary.BigEndian.Uint16(a.data[a.off : a.off+2])
+}
+
+// field writes the next n bytes with their offset and annotation,
+// wrapping long fields over several lines
+func (a *annotator) field(n int, format string, args ...any) {
+	note := fmt.Sprintf(format, args...)
+	for n > 0 {
+		chunk := min(n, annotateBytesPerLine)
+		hex := fmt.Sprintf("% x", a.data[a.off:a.off+chunk])
+		fmt.Fprintf(&a.out, "%04x  %-*s  %s\n", a.off, annotateBytesPerLine*3-1, hex, note)
+		a.off += chunk
+		n -= chunk
+		note = ""
+	}
+}
+
+// comment writes a line starting a new part of the message
+func (a *annotator) comment(format string, args ...any) {
+	fmt.Fprintf(&a.out, "; "+format+"\n", args...)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotateMessage(t *testing.T) {
+	// Response to example.com/A whose answer owner and MX exchange use
+	// compression pointers to the question name
+	data := []byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		// Question: example.com A IN
+		0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x03, 'c', 'o', 'm', 0x00,
+		0x00, 0x01, 0x00, 0x01,
+		// Answer: example.com MX 10 mail.example.com
+		0xC0, 0x0C, 0x00, 0x0F, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C, 0x00, 0x09,
+		0x00, 0x0A, 0x04, 'm', 'a', 'i', 'l', 0xC0, 0x0C,
+	}
+
+	dump := AnnotateMessage(data)
+	for _, want := range []string{
+		"0000  12 34                    ID 0x1234\n",
+		"0002  81 80                    flags 0x8180: qr rd ra",
+		"0006  00 01                    ANCOUNT 1\n",
+		"000c  07 65 78 61 6d 70 6c 65  name: label \"example\"\n",
+		"0018  00                       name: root label\n",
+		"0019  00 01                    type A\n",
+		"; answer 1\n",
+		"001d  c0 0c                    owner: pointer to 0x000C (example.com.)\n",
+		"0021  00 01                    class IN\n",
+		"0023  00 00 01 2c              TTL 300\n",
+		"0027  00 09                    RDLENGTH 9\n",
+		"0029  00 0a                    10\n",
+		"002b  04 6d 61 69 6c           exchange: label \"mail\"\n",
+		"0030  c0 0c                    exchange: pointer to 0x000C (example.com.)\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("AnnotateMessage() is missing %q:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "malformed") {
+		t.Errorf("AnnotateMessage() reported a valid message as malformed:\n%s", dump)
+	}
+}
+
+func TestAnnotateMessageMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"short header", []byte{0x12, 0x34, 0x01}, "; malformed: header truncated: 3 bytes\n0000  12 34 01                 unparsed\n"},
+		{"truncated question", []byte{0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0x07, 'e', 'x'},
+			"; malformed: truncated at offset 12: need 8 bytes, have 3\n000c  07 65 78                 unparsed\n"},
+		{"pointer loop", []byte{0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0xC0, 0x0C, 0, 1, 0, 1},
+			"; malformed: name: "},
+		{"trailing data", []byte{0x12, 0x34, 0x01, 0x00, 0, 0, 0, 0, 0, 0, 0, 0, 0xFF},
+			"; trailing data\n000c  ff                       1 bytes\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if dump := AnnotateMessage(test.data); !strings.Contains(dump, test.want) {
+				t.Errorf("AnnotateMessage() = \n%s\nwant it to contain\n%s", dump, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// DefaultCacheSize is the number of responses a Cache created with a
+// size of zero holds
+const DefaultCacheSize = 1024
+
+// Cache holds responses until their TTL expires. Responses are keyed by
+// their question and the DO and CD bits of the query. Only NOERROR and
+// NXDOMAIN responses that are not truncated are cached; negative answers
+// are kept for the TTL of their SOA record (RFC 2308 Section 5). A Cache
+// is safe for concurrent use and may be shared by clients using the same
+// servers.
+type Cache struct {
+	MaxTTL time.Duration // Upper bound of the time a response is kept; zero means no bound
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*cacheEntry
+	now     func() time.Time
+}
+
+// cacheEntry is a cached response
+type cacheEntry struct {
+	response *dns.Message
+	stored   time.Time
+	expires  time.Time
+}
+
+// NewCache creates a cache holding up to size responses; zero uses
+// DefaultCacheSize
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{size: size, entries: make(map[string]*cacheEntry), now: time.Now}
+}
+
+// SetCache makes the client answer queries from c while their responses
+// are fresh and store the responses it receives in c; nil disables
+// caching. It must not be called concurrently with queries.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// Get returns a copy of the cached response to the query, with the ID of
+// the query and TTLs reduced by the time it was cached, or nil
+func (c *Cache) Get(query *dns.Message) *dns.Message {
+	key, ok := cacheKey(query)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	now := c.now()
+	if !now.Before(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+
+	response := copyMessage(entry.response, uint32(now.Sub(entry.stored)/time.Second))
+	response.Header.ID = query.Header.ID
+	return response
+}
+
+// Put stores the response to the query if it may be cached. When the
+// cache is full, expired responses are dropped, then the response that
+// expires first.
+func (c *Cache) Put(query, response *dns.Message) {
+	key, ok := cacheKey(query)
+	if !ok || response.Header.TC() {
+		return
+	}
+	ttl := cacheTTL(response)
+	if c.MaxTTL > 0 && ttl > c.MaxTTL {
+		ttl = c.MaxTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = &cacheEntry{response: copyMessage(response, 0), stored: now, expires: now.Add(ttl)}
+}
+
+// Len returns the number of cached responses, including expired ones not
+// yet dropped
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// evict drops expired responses or, if there are none, the response that
+// expires first
+func (c *Cache) evict(now time.Time) {
+	var first string
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if first == "" || entry.expires.Before(c.entries[first].expires) {
+			first = key
+		}
+	}
+	if len(c.entries) >= c.size {
+		delete(c.entries, first)
+	}
+}
+
+// cacheKey returns the key of a query with a single question
+func cacheKey(query *dns.Message) (string, bool) {
+	if len(query.Question) != 1 {
+		return "", false
+	}
+	q := query.Question[0]
+	return fmt.Sprintf("%s/%d/%d/%t/%t", q.Name.Key(), q.Type, q.Class, query.DNSSECOK(), query.Header.CD()), true
+}
+
+// cacheTTL returns how long a response may be cached: the lowest TTL of
+// its answer records or, for negative answers, the TTL of its SOA record
+func cacheTTL(response *dns.Message) time.Duration {
+	switch rc := response.Rcode(); {
+	case rc == dns.RcodeNXDomain || rc == dns.RcodeSuccess && len(response.Answer) == 0:
+		return negativeTTL(response)
+	case rc != dns.RcodeSuccess:
+		return 0
+	}
+
+	ttl := uint32(response.Answer[0].TTL)
+	for _, rr := range response.Answer[1:] {
+		ttl = min(ttl, uint32(rr.TTL))
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// copyMessage returns a copy of msg whose record TTLs are reduced by age
+// seconds. The OPT record, whose TTL field holds flags, is copied as is.
+func copyMessage(msg *dns.Message, age uint32) *dns.Message {
+	result := *msg
+	result.Question = append([]dns.Question(nil), msg.Question...)
+	sections := []*[]dns.ResourceRecord{&result.Answer, &result.Authority, &result.Additional}
+	for _, section := range sections {
+		rrs := append([]dns.ResourceRecord(nil), *section...)
+		for i := range rrs {
+			if rrs[i].Type == dns.TypeOPT {
+				continue
+			}
+			rrs[i].TTL = int32(uint32(rrs[i].TTL) - min(age, uint32(rrs[i].TTL)))
+		}
+		*section = rrs
+	}
+	return &result
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// testCache returns a cache whose clock is advanced through the returned
+// pointer
+func testCache(size int) (*Cache, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(size)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+// respond returns the response of the handler to a query for name
+func respond(t *testing.T, handler func(*dns.Message) (*dns.Message, error), name string, qtype dns.QType) (*dns.Message, *dns.Message) {
+	t.Helper()
+	query, err := dns.NewQuery(name, qtype).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	response, err := handler(query)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	return query, response
+}
+
+func TestCache(t *testing.T) {
+	cache, now := testCache(0)
+	query, response := respond(t, answerA("192.0.2.1"), "example.com", dns.TypeA)
+	cache.Put(query, response)
+
+	// Another query for the same question, differing in ID and case
+	again, err := dns.NewQuery("EXAMPLE.com", dns.TypeA).WithID(query.Header.ID + 1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(100 * time.Second)
+	cached := cache.Get(again)
+	if cached == nil {
+		t.Fatal("Get() = nil, want the cached response")
+	}
+	if cached.Header.ID != again.Header.ID {
+		t.Errorf("cached ID = %04X, want %04X", cached.Header.ID, again.Header.ID)
+	}
+	if cached.Answer[0].TTL != 200 {
+		t.Errorf("cached TTL = %d, want 200", cached.Answer[0].TTL)
+	}
+	if response.Answer[0].TTL != 300 {
+		t.Errorf("Get() changed the stored response TTL to %d", response.Answer[0].TTL)
+	}
+
+	other, err := dns.NewQuery("example.com", dns.TypeA).WithEDNS(dns.DefaultEDNSSize).WithDO().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Get(other) != nil {
+		t.Error("Get() with the DO bit returned the response to a query without it")
+	}
+
+	*now = now.Add(200 * time.Second)
+	if cache.Get(again) != nil {
+		t.Error("Get() returned an expired response")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d after expiry, want 0", cache.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	truncated := func(query *dns.Message) (*dns.Message, error) {
+		response, err := answerA("192.0.2.1")(query)
+		if err == nil {
+			response.Header.SetTC(true)
+		}
+		return response, err
+	}
+
+	tests := []struct {
+		name    string
+		handler func(*dns.Message) (*dns.Message, error)
+		maxTTL  time.Duration
+		want    time.Duration // Zero if not cached
+	}{
+		{"answer", answerA("192.0.2.1"), 0, 300 * time.Second},
+		{"answer capped", answerA("192.0.2.1"), time.Minute, time.Minute},
+		{"nxdomain uses SOA minimum", answerRcode(dns.RcodeNXDomain), 0, 60 * time.Second},
+		{"servfail", answerRcode(dns.RcodeServFail), 0, 0},
+		{"truncated", truncated, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, now := testCache(0)
+			cache.MaxTTL = tt.maxTTL
+			query, response := respond(t, tt.handler, "example.com", dns.TypeA)
+			cache.Put(query, response)
+
+			if tt.want == 0 {
+				if cache.Len() != 0 {
+					t.Error("Put() cached the response")
+				}
+				return
+			}
+			*now = now.Add(tt.want - time.Second)
+			if cache.Get(query) == nil {
+				t.Errorf("Get() = nil before %v", tt.want)
+			}
+			*now = now.Add(time.Second)
+			if cache.Get(query) != nil {
+				t.Errorf("Get() returned the response after %v", tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache, now := testCache(2)
+	first, response := respond(t, answerA("192.0.2.1"), "a.example", dns.TypeA)
+	cache.Put(first, response)
+	*now = now.Add(time.Second)
+	second, response := respond(t, answerA("192.0.2.2"), "b.example", dns.TypeA)
+	cache.Put(second, response)
+	third, response := respond(t, answerA("192.0.2.3"), "c.example", dns.TypeA)
+	cache.Put(third, response)
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if cache.Get(first) != nil {
+		t.Error("the response expiring first was not evicted")
+	}
+	if cache.Get(second) == nil || cache.Get(third) == nil {
+		t.Error("later responses were evicted")
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	client, fake := newResolveClient(t, answerA("192.0.2.1"))
+	client.SetCache(NewCache(0))
+
+	for i := 0; i < 2; i++ {
+		response, err := client.Query("example.com", dns.TypeA)
+		if err != nil {
+			t.Fatalf("Query() returned error: %v", err)
+		}
+		if len(response.Answer) != 1 {
+			t.Fatalf("Query() answers = %v, want one", response.Answer)
+		}
+	}
+	if len(fake.queries) != 1 {
+		t.Errorf("transport received %d queries, want 1", len(fake.queries))
+	}
+}
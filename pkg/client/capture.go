@@ -0,0 +1,92 @@
+package client
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/pcap"
+)
+
+// SetCapture makes the client write the queries and responses it
+// exchanges through UDPTransport and TCPTransport to w, as packets between
+// the actual local and remote addresses; nil stops capturing. It must not
+// be called concurrently with queries. Write errors are logged.
+func (c *Client) SetCapture(w *pcap.Writer) {
+	c.capture = w
+}
+
+// captureTrace returns the hooks writing one exchange to the capture
+func (c *Client) captureTrace(w *pcap.Writer) *Trace {
+	var network string
+	var local, remote netip.AddrPort
+	write := func(src, dst netip.AddrPort, data []byte) {
+		if err := w.WriteDNS(time.Now(), network, src, dst, data); err != nil {
+			c.logger.Warn("Failed to write capture", "error", err)
+		}
+	}
+
+	return &Trace{
+		DialDone: func(n string, l, r net.Addr, err error) {
+			if err == nil {
+				network, local, remote = n, addrPort(l), addrPort(r)
+			}
+		},
+		WroteQuery: func(data []byte, err error) {
+			if err == nil && remote.IsValid() {
+				write(local, remote, data)
+			}
+		},
+		ReadResponse: func(data []byte, err error) {
+			if err == nil && remote.IsValid() {
+				write(remote, local, data)
+			}
+		},
+	}
+}
+
+// addrPort returns the address of a UDP or TCP endpoint
+func addrPort(addr net.Addr) netip.AddrPort {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.AddrPort()
+	case *net.TCPAddr:
+		return a.AddrPort()
+	}
+	return netip.AddrPort{}
+}
+
+// CapturedMessage is a DNS message read from a packet capture
+type CapturedMessage struct {
+	*pcap.Message
+	Msg *dns.Message // Parsed message; nil if Err is set
+	Err error        // Error parsing Data
+}
+
+// ReadCapture returns the DNS messages to or from port in a pcap or pcapng
+// capture, parsed with ParseMessage. Messages that fail to parse are
+// returned with Err set; an error is returned only for unreadable
+// captures.
+func ReadCapture(r io.Reader, port uint16) ([]CapturedMessage, error) {
+	reader, err := pcap.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	dnsReader := pcap.NewDNSReader(reader)
+	dnsReader.Port = port
+
+	var messages []CapturedMessage
+	for {
+		m, err := dnsReader.Next()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		msg, err := ParseMessage(m.Data)
+		messages = append(messages, CapturedMessage{Message: m, Msg: msg, Err: err})
+	}
+}
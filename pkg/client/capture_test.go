@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/pcap"
+)
+
+func TestClientCapture(t *testing.T) {
+	for _, protocol := range []string{"udp", "tcp"} {
+		t.Run(protocol, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			if protocol == "udp" {
+				cfg.NameServer = serveUDP(t, answerA("192.0.2.1"))
+			} else {
+				cfg.NameServer = serveTCP(t, answerA("192.0.2.1"))
+			}
+			cfg.Protocol = protocol
+			cfg.RetryCount = 0
+			cfg.Timeout = 2 * time.Second
+			client, err := New(cfg, nil)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			writer, err := pcap.NewWriter(&buf, pcap.LinkTypeRaw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.SetCapture(writer)
+			if _, err := client.Query("example.com", dns.TypeA); err != nil {
+				t.Fatalf("Query() returned error: %v", err)
+			}
+
+			// The server does not listen on port 53
+			server := netip.MustParseAddrPort(cfg.NameServer)
+			messages, err := ReadCapture(&buf, server.Port())
+			if err != nil {
+				t.Fatalf("ReadCapture() returned error: %v", err)
+			}
+			if len(messages) != 2 {
+				t.Fatalf("ReadCapture() returned %d messages, want the query and the response", len(messages))
+			}
+			query, response := messages[0], messages[1]
+			if query.Err != nil || query.Msg.Header.IsResponse() || query.Dst != server || query.Network != protocol {
+				t.Errorf("first message = %+v, want a %s query to %s", query, protocol, server)
+			}
+			if response.Err != nil || !response.Msg.Header.IsResponse() || response.Src != server || response.Dst != query.Src {
+				t.Errorf("second message = %+v, want the response from %s", response, server)
+			}
+			if len(response.Msg.Answer) != 1 || response.Msg.Answer[0].RData.String() != "ADDRESS: 192.0.2.1" {
+				t.Errorf("captured answer = %v, want ADDRESS: 192.0.2.1", response.Msg.Answer)
+			}
+		})
+	}
+}
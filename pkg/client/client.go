@@ -2,392 +2,364 @@
 package client
 
 import (
-	"bytes"
-	"encoding/binary"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"math/rand"
-	"net"
-	"time"
+	"os"
+	"strings"
 
 	"dklbreitling/goDNS/internal/config"
 	"dklbreitling/goDNS/pkg/dns"
-	"dklbreitling/goDNS/pkg/records"
+	"dklbreitling/goDNS/pkg/dnstap"
+	"dklbreitling/goDNS/pkg/pcap"
 )
 
 // Client represents a DNS client
 type Client struct {
-	config *config.Config
-	logger *slog.Logger
+	config    *config.Config
+	logger    *slog.Logger
+	transport Transport
+	hosts     *Hosts    // Entries of Config.HostsFile, or nil
+	dumpOut   io.Writer // Destination of the dumps written with Config.Debug
+	dumps     dumper
+	capture   *pcap.Writer   // Destination of SetCapture, or nil
+	dnstap    *dnstap.Logger // Destination of SetDnstap, or nil
+	metrics   *Metrics       // Destination of SetMetrics, or nil
+	cache     *Cache         // Set by SetCache, or nil
 }
 
-// New creates a new DNS client with the given configuration
+// New creates a new DNS client with the given configuration, using the
+// transport selected by cfg.Protocol. A nil logger is replaced by one
+// writing to stderr at the configured level (config.Config.NewLogger).
 func New(cfg *config.Config, logger *slog.Logger) (*Client, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
-	
-	return &Client{
-		config: cfg,
-		logger: logger,
-	}, nil
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return NewWithTransport(cfg, logger, transport)
+}
+
+// NewWithTransport creates a new DNS client that sends all queries through
+// the given transport instead of the one selected by cfg.Protocol. As with
+// New, a nil logger uses the configured level. The hosts file named by
+// cfg.HostsFile is read once; a missing file is treated as empty.
+func NewWithTransport(cfg *config.Config, logger *slog.Logger, transport Transport) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if transport == nil {
+		return nil, fmt.Errorf("transport cannot be nil")
+	}
+	if logger == nil {
+		logger = cfg.NewLogger(os.Stderr)
+	}
+
+	var hosts *Hosts
+	if cfg.HostsFile != "" {
+		var err error
+		hosts, err = LoadHosts(cfg.HostsFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			hosts, err = nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load hosts file: %w", err)
+		}
+	}
+
+	c := &Client{
+		config:    cfg,
+		logger:    logger,
+		transport: transport,
+		hosts:     hosts,
+		dumpOut:   os.Stderr,
+	}
+	c.dumps.client = c
+	return c, nil
 }
 
-// Query performs a DNS query for the given domain and record type
+// Query performs a DNS query for the given domain and record type. The
+// domain may contain Unicode labels, which are sent as A-labels.
+// Address queries require a hostname; other types accept any DNS name
+// (dns.DNSPolicy), such as _dmarc or SRV owner names.
+// A, AAAA and PTR queries are answered from the hosts file when it has an
+// entry. Relative names are expanded with the search list (see
+// searchNames); a trailing dot makes a name absolute.
+// With Config.RcodeErrors set, responses with a failure RCODE are returned
+// as an *RcodeError instead.
 func (c *Client) Query(domain string, qtype dns.QType) (*dns.Message, error) {
-	// Validate domain
-	if err := dns.ValidateDomain(domain); err != nil {
+	return c.QueryWithPolicy(domain, qtype, defaultPolicy(qtype))
+}
+
+// QueryContext is like Query but sends the query with the given context,
+// which may carry a deadline or a Trace
+func (c *Client) QueryContext(ctx context.Context, domain string, qtype dns.QType) (*dns.Message, error) {
+	return c.query(ctx, domain, qtype, defaultPolicy(qtype))
+}
+
+// QueryWithPolicy is like Query but validates the domain with the given
+// policy
+func (c *Client) QueryWithPolicy(domain string, qtype dns.QType, policy dns.ValidationPolicy) (*dns.Message, error) {
+	return c.query(context.Background(), domain, qtype, policy)
+}
+
+// query validates the domain, then sends a query for it and returns the
+// response
+func (c *Client) query(ctx context.Context, domain string, qtype dns.QType, policy dns.ValidationPolicy) (*dns.Message, error) {
+	// Encode Unicode labels as A-labels, then validate
+	domain, err := dns.ToASCII(domain)
+	if err != nil {
 		return nil, fmt.Errorf("invalid domain: %w", err)
 	}
-	
-	// Build query message
-	query, err := c.buildQuery(domain, qtype)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build query: %w", err)
+	if err := policy.Validate(domain); err != nil {
+		return nil, fmt.Errorf("invalid domain: %w", err)
 	}
-	
-	// Send query and receive response
-	response, err := c.sendQuery(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send query: %w", err)
+
+	// The hosts file is consulted for the name as given, before the network
+	given := strings.TrimSuffix(domain, ".")
+	if response := c.lookupHosts(given, qtype); response != nil {
+		return response, nil
+	}
+
+	// Try each candidate name until one has answers. If none has, the
+	// response for the name as given is returned.
+	var response *dns.Message
+	for _, name := range c.searchNames(domain) {
+		query, err := c.buildQuery(name, qtype)
+		if err != nil {
+			if name != given {
+				// A search domain made the name too long
+				continue
+			}
+			return nil, fmt.Errorf("failed to build query: %w", err)
+		}
+
+		answer, err := c.exchange(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send query: %w", err)
+		}
+		if len(answer.Answer) > 0 {
+			response = answer
+			break
+		}
+		if name == given {
+			response = answer
+		}
+	}
+
+	if c.config.RcodeErrors {
+		if err := checkRcode(response); err != nil {
+			return nil, err
+		}
 	}
 	
 	return response, nil
 }
 
-// buildQuery creates a DNS query message
-func (c *Client) buildQuery(domain string, qtype dns.QType) (*dns.Message, error) {
-	// Generate random query ID
-	queryID := uint16(rand.Intn(65536))
-	
-	// Build header
-	flags := dns.HeaderQRQuery | dns.HeaderOpcodeQuery
-	if c.config.RecursionDesired {
-		flags |= dns.HeaderRD
+// searchNames returns the names to query for domain, in order (the
+// ndots rule of resolv.conf(5)). A name with a trailing dot is only tried
+// as is. Other names are tried as is first if they have at least
+// Config.NDots dots and last otherwise, with each search domain appended
+// in between.
+func (c *Client) searchNames(domain string) []string {
+	if strings.HasSuffix(domain, ".") {
+		return []string{strings.TrimSuffix(domain, ".")}
 	}
-	
-	header := dns.Header{
-		ID:      queryID,
-		Flags:   flags,
-		QDCount: 1,
-		ANCount: 0,
-		NSCount: 0,
-		ARCount: 0,
+
+	var names []string
+	for _, suffix := range c.config.Search {
+		names = append(names, domain+"."+strings.TrimSuffix(suffix, "."))
 	}
-	
-	// Build question
-	question := dns.Question{
-		Name:  dns.StringToLabels(domain),
-		Type:  qtype,
-		Class: dns.ClassIN,
+	if strings.Count(domain, ".") >= c.config.NDots {
+		return append([]string{domain}, names...)
 	}
-	
-	return &dns.Message{
-		Header:     header,
-		Question:   []dns.Question{question},
-		Answer:     nil,
-		Authority:  nil,
-		Additional: nil,
-	}, nil
+	return append(names, domain)
 }
 
-// sendQuery sends a DNS query and returns the response
-func (c *Client) sendQuery(query *dns.Message) (*dns.Message, error) {
-	// Convert query to bytes
-	queryBytes, err := query.ToBytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to serialize query: %w", err)
+// lookupHosts returns a response built from the hosts file, or nil if the
+// file has no entry for the name of type qtype
+func (c *Client) lookupHosts(name string, qtype dns.QType) *dns.Message {
+	if c.hosts == nil {
+		return nil
 	}
-	
-	// Add TCP length prefix if needed
-	if c.config.Protocol == "tcp" {
-		length := uint16(len(queryBytes))
-		buf := new(bytes.Buffer)
-		if err := binary.Write(buf, binary.BigEndian, length); err != nil {
-			return nil, fmt.Errorf("failed to add TCP length prefix: %w", err)
-		}
-		queryBytes = append(buf.Bytes(), queryBytes...)
-	}
-	
-	c.logger.Debug("Sending DNS query", "size", len(queryBytes), "protocol", c.config.Protocol)
-	
-	// Connect to DNS server
-	conn, err := net.DialTimeout(c.config.Protocol, c.config.NameServer, c.config.Timeout)
+	query, err := c.buildQuery(name, qtype)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to DNS server: %w", err)
-	}
-	defer conn.Close()
-	
-	// Set write deadline
-	if err := conn.SetWriteDeadline(time.Now().Add(c.config.Timeout)); err != nil {
-		return nil, fmt.Errorf("failed to set write deadline: %w", err)
+		return nil
 	}
-	
-	// Send query
-	n, err := conn.Write(queryBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write query: %w", err)
+	response := hostsResponse(c.hosts, query)
+	if response != nil {
+		c.logger.Debug("Answered from hosts file", "name", name, "type", qtype)
+		if c.metrics != nil {
+			c.metrics.HostsAnswers.Inc(qtype.String())
+		}
 	}
-	if n != len(queryBytes) {
-		return nil, fmt.Errorf("incomplete write: wrote %d bytes, expected %d", n, len(queryBytes))
+	return response
+}
+
+// defaultPolicy returns the validation policy Query uses for qtype
+func defaultPolicy(qtype dns.QType) dns.ValidationPolicy {
+	if qtype == dns.TypeA || qtype == dns.TypeAAAA {
+		return dns.HostnamePolicy
 	}
-	
-	// Set read deadline
-	if err := conn.SetReadDeadline(time.Now().Add(c.config.Timeout)); err != nil {
-		return nil, fmt.Errorf("failed to set read deadline: %w", err)
+	return dns.DNSPolicy
+}
+
+// Exchange sends a caller-built message and returns the validated response.
+// The message may use any opcode, class, flags and sections; it is sent
+// as-is except that a zero ID is replaced with a random one. The caller's
+// message is not modified.
+func (c *Client) Exchange(msg *dns.Message) (*dns.Message, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("message cannot be nil")
 	}
-	
-	// Read response
-	responseBytes := make([]byte, c.config.GetMaxMessageSize())
-	n, err = conn.Read(responseBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+
+	query := *msg
+	if query.Header.ID == 0 {
+		query.Header.ID = uint16(rand.Intn(65536))
 	}
-	responseBytes = responseBytes[:n]
-	
-	c.logger.Debug("Received DNS response", "size", n)
-	
-	// Parse response
-	response, err := c.parseResponse(responseBytes, query.Header.ID)
+
+	response, err := c.exchange(context.Background(), &query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to exchange message: %w", err)
 	}
-	
+
 	return response, nil
 }
 
-// parseResponse parses a DNS response from wire format
-func (c *Client) parseResponse(data []byte, expectedID uint16) (*dns.Message, error) {
-	// Handle TCP length prefix
-	if c.config.Protocol == "tcp" {
-		if len(data) < 2 {
-			return nil, fmt.Errorf("TCP response too short for length prefix")
+// exchange answers the query from the cache set with SetCache, or sends
+// it and checks that the response answers it
+func (c *Client) exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	if c.cache != nil {
+		response := c.cache.Get(query)
+		if c.metrics != nil {
+			if response != nil {
+				c.metrics.CacheHits.Inc(queryType(query))
+			} else {
+				c.metrics.CacheMisses.Inc(queryType(query))
+			}
 		}
-		length := binary.BigEndian.Uint16(data[:2])
-		if int(length) != len(data)-2 {
-			return nil, fmt.Errorf("TCP length mismatch: expected %d, got %d", length, len(data)-2)
+		if response != nil {
+			c.logger.Debug("Answered DNS query from cache", "id", query.Header.ID)
+			return response, nil
 		}
-		data = data[2:] // Remove length prefix
-	}
-	
-	if len(data) < 12 {
-		return nil, fmt.Errorf("DNS response too short: %d bytes", len(data))
 	}
-	
-	index := 0
-	
-	// Parse header
-	header := dns.Header{
-		ID:      binary.BigEndian.Uint16(data[index : index+2]),
-		Flags:   dns.HeaderBitfield(binary.BigEndian.Uint16(data[index+2 : index+4])),
-		QDCount: binary.BigEndian.Uint16(data[index+4 : index+6]),
-		ANCount: binary.BigEndian.Uint16(data[index+6 : index+8]),
-		NSCount: binary.BigEndian.Uint16(data[index+8 : index+10]),
-		ARCount: binary.BigEndian.Uint16(data[index+10 : index+12]),
-	}
-	index += 12
-	
-	// Verify query ID matches
-	if header.ID != expectedID {
-		return nil, fmt.Errorf("response ID %d does not match query ID %d", header.ID, expectedID)
+
+	response, err := c.sendQuery(ctx, query)
+	if err != nil {
+		return nil, err
 	}
-	
-	message := &dns.Message{
-		Header:     header,
-		Question:   make([]dns.Question, header.QDCount),
-		Answer:     make([]dns.ResourceRecord, header.ANCount),
-		Authority:  make([]dns.ResourceRecord, header.NSCount),
-		Additional: make([]dns.ResourceRecord, header.ARCount),
+
+	if err := validateResponse(query, response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
 	}
-	
-	// Parse questions (should match what we sent)
-	for i := uint16(0); i < header.QDCount; i++ {
-		question, newIndex, err := c.parseQuestion(data, index)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse question %d: %w", i, err)
-		}
-		message.Question[i] = question
-		index = newIndex
+
+	if c.cache != nil {
+		c.cache.Put(query, response)
 	}
-	
-	// Parse answer records
-	for i := uint16(0); i < header.ANCount; i++ {
-		rr, newIndex, err := c.parseResourceRecord(data, index)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse answer record %d: %w", i, err)
-		}
-		message.Answer[i] = rr
-		index = newIndex
+	return response, nil
+}
+
+// buildQuery creates a DNS query message
+func (c *Client) buildQuery(domain string, qtype dns.QType) (*dns.Message, error) {
+	builder := dns.NewQuery(domain, qtype)
+	if c.config.RecursionDesired {
+		builder.WithRD()
 	}
-	
-	// Parse authority records
-	for i := uint16(0); i < header.NSCount; i++ {
-		rr, newIndex, err := c.parseResourceRecord(data, index)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse authority record %d: %w", i, err)
-		}
-		message.Authority[i] = rr
-		index = newIndex
+	if c.config.EDNS || c.config.DNSSEC {
+		builder.WithEDNS(dns.DefaultEDNSSize)
 	}
-	
-	// Parse additional records
-	for i := uint16(0); i < header.ARCount; i++ {
-		rr, newIndex, err := c.parseResourceRecord(data, index)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse additional record %d: %w", i, err)
-		}
-		message.Additional[i] = rr
-		index = newIndex
+	if c.config.DNSSEC {
+		builder.WithDO()
 	}
-	
-	return message, nil
+	return builder.Build()
 }
 
-// parseQuestion parses a DNS question from wire format
-func (c *Client) parseQuestion(data []byte, index int) (dns.Question, int, error) {
-	// Parse name labels
-	labels, newIndex, err := c.parseLabels(data, index)
-	if err != nil {
-		return dns.Question{}, 0, fmt.Errorf("failed to parse question name: %w", err)
+// sendQuery sends a DNS query through the transport and returns the
+// response. With Config.Debug or Config.DumpFiles, the exchanged bytes are
+// dumped, with SetCapture they are written to the capture, with
+// SetDnstap they are logged to dnstap and with SetMetrics they are
+// counted.
+func (c *Client) sendQuery(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	c.logger.Debug("Sending DNS query", "id", query.Header.ID, "protocol", c.config.Protocol)
+
+	var dump *dumpExchange
+	if c.config.Debug || c.config.DumpFiles {
+		dump = c.dumps.start(query)
+		ctx = WithTrace(ctx, dump.trace())
 	}
-	
-	if newIndex+4 > len(data) {
-		return dns.Question{}, 0, fmt.Errorf("question data truncated")
+	if c.capture != nil {
+		ctx = WithTrace(ctx, c.captureTrace(c.capture))
+	}
+	var tap *tapExchange
+	if c.dnstap != nil {
+		tap = &tapExchange{c: c}
+		ctx = WithTrace(ctx, tap.trace())
+	}
+	var measure *metricsExchange
+	if c.metrics != nil {
+		measure = newMetricsExchange(c.metrics, query, c.config.Protocol)
+		ctx = WithTrace(ctx, measure.trace())
 	}
-	
-	qtype := dns.QType(binary.BigEndian.Uint16(data[newIndex : newIndex+2]))
-	qclass := dns.QClass(binary.BigEndian.Uint16(data[newIndex+2 : newIndex+4]))
-	
-	return dns.Question{
-		Name:  labels,
-		Type:  qtype,
-		Class: qclass,
-	}, newIndex + 4, nil
-}
 
-// parseResourceRecord parses a DNS resource record from wire format
-func (c *Client) parseResourceRecord(data []byte, index int) (dns.ResourceRecord, int, error) {
-	// Parse name labels
-	labels, newIndex, err := c.parseLabels(data, index)
-	if err != nil {
-		return dns.ResourceRecord{}, 0, fmt.Errorf("failed to parse RR name: %w", err)
+	response, err := c.transport.Exchange(ctx, query)
+	if dump != nil {
+		dump.finish(query, response)
 	}
-	
-	if newIndex+10 > len(data) {
-		return dns.ResourceRecord{}, 0, fmt.Errorf("RR header data truncated")
+	if tap != nil {
+		tap.finish(query, response)
 	}
-	
-	rrType := dns.QType(binary.BigEndian.Uint16(data[newIndex : newIndex+2]))
-	rrClass := dns.QClass(binary.BigEndian.Uint16(data[newIndex+2 : newIndex+4]))
-	ttl := int32(binary.BigEndian.Uint32(data[newIndex+4 : newIndex+8]))
-	rdLength := binary.BigEndian.Uint16(data[newIndex+8 : newIndex+10])
-	newIndex += 10
-	
-	if newIndex+int(rdLength) > len(data) {
-		return dns.ResourceRecord{}, 0, fmt.Errorf("RR data truncated")
+	if measure != nil {
+		measure.finish(response, err)
 	}
-	
-	// Parse resource data based on type
-	var rdata dns.ResourceData
-	rdataBytes := data[newIndex : newIndex+int(rdLength)]
-	
-	switch rrType {
-	case dns.TypeA:
-		if len(rdataBytes) != 4 {
-			return dns.ResourceRecord{}, 0, fmt.Errorf("invalid A record length: %d", len(rdataBytes))
-		}
-		ip := net.IPv4(rdataBytes[0], rdataBytes[1], rdataBytes[2], rdataBytes[3])
-		if aRecord, err := records.NewARecord(ip); err == nil {
-			rdata = aRecord
-		} else {
-			rdata = records.NewGenericRecord(rrType, rdataBytes)
-		}
-	case dns.TypeAAAA:
-		if len(rdataBytes) != 16 {
-			return dns.ResourceRecord{}, 0, fmt.Errorf("invalid AAAA record length: %d", len(rdataBytes))
-		}
-		ip := net.IP(rdataBytes)
-		if aaaaRecord, err := records.NewAAAARecord(ip); err == nil {
-			rdata = aaaaRecord
-		} else {
-			rdata = records.NewGenericRecord(rrType, rdataBytes)
-		}
-	case dns.TypeNS:
-		nsLabels, _, err := c.parseLabels(data, newIndex)
-		if err != nil {
-			rdata = records.NewGenericRecord(rrType, rdataBytes)
-		} else {
-			rdata = records.NewNSRecord(nsLabels)
-		}
-	default:
-		rdata = records.NewGenericRecord(rrType, rdataBytes)
+	if err != nil {
+		return nil, err
 	}
-	
-	return dns.ResourceRecord{
-		Name:     labels,
-		Type:     rrType,
-		Class:    rrClass,
-		TTL:      ttl,
-		RDLength: rdLength,
-		RData:    rdata,
-	}, newIndex + int(rdLength), nil
+
+	c.logger.Debug("Received DNS response", "id", response.Header.ID)
+
+	return response, nil
 }
 
-// parseLabels parses DNS labels from wire format, handling compression
-func (c *Client) parseLabels(data []byte, index int) ([]dns.Label, int, error) {
-	var labels []dns.Label
-	originalIndex := index
-	followed := false
-	
-	for index < len(data) {
-		length := data[index]
-		
-		// Check for compression pointer
-		if length&0xC0 == 0xC0 {
-			if index+1 >= len(data) {
-				return nil, 0, fmt.Errorf("compression pointer truncated")
-			}
-			pointer := int(binary.BigEndian.Uint16(data[index:index+2]) & 0x3FFF)
-			if pointer >= len(data) {
-				return nil, 0, fmt.Errorf("invalid compression pointer: %d", pointer)
-			}
-			
-			// Follow the pointer recursively
-			compressedLabels, _, err := c.parseLabels(data, pointer)
-			if err != nil {
-				return nil, 0, fmt.Errorf("failed to follow compression pointer: %w", err)
-			}
-			labels = append(labels, compressedLabels...)
-			
-			if !followed {
-				return labels, index + 2, nil
-			}
-			return labels, originalIndex + 2, nil
-		}
-		
-		// Regular label
-		if length == 0 {
-			// Null terminator
-			labels = append(labels, dns.Label{Length: 0, Data: nil})
-			if !followed {
-				return labels, index + 1, nil
-			}
-			return labels, originalIndex + 1, nil
-		}
-		
-		if index+1+int(length) > len(data) {
-			return nil, 0, fmt.Errorf("label data truncated")
-		}
-		
-		label := dns.Label{
-			Length: length,
-			Data:   make([]byte, length),
+// validateResponse checks that the response belongs to the query: same ID
+// and opcode, QR bit set, and, when the server echoes the question section,
+// the same questions (names compared case-insensitively)
+func validateResponse(query, response *dns.Message) error {
+	if response.Header.ID != query.Header.ID {
+		return fmt.Errorf("response ID %d does not match query ID %d", response.Header.ID, query.Header.ID)
+	}
+
+	if !response.Header.IsResponse() {
+		return fmt.Errorf("QR bit not set in response")
+	}
+
+	if response.Header.Opcode() != query.Header.Opcode() {
+		return fmt.Errorf("response opcode %s does not match query opcode %s",
+			response.Header.Opcode(), query.Header.Opcode())
+	}
+
+	// Servers may omit the question section, e.g. in FORMERR responses
+	if len(response.Question) == 0 {
+		return nil
+	}
+
+	if len(response.Question) != len(query.Question) {
+		return fmt.Errorf("response has %d questions, query has %d", len(response.Question), len(query.Question))
+	}
+
+	for i, q := range query.Question {
+		r := response.Question[i]
+		if r.Type != q.Type || r.Class != q.Class || !r.Name.Equal(q.Name) {
+			return fmt.Errorf("response question %q does not match query question %q", r.String(), q.String())
 		}
-		copy(label.Data, data[index+1:index+1+int(length)])
-		labels = append(labels, label)
-		
-		index += 1 + int(length)
 	}
-	
-	return nil, 0, fmt.Errorf("labels not properly terminated")
+
+	return nil
 }
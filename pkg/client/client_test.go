@@ -3,11 +3,13 @@ package client
 import (
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"dklbreitling/goDNS/internal/config"
 	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
 )
 
 func TestNewClient(t *testing.T) {
@@ -63,3 +65,268 @@ func TestClientQueryValidation(t *testing.T) {
 
 // Note: We don't test actual network queries in unit tests
 // Those would be integration tests that require network access
+
+func TestClientExchange(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: func(query *dns.Message) (*dns.Message, error) {
+		return &dns.Message{
+			Header: dns.Header{
+				ID:      query.Header.ID,
+				Flags:   dns.HeaderQRResponse | dns.HeaderAA,
+				QDCount: 1,
+				ANCount: 1,
+			},
+			Question: query.Question,
+			Answer: []dns.ResourceRecord{
+				{
+					Name:  query.Question[0].Name,
+					Type:  dns.TypeTXT,
+					Class: dns.ClassCH,
+					RData: records.NewGenericRecord(dns.TypeTXT, []byte("\x069.18.0")),
+				},
+			},
+		}, nil
+	}}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	query := &dns.Message{
+		Header: dns.Header{QDCount: 1, ARCount: 1},
+		Question: []dns.Question{
+			{Name: dns.MustParseName("version.bind"), Type: dns.TypeTXT, Class: dns.ClassCH},
+		},
+		Additional: []dns.ResourceRecord{
+			{Name: dns.MustParseName(""), Type: dns.TypeOPT, Class: dns.QClass(1232), RData: &dns.OPT{}},
+		},
+	}
+
+	response, err := client.Exchange(query)
+	if err != nil {
+		t.Fatalf("Exchange() returned error: %v", err)
+	}
+
+	if query.Header.ID != 0 {
+		t.Error("Exchange() should not modify the caller's message")
+	}
+	sent := fake.queries[0]
+	if sent.Header.Flags&dns.HeaderRD != 0 {
+		t.Error("Exchange() should send the flags as given")
+	}
+	if len(sent.Additional) != 1 || sent.Question[0].Class != dns.ClassCH {
+		t.Error("Exchange() should send the message sections as given")
+	}
+	if len(response.Answer) != 1 || response.Answer[0].Class != dns.ClassCH {
+		t.Errorf("Exchange() answer = %v, want one CH record", response.Answer)
+	}
+}
+
+func TestClientExchangeValidation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	tests := []struct {
+		name   string
+		mutate func(response *dns.Message)
+	}{
+		{"wrong ID", func(r *dns.Message) { r.Header.ID++ }},
+		{"QR not set", func(r *dns.Message) { r.Header.Flags &^= dns.HeaderQRResponse }},
+		{"wrong opcode", func(r *dns.Message) { r.Header.Flags |= dns.HeaderOpcodeStatus }},
+		{"wrong question", func(r *dns.Message) {
+			r.Question = []dns.Question{{Name: dns.MustParseName("example.org"), Type: dns.TypeA, Class: dns.ClassIN}}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeTransport{handler: func(query *dns.Message) (*dns.Message, error) {
+				response, err := answerA("192.0.2.1")(query)
+				if err != nil {
+					return nil, err
+				}
+				test.mutate(response)
+				return response, nil
+			}}
+
+			client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+			if err != nil {
+				t.Fatalf("NewWithTransport() returned error: %v", err)
+			}
+
+			if _, err := client.Query("example.com", dns.TypeA); err == nil {
+				t.Error("Query() should reject the response")
+			}
+		})
+	}
+}
+
+func TestClientQueryCaseInsensitiveQuestion(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: func(query *dns.Message) (*dns.Message, error) {
+		response, err := answerA("192.0.2.1")(query)
+		if err != nil {
+			return nil, err
+		}
+		response.Question = []dns.Question{{Name: dns.MustParseName("EXAMPLE.com"), Type: dns.TypeA, Class: dns.ClassIN}}
+		return response, nil
+	}}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Errorf("Query() returned error: %v", err)
+	}
+}
+
+func TestClientQueryDNSSEC(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+
+	cfg := config.DefaultConfig()
+	cfg.DNSSEC = true
+	client, err := NewWithTransport(cfg, logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	query := fake.queries[0]
+	if !query.DNSSECOK() {
+		t.Error("query should set the DO bit when DNSSEC is enabled")
+	}
+	if query.UDPSize() != dns.DefaultEDNSSize {
+		t.Errorf("query UDP size = %d, want %d", query.UDPSize(), dns.DefaultEDNSSize)
+	}
+}
+
+func TestClientQueryUnicodeDomain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	if _, err := client.Query("München.de", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if name := fake.queries[0].Question[0].Name.String(); name != "xn--mnchen-3ya.de" {
+		t.Errorf("query name = %q, want %q", name, "xn--mnchen-3ya.de")
+	}
+
+	if _, err := client.Query("mün☃chen.de", dns.TypeA); err == nil {
+		t.Error("Query() should return error for disallowed Unicode characters")
+	}
+}
+
+func TestClientQueryPolicy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	// Address queries require hostnames unless a policy says otherwise
+	if _, err := client.Query("_acme-challenge.example.com", dns.TypeA); err == nil {
+		t.Error("Query() should reject underscores for address queries")
+	}
+	if _, err := client.QueryWithPolicy("_acme-challenge.example.com", dns.TypeA, dns.UnderscorePolicy); err != nil {
+		t.Errorf("QueryWithPolicy() returned error: %v", err)
+	}
+
+	// Other types accept any DNS name by default
+	if _, err := client.Query("_dmarc.example.com", dns.TypeNS); err != nil {
+		t.Errorf("Query() returned error for underscore name: %v", err)
+	}
+	if len(fake.queries) != 2 {
+		t.Errorf("sent %d queries, want 2", len(fake.queries))
+	}
+}
+
+func TestClientQueryEDNS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+
+	cfg := config.DefaultConfig()
+	cfg.EDNS = true
+	client, err := NewWithTransport(cfg, logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	query := fake.queries[0]
+	if query.UDPSize() != dns.DefaultEDNSSize || query.DNSSECOK() {
+		t.Errorf("query UDP size = %d, DO = %t, want %d without DO", query.UDPSize(), query.DNSSECOK(), dns.DefaultEDNSSize)
+	}
+}
+
+func TestClientQuerySearch(t *testing.T) {
+	zone := []dns.ResourceRecord{
+		aliasRecord("www.corp.example", dns.TypeA, "192.0.2.1"),
+		aliasRecord("www.example.com", dns.TypeA, "192.0.2.2"),
+		aliasRecord("host.sub.corp.example", dns.TypeA, "192.0.2.3"),
+	}
+
+	tests := []struct {
+		domain   string
+		ndots    int
+		queries  []string
+		expected string
+	}{
+		// Too few dots: the search list comes first
+		{"www", 1, []string{"www.example.com"}, "192.0.2.2"},
+		{"www.corp", 2, []string{"www.corp.example.com", "www.corp.corp.example", "www.corp"}, ""},
+		// Enough dots: the name as given comes first
+		{"host.sub", 1, []string{"host.sub", "host.sub.example.com", "host.sub.corp.example"}, "192.0.2.3"},
+		{"www.example.com", 1, []string{"www.example.com"}, "192.0.2.2"},
+		// Absolute names are never expanded
+		{"www.", 1, []string{"www"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.domain, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+			fake := &fakeTransport{handler: serveRecords(zone, false)}
+			cfg := config.DefaultConfig()
+			cfg.Search = []string{"example.com", "corp.example."}
+			cfg.NDots = test.ndots
+			client, err := NewWithTransport(cfg, logger, fake)
+			if err != nil {
+				t.Fatalf("NewWithTransport() returned error: %v", err)
+			}
+
+			response, err := client.Query(test.domain, dns.TypeA)
+			if err != nil {
+				t.Fatalf("Query() returned error: %v", err)
+			}
+			var queries []string
+			for _, query := range fake.queries {
+				queries = append(queries, query.Question[0].Name.String())
+			}
+			if strings.Join(queries, " ") != strings.Join(test.queries, " ") {
+				t.Errorf("queried %v, want %v", queries, test.queries)
+			}
+
+			if test.expected == "" {
+				// The response for the name as given is returned
+				if response.Rcode() != dns.RcodeNXDomain || response.Question[0].Name.String() != strings.TrimSuffix(test.domain, ".") {
+					t.Errorf("Query() = %s for %s, want NXDOMAIN for the name as given", response.Rcode(), response.Question[0].Name)
+				}
+			} else if len(response.Answer) != 1 || response.Answer[0].RData.String() != "ADDRESS: "+test.expected {
+				t.Errorf("Query() answer = %v, want %s", response.Answer, test.expected)
+			}
+		})
+	}
+}
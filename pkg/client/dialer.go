@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Dial connects Go's stub resolver to the client. Use it as the Dial
+// function of a net.Resolver with PreferGo set (see NetResolver): every
+// query the resolver writes is answered from the client's cache (see
+// SetCache) or sent through its transport, whatever server address the
+// resolver asks for.
+//
+// The returned connection is not a net.PacketConn, so the stub resolver
+// frames its messages with the two-byte length prefix of TCP on every
+// network.
+func (c *Client) Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	return &stubConn{client: c, network: network, address: address}, nil
+}
+
+// NetResolver returns a net.Resolver that resolves through the client
+func (c *Client) NetResolver() *net.Resolver {
+	return &net.Resolver{PreferGo: true, Dial: c.Dial}
+}
+
+// stubConn is the in-process connection returned by Dial. Each complete
+// query written to it is exchanged synchronously; the framed response is
+// then available for reading. Exchanges run without holding the mutex, so
+// concurrent writes do not wait for each other's exchanges.
+type stubConn struct {
+	client   *Client
+	network  string
+	address  string
+	mu       sync.Mutex
+	pending  bytes.Buffer // Query bytes written so far
+	response bytes.Buffer // Framed responses not yet read
+	deadline time.Time
+	closed   bool
+}
+
+// Write buffers query data and exchanges every complete message
+func (s *stubConn) Write(b []byte) (int, error) {
+	queries, deadline, err := s.complete(b)
+	if err != nil {
+		return 0, err
+	}
+	for _, query := range queries {
+		framed, err := s.exchange(query, deadline)
+		if err != nil {
+			return 0, err
+		}
+		s.mu.Lock()
+		if !s.closed {
+			s.response.Write(framed)
+		}
+		s.mu.Unlock()
+	}
+	return len(b), nil
+}
+
+// complete buffers query data and returns the complete messages written
+// so far, with the deadline for exchanging them
+func (s *stubConn) complete(b []byte) ([][]byte, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, time.Time{}, net.ErrClosed
+	}
+
+	var queries [][]byte
+	s.pending.Write(b)
+	for s.pending.Len() >= 2 {
+		length := int(binary.BigEndian.Uint16(s.pending.Bytes()))
+		if s.pending.Len() < 2+length {
+			break
+		}
+		s.pending.Next(2)
+		queries = append(queries, bytes.Clone(s.pending.Next(length)))
+	}
+	return queries, s.deadline, nil
+}
+
+// exchange sends one query through the client and returns the framed
+// response
+func (s *stubConn) exchange(data []byte, deadline time.Time) ([]byte, error) {
+	query, err := ParseMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query from resolver: %w", err)
+	}
+
+	ctx := context.Background()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	response, err := s.client.exchange(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	responseBytes, err := response.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize response: %w", err)
+	}
+	if len(responseBytes) > 65535 {
+		return nil, fmt.Errorf("response too large: %d bytes", len(responseBytes))
+	}
+
+	framed := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(responseBytes)), uint16(len(responseBytes)))
+	return append(framed, responseBytes...), nil
+}
+
+// Read returns queued response data. There is nothing to wait for: a
+// read without a preceding query is an error.
+func (s *stubConn) Read(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, net.ErrClosed
+	}
+	if s.response.Len() == 0 {
+		return 0, errors.New("no response pending")
+	}
+	return s.response.Read(b)
+}
+
+// Close discards buffered data
+func (s *stubConn) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.pending.Reset()
+	s.response.Reset()
+	return nil
+}
+
+// LocalAddr returns a placeholder address
+func (s *stubConn) LocalAddr() net.Addr {
+	return stubAddr{network: s.network, address: "goDNS"}
+}
+
+// RemoteAddr returns the address the resolver dialed
+func (s *stubConn) RemoteAddr() net.Addr {
+	return stubAddr{network: s.network, address: s.address}
+}
+
+// SetDeadline sets the deadline for the exchanges of later writes
+func (s *stubConn) SetDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadline = t
+	return nil
+}
+
+// SetReadDeadline has no effect, since reads never block
+func (s *stubConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline is like SetDeadline, since writes do the exchange
+func (s *stubConn) SetWriteDeadline(t time.Time) error {
+	return s.SetDeadline(t)
+}
+
+// stubAddr is the net.Addr of a stubConn
+type stubAddr struct {
+	network, address string
+}
+
+func (a stubAddr) Network() string { return a.network }
+func (a stubAddr) String() string  { return a.address }
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestClientNetResolver(t *testing.T) {
+	a, err := records.NewARecordFromString("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aaaa, err := records.NewAAAARecordFromString("2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone := []dns.ResourceRecord{
+		record("gateway.example.com", a),
+		record("gateway.example.com", aaaa),
+		record("example.com", records.NewMXRecord(10, dns.MustParseName("mail.example.com"))),
+	}
+	client, fake := newResolveClient(t, serveRecords(zone, true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resolver := client.NetResolver()
+
+	addrs, err := resolver.LookupHost(ctx, "gateway.example.com.")
+	if err != nil {
+		t.Fatalf("LookupHost() returned error: %v", err)
+	}
+	if len(addrs) != 2 || !strings.Contains(strings.Join(addrs, " "), "192.0.2.1") || !strings.Contains(strings.Join(addrs, " "), "2001:db8::1") {
+		t.Errorf("LookupHost() = %v, want 192.0.2.1 and 2001:db8::1", addrs)
+	}
+
+	mxs, err := resolver.LookupMX(ctx, "example.com.")
+	if err != nil || len(mxs) != 1 || mxs[0].Host != "mail.example.com." {
+		t.Errorf("LookupMX() = %v, %v, want mail.example.com.", mxs, err)
+	}
+
+	_, err = resolver.LookupHost(ctx, "missing.example.com.")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Errorf("LookupHost(missing) error = %v, want not found", err)
+	}
+
+	if len(fake.queries) == 0 {
+		t.Error("resolver did not send queries through the client transport")
+	}
+}
+
+func TestStubConnFraming(t *testing.T) {
+	client, _ := newResolveClient(t, answerA("192.0.2.1"))
+	conn, err := client.Dial(context.Background(), "udp", "192.0.2.53:53")
+	if err != nil {
+		t.Fatalf("Dial() returned error: %v", err)
+	}
+	defer conn.Close()
+
+	query, err := dns.NewQuery("example.com", dns.TypeA).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	data, err := query.ToBytes()
+	if err != nil {
+		t.Fatalf("ToBytes() returned error: %v", err)
+	}
+	framed := append([]byte{byte(len(data) >> 8), byte(len(data))}, data...)
+
+	// The query may arrive in pieces
+	if _, err := conn.Write(framed[:3]); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if _, err := conn.Read(make([]byte, 2)); err == nil {
+		t.Error("Read() before a complete query should return error")
+	}
+	if _, err := conn.Write(framed[3:]); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	if length := int(buf[0])<<8 | int(buf[1]); length != n-2 {
+		t.Fatalf("response length prefix = %d, want %d", length, n-2)
+	}
+	response, err := ParseMessage(buf[2:n])
+	if err != nil || response.Header.ID != query.Header.ID || len(response.Answer) != 1 {
+		t.Errorf("response = %v, %v", response, err)
+	}
+
+	if conn.RemoteAddr().String() != "192.0.2.53:53" || conn.RemoteAddr().Network() != "udp" {
+		t.Errorf("RemoteAddr() = %s/%s", conn.RemoteAddr().Network(), conn.RemoteAddr())
+	}
+	if _, ok := conn.(net.PacketConn); ok {
+		t.Error("Dial() should not return a PacketConn, so that messages are length-prefixed")
+	}
+}
+
+func TestStubConnConcurrentWrites(t *testing.T) {
+	// Each query is answered only once both are in flight, so the test
+	// fails if the conn exchanges them one at a time
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	both := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(both)
+	}()
+	client, _ := newResolveClient(t, func(query *dns.Message) (*dns.Message, error) {
+		arrived.Done()
+		select {
+		case <-both:
+		case <-time.After(2 * time.Second):
+			return nil, errors.New("queries were exchanged one at a time")
+		}
+		return answerA("192.0.2.1")(query)
+	})
+	conn, err := client.Dial(context.Background(), "udp", "192.0.2.53:53")
+	if err != nil {
+		t.Fatalf("Dial() returned error: %v", err)
+	}
+	defer conn.Close()
+
+	errs := make(chan error, 2)
+	for _, id := range []uint16{1, 2} {
+		go func(id uint16) {
+			data, err := testQuery(id).ToBytes()
+			if err != nil {
+				errs <- err
+				return
+			}
+			_, err = conn.Write(append([]byte{byte(len(data) >> 8), byte(len(data))}, data...))
+			errs <- err
+		}(id)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+
+	ids := make(map[uint16]bool)
+	for i := 0; i < 2; i++ {
+		prefix := make([]byte, 2)
+		if _, err := io.ReadFull(conn, prefix); err != nil {
+			t.Fatalf("Read() returned error: %v", err)
+		}
+		data := make([]byte, int(prefix[0])<<8|int(prefix[1]))
+		if _, err := io.ReadFull(conn, data); err != nil {
+			t.Fatalf("Read() returned error: %v", err)
+		}
+		response, err := ParseMessage(data)
+		if err != nil {
+			t.Fatalf("ParseMessage() returned error: %v", err)
+		}
+		ids[response.Header.ID] = true
+	}
+	if !ids[1] || !ids[2] {
+		t.Errorf("response IDs = %v, want 1 and 2", ids)
+	}
+}
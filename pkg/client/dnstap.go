@@ -0,0 +1,99 @@
+package client
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnstap"
+)
+
+// SetDnstap makes the client log every query it sends as CLIENT_QUERY and
+// every response it receives as CLIENT_RESPONSE to l; nil stops logging.
+// It must not be called concurrently with queries. Log errors are logged.
+func (c *Client) SetDnstap(l *dnstap.Logger) {
+	c.dnstap = l
+}
+
+// tapExchange logs one exchange of a query to dnstap
+type tapExchange struct {
+	c         *Client
+	network   string
+	local     netip.AddrPort
+	remote    netip.AddrPort
+	queryTime time.Time
+	query     bool // Whether the transport reported the query bytes
+	reply     bool // Whether the transport reported the response bytes
+}
+
+// trace returns the hooks logging the bytes reported by the transport
+func (e *tapExchange) trace() *Trace {
+	return &Trace{
+		DialDone: func(network string, local, remote net.Addr, err error) {
+			if err == nil {
+				e.network, e.local, e.remote = network, addrPort(local), addrPort(remote)
+			}
+		},
+		WroteQuery: func(data []byte, err error) {
+			if err == nil {
+				e.query = true
+				e.logQuery(data)
+			}
+		},
+		ReadResponse: func(data []byte, err error) {
+			if err == nil {
+				e.reply = true
+				e.logResponse(data)
+			}
+		},
+	}
+}
+
+// finish logs the messages the transport did not report, without
+// addresses, as with transports that do not support Trace
+func (e *tapExchange) finish(query, response *dns.Message) {
+	if !e.query {
+		if data, err := query.ToBytes(); err == nil {
+			e.logQuery(data)
+		}
+	}
+	if !e.reply && response != nil {
+		if data, err := response.ToBytes(); err == nil {
+			e.logResponse(data)
+		}
+	}
+}
+
+// logQuery logs a CLIENT_QUERY message
+func (e *tapExchange) logQuery(data []byte) {
+	e.queryTime = time.Now()
+	e.log(&dnstap.Message{
+		Type:            dnstap.ClientQuery,
+		Network:         e.network,
+		QueryAddress:    e.local,
+		ResponseAddress: e.remote,
+		QueryTime:       e.queryTime,
+		QueryMessage:    data,
+	})
+}
+
+// logResponse logs a CLIENT_RESPONSE message
+func (e *tapExchange) logResponse(data []byte) {
+	e.log(&dnstap.Message{
+		Type:            dnstap.ClientResponse,
+		Network:         e.network,
+		QueryAddress:    e.local,
+		ResponseAddress: e.remote,
+		QueryTime:       e.queryTime,
+		ResponseTime:    time.Now(),
+		ResponseMessage: data,
+	})
+}
+
+// log writes a message to the logger of the client
+func (e *tapExchange) log(m *dnstap.Message) {
+	if err := e.c.dnstap.Log(m); err != nil {
+		e.c.logger.Warn("Failed to write dnstap message", "type", m.Type, "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnstap"
+)
+
+// dataFrames returns the data frames of a unidirectional Frame Stream
+func dataFrames(t *testing.T, stream []byte) [][]byte {
+	t.Helper()
+	var frames [][]byte
+	for len(stream) >= 4 {
+		length := binary.BigEndian.Uint32(stream[0:4])
+		if length == 0 {
+			// Control frame: escape, length, body
+			stream = stream[8+binary.BigEndian.Uint32(stream[4:8]):]
+			continue
+		}
+		frames = append(frames, stream[4:4+length])
+		stream = stream[4+length:]
+	}
+	return frames
+}
+
+func TestClientDnstap(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.NameServer = serveUDP(t, answerA("192.0.2.1"))
+	cfg.RetryCount = 0
+	cfg.Timeout = 2 * time.Second
+	client, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger, err := dnstap.NewLogger(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetDnstap(logger)
+	response, err := client.Query("example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := dataFrames(t, buf.Bytes())
+	if len(frames) != 2 {
+		t.Fatalf("logged %d frames, want CLIENT_QUERY and CLIENT_RESPONSE", len(frames))
+	}
+	responseBytes, err := response.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The message type is the first field of the dnstap.Message
+	for i, want := range []struct {
+		messageType dnstap.MessageType
+		contains    []byte
+	}{
+		{dnstap.ClientQuery, []byte("\x07example\x03com\x00")},
+		{dnstap.ClientResponse, responseBytes},
+	} {
+		if !bytes.Contains(frames[i], []byte{0x08, byte(want.messageType)}) || !bytes.Contains(frames[i], want.contains) {
+			t.Errorf("frame %d = % x, want %s carrying % x", i, frames[i], want.messageType, want.contains)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// dumper writes annotated hex dumps (see AnnotateMessage) of the bytes a
+// client sends and receives: to stderr with Config.Debug and to files in
+// Config.DumpDir with Config.DumpFiles. Failures are logged, not
+// returned, so that dumping never breaks a query.
+type dumper struct {
+	client *Client
+	seq    atomic.Uint64 // Number of the last dumped exchange
+	mu     sync.Mutex    // Serializes writes to out
+}
+
+// dumpExchange dumps one exchange of query through the transport
+type dumpExchange struct {
+	d      *dumper
+	seq    uint64
+	id     uint16
+	server string // Server address as dialed, once known
+	query  bool   // Whether the transport reported the query bytes
+	reply  bool   // Whether the transport reported the response bytes
+}
+
+// start returns the dump of a new exchange of query
+func (d *dumper) start(query *dns.Message) *dumpExchange {
+	return &dumpExchange{d: d, seq: d.seq.Add(1), id: query.Header.ID}
+}
+
+// trace returns the hooks dumping the bytes reported by the transport.
+// With failover, every attempt is dumped under the same exchange number.
+func (e *dumpExchange) trace() *Trace {
+	return &Trace{
+		DialDone: func(network string, local, remote net.Addr, err error) {
+			if remote != nil {
+				e.server = network + " " + remote.String()
+			}
+		},
+		WroteQuery: func(data []byte, err error) {
+			if err == nil {
+				e.query = true
+				e.write("query", data)
+			}
+		},
+		ReadResponse: func(data []byte, err error) {
+			if err == nil {
+				e.reply = true
+				e.write("response", data)
+			}
+		},
+	}
+}
+
+// finish dumps the messages the transport did not report, as with
+// transports that do not support Trace. They are serialized again, so
+// compression may differ from the bytes actually exchanged.
+func (e *dumpExchange) finish(query, response *dns.Message) {
+	for _, m := range []struct {
+		kind     string
+		msg      *dns.Message
+		reported bool
+	}{{"query", query, e.query}, {"response", response, e.reply}} {
+		if m.msg == nil || m.reported {
+			continue
+		}
+		data, err := m.msg.ToBytes()
+		if err != nil {
+			e.d.client.logger.Warn("Failed to serialize message for dump", "kind", m.kind, "error", err)
+			continue
+		}
+		e.write(m.kind, data)
+	}
+}
+
+// write dumps the bytes of one message
+func (e *dumpExchange) write(kind string, data []byte) {
+	c := e.d.client
+	heading := fmt.Sprintf(";; exchange %d, %s ID 0x%04X, %d bytes", e.seq, kind, e.id, len(data))
+	if e.server != "" {
+		heading += ", " + e.server
+	}
+	dump := heading + "\n" + AnnotateMessage(data)
+
+	if c.config.Debug {
+		e.d.mu.Lock()
+		fmt.Fprint(c.dumpOut, dump)
+		e.d.mu.Unlock()
+	}
+
+	if c.config.DumpFiles {
+		// The raw bytes go next to the annotated dump
+		base := filepath.Join(c.config.DumpDir, dumpFileName(e.seq, e.id, kind))
+		err := os.WriteFile(base+".txt", []byte(dump), 0o644)
+		if err == nil {
+			err = os.WriteFile(base+".bin", data, 0o644)
+		}
+		if err != nil {
+			c.logger.Warn("Failed to write dump file", "file", base, "error", err)
+		}
+	}
+}
+
+// dumpFileName returns the base name of the dump files of a message, such
+// as "000001-1a2b-query". Later attempts of an exchange overwrite the files
+// of earlier ones.
+func dumpFileName(seq uint64, id uint16, kind string) string {
+	return fmt.Sprintf("%06d-%04x-%s", seq, id, kind)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestClientDumpFiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.DumpFiles = true
+	cfg.DumpDir = dir
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+	client, err := NewWithTransport(cfg, nil, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	// The fake transport does not report bytes, so the messages are
+	// serialized again
+	query, err := fake.queries[0].ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, dumpFileName(1, fake.queries[0].Header.ID, "query"))
+	raw, err := os.ReadFile(base + ".bin")
+	if err != nil || !bytes.Equal(raw, query) {
+		t.Errorf("query.bin = % x, %v, want the query % x", raw, err, query)
+	}
+	annotated, err := os.ReadFile(base + ".txt")
+	if err != nil || !strings.Contains(string(annotated), "0000  ") || !strings.Contains(string(annotated), "QDCOUNT 1") {
+		t.Errorf("query.txt = %q, %v, want an annotated dump", annotated, err)
+	}
+	for _, ext := range []string{".txt", ".bin"} {
+		name := dumpFileName(1, fake.queries[0].Header.ID, "response") + ext
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("response dump %s not written: %v", name, err)
+		}
+	}
+}
+
+func TestClientDebugDump(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.NameServer = serveUDP(t, answerA("192.0.2.1"))
+	cfg.RetryCount = 0
+	cfg.Timeout = 2 * time.Second
+	cfg.Debug = true
+	client, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	var out bytes.Buffer
+	client.dumpOut = &out
+
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	// The bytes exchanged with the server are dumped as they were sent
+	dump := out.String()
+	for _, want := range []string{
+		";; exchange 1, query ID",
+		", udp " + cfg.NameServer + "\n",
+		";; exchange 1, response ID",
+		"; RDATA ADDRESS: 192.0.2.1\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("debug dump is missing %q:\n%s", want, dump)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Sentinel errors for failure response codes. They are matched by
+// *RcodeError through errors.Is when Config.RcodeErrors is enabled.
+var (
+	ErrNXDomain = errors.New("non-existent domain")
+	ErrServFail = errors.New("server failure")
+	ErrRefused  = errors.New("query refused")
+)
+
+// RcodeError is returned by Query for responses with a failure RCODE when
+// Config.RcodeErrors is enabled. The full response is attached, so the
+// authority section (e.g. the SOA of a negative answer) remains available.
+type RcodeError struct {
+	Rcode    dns.Rcode
+	Response *dns.Message
+}
+
+// Error returns a description including the question that failed
+func (e *RcodeError) Error() string {
+	if e.Response != nil && len(e.Response.Question) > 0 {
+		q := e.Response.Question[0]
+		return fmt.Sprintf("server returned %s for %s %s", e.Rcode, q.Name, q.Type)
+	}
+	return fmt.Sprintf("server returned %s", e.Rcode)
+}
+
+// Unwrap returns the sentinel error for the response code, if there is one
+func (e *RcodeError) Unwrap() error {
+	switch e.Rcode {
+	case dns.RcodeNXDomain:
+		return ErrNXDomain
+	case dns.RcodeServFail:
+		return ErrServFail
+	case dns.RcodeRefused:
+		return ErrRefused
+	default:
+		return nil
+	}
+}
+
+// SOA returns the SOA record from the authority section of the response,
+// or nil if there is none
+func (e *RcodeError) SOA() *records.SOARecord {
+	if e.Response == nil {
+		return nil
+	}
+	for _, rr := range e.Response.Authority {
+		if soa, ok := rr.RData.(*records.SOARecord); ok {
+			return soa
+		}
+	}
+	return nil
+}
+
+// NegativeTTL returns how long the negative answer may be cached: the
+// smaller of the SOA record's TTL and its MINIMUM field (RFC 2308 Section 5).
+// It returns zero if the response carries no SOA record.
+func (e *RcodeError) NegativeTTL() time.Duration {
+	if e.Response == nil {
+		return 0
+	}
+	return negativeTTL(e.Response)
+}
+
+// negativeTTL returns the negative caching TTL of a response, as
+// described for NegativeTTL
+func negativeTTL(response *dns.Message) time.Duration {
+	for _, rr := range response.Authority {
+		if soa, ok := rr.RData.(*records.SOARecord); ok {
+			ttl := uint32(rr.TTL)
+			if soa.Minimum < ttl {
+				ttl = soa.Minimum
+			}
+			return time.Duration(ttl) * time.Second
+		}
+	}
+	return 0
+}
+
+// checkRcode returns an *RcodeError if the response carries a failure RCODE
+func checkRcode(response *dns.Message) error {
+	if rc := response.Rcode(); rc != dns.RcodeSuccess {
+		return &RcodeError{Rcode: rc, Response: response}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// answerRcode returns a handler answering with the given RCODE and an SOA
+// record in the authority section
+func answerRcode(rc dns.Rcode) func(*dns.Message) (*dns.Message, error) {
+	return func(query *dns.Message) (*dns.Message, error) {
+		soa := records.NewSOARecord(
+			dns.MustParseName("ns.example.com"),
+			dns.MustParseName("hostmaster.example.com"),
+			1, 7200, 3600, 1209600, 60,
+		)
+		return dns.NewResponse(query).WithRA().WithRcode(rc).Authority(dns.ResourceRecord{
+			Name:  dns.MustParseName("example.com"),
+			Type:  dns.TypeSOA,
+			Class: dns.ClassIN,
+			TTL:   3600,
+			RData: soa,
+		}).Build()
+	}
+}
+
+func TestQueryRcodeErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	tests := []struct {
+		rcode    dns.Rcode
+		sentinel error
+	}{
+		{dns.RcodeNXDomain, ErrNXDomain},
+		{dns.RcodeServFail, ErrServFail},
+		{dns.RcodeRefused, ErrRefused},
+		{dns.RcodeNotImp, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rcode.String(), func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.RcodeErrors = true
+			client, err := NewWithTransport(cfg, logger, &fakeTransport{handler: answerRcode(test.rcode)})
+			if err != nil {
+				t.Fatalf("NewWithTransport() returned error: %v", err)
+			}
+
+			_, err = client.Query("missing.example.com", dns.TypeA)
+			if err == nil {
+				t.Fatal("Query() should return error")
+			}
+
+			var rcodeErr *RcodeError
+			if !errors.As(err, &rcodeErr) {
+				t.Fatalf("Query() error %v is not an *RcodeError", err)
+			}
+			if rcodeErr.Rcode != test.rcode {
+				t.Errorf("RcodeError.Rcode = %v, want %v", rcodeErr.Rcode, test.rcode)
+			}
+			if test.sentinel != nil && !errors.Is(err, test.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, test.sentinel)
+			}
+			if rcodeErr.SOA() == nil {
+				t.Error("RcodeError.SOA() = nil, want the authority SOA")
+			}
+		})
+	}
+}
+
+func TestQueryRcodeErrorsDisabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	client, err := NewWithTransport(config.DefaultConfig(), logger, &fakeTransport{handler: answerRcode(dns.RcodeNXDomain)})
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	response, err := client.Query("missing.example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if response.Rcode() != dns.RcodeNXDomain {
+		t.Errorf("response Rcode() = %v, want NXDOMAIN", response.Rcode())
+	}
+}
+
+func TestRcodeErrorNegativeTTL(t *testing.T) {
+	query, err := dns.NewQuery("missing.example.com", dns.TypeA).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	response, err := answerRcode(dns.RcodeNXDomain)(query)
+	if err != nil {
+		t.Fatalf("answerRcode() returned error: %v", err)
+	}
+
+	rcodeErr := &RcodeError{Rcode: dns.RcodeNXDomain, Response: response}
+	if ttl := rcodeErr.NegativeTTL(); ttl != 60*time.Second {
+		t.Errorf("NegativeTTL() = %v, want 1m0s", ttl)
+	}
+
+	expected := "server returned NXDOMAIN for missing.example.com A"
+	if rcodeErr.Error() != expected {
+		t.Errorf("Error() = %q, want %q", rcodeErr.Error(), expected)
+	}
+
+	empty := &RcodeError{Rcode: dns.RcodeServFail}
+	if empty.NegativeTTL() != 0 || empty.SOA() != nil {
+		t.Error("RcodeError without response should have no SOA and zero TTL")
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Hosts holds the static name-to-address mappings of a hosts file
+type Hosts struct {
+	addrs map[string][]netip.Addr // Addresses by lowercased name
+	names map[netip.Addr][]string // Names by address, canonical name first
+}
+
+// ParseHosts parses a hosts file: an address followed by a canonical name
+// and optional aliases on each line, with comments starting at "#".
+// Malformed lines are skipped like the system resolver does.
+func ParseHosts(r io.Reader) (*Hosts, error) {
+	h := &Hosts{
+		addrs: make(map[string][]netip.Addr),
+		names: make(map[netip.Addr][]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		addr, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+
+		for _, name := range fields[1:] {
+			key := hostsKey(name)
+			if dns.DNSPolicy.Validate(key) != nil {
+				continue
+			}
+			h.addrs[key] = append(h.addrs[key], addr)
+			h.names[addr] = append(h.names[addr], strings.TrimSuffix(name, "."))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// LoadHosts reads and parses the hosts file at path
+func LoadHosts(path string) (*Hosts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseHosts(f)
+}
+
+// LookupHost returns the addresses of a name in file order. Names compare
+// case-insensitively, with or without a trailing dot.
+func (h *Hosts) LookupHost(name string) []netip.Addr {
+	return h.addrs[hostsKey(name)]
+}
+
+// LookupAddr returns the names of an address, canonical name first
+func (h *Hosts) LookupAddr(addr netip.Addr) []string {
+	return h.names[addr.Unmap()]
+}
+
+// hostsKey returns the map key of a name in a hosts file
+func hostsKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// hostsResponse answers an A, AAAA or PTR query from the hosts file, or
+// returns nil if the file has no entry of the requested type
+func hostsResponse(hosts *Hosts, query *dns.Message) *dns.Message {
+	q := query.Question[0]
+	var answers []dns.ResourceRecord
+	switch q.Type {
+	case dns.TypeA, dns.TypeAAAA:
+		for _, addr := range hosts.LookupHost(q.Name.String()) {
+			var rdata dns.ResourceData
+			switch {
+			case q.Type == dns.TypeA && addr.Is4():
+				rdata = &records.ARecord{Address: net.IP(addr.AsSlice())}
+			case q.Type == dns.TypeAAAA && addr.Is6():
+				rdata = &records.AAAARecord{Address: net.IP(addr.AsSlice())}
+			default:
+				continue
+			}
+			answers = append(answers, hostsRecord(q, rdata))
+		}
+	case dns.TypePTR:
+		addr, err := dns.ParseReverseName(q.Name)
+		if err != nil {
+			return nil
+		}
+		for _, name := range hosts.LookupAddr(addr) {
+			target, err := dns.ParseName(name)
+			if err != nil {
+				continue
+			}
+			answers = append(answers, hostsRecord(q, records.NewPTRRecord(target)))
+		}
+	}
+	if len(answers) == 0 {
+		return nil
+	}
+
+	response, err := dns.NewResponse(query).WithAA().WithRA().Answer(answers...).Build()
+	if err != nil {
+		return nil
+	}
+	return response
+}
+
+// hostsRecord returns an answer to q with the given data. Entries of a
+// hosts file have no TTL; zero keeps them from being cached.
+func hostsRecord(q dns.Question, rdata dns.ResourceData) dns.ResourceRecord {
+	return dns.ResourceRecord{Name: q.Name, Type: q.Type, Class: dns.ClassIN, RData: rdata}
+}
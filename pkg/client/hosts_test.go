@@ -0,0 +1,148 @@
+package client
+
+import (
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+const testHosts = `# static entries
+127.0.0.1	localhost
+::1		localhost ip6-localhost
+192.0.2.10	Printer.example.com printer # office
+192.0.2.11	printer
+not-an-address	ignored.example.com
+192.0.2.12
+`
+
+func TestParseHosts(t *testing.T) {
+	hosts, err := ParseHosts(strings.NewReader(testHosts))
+	if err != nil {
+		t.Fatalf("ParseHosts() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"localhost", []string{"127.0.0.1", "::1"}},
+		{"LOCALHOST.", []string{"127.0.0.1", "::1"}},
+		{"printer.example.com", []string{"192.0.2.10"}},
+		{"printer", []string{"192.0.2.10", "192.0.2.11"}},
+		{"ignored.example.com", nil},
+	}
+	for _, test := range tests {
+		var result []string
+		for _, addr := range hosts.LookupHost(test.name) {
+			result = append(result, addr.String())
+		}
+		if strings.Join(result, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("LookupHost(%q) = %v, want %v", test.name, result, test.expected)
+		}
+	}
+
+	names := hosts.LookupAddr(netip.MustParseAddr("::ffff:192.0.2.10"))
+	if strings.Join(names, " ") != "Printer.example.com printer" {
+		t.Errorf("LookupAddr(192.0.2.10) = %v, want [Printer.example.com printer]", names)
+	}
+}
+
+// newHostsClient returns a client using a hosts file with testHosts
+func newHostsClient(t *testing.T, fake *fakeTransport) *Client {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte(testHosts), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.HostsFile = path
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	client, err := NewWithTransport(cfg, logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+	return client
+}
+
+func TestClientQueryHosts(t *testing.T) {
+	fake := &fakeTransport{handler: answerA("198.51.100.1")}
+	client := newHostsClient(t, fake)
+
+	response, err := client.Query("Printer.Example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(response.Answer) != 1 || response.Answer[0].RData.String() != "ADDRESS: 192.0.2.10" {
+		t.Errorf("Query() answer = %v, want ADDRESS: 192.0.2.10", response.Answer)
+	}
+
+	response, err = client.Query("localhost", dns.TypeAAAA)
+	if err != nil || len(response.Answer) != 1 || response.Answer[0].Type != dns.TypeAAAA {
+		t.Errorf("Query(localhost, AAAA) = %v, %v, want ::1", response, err)
+	}
+
+	names, err := client.ReverseLookup(netip.MustParseAddr("192.0.2.10").AsSlice())
+	if err != nil || len(names) != 2 || names[0].String() != "Printer.example.com" {
+		t.Errorf("ReverseLookup() = %v, %v, want [Printer.example.com printer]", names, err)
+	}
+	if len(fake.queries) != 0 {
+		t.Errorf("hosts file answers sent %d queries, want 0", len(fake.queries))
+	}
+
+	// Names or types without an entry go to the network
+	for _, q := range []struct {
+		name  string
+		qtype dns.QType
+	}{{"www.example.com", dns.TypeA}, {"printer", dns.TypeAAAA}, {"printer", dns.TypeMX}} {
+		if _, err := client.Query(q.name, q.qtype); err != nil {
+			t.Errorf("Query(%s, %s) returned error: %v", q.name, q.qtype, err)
+		}
+	}
+	if len(fake.queries) != 3 {
+		t.Errorf("sent %d queries, want 3", len(fake.queries))
+	}
+}
+
+func TestHostsResponse(t *testing.T) {
+	hosts, err := ParseHosts(strings.NewReader(testHosts))
+	if err != nil {
+		t.Fatalf("ParseHosts() returned error: %v", err)
+	}
+
+	query, err := dns.NewQuery(dns.ReverseName(netip.MustParseAddr("::1")).String(), dns.TypePTR).WithRD().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := hostsResponse(hosts, query)
+	if response == nil {
+		t.Fatal("hostsResponse() returned nil for ::1")
+	}
+	if err := validateResponse(query, response); err != nil {
+		t.Errorf("hostsResponse() does not answer the query: %v", err)
+	}
+	if len(response.Answer) != 2 || response.Answer[1].RData.(*records.PTRRecord).Target.String() != "ip6-localhost" {
+		t.Errorf("hostsResponse() answer = %v", response.Answer)
+	}
+
+	query, _ = dns.NewQuery(dns.ReverseName(netip.MustParseAddr("192.0.2.99")).String(), dns.TypePTR).Build()
+	if response := hostsResponse(hosts, query); response != nil {
+		t.Errorf("hostsResponse() = %v, want nil for an unknown address", response)
+	}
+}
+
+func TestNewClientMissingHostsFile(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.HostsFile = filepath.Join(t.TempDir(), "missing")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if _, err := NewWithTransport(cfg, logger, &fakeTransport{handler: answerA("192.0.2.1")}); err != nil {
+		t.Errorf("NewWithTransport() returned error for a missing hosts file: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/metrics"
+)
+
+// Metrics are the metrics recorded by a client. Each server tried for a
+// query counts as one exchange, so a query answered after failover is
+// counted once per server. Exchanges through transports that do not
+// support Trace have an empty server label. Queries answered locally from
+// the hosts file or the cache set with SetCache are not exchanges; they
+// are counted by HostsAnswers and CacheHits instead.
+type Metrics struct {
+	Queries   *metrics.Counter   // godns_client_queries_total{type,server,transport}
+	Responses *metrics.Counter   // godns_client_responses_total{type,rcode,server,transport}
+	Duration  *metrics.Histogram // godns_client_exchange_duration_seconds{server,transport}
+	Timeouts  *metrics.Counter   // godns_client_timeouts_total{server,transport}
+	Errors    *metrics.Counter   // godns_client_errors_total{server,transport}
+	Truncated *metrics.Counter   // godns_client_truncated_total{server,transport}
+
+	HostsAnswers *metrics.Counter // godns_client_hosts_answers_total{type}
+	CacheHits    *metrics.Counter // godns_client_cache_hits_total{type}
+	CacheMisses  *metrics.Counter // godns_client_cache_misses_total{type}
+}
+
+// NewMetrics registers the client metrics with r
+func NewMetrics(r *metrics.Registry) *Metrics {
+	return &Metrics{
+		Queries: r.NewCounter("godns_client_queries_total",
+			"DNS queries sent, by query type, server and transport.",
+			"type", "server", "transport"),
+		Responses: r.NewCounter("godns_client_responses_total",
+			"DNS responses received, by query type, RCODE, server and transport.",
+			"type", "rcode", "server", "transport"),
+		Duration: r.NewHistogram("godns_client_exchange_duration_seconds",
+			"Time from dialing a server to reading its response.",
+			nil, "server", "transport"),
+		Timeouts: r.NewCounter("godns_client_timeouts_total",
+			"Exchanges that timed out, by server and transport.",
+			"server", "transport"),
+		Errors: r.NewCounter("godns_client_errors_total",
+			"Exchanges that failed for reasons other than a timeout, by server and transport.",
+			"server", "transport"),
+		Truncated: r.NewCounter("godns_client_truncated_total",
+			"Responses with the TC bit set, by server and transport.",
+			"server", "transport"),
+		HostsAnswers: r.NewCounter("godns_client_hosts_answers_total",
+			"Queries answered from the hosts file, by query type.",
+			"type"),
+		CacheHits: r.NewCounter("godns_client_cache_hits_total",
+			"Queries answered from the cache, by query type.",
+			"type"),
+		CacheMisses: r.NewCounter("godns_client_cache_misses_total",
+			"Queries not found in the cache and sent to a server, by query type.",
+			"type"),
+	}
+}
+
+// SetMetrics makes the client record its exchanges in m; nil stops
+// recording. It must not be called concurrently with queries.
+func (c *Client) SetMetrics(m *Metrics) {
+	c.metrics = m
+}
+
+// metricsExchange records the exchanges of one query with the servers
+// reported by the transport
+type metricsExchange struct {
+	m       *Metrics
+	qtype   string
+	network string
+	server  string
+	start   time.Time
+	pending bool // Whether an exchange with server is in progress
+	traced  bool // Whether the transport reported any exchange
+}
+
+// newMetricsExchange starts recording the exchanges of a query
+func newMetricsExchange(m *Metrics, query *dns.Message, network string) *metricsExchange {
+	return &metricsExchange{m: m, qtype: queryType(query), network: network, start: time.Now()}
+}
+
+// queryType returns the type label of a query
+func queryType(query *dns.Message) string {
+	if len(query.Question) == 0 {
+		return ""
+	}
+	return query.Question[0].Type.String()
+}
+
+// trace returns the hooks recording each server tried
+func (e *metricsExchange) trace() *Trace {
+	return &Trace{
+		DialStart: func(network, server string) {
+			e.network, e.server, e.start = network, server, time.Now()
+			e.pending, e.traced = true, true
+		},
+		DialDone: func(network string, local, remote net.Addr, err error) {
+			if err != nil {
+				e.failed(err)
+			}
+		},
+		WroteQuery: func(data []byte, err error) {
+			if err != nil {
+				e.failed(err)
+				return
+			}
+			e.m.Queries.Inc(e.qtype, e.server, e.network)
+		},
+		ReadResponse: func(data []byte, err error) {
+			if err != nil {
+				e.failed(err)
+				return
+			}
+			// The RCODE and TC bit are read from the header, as the
+			// response has not been parsed yet
+			if len(data) < 12 {
+				e.failed(errors.New("short response"))
+				return
+			}
+			e.received(dns.Rcode(data[3]&0x0F), data[2]&0x02 != 0)
+		},
+	}
+}
+
+// finish records the outcome of the query when the transport did not
+// report its exchanges, as with transports that do not support Trace
+func (e *metricsExchange) finish(response *dns.Message, err error) {
+	if e.traced {
+		return
+	}
+	e.pending = true
+	e.m.Queries.Inc(e.qtype, e.server, e.network)
+	if err != nil {
+		e.failed(err)
+		return
+	}
+	e.received(response.Rcode(), response.Header.TC())
+}
+
+// received records a response of the current exchange
+func (e *metricsExchange) received(rcode dns.Rcode, truncated bool) {
+	if !e.pending {
+		return
+	}
+	e.pending = false
+	e.m.Duration.Observe(time.Since(e.start).Seconds(), e.server, e.network)
+	e.m.Responses.Inc(e.qtype, rcode.String(), e.server, e.network)
+	if truncated {
+		e.m.Truncated.Inc(e.server, e.network)
+	}
+}
+
+// failed records the failure of the current exchange
+func (e *metricsExchange) failed(err error) {
+	if !e.pending {
+		return
+	}
+	e.pending = false
+	if isTimeout(err) {
+		e.m.Timeouts.Inc(e.server, e.network)
+	} else {
+		e.m.Errors.Inc(e.server, e.network)
+	}
+}
+
+// isTimeout reports whether err is a timeout of the network or the
+// context deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
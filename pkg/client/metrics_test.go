@@ -0,0 +1,116 @@
+package client
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/metrics"
+)
+
+func TestClientMetrics(t *testing.T) {
+	// The first server never answers, so the query fails over
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer silent.Close()
+	server := serveUDP(t, answerRcode(dns.RcodeNXDomain))
+
+	cfg := config.DefaultConfig()
+	cfg.NameServer = silent.LocalAddr().String()
+	cfg.FallbackServers = []string{server}
+	cfg.RetryCount = 0
+	cfg.Timeout = 100 * time.Millisecond
+	client, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	m := NewMetrics(metrics.NewRegistry())
+	client.SetMetrics(m)
+
+	if _, err := client.Query("example.com", dns.TypeAAAA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	silentAddr := silent.LocalAddr().String()
+	for _, tt := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"queries to silent server", m.Queries.Value("AAAA", silentAddr, "udp"), 1},
+		{"queries to server", m.Queries.Value("AAAA", server, "udp"), 1},
+		{"timeouts of silent server", m.Timeouts.Value(silentAddr, "udp"), 1},
+		{"errors of silent server", m.Errors.Value(silentAddr, "udp"), 0},
+		{"NXDOMAIN responses", m.Responses.Value("AAAA", "NXDOMAIN", server, "udp"), 1},
+		{"truncated responses", m.Truncated.Value(server, "udp"), 0},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if got := m.Duration.Count(server, "udp"); got != 1 {
+		t.Errorf("duration observations of server = %d, want 1", got)
+	}
+	if got := m.Duration.Count(silentAddr, "udp"); got != 0 {
+		t.Errorf("duration observations of silent server = %d, want 0", got)
+	}
+}
+
+func TestClientMetricsUntraced(t *testing.T) {
+	fake := &fakeTransport{handler: func(query *dns.Message) (*dns.Message, error) {
+		response, err := answerA("192.0.2.1")(query)
+		if err == nil {
+			response.Header.SetTC(true)
+		}
+		return response, err
+	}}
+	client, err := NewWithTransport(config.DefaultConfig(), nil, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+	m := NewMetrics(metrics.NewRegistry())
+	client.SetMetrics(m)
+
+	if _, err := client.Query("example.com", dns.TypeA); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if got := m.Responses.Value("A", "NOERROR", "", "udp"); got != 1 {
+		t.Errorf("NOERROR responses = %v, want 1", got)
+	}
+	if got := m.Truncated.Value("", "udp"); got != 1 {
+		t.Errorf("truncated responses = %v, want 1", got)
+	}
+}
+
+func TestClientMetricsLocalAnswers(t *testing.T) {
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+	client := newHostsClient(t, fake)
+	client.SetCache(NewCache(0))
+	m := NewMetrics(metrics.NewRegistry())
+	client.SetMetrics(m)
+
+	for _, name := range []string{"printer.example.com", "example.com", "example.com"} {
+		if _, err := client.Query(name, dns.TypeA); err != nil {
+			t.Fatalf("Query(%s) returned error: %v", name, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"hosts answers", m.HostsAnswers.Value("A"), 1},
+		{"cache hits", m.CacheHits.Value("A"), 1},
+		{"cache misses", m.CacheMisses.Value("A"), 1},
+		{"queries", m.Queries.Value("A", "", "udp"), 1},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
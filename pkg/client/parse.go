@@ -0,0 +1,369 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// ParseMessage parses a complete DNS message from wire format.
+// Transports use it to decode responses; it is exported so that custom
+// Transport implementations can do the same.
+func ParseMessage(data []byte) (*dns.Message, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("DNS message too short: %d bytes", len(data))
+	}
+
+	index := 0
+
+	// Parse header
+	header := dns.Header{
+		ID:      binary.BigEndian.Uint16(data[index : index+2]),
+		Flags:   dns.HeaderBitfield(binary.BigEndian.Uint16(data[index+2 : index+4])),
+		QDCount: binary.BigEndian.Uint16(data[index+4 : index+6]),
+		ANCount: binary.BigEndian.Uint16(data[index+6 : index+8]),
+		NSCount: binary.BigEndian.Uint16(data[index+8 : index+10]),
+		ARCount: binary.BigEndian.Uint16(data[index+10 : index+12]),
+	}
+	index += 12
+
+	message := &dns.Message{
+		Header:     header,
+		Question:   make([]dns.Question, header.QDCount),
+		Answer:     make([]dns.ResourceRecord, header.ANCount),
+		Authority:  make([]dns.ResourceRecord, header.NSCount),
+		Additional: make([]dns.ResourceRecord, header.ARCount),
+	}
+
+	// Parse questions
+	for i := uint16(0); i < header.QDCount; i++ {
+		question, newIndex, err := parseQuestion(data, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse question %d: %w", i, err)
+		}
+		message.Question[i] = question
+		index = newIndex
+	}
+
+	// Parse answer records
+	for i := uint16(0); i < header.ANCount; i++ {
+		rr, newIndex, err := parseResourceRecord(data, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse answer record %d: %w", i, err)
+		}
+		message.Answer[i] = rr
+		index = newIndex
+	}
+
+	// Parse authority records
+	for i := uint16(0); i < header.NSCount; i++ {
+		rr, newIndex, err := parseResourceRecord(data, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse authority record %d: %w", i, err)
+		}
+		message.Authority[i] = rr
+		index = newIndex
+	}
+
+	// Parse additional records
+	for i := uint16(0); i < header.ARCount; i++ {
+		rr, newIndex, err := parseResourceRecord(data, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse additional record %d: %w", i, err)
+		}
+		message.Additional[i] = rr
+		index = newIndex
+	}
+
+	return message, nil
+}
+
+// parseQuestion parses a DNS question from wire format
+func parseQuestion(data []byte, index int) (dns.Question, int, error) {
+	// Parse name labels
+	labels, newIndex, err := parseLabels(data, index)
+	if err != nil {
+		return dns.Question{}, 0, fmt.Errorf("failed to parse question name: %w", err)
+	}
+
+	if newIndex+4 > len(data) {
+		return dns.Question{}, 0, fmt.Errorf("question data truncated")
+	}
+
+	qtype := dns.QType(binary.BigEndian.Uint16(data[newIndex : newIndex+2]))
+	qclass := dns.QClass(binary.BigEndian.Uint16(data[newIndex+2 : newIndex+4]))
+
+	return dns.Question{
+		Name:  labels,
+		Type:  qtype,
+		Class: qclass,
+	}, newIndex + 4, nil
+}
+
+// parseResourceRecord parses a DNS resource record from wire format
+func parseResourceRecord(data []byte, index int) (dns.ResourceRecord, int, error) {
+	// Parse name labels
+	labels, newIndex, err := parseLabels(data, index)
+	if err != nil {
+		return dns.ResourceRecord{}, 0, fmt.Errorf("failed to parse RR name: %w", err)
+	}
+
+	if newIndex+10 > len(data) {
+		return dns.ResourceRecord{}, 0, fmt.Errorf("RR header data truncated")
+	}
+
+	rrType := dns.QType(binary.BigEndian.Uint16(data[newIndex : newIndex+2]))
+	rrClass := dns.QClass(binary.BigEndian.Uint16(data[newIndex+2 : newIndex+4]))
+	ttl := int32(binary.BigEndian.Uint32(data[newIndex+4 : newIndex+8]))
+	rdLength := binary.BigEndian.Uint16(data[newIndex+8 : newIndex+10])
+	newIndex += 10
+
+	if newIndex+int(rdLength) > len(data) {
+		return dns.ResourceRecord{}, 0, fmt.Errorf("RR data truncated")
+	}
+
+	// Parse resource data based on type
+	var rdata dns.ResourceData
+	rdataBytes := data[newIndex : newIndex+int(rdLength)]
+
+	switch rrType {
+	case dns.TypeA:
+		if len(rdataBytes) != 4 {
+			return dns.ResourceRecord{}, 0, fmt.Errorf("invalid A record length: %d", len(rdataBytes))
+		}
+		ip := net.IPv4(rdataBytes[0], rdataBytes[1], rdataBytes[2], rdataBytes[3])
+		if aRecord, err := records.NewARecord(ip); err == nil {
+			rdata = aRecord
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	case dns.TypeAAAA:
+		if len(rdataBytes) != 16 {
+			return dns.ResourceRecord{}, 0, fmt.Errorf("invalid AAAA record length: %d", len(rdataBytes))
+		}
+		ip := make(net.IP, 16)
+		copy(ip, rdataBytes)
+		if aaaaRecord, err := records.NewAAAARecord(ip); err == nil {
+			rdata = aaaaRecord
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	case dns.TypeNS:
+		nsLabels, _, err := parseLabels(data, newIndex)
+		if err != nil {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		} else {
+			rdata = records.NewNSRecord(nsLabels)
+		}
+	case dns.TypePTR:
+		target, err := parseTarget(data, newIndex, newIndex+int(rdLength))
+		if err != nil {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		} else {
+			rdata = records.NewPTRRecord(target)
+		}
+	case dns.TypeCNAME, dns.TypeDNAME:
+		target, err := parseTarget(data, newIndex, newIndex+int(rdLength))
+		switch {
+		case err != nil:
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		case rrType == dns.TypeCNAME:
+			rdata = records.NewCNAMERecord(target)
+		default:
+			rdata = records.NewDNAMERecord(target)
+		}
+	case dns.TypeMX:
+		if mx, err := parseMX(data, newIndex, newIndex+int(rdLength)); err == nil {
+			rdata = mx
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	case dns.TypeSRV:
+		if srv, err := parseSRV(data, newIndex, newIndex+int(rdLength)); err == nil {
+			rdata = srv
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	case dns.TypeTXT:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseTXTRecord)
+	case dns.TypeSOA:
+		if soa, err := parseSOA(data, newIndex, newIndex+int(rdLength)); err == nil {
+			rdata = soa
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	case dns.TypeDNSKEY:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseDNSKEYRecord)
+	case dns.TypeDS:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseDSRecord)
+	case dns.TypeRRSIG:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseRRSIGRecord)
+	case dns.TypeNSEC:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseNSECRecord)
+	case dns.TypeNSEC3:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseNSEC3Record)
+	case dns.TypeNSEC3PARAM:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseNSEC3PARAMRecord)
+	case dns.TypeCDS:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseCDSRecord)
+	case dns.TypeCDNSKEY:
+		rdata = parseOrGeneric(rrType, rdataBytes, records.ParseCDNSKEYRecord)
+	case dns.TypeOPT:
+		if opt, err := dns.ParseOPT(rdataBytes); err == nil {
+			rdata = opt
+		} else {
+			rdata = records.NewGenericRecord(rrType, rdataBytes)
+		}
+	default:
+		rdata = records.NewGenericRecord(rrType, rdataBytes)
+	}
+
+	return dns.ResourceRecord{
+		Name:     labels,
+		Type:     rrType,
+		Class:    rrClass,
+		TTL:      ttl,
+		RDLength: rdLength,
+		RData:    rdata,
+	}, newIndex + int(rdLength), nil
+}
+
+// parseOrGeneric decodes self-contained resource data with the given
+// parser, falling back to a generic record if the data is malformed
+func parseOrGeneric[T dns.ResourceData](rrType dns.QType, data []byte, parse func([]byte) (T, error)) dns.ResourceData {
+	if rdata, err := parse(data); err == nil {
+		return rdata
+	}
+	return records.NewGenericRecord(rrType, data)
+}
+
+// parseTarget parses resource data consisting of a single name, which may
+// be compressed
+func parseTarget(data []byte, index, end int) (dns.Name, error) {
+	target, next, err := parseLabels(data, index)
+	if err != nil {
+		return nil, err
+	}
+	if next != end {
+		return nil, fmt.Errorf("record length mismatch")
+	}
+	return target, nil
+}
+
+// parseMX parses MX resource data, whose exchange may be compressed
+func parseMX(data []byte, index, end int) (*records.MXRecord, error) {
+	if index+2 > end {
+		return nil, fmt.Errorf("MX record truncated")
+	}
+	exchange, next, err := parseLabels(data, index+2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MX exchange: %w", err)
+	}
+	if next != end {
+		return nil, fmt.Errorf("MX record length mismatch")
+	}
+	return records.NewMXRecord(binary.BigEndian.Uint16(data[index:index+2]), exchange), nil
+}
+
+// parseSRV parses SRV resource data. The target must not be compressed
+// (RFC 2782), but compressed targets are accepted like in MX records.
+func parseSRV(data []byte, index, end int) (*records.SRVRecord, error) {
+	if index+6 > end {
+		return nil, fmt.Errorf("SRV record truncated")
+	}
+	target, next, err := parseLabels(data, index+6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SRV target: %w", err)
+	}
+	if next != end {
+		return nil, fmt.Errorf("SRV record length mismatch")
+	}
+	return records.NewSRVRecord(
+		binary.BigEndian.Uint16(data[index:index+2]),
+		binary.BigEndian.Uint16(data[index+2:index+4]),
+		binary.BigEndian.Uint16(data[index+4:index+6]),
+		target,
+	), nil
+}
+
+// parseSOA parses SOA resource data, whose names may be compressed
+func parseSOA(data []byte, index, end int) (*records.SOARecord, error) {
+	mname, index, err := parseLabels(data, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SOA MNAME: %w", err)
+	}
+	rname, index, err := parseLabels(data, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SOA RNAME: %w", err)
+	}
+	if index+20 != end {
+		return nil, fmt.Errorf("invalid SOA record length")
+	}
+
+	fields := make([]uint32, 5)
+	for i := range fields {
+		fields[i] = binary.BigEndian.Uint32(data[index+4*i : index+4*i+4])
+	}
+
+	return records.NewSOARecord(mname, rname, fields[0], fields[1], fields[2], fields[3], fields[4]), nil
+}
+
+// parseLabels parses DNS labels from wire format, handling compression.
+// Compression pointers must point strictly backwards, which rules out
+// pointer loops in malformed or hostile messages.
+func parseLabels(data []byte, index int) (dns.Name, int, error) {
+	var labels dns.Name
+
+	for index < len(data) {
+		length := data[index]
+
+		// Check for compression pointer
+		if length&0xC0 == 0xC0 {
+			if index+1 >= len(data) {
+				return nil, 0, fmt.Errorf("compression pointer truncated")
+			}
+			pointer := int(binary.BigEndian.Uint16(data[index:index+2]) & 0x3FFF)
+			if pointer >= index {
+				return nil, 0, fmt.Errorf("invalid compression pointer: %d", pointer)
+			}
+
+			// Follow the pointer recursively
+			compressedLabels, _, err := parseLabels(data, pointer)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to follow compression pointer: %w", err)
+			}
+			labels = append(labels, compressedLabels...)
+
+			return labels, index + 2, nil
+		}
+
+		if length&0xC0 != 0 {
+			return nil, 0, fmt.Errorf("unsupported label type: %#02x", length&0xC0)
+		}
+
+		// Regular label
+		if length == 0 {
+			// Null terminator
+			labels = append(labels, dns.Label{Length: 0, Data: nil})
+			return labels, index + 1, nil
+		}
+
+		if index+1+int(length) > len(data) {
+			return nil, 0, fmt.Errorf("label data truncated")
+		}
+
+		label := dns.Label{
+			Length: length,
+			Data:   make([]byte, length),
+		}
+		copy(label.Data, data[index+1:index+1+int(length)])
+		labels = append(labels, label)
+
+		index += 1 + int(length)
+	}
+
+	return nil, 0, fmt.Errorf("labels not properly terminated")
+}
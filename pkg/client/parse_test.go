@@ -0,0 +1,259 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestParseMessageCompression(t *testing.T) {
+	data := []byte{
+		0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		// Question: example.com A IN
+		7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+		0x00, 0x01, 0x00, 0x01,
+		// Answer: pointer to offset 12, A IN, TTL 300, 192.0.2.1
+		0xC0, 0x0C, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C, 0x00, 0x04,
+		192, 0, 2, 1,
+	}
+
+	msg, err := ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage() returned error: %v", err)
+	}
+
+	if msg.Header.ID != 0x1234 {
+		t.Errorf("Header.ID = %04X, want 1234", msg.Header.ID)
+	}
+	if len(msg.Answer) != 1 {
+		t.Fatalf("len(Answer) = %d, want 1", len(msg.Answer))
+	}
+	if name := dns.LabelsToString(msg.Answer[0].Name); name != "example.com" {
+		t.Errorf("Answer name = %q, want %q", name, "example.com")
+	}
+	if msg.Answer[0].RData.String() != "ADDRESS: 192.0.2.1" {
+		t.Errorf("Answer RData = %q, want %q", msg.Answer[0].RData.String(), "ADDRESS: 192.0.2.1")
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	header := []byte{0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"short header", header[:10]},
+		{"missing question", header},
+		{"pointer loop", append(append([]byte{}, header...), 0xC0, 0x0C, 0x00, 0x01, 0x00, 0x01)},
+		{"forward pointer", append(append([]byte{}, header...), 0xC0, 0x20, 0x00, 0x01, 0x00, 0x01)},
+		{"truncated label", append(append([]byte{}, header...), 7, 'e', 'x')},
+		{"unterminated name", append(append([]byte{}, header...), 3, 'c', 'o', 'm')},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseMessage(test.data); err == nil {
+				t.Error("ParseMessage() should return error")
+			}
+		})
+	}
+}
+
+func TestParseMessageSOA(t *testing.T) {
+	data := []byte{
+		0x00, 0x01, 0x81, 0x83, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+		// Question: example.com A IN
+		7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+		0x00, 0x01, 0x00, 0x01,
+		// Authority: example.com SOA IN, TTL 3600
+		0xC0, 0x0C, 0x00, 0x06, 0x00, 0x01, 0x00, 0x00, 0x0E, 0x10, 0x00, 0x21,
+		// MNAME ns.<example.com>, RNAME admin.<example.com>
+		2, 'n', 's', 0xC0, 0x0C,
+		5, 'a', 'd', 'm', 'i', 'n', 0xC0, 0x0C,
+		0x00, 0x00, 0x00, 0x01, // serial
+		0x00, 0x00, 0x1C, 0x20, // refresh
+		0x00, 0x00, 0x0E, 0x10, // retry
+		0x00, 0x12, 0x75, 0x00, // expire
+		0x00, 0x00, 0x01, 0x2C, // minimum
+	}
+
+	msg, err := ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage() returned error: %v", err)
+	}
+
+	soa, ok := msg.Authority[0].RData.(*records.SOARecord)
+	if !ok {
+		t.Fatalf("Authority RData is %T, want *records.SOARecord", msg.Authority[0].RData)
+	}
+	if dns.LabelsToString(soa.MName) != "ns.example.com" || dns.LabelsToString(soa.RName) != "admin.example.com" {
+		t.Errorf("SOA names = %s %s, want ns.example.com admin.example.com",
+			dns.LabelsToString(soa.MName), dns.LabelsToString(soa.RName))
+	}
+	if soa.Serial != 1 || soa.Minimum != 300 {
+		t.Errorf("SOA serial/minimum = %d/%d, want 1/300", soa.Serial, soa.Minimum)
+	}
+}
+
+func TestParseMessagePTR(t *testing.T) {
+	data := []byte{
+		0x00, 0x01, 0x81, 0x80, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		// Question: 1.2.0.192.in-addr.arpa PTR IN
+		1, '1', 1, '2', 1, '0', 3, '1', '9', '2', 7, 'i', 'n', '-', 'a', 'd', 'd', 'r', 4, 'a', 'r', 'p', 'a', 0,
+		0x00, 0x0C, 0x00, 0x01,
+		// Answer: pointer to offset 12, PTR IN, TTL 300, host.<in-addr.arpa>
+		0xC0, 0x0C, 0x00, 0x0C, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C, 0x00, 0x07,
+		4, 'h', 'o', 's', 't', 0xC0, 0x16,
+	}
+
+	msg, err := ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage() returned error: %v", err)
+	}
+
+	ptr, ok := msg.Answer[0].RData.(*records.PTRRecord)
+	if !ok {
+		t.Fatalf("Answer RData is %T, want *records.PTRRecord", msg.Answer[0].RData)
+	}
+	if target := ptr.Target.String(); target != "host.in-addr.arpa" {
+		t.Errorf("PTR target = %q, want %q", target, "host.in-addr.arpa")
+	}
+}
+
+func TestParseResourceRecordDNSSECTypes(t *testing.T) {
+	key := records.NewDNSKEYRecord(records.DNSKEYFlagZone, records.AlgorithmED25519, make([]byte, 32))
+	nsec := records.NewNSECRecord(dns.MustParseName("b.example.com"), []dns.QType{dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC})
+
+	tests := []struct {
+		rdata    dns.ResourceData
+		expected string
+	}{
+		{key, "*records.DNSKEYRecord"},
+		{nsec, "*records.NSECRecord"},
+		{records.NewDSRecord(1, 13, 2, []byte{1, 2}), "*records.DSRecord"},
+		{records.NewCDSRecord(1, 13, 2, []byte{1, 2}), "*records.CDSRecord"},
+		{records.NewMXRecord(10, dns.MustParseName("mail.example.com")), "*records.MXRecord"},
+		{records.NewSRVRecord(0, 5, 5060, dns.MustParseName("sip.example.com")), "*records.SRVRecord"},
+		{records.NewTXTRecord("v=spf1 -all"), "*records.TXTRecord"},
+		{records.NewCNAMERecord(dns.MustParseName("www.example.net")), "*records.CNAMERecord"},
+		{records.NewDNAMERecord(dns.MustParseName("example.net")), "*records.DNAMERecord"},
+		{records.NewCDNSKEYRecord(records.DNSKEYFlagZone, records.AlgorithmED25519, make([]byte, 32)), "*records.CDNSKEYRecord"},
+		{records.NewNSEC3PARAMRecord(records.NSEC3HashSHA1, 0, nil), "*records.NSEC3PARAMRecord"},
+		// Malformed data falls back to a generic record
+		{records.NewGenericRecord(dns.TypeNSEC, []byte{0xC0, 0x0C}), "*records.GenericRecord"},
+	}
+
+	for _, test := range tests {
+		rr := dns.ResourceRecord{Name: dns.MustParseName("example.com"), Type: test.rdata.Type(), Class: dns.ClassIN, TTL: 60, RData: test.rdata}
+		msg, err := dns.NewMessage().Answer(rr).Build()
+		if err != nil {
+			t.Fatalf("Build() returned error: %v", err)
+		}
+		data, err := msg.ToBytes()
+		if err != nil {
+			t.Fatalf("ToBytes() returned error: %v", err)
+		}
+
+		parsed, err := ParseMessage(data)
+		if err != nil {
+			t.Fatalf("ParseMessage() returned error: %v", err)
+		}
+		if result := fmt.Sprintf("%T", parsed.Answer[0].RData); result != test.expected {
+			t.Errorf("parsed %v RData type = %s, want %s", test.rdata.Type(), result, test.expected)
+		}
+	}
+}
+
+// answerMessage returns a response to example.com whose answer has the
+// given type and resource data; 0xC0, 0x0C in the data points to
+// example.com
+func answerMessage(rrType dns.QType, rdata ...byte) []byte {
+	data := []byte{
+		0x00, 0x01, 0x81, 0x80, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+		7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+		byte(rrType >> 8), byte(rrType), 0x00, 0x01,
+		0xC0, 0x0C, byte(rrType >> 8), byte(rrType), 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C,
+		byte(len(rdata) >> 8), byte(len(rdata)),
+	}
+	return append(data, rdata...)
+}
+
+func TestParseMessagePTRLengthMismatch(t *testing.T) {
+	// The name ends before the RDATA does
+	msg, err := ParseMessage(answerMessage(dns.TypePTR, 4, 'h', 'o', 's', 't', 0xC0, 0x0C, 0xFF))
+	if err != nil {
+		t.Fatalf("ParseMessage() returned error: %v", err)
+	}
+	if _, ok := msg.Answer[0].RData.(*records.GenericRecord); !ok {
+		t.Errorf("Answer RData is %T, want *records.GenericRecord", msg.Answer[0].RData)
+	}
+}
+
+func TestParseMessageAlias(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		want  string // Type of the answer RData
+		value string // Its target, if parsed
+	}{
+		{"CNAME", answerMessage(dns.TypeCNAME, 3, 'w', 'w', 'w', 0xC0, 0x0C), "*records.CNAMERecord", "www.example.com"},
+		{"DNAME", answerMessage(dns.TypeDNAME, 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'n', 'e', 't', 0), "*records.DNAMERecord", "example.net"},
+		{"CNAME length mismatch", answerMessage(dns.TypeCNAME, 0xC0, 0x0C, 0x00), "*records.GenericRecord", ""},
+		{"DNAME length mismatch", answerMessage(dns.TypeDNAME, 3, 'n', 'e', 't', 0, 0x00), "*records.GenericRecord", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := ParseMessage(test.data)
+			if err != nil {
+				t.Fatalf("ParseMessage() returned error: %v", err)
+			}
+			rdata := msg.Answer[0].RData
+			if got := fmt.Sprintf("%T", rdata); got != test.want {
+				t.Fatalf("Answer RData is %s, want %s", got, test.want)
+			}
+			if test.value != "" && rdata.String() != "NAME: "+test.value {
+				t.Errorf("Answer RData = %q, want target %s", rdata.String(), test.value)
+			}
+		})
+	}
+}
+
+func TestParseMessageMXSRV(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string // String of the answer RData, or empty for a generic record
+	}{
+		{"MX compressed exchange", answerMessage(dns.TypeMX, 0x00, 0x0A, 4, 'm', 'a', 'i', 'l', 0xC0, 0x0C),
+			"PREFERENCE: 10\tEXCHANGE: mail.example.com"},
+		{"SRV compressed target", answerMessage(dns.TypeSRV, 0x00, 0x01, 0x00, 0x14, 0x13, 0xC4, 3, 's', 'i', 'p', 0xC0, 0x0C),
+			"PRIORITY: 1\tWEIGHT: 20\tPORT: 5060\tTARGET: sip.example.com"},
+		{"MX length mismatch", answerMessage(dns.TypeMX, 0x00, 0x0A, 0xC0, 0x0C, 0x00), ""},
+		{"SRV length mismatch", answerMessage(dns.TypeSRV, 0x00, 0x01, 0x00, 0x14, 0x13, 0xC4, 0xC0, 0x0C, 0x00), ""},
+		{"MX truncated", answerMessage(dns.TypeMX, 0x00), ""},
+		{"SRV truncated", answerMessage(dns.TypeSRV, 0x00, 0x01, 0x00, 0x14), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := ParseMessage(test.data)
+			if err != nil {
+				t.Fatalf("ParseMessage() returned error: %v", err)
+			}
+			rdata := msg.Answer[0].RData
+			if test.want == "" {
+				if _, ok := rdata.(*records.GenericRecord); !ok {
+					t.Errorf("Answer RData is %T, want *records.GenericRecord", rdata)
+				}
+				return
+			}
+			if rdata.String() != test.want {
+				t.Errorf("Answer RData = %q, want %q", rdata.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// MaxChainLength is the largest number of CNAME and DNAME records Resolve
+// follows for one lookup
+const MaxChainLength = 12
+
+// Errors for alias chains that cannot be followed to the end
+var (
+	ErrAliasLoop     = errors.New("CNAME/DNAME loop")
+	ErrChainTooLong  = errors.New("CNAME/DNAME chain too long")
+	ErrDNAMEOverflow = errors.New("DNAME substitution exceeds the maximum name length")
+)
+
+// Resolution is the result of following the aliases of a name
+type Resolution struct {
+	// Name is the name that was asked for and Target the canonical name
+	// the chain ends at
+	Name   dns.Name
+	Target dns.Name
+	// Chain holds the CNAME and DNAME records traversed, in order
+	Chain []dns.ResourceRecord
+	// Answer holds the records of the queried type owned by Target; it is
+	// empty if Target has none
+	Answer []dns.ResourceRecord
+	// Response is the last response received, whose RCODE and authority
+	// section apply to Target
+	Response *dns.Message
+}
+
+// Resolve queries domain like Query and follows CNAME and DNAME records
+// (RFC 1034 Section 3.6.2, RFC 6672) to the records of qtype. Aliases are
+// followed within each response first; the target is queried again only
+// when the response stops short of it. Loops and chains longer than
+// MaxChainLength are errors.
+func (c *Client) Resolve(domain string, qtype dns.QType) (*Resolution, error) {
+	return c.resolve(context.Background(), domain, qtype)
+}
+
+// resolve implements Resolve
+func (c *Client) resolve(ctx context.Context, domain string, qtype dns.QType) (*Resolution, error) {
+	domain, err := dns.ToASCII(domain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain: %w", err)
+	}
+	response, err := c.query(ctx, domain, qtype, defaultPolicy(qtype))
+	if err != nil {
+		return nil, err
+	}
+	// The search list may have expanded the name
+	name, err := dns.ParseName(domain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain: %w", err)
+	}
+	if len(response.Question) > 0 {
+		name = response.Question[0].Name
+	}
+
+	result := &Resolution{Name: name, Target: name}
+	seen := map[string]bool{name.Key(): true}
+	for {
+		asked := result.Target
+		result.Response = response
+		if err := followChain(result, seen, qtype); err != nil {
+			return nil, err
+		}
+
+		// Query again only if the response stopped at an alias target
+		// without answering for it
+		if len(result.Answer) > 0 || result.Target.Equal(asked) || response.Rcode() != dns.RcodeSuccess {
+			return result, nil
+		}
+		c.logger.Debug("Following alias", "target", result.Target.String())
+		response, err = c.query(ctx, absolute(result.Target), qtype, dns.DNSPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// followChain follows aliases from result.Target through the answer
+// section of result.Response, collecting the chain and, once the target
+// is reached, its records of qtype
+func followChain(result *Resolution, seen map[string]bool, qtype dns.QType) error {
+	answers := result.Response.Answer
+	for {
+		for _, rr := range answers {
+			if rr.Type == qtype && rr.Name.Equal(result.Target) {
+				result.Answer = append(result.Answer, rr)
+			}
+		}
+		if len(result.Answer) > 0 {
+			return nil
+		}
+
+		alias, next, err := nextAlias(answers, result.Target)
+		if err != nil || alias == nil {
+			return err
+		}
+
+		result.Chain = append(result.Chain, *alias)
+		if len(result.Chain) > MaxChainLength {
+			return fmt.Errorf("%w: more than %d aliases for %s", ErrChainTooLong, MaxChainLength, result.Name)
+		}
+		if seen[next.Key()] {
+			return fmt.Errorf("%w: %s", ErrAliasLoop, next)
+		}
+		seen[next.Key()] = true
+		result.Target = next
+	}
+}
+
+// nextAlias returns the DNAME or CNAME record that redirects name, and the
+// name it redirects to. A DNAME takes precedence over the CNAME
+// synthesized from it (RFC 6672 Section 3.4).
+func nextAlias(answers []dns.ResourceRecord, name dns.Name) (*dns.ResourceRecord, dns.Name, error) {
+	for i, rr := range answers {
+		dname, ok := rr.RData.(*records.DNAMERecord)
+		if !ok || rr.Name.Equal(name) || !name.IsSubdomainOf(rr.Name) {
+			continue
+		}
+		next, err := name.ReplaceSuffix(rr.Name, dname.Target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrDNAMEOverflow, err)
+		}
+		return &answers[i], next, nil
+	}
+	for i, rr := range answers {
+		if cname, ok := rr.RData.(*records.CNAMERecord); ok && rr.Name.Equal(name) {
+			return &answers[i], cname.Target, nil
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// serveRecords returns a handler answering from the given records. With
+// follow set, the answer section follows CNAMEs and DNAMEs the way a
+// recursive resolver does; otherwise it stops at the first alias.
+func serveRecords(rrs []dns.ResourceRecord, follow bool) func(*dns.Message) (*dns.Message, error) {
+	return func(query *dns.Message) (*dns.Message, error) {
+		q := query.Question[0]
+		var answer []dns.ResourceRecord
+		name := q.Name
+		for hops := 0; hops < 20; hops++ {
+			var next dns.Name
+			for _, rr := range rrs {
+				switch {
+				case rr.Name.Equal(name) && rr.Type == q.Type:
+					answer = append(answer, rr)
+				case rr.Name.Equal(name) && rr.Type == dns.TypeCNAME:
+					answer = append(answer, rr)
+					next = rr.RData.(*records.CNAMERecord).Target
+				case rr.Type == dns.TypeDNAME && name.IsSubdomainOf(rr.Name) && !name.Equal(rr.Name):
+					target, err := name.ReplaceSuffix(rr.Name, rr.RData.(*records.DNAMERecord).Target)
+					if err != nil {
+						return nil, err
+					}
+					synthesized := dns.ResourceRecord{Name: name, Type: dns.TypeCNAME, Class: dns.ClassIN, TTL: rr.TTL, RData: records.NewCNAMERecord(target)}
+					answer = append(answer, rr, synthesized)
+					next = target
+				}
+			}
+			if next == nil || !follow {
+				break
+			}
+			name = next
+		}
+		response := &dns.Message{
+			Header: dns.Header{
+				ID:      query.Header.ID,
+				Flags:   dns.HeaderQRResponse | dns.HeaderRD | dns.HeaderRA,
+				QDCount: 1,
+				ANCount: uint16(len(answer)),
+			},
+			Question: query.Question,
+			Answer:   answer,
+		}
+		if len(answer) == 0 && !ownsRecords(rrs, q.Name) {
+			response.SetRcode(dns.RcodeNXDomain)
+		}
+		return response, nil
+	}
+}
+
+// ownsRecords reports whether any record is owned by name
+func ownsRecords(rrs []dns.ResourceRecord, name dns.Name) bool {
+	for _, rr := range rrs {
+		if rr.Name.Equal(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func aliasRecord(owner string, qtype dns.QType, target string) dns.ResourceRecord {
+	rr := dns.ResourceRecord{Name: dns.MustParseName(owner), Type: qtype, Class: dns.ClassIN, TTL: 300}
+	switch qtype {
+	case dns.TypeCNAME:
+		rr.RData = records.NewCNAMERecord(dns.MustParseName(target))
+	case dns.TypeDNAME:
+		rr.RData = records.NewDNAMERecord(dns.MustParseName(target))
+	default:
+		a, _ := records.NewARecordFromString(target)
+		rr.RData = a
+	}
+	return rr
+}
+
+// record returns a resource record with the given data
+func record(owner string, rdata dns.ResourceData) dns.ResourceRecord {
+	return dns.ResourceRecord{Name: dns.MustParseName(owner), Type: rdata.Type(), Class: dns.ClassIN, TTL: 300, RData: rdata}
+}
+
+func newResolveClient(t *testing.T, handler func(*dns.Message) (*dns.Message, error)) (*Client, *fakeTransport) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: handler}
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+	return client, fake
+}
+
+func TestClientResolve(t *testing.T) {
+	zone := []dns.ResourceRecord{
+		aliasRecord("www.example.com", dns.TypeCNAME, "web.example.net"),
+		aliasRecord("web.example.net", dns.TypeCNAME, "host.old.example"),
+		aliasRecord("old.example", dns.TypeDNAME, "new.example"),
+		aliasRecord("host.new.example", dns.TypeA, "192.0.2.1"),
+		aliasRecord("empty.example.com", dns.TypeCNAME, "nodata.example.net"),
+	}
+
+	tests := []struct {
+		name    string
+		follow  bool
+		domain  string
+		qtype   dns.QType
+		queries int
+		chain   []dns.QType
+		answers int
+		target  string
+	}{
+		{"in message", true, "www.example.com", dns.TypeA, 1, []dns.QType{dns.TypeCNAME, dns.TypeCNAME, dns.TypeDNAME}, 1, "host.new.example"},
+		{"requery", false, "WWW.example.com", dns.TypeA, 4, []dns.QType{dns.TypeCNAME, dns.TypeCNAME, dns.TypeDNAME}, 1, "host.new.example"},
+		{"no alias", true, "host.new.example", dns.TypeA, 1, nil, 1, "host.new.example"},
+		{"query alias type", true, "www.example.com", dns.TypeCNAME, 1, nil, 1, "www.example.com"},
+		{"nodata at target", false, "empty.example.com", dns.TypeA, 2, []dns.QType{dns.TypeCNAME}, 0, "nodata.example.net"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, fake := newResolveClient(t, serveRecords(zone, test.follow))
+
+			result, err := client.Resolve(test.domain, test.qtype)
+			if err != nil {
+				t.Fatalf("Resolve() returned error: %v", err)
+			}
+			if len(fake.queries) != test.queries {
+				t.Errorf("Resolve() sent %d queries, want %d", len(fake.queries), test.queries)
+			}
+			var chain []dns.QType
+			for _, rr := range result.Chain {
+				chain = append(chain, rr.Type)
+			}
+			if fmt.Sprint(chain) != fmt.Sprint(test.chain) {
+				t.Errorf("chain types = %v, want %v", chain, test.chain)
+			}
+			if len(result.Answer) != test.answers || result.Target.String() != test.target {
+				t.Errorf("Resolve() = %d answers at %s, want %d at %s", len(result.Answer), result.Target, test.answers, test.target)
+			}
+			if !result.Name.Equal(dns.MustParseName(test.domain)) {
+				t.Errorf("Resolve() name = %s, want %s", result.Name, test.domain)
+			}
+		})
+	}
+}
+
+func TestClientResolveErrors(t *testing.T) {
+	loop := []dns.ResourceRecord{
+		aliasRecord("a.example.com", dns.TypeCNAME, "b.example.com"),
+		aliasRecord("b.example.com", dns.TypeCNAME, "A.example.com"),
+	}
+	var long []dns.ResourceRecord
+	for i := 0; i <= MaxChainLength; i++ {
+		long = append(long, aliasRecord(fmt.Sprintf("c%d.example.com", i), dns.TypeCNAME, fmt.Sprintf("c%d.example.com", i+1)))
+	}
+
+	tests := []struct {
+		name     string
+		zone     []dns.ResourceRecord
+		follow   bool
+		domain   string
+		expected error
+	}{
+		{"loop in message", loop, true, "a.example.com", ErrAliasLoop},
+		{"loop across queries", loop, false, "a.example.com", ErrAliasLoop},
+		{"too long", long, true, "c0.example.com", ErrChainTooLong},
+		{"too long across queries", long, false, "c0.example.com", ErrChainTooLong},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := newResolveClient(t, serveRecords(test.zone, test.follow))
+			if _, err := client.Resolve(test.domain, dns.TypeA); !errors.Is(err, test.expected) {
+				t.Errorf("Resolve() error = %v, want %v", err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// ErrNoRecords is returned by Resolver lookups when the name exists but
+// has no records of the requested type
+var ErrNoRecords = errors.New("no records found")
+
+// Resolver offers the lookups of net.Resolver on top of a Client. Aliases
+// are followed, failure RCODEs are errors and results use the types of the
+// net package, with names in absolute form ("mail.example.com.").
+type Resolver struct {
+	client   *Client
+	randIntn func(n int) int // Random source for SRV ordering
+}
+
+// NewResolver creates a resolver that sends its queries through client
+func NewResolver(client *Client) *Resolver {
+	return &Resolver{client: client, randIntn: rand.Intn}
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of host, querying A and
+// AAAA records in parallel
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	ips, err := r.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.String()
+	}
+	return addrs, nil
+}
+
+// LookupIP returns the addresses of host for network "ip" (A and AAAA),
+// "ip4" (A) or "ip6" (AAAA). IPv4 addresses come first. An error is
+// returned only if every query failed.
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var qtypes []dns.QType
+	switch network {
+	case "ip":
+		qtypes = []dns.QType{dns.TypeA, dns.TypeAAAA}
+	case "ip4":
+		qtypes = []dns.QType{dns.TypeA}
+	case "ip6":
+		qtypes = []dns.QType{dns.TypeAAAA}
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	answers := make([][]dns.ResourceRecord, len(qtypes))
+	errs := make([]error, len(qtypes))
+	var wg sync.WaitGroup
+	for i, qtype := range qtypes {
+		wg.Add(1)
+		go func(i int, qtype dns.QType) {
+			defer wg.Done()
+			answers[i], errs[i] = r.lookup(ctx, host, qtype)
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	var ips []net.IP
+	for _, answer := range answers {
+		for _, rr := range answer {
+			switch rd := rr.RData.(type) {
+			case *records.ARecord:
+				ips = append(ips, rd.Address)
+			case *records.AAAARecord:
+				ips = append(ips, rd.Address)
+			}
+		}
+	}
+	if len(ips) == 0 {
+		// Prefer an error more specific than a missing record type
+		for _, err := range errs {
+			if err != nil && !errors.Is(err, ErrNoRecords) {
+				return nil, err
+			}
+		}
+		return nil, errs[0]
+	}
+	return ips, nil
+}
+
+// LookupMX returns the MX records of name, sorted by preference
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	answer, err := r.lookup(ctx, name, dns.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+	var mxs []*net.MX
+	for _, rr := range answer {
+		if mx, ok := rr.RData.(*records.MXRecord); ok {
+			mxs = append(mxs, &net.MX{Host: absolute(mx.Exchange), Pref: mx.Preference})
+		}
+	}
+	sort.SliceStable(mxs, func(i, j int) bool { return mxs[i].Pref < mxs[j].Pref })
+	return mxs, nil
+}
+
+// LookupTXT returns the TXT records of name, each with its
+// character-strings joined
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	answer, err := r.lookup(ctx, name, dns.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	var texts []string
+	for _, rr := range answer {
+		if txt, ok := rr.RData.(*records.TXTRecord); ok {
+			texts = append(texts, txt.Text())
+		}
+	}
+	return texts, nil
+}
+
+// LookupSRV looks up the SRV records of _service._proto.name, or of name
+// itself if service and proto are empty. It returns the canonical name
+// and the records ordered by priority and, within a priority, randomly by
+// weight (RFC 2782).
+func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	target := name
+	if service != "" || proto != "" {
+		target = "_" + service + "._" + proto + "." + name
+	}
+	result, err := r.resolve(ctx, target, dns.TypeSRV)
+	if err != nil {
+		return "", nil, err
+	}
+	var srvs []*net.SRV
+	for _, rr := range result.Answer {
+		if srv, ok := rr.RData.(*records.SRVRecord); ok {
+			srvs = append(srvs, &net.SRV{Target: absolute(srv.Target), Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight})
+		}
+	}
+	sortSRV(srvs, r.randIntn)
+	return absolute(result.Target), srvs, nil
+}
+
+// LookupNS returns the NS records of name
+func (r *Resolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	answer, err := r.lookup(ctx, name, dns.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	var nss []*net.NS
+	for _, rr := range answer {
+		if ns, ok := rr.RData.(*records.NSRecord); ok {
+			nss = append(nss, &net.NS{Host: absolute(ns.NameServer)})
+		}
+	}
+	return nss, nil
+}
+
+// LookupAddr returns the names an address maps to through PTR records
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("lookup %s: invalid IP address", addr)
+	}
+	names, err := r.client.reverseLookup(ctx, ip)
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s: %w", addr, err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("lookup %s: %w", addr, ErrNoRecords)
+	}
+	hosts := make([]string, len(names))
+	for i, name := range names {
+		hosts[i] = absolute(name)
+	}
+	return hosts, nil
+}
+
+// lookup resolves name and returns the records of qtype at the end of its
+// alias chain
+func (r *Resolver) lookup(ctx context.Context, name string, qtype dns.QType) ([]dns.ResourceRecord, error) {
+	result, err := r.resolve(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	return result.Answer, nil
+}
+
+// resolve resolves name, turning failure RCODEs and empty answers into
+// errors
+func (r *Resolver) resolve(ctx context.Context, name string, qtype dns.QType) (*Resolution, error) {
+	result, err := r.client.resolve(ctx, name, qtype)
+	if err == nil {
+		err = checkRcode(result.Response)
+	}
+	if err == nil && len(result.Answer) == 0 {
+		err = fmt.Errorf("%s %w", qtype, ErrNoRecords)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// sortSRV orders SRV records by priority and shuffles each priority by
+// weight. randIntn returns a random number in [0, n).
+func sortSRV(srvs []*net.SRV, randIntn func(n int) int) {
+	sort.SliceStable(srvs, func(i, j int) bool { return srvs[i].Priority < srvs[j].Priority })
+	for start := 0; start < len(srvs); {
+		end := start
+		for end < len(srvs) && srvs[end].Priority == srvs[start].Priority {
+			end++
+		}
+		shuffleByWeight(srvs[start:end], randIntn)
+		start = end
+	}
+}
+
+// shuffleByWeight orders records of one priority by repeated weighted
+// random selection (RFC 2782, "Usage rules"): records with weight zero are
+// placed first, so they are picked only with a small probability
+func shuffleByWeight(srvs []*net.SRV, randIntn func(n int) int) {
+	sort.SliceStable(srvs, func(i, j int) bool { return srvs[i].Weight == 0 && srvs[j].Weight != 0 })
+	for ; len(srvs) > 1; srvs = srvs[1:] {
+		sum := 0
+		for _, srv := range srvs {
+			sum += int(srv.Weight)
+		}
+		pick, running := randIntn(sum+1), 0
+		for k, srv := range srvs {
+			running += int(srv.Weight)
+			if running >= pick {
+				// Move the selected record to the front, keeping the order
+				// of the others
+				copy(srvs[1:k+1], srvs[:k])
+				srvs[0] = srv
+				break
+			}
+		}
+	}
+}
+
+// absolute returns a name in presentation format with a trailing dot
+func absolute(name dns.Name) string {
+	return name.String() + "."
+}
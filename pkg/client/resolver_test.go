@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func testResolver(t *testing.T) (*Resolver, *fakeTransport) {
+	t.Helper()
+	mustA := func(addr string) dns.ResourceData {
+		a, err := records.NewARecordFromString(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	aaaa, err := records.NewAAAARecordFromString("2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverse := dns.ReverseName(netip.MustParseAddr("192.0.2.1")).String()
+
+	zone := []dns.ResourceRecord{
+		record("example.com", mustA("192.0.2.1")),
+		record("example.com", mustA("192.0.2.2")),
+		record("example.com", aaaa),
+		record("example.com", records.NewMXRecord(20, dns.MustParseName("mx2.example.com"))),
+		record("example.com", records.NewMXRecord(10, dns.MustParseName("mx1.example.com"))),
+		record("example.com", records.NewTXTRecord("v=spf1 ", "-all")),
+		record("example.com", records.NewTXTRecord("second")),
+		record("example.com", records.NewNSRecord(dns.MustParseName("ns1.example.com"))),
+		record("v4only.example.com", mustA("192.0.2.3")),
+		aliasRecord("www.example.com", dns.TypeCNAME, "example.com"),
+		aliasRecord("_sip._tcp.example.com", dns.TypeCNAME, "_sip._tcp.example.net"),
+		record("_sip._tcp.example.net", records.NewSRVRecord(20, 0, 5060, dns.MustParseName("backup.example.net"))),
+		record("_sip._tcp.example.net", records.NewSRVRecord(10, 0, 5060, dns.MustParseName("zero.example.net"))),
+		record("_sip._tcp.example.net", records.NewSRVRecord(10, 60, 5060, dns.MustParseName("heavy.example.net"))),
+		record("_sip._tcp.example.net", records.NewSRVRecord(10, 40, 5060, dns.MustParseName("light.example.net"))),
+		record(reverse, records.NewPTRRecord(dns.MustParseName("example.com"))),
+	}
+
+	client, fake := newResolveClient(t, serveRecords(zone, true))
+	return NewResolver(client), fake
+}
+
+func TestResolverLookupHost(t *testing.T) {
+	resolver, fake := testResolver(t)
+	ctx := context.Background()
+
+	addrs, err := resolver.LookupHost(ctx, "www.example.com")
+	if err != nil {
+		t.Fatalf("LookupHost() returned error: %v", err)
+	}
+	if result := strings.Join(addrs, " "); result != "192.0.2.1 192.0.2.2 2001:db8::1" {
+		t.Errorf("LookupHost() = %s, want 192.0.2.1 192.0.2.2 2001:db8::1", result)
+	}
+	if len(fake.queries) != 2 {
+		t.Errorf("LookupHost() sent %d queries, want 2", len(fake.queries))
+	}
+
+	// A missing AAAA record is not an error when there are A records
+	if addrs, err := resolver.LookupHost(ctx, "v4only.example.com"); err != nil || len(addrs) != 1 {
+		t.Errorf("LookupHost(v4only) = %v, %v, want one address", addrs, err)
+	}
+	if _, err := resolver.LookupHost(ctx, "missing.example.com"); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("LookupHost(missing) error = %v, want ErrNXDomain", err)
+	}
+	if addrs, err := resolver.LookupHost(ctx, "192.0.2.9"); err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.9" {
+		t.Errorf("LookupHost(192.0.2.9) = %v, %v, want the address itself", addrs, err)
+	}
+
+	ips, err := resolver.LookupIP(ctx, "ip6", "example.com")
+	if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("LookupIP(ip6) = %v, %v, want [2001:db8::1]", ips, err)
+	}
+	if _, err := resolver.LookupIP(ctx, "tcp", "example.com"); err == nil {
+		t.Error("LookupIP() should reject unknown networks")
+	}
+}
+
+func TestResolverLookupMXTXTNS(t *testing.T) {
+	resolver, _ := testResolver(t)
+	ctx := context.Background()
+
+	mxs, err := resolver.LookupMX(ctx, "example.com")
+	if err != nil || len(mxs) != 2 || mxs[0].Host != "mx1.example.com." || mxs[0].Pref != 10 || mxs[1].Host != "mx2.example.com." {
+		t.Errorf("LookupMX() = %v, %v, want mx1 then mx2", mxs, err)
+	}
+
+	texts, err := resolver.LookupTXT(ctx, "example.com")
+	if err != nil || len(texts) != 2 || texts[0] != "v=spf1 -all" || texts[1] != "second" {
+		t.Errorf("LookupTXT() = %q, %v", texts, err)
+	}
+
+	nss, err := resolver.LookupNS(ctx, "example.com")
+	if err != nil || len(nss) != 1 || nss[0].Host != "ns1.example.com." {
+		t.Errorf("LookupNS() = %v, %v", nss, err)
+	}
+
+	if _, err := resolver.LookupMX(ctx, "v4only.example.com"); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("LookupMX(v4only) error = %v, want ErrNoRecords", err)
+	}
+}
+
+func TestResolverLookupSRV(t *testing.T) {
+	resolver, _ := testResolver(t)
+	resolver.randIntn = func(n int) int { return n - 1 } // Always pick the last candidate
+
+	cname, srvs, err := resolver.LookupSRV(context.Background(), "sip", "tcp", "example.com")
+	if err != nil {
+		t.Fatalf("LookupSRV() returned error: %v", err)
+	}
+	if cname != "_sip._tcp.example.net." {
+		t.Errorf("LookupSRV() cname = %q, want _sip._tcp.example.net.", cname)
+	}
+	var targets []string
+	for _, srv := range srvs {
+		targets = append(targets, srv.Target)
+	}
+	expected := "light.example.net. heavy.example.net. zero.example.net. backup.example.net."
+	if result := strings.Join(targets, " "); result != expected {
+		t.Errorf("LookupSRV() order = %s, want %s", result, expected)
+	}
+}
+
+func TestSortSRV(t *testing.T) {
+	srvs := func() []*net.SRV {
+		return []*net.SRV{
+			{Target: "b.", Priority: 2, Weight: 10},
+			{Target: "heavy.", Priority: 1, Weight: 90},
+			{Target: "zero.", Priority: 1, Weight: 0},
+			{Target: "light.", Priority: 1, Weight: 10},
+		}
+	}
+	order := func(srvs []*net.SRV) string {
+		var targets []string
+		for _, srv := range srvs {
+			targets = append(targets, srv.Target)
+		}
+		return strings.Join(targets, " ")
+	}
+
+	tests := []struct {
+		pick     func(n int) int
+		expected string
+	}{
+		// A random value of zero selects a zero-weight record
+		{func(n int) int { return 0 }, "zero. heavy. light. b."},
+		{func(n int) int { return n - 1 }, "light. heavy. zero. b."},
+		// 50 of 0..100 falls within the running sum of heavy
+		{func(n int) int { return n / 2 }, "heavy. light. zero. b."},
+	}
+	for i, test := range tests {
+		list := srvs()
+		sortSRV(list, test.pick)
+		if result := order(list); result != test.expected {
+			t.Errorf("case %d: sortSRV() = %s, want %s", i, result, test.expected)
+		}
+	}
+
+	// Selection frequencies follow the weights
+	counts := map[string]int{}
+	resolver := NewResolver(nil)
+	for i := 0; i < 2000; i++ {
+		list := srvs()
+		sortSRV(list, resolver.randIntn)
+		counts[list[0].Target]++
+	}
+	if counts["heavy."] < counts["light."]*3 {
+		t.Errorf("first picks = %v, want heavy chosen far more often than light", counts)
+	}
+}
+
+func TestResolverLookupAddr(t *testing.T) {
+	resolver, _ := testResolver(t)
+	ctx := context.Background()
+
+	names, err := resolver.LookupAddr(ctx, "192.0.2.1")
+	if err != nil || fmt.Sprint(names) != "[example.com.]" {
+		t.Errorf("LookupAddr() = %v, %v, want [example.com.]", names, err)
+	}
+	if _, err := resolver.LookupAddr(ctx, "not-an-ip"); err == nil {
+		t.Error("LookupAddr() should reject invalid addresses")
+	}
+	if _, err := resolver.LookupAddr(ctx, "192.0.2.77"); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("LookupAddr(192.0.2.77) error = %v, want ErrNXDomain", err)
+	}
+}
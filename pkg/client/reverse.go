@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// MaxReverseRange is the largest number of addresses ReverseLookupPrefix
+// resolves
+const MaxReverseRange = 65536
+
+// ReverseResult is the outcome of a reverse lookup of one address
+type ReverseResult struct {
+	Addr  netip.Addr
+	Names []dns.Name
+	Err   error
+}
+
+// ReverseLookup returns the PTR targets for an IP address. Failure RCODEs
+// such as NXDOMAIN are returned as an *RcodeError regardless of
+// Config.RcodeErrors; an address without PTR records yields no names and
+// no error.
+func (c *Client) ReverseLookup(ip net.IP) ([]dns.Name, error) {
+	return c.reverseLookup(context.Background(), ip)
+}
+
+// reverseLookup implements ReverseLookup
+func (c *Client) reverseLookup(ctx context.Context, ip net.IP) ([]dns.Name, error) {
+	name, err := dns.ReverseNameFromIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	// Classless delegations (RFC 2317) answer through a CNAME
+	result, err := c.resolve(ctx, absolute(name), dns.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRcode(result.Response); err != nil {
+		return nil, err
+	}
+
+	var names []dns.Name
+	for _, rr := range result.Answer {
+		if ptr, ok := rr.RData.(*records.PTRRecord); ok {
+			names = append(names, ptr.Target)
+		}
+	}
+	return names, nil
+}
+
+// ReverseLookupPrefix reverse-resolves every address of a CIDR range in
+// order. Lookup failures are reported per address; an error is returned
+// only for ranges larger than MaxReverseRange.
+func (c *Client) ReverseLookupPrefix(prefix netip.Prefix) ([]ReverseResult, error) {
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("invalid prefix: %v", prefix)
+	}
+	prefix = prefix.Masked()
+	if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits > 16 {
+		return nil, fmt.Errorf("prefix %s has more than %d addresses", prefix, MaxReverseRange)
+	}
+
+	var results []ReverseResult
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		names, err := c.ReverseLookup(addr.AsSlice())
+		results = append(results, ReverseResult{Addr: addr, Names: names, Err: err})
+	}
+	return results, nil
+}
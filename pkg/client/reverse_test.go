@@ -0,0 +1,110 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// answerPTR returns a handler answering reverse queries for the addresses
+// in targets and NXDOMAIN for all others
+func answerPTR(targets map[string]string) func(*dns.Message) (*dns.Message, error) {
+	return func(query *dns.Message) (*dns.Message, error) {
+		response := &dns.Message{
+			Header: dns.Header{
+				ID:      query.Header.ID,
+				Flags:   dns.HeaderQRResponse | dns.HeaderRD | dns.HeaderRA,
+				QDCount: 1,
+			},
+			Question: query.Question,
+		}
+		addr, err := dns.ParseReverseName(query.Question[0].Name)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := targets[addr.String()]
+		if !ok {
+			response.SetRcode(dns.RcodeNXDomain)
+			return response, nil
+		}
+		response.Answer = []dns.ResourceRecord{{
+			Name:  query.Question[0].Name,
+			Type:  dns.TypePTR,
+			Class: dns.ClassIN,
+			TTL:   300,
+			RData: records.NewPTRRecord(dns.MustParseName(target)),
+		}}
+		response.Header.ANCount = 1
+		return response, nil
+	}
+}
+
+func TestClientReverseLookup(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerPTR(map[string]string{
+		"192.0.2.1":   "host.example.com",
+		"2001:db8::1": "v6.example.com",
+	})}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	names, err := client.ReverseLookup(net.ParseIP("192.0.2.1"))
+	if err != nil || len(names) != 1 || names[0].String() != "host.example.com" {
+		t.Errorf("ReverseLookup(192.0.2.1) = %v, %v, want [host.example.com]", names, err)
+	}
+	if q := fake.queries[0].Question[0]; q.Type != dns.TypePTR || q.Name.String() != "1.2.0.192.in-addr.arpa" {
+		t.Errorf("query = %s %s, want 1.2.0.192.in-addr.arpa PTR", q.Name, q.Type)
+	}
+
+	names, err = client.ReverseLookup(net.ParseIP("2001:db8::1"))
+	if err != nil || len(names) != 1 || names[0].String() != "v6.example.com" {
+		t.Errorf("ReverseLookup(2001:db8::1) = %v, %v, want [v6.example.com]", names, err)
+	}
+
+	if _, err := client.ReverseLookup(net.ParseIP("192.0.2.2")); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("ReverseLookup(192.0.2.2) error = %v, want ErrNXDomain", err)
+	}
+}
+
+func TestClientReverseLookupPrefix(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerPTR(map[string]string{"192.0.2.5": "five.example.com"})}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	results, err := client.ReverseLookupPrefix(netip.MustParsePrefix("192.0.2.6/30"))
+	if err != nil {
+		t.Fatalf("ReverseLookupPrefix() returned error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("ReverseLookupPrefix() returned %d results, want 4", len(results))
+	}
+	for i, result := range results {
+		if expected := netip.AddrFrom4([4]byte{192, 0, 2, byte(4 + i)}); result.Addr != expected {
+			t.Errorf("result %d address = %s, want %s", i, result.Addr, expected)
+		}
+	}
+	if results[1].Err != nil || len(results[1].Names) != 1 || results[1].Names[0].String() != "five.example.com" {
+		t.Errorf("result for 192.0.2.5 = %v, %v", results[1].Names, results[1].Err)
+	}
+	if !errors.Is(results[0].Err, ErrNXDomain) {
+		t.Errorf("result for 192.0.2.4 error = %v, want ErrNXDomain", results[0].Err)
+	}
+
+	if _, err := client.ReverseLookupPrefix(netip.MustParsePrefix("2001:db8::/64")); err == nil {
+		t.Error("ReverseLookupPrefix() should reject ranges larger than MaxReverseRange")
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net"
+)
+
+// Trace holds hooks called at the steps of a network exchange by
+// UDPTransport and TCPTransport, in the manner of net/http/httptrace.
+// Attach it to the context of a query with WithTrace. Any hook may be nil.
+// With failover, the hooks are called once per server tried.
+type Trace struct {
+	// DialStart is called before the server address is resolved and dialed
+	DialStart func(network, server string)
+	// DialDone is called when dialing finished with the local and remote
+	// addresses of the connection, which are nil on failure
+	DialDone func(network string, local, remote net.Addr, err error)
+	// WroteQuery is called with the wire-format query after it was written,
+	// without the TCP length prefix
+	WroteQuery func(data []byte, err error)
+	// ReadResponse is called with the wire-format response after it was
+	// read, before it is parsed
+	ReadResponse func(data []byte, err error)
+}
+
+// traceKey is the context key of the Trace
+type traceKey struct{}
+
+// WithTrace returns a context carrying trace. If ctx already carries a
+// trace, the hooks of both are called, those of the new trace first.
+func WithTrace(ctx context.Context, trace *Trace) context.Context {
+	if old := ContextTrace(ctx); old != nil {
+		trace = trace.compose(old)
+	}
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// ContextTrace returns the Trace of ctx, or nil
+func ContextTrace(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// compose returns a trace calling the hooks of t, then those of old
+func (t *Trace) compose(old *Trace) *Trace {
+	return &Trace{
+		DialStart: func(network, server string) {
+			if t.DialStart != nil {
+				t.DialStart(network, server)
+			}
+			if old.DialStart != nil {
+				old.DialStart(network, server)
+			}
+		},
+		DialDone: func(network string, local, remote net.Addr, err error) {
+			if t.DialDone != nil {
+				t.DialDone(network, local, remote, err)
+			}
+			if old.DialDone != nil {
+				old.DialDone(network, local, remote, err)
+			}
+		},
+		WroteQuery: func(data []byte, err error) {
+			if t.WroteQuery != nil {
+				t.WroteQuery(data, err)
+			}
+			if old.WroteQuery != nil {
+				old.WroteQuery(data, err)
+			}
+		},
+		ReadResponse: func(data []byte, err error) {
+			if t.ReadResponse != nil {
+				t.ReadResponse(data, err)
+			}
+			if old.ReadResponse != nil {
+				old.ReadResponse(data, err)
+			}
+		},
+	}
+}
+
+// The trace helpers below do nothing on a nil trace or hook, so that
+// transports can call them unconditionally.
+
+func (t *Trace) dialStart(network, server string) {
+	if t != nil && t.DialStart != nil {
+		t.DialStart(network, server)
+	}
+}
+
+func (t *Trace) dialDone(network string, conn net.Conn, err error) {
+	if t != nil && t.DialDone != nil {
+		if conn == nil {
+			t.DialDone(network, nil, nil, err)
+			return
+		}
+		t.DialDone(network, conn.LocalAddr(), conn.RemoteAddr(), err)
+	}
+}
+
+func (t *Trace) wroteQuery(data []byte, err error) {
+	if t != nil && t.WroteQuery != nil {
+		t.WroteQuery(data, err)
+	}
+}
+
+func (t *Trace) readResponse(data []byte, err error) {
+	if t != nil && t.ReadResponse != nil {
+		t.ReadResponse(data, err)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestTransportTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport func(t *testing.T) Transport
+	}{
+		{"udp", func(t *testing.T) Transport {
+			return NewUDPTransport(serveUDP(t, answerA("192.0.2.53")), 2*time.Second)
+		}},
+		{"tcp", func(t *testing.T) Transport {
+			return NewTCPTransport(serveTCP(t, answerA("192.0.2.53")), 2*time.Second)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var steps []string
+			var wrote, read []byte
+			trace := &Trace{
+				DialStart: func(network, server string) { steps = append(steps, "dial "+network) },
+				DialDone: func(network string, local, remote net.Addr, err error) {
+					if err != nil || local == nil || remote == nil {
+						t.Errorf("DialDone(%s, %v, %v, %v), want addresses", network, local, remote, err)
+					}
+					steps = append(steps, "connected")
+				},
+				WroteQuery:   func(data []byte, err error) { wrote = data; steps = append(steps, "wrote") },
+				ReadResponse: func(data []byte, err error) { read = data; steps = append(steps, "read") },
+			}
+
+			// The hooks of an outer trace run too
+			var outer int
+			ctx := WithTrace(context.Background(), &Trace{ReadResponse: func([]byte, error) { outer++ }})
+			ctx = WithTrace(ctx, trace)
+
+			query := testQuery(0x4242)
+			if _, err := test.transport(t).Exchange(ctx, query); err != nil {
+				t.Fatalf("Exchange() returned error: %v", err)
+			}
+
+			want := []string{"dial " + test.name, "connected", "wrote", "read"}
+			if len(steps) != len(want) {
+				t.Fatalf("trace steps = %v, want %v", steps, want)
+			}
+			for i := range want {
+				if steps[i] != want[i] {
+					t.Errorf("trace steps = %v, want %v", steps, want)
+					break
+				}
+			}
+			if queryBytes, _ := query.ToBytes(); string(wrote) != string(queryBytes) {
+				t.Errorf("WroteQuery data = % x, want the query % x", wrote, queryBytes)
+			}
+			if response, err := ParseMessage(read); err != nil || response.Header.ID != 0x4242 {
+				t.Errorf("ReadResponse data = % x, want the response", read)
+			}
+			if outer != 1 {
+				t.Errorf("outer ReadResponse called %d times, want 1", outer)
+			}
+		})
+	}
+}
+
+func TestTransportTraceDialError(t *testing.T) {
+	var dialErr error
+	ctx := WithTrace(context.Background(), &Trace{
+		DialDone: func(network string, local, remote net.Addr, err error) { dialErr = err },
+	})
+
+	transport := NewUDPTransport("ns.example:53", time.Second)
+	transport.Bootstrap = bootstrapFunc(func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		return nil, errors.New("no such host")
+	})
+	if _, err := transport.Exchange(ctx, testQuery(1)); err == nil {
+		t.Fatal("Exchange() returned no error")
+	}
+	if dialErr == nil {
+		t.Error("DialDone not called with the dial error")
+	}
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// Transport exchanges a single DNS message with a name server.
+// Implementations are responsible for framing, serializing the query and
+// parsing the response; ParseMessage is available for the latter.
+type Transport interface {
+	Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error)
+}
+
+// UDPTransport sends queries over UDP, one datagram per message.
+// Datagrams that do not carry the ID of the query, such as late answers
+// to earlier queries or spoofed packets, are dropped. Truncated responses
+// are retried over TCP (RFC 7766 Section 5).
+type UDPTransport struct {
+	Server           string                   // DNS server address (host:port)
+	Timeout          time.Duration            // Per-exchange timeout
+	MaxSize          int                      // Size of the receive buffer without EDNS
+	Bootstrap        config.BootstrapResolver // Resolves a server hostname; nil uses the system resolver
+	IgnoreTruncation bool                     // Return truncated responses instead of retrying over TCP
+}
+
+// NewUDPTransport creates a UDP transport for the given server
+func NewUDPTransport(server string, timeout time.Duration) *UDPTransport {
+	return &UDPTransport{
+		Server:  server,
+		Timeout: timeout,
+		MaxSize: 512, // RFC 1035 limit for UDP
+	}
+}
+
+// Exchange sends the query and waits for the matching response, retrying
+// over TCP if it is truncated
+func (t *UDPTransport) Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	response, err := t.exchange(ctx, query)
+	if err != nil || !response.Header.TC() || t.IgnoreTruncation {
+		return response, err
+	}
+
+	tcp := NewTCPTransport(t.Server, t.Timeout)
+	tcp.Bootstrap = t.Bootstrap
+	return tcp.Exchange(ctx, query)
+}
+
+// exchange sends the query over UDP and reads datagrams until one carries
+// the ID of the query or the deadline passes
+func (t *UDPTransport) exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	queryBytes, err := query.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize query: %w", err)
+	}
+
+	conn, done, err := dial(ctx, "udp", t.Server, t.Timeout, t.Bootstrap)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	trace := ContextTrace(ctx)
+	err = writeFull(conn, queryBytes)
+	trace.wroteQuery(queryBytes, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// Accept responses up to the size advertised through EDNS
+	size := t.MaxSize
+	if advertised := int(query.UDPSize()); advertised > size {
+		size = advertised
+	}
+
+	responseBytes := make([]byte, size)
+	for {
+		n, err := conn.Read(responseBytes)
+		if err != nil {
+			trace.readResponse(nil, err)
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if n < 2 || binary.BigEndian.Uint16(responseBytes) != query.Header.ID {
+			continue
+		}
+		trace.readResponse(responseBytes[:n], nil)
+		return parseResponse(responseBytes[:n], query.Header.ID)
+	}
+}
+
+// TCPTransport sends queries over TCP using the two-byte length prefix
+// described in RFC 1035 Section 4.2.2
+type TCPTransport struct {
+	Server    string                   // DNS server address (host:port)
+	Timeout   time.Duration            // Per-exchange timeout
+	Bootstrap config.BootstrapResolver // Resolves a server hostname; nil uses the system resolver
+}
+
+// NewTCPTransport creates a TCP transport for the given server
+func NewTCPTransport(server string, timeout time.Duration) *TCPTransport {
+	return &TCPTransport{
+		Server:  server,
+		Timeout: timeout,
+	}
+}
+
+// Exchange sends the query and waits for the matching response
+func (t *TCPTransport) Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	queryBytes, err := query.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize query: %w", err)
+	}
+	if len(queryBytes) > 65535 {
+		return nil, fmt.Errorf("query too large for TCP: %d bytes", len(queryBytes))
+	}
+
+	conn, done, err := dial(ctx, "tcp", t.Server, t.Timeout, t.Bootstrap)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	framed := make([]byte, 2, 2+len(queryBytes))
+	binary.BigEndian.PutUint16(framed, uint16(len(queryBytes)))
+	framed = append(framed, queryBytes...)
+
+	trace := ContextTrace(ctx)
+	err = writeFull(conn, framed)
+	trace.wroteQuery(queryBytes, err)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefix [2]byte
+	if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+		trace.readResponse(nil, err)
+		return nil, fmt.Errorf("failed to read TCP length prefix: %w", err)
+	}
+	responseBytes := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+	n, err := io.ReadFull(conn, responseBytes)
+	trace.readResponse(responseBytes[:n], err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return parseResponse(responseBytes, query.Header.ID)
+}
+
+// FailoverTransport sends each query to a list of transports, one per
+// name server, moving on to the next when a server fails or answers
+// SERVFAIL or REFUSED. The list is tried Retries+1 times in all.
+type FailoverTransport struct {
+	Transports []Transport // One transport per server, in order of preference
+	Retries    int         // Additional rounds over all transports
+	Rotate     bool        // Start each query at the next transport (round-robin)
+
+	next atomic.Uint32 // Starting transport of the next query with Rotate set
+}
+
+// NewFailoverTransport creates a failover transport over the given transports
+func NewFailoverTransport(retries int, rotate bool, transports ...Transport) *FailoverTransport {
+	return &FailoverTransport{
+		Transports: transports,
+		Retries:    retries,
+		Rotate:     rotate,
+	}
+}
+
+// Exchange sends the query until a server answers it. When every attempt
+// fails, the last server failure response is returned, or else the last
+// error.
+func (t *FailoverTransport) Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	if len(t.Transports) == 0 {
+		return nil, fmt.Errorf("no transports configured")
+	}
+
+	start := 0
+	if t.Rotate {
+		start = int((t.next.Add(1) - 1) % uint32(len(t.Transports)))
+	}
+
+	var failed *dns.Message
+	var lastErr error
+	for round := 0; round <= t.Retries; round++ {
+		for i := range t.Transports {
+			transport := t.Transports[(start+i)%len(t.Transports)]
+			response, err := transport.Exchange(ctx, query)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				lastErr = err
+				continue
+			}
+			if rcode := response.Rcode(); rcode != dns.RcodeServFail && rcode != dns.RcodeRefused {
+				return response, nil
+			}
+			failed = response
+		}
+	}
+
+	if failed != nil {
+		return failed, nil
+	}
+	return nil, lastErr
+}
+
+// newTransport builds the transport selected by the configuration. With
+// several name servers or retries, the servers are wrapped in a
+// FailoverTransport.
+func newTransport(cfg *config.Config) (Transport, error) {
+	servers := cfg.NameServers()
+	transports := make([]Transport, len(servers))
+	for i, server := range servers {
+		switch cfg.Protocol {
+		case "udp":
+			t := NewUDPTransport(server, cfg.Timeout)
+			t.MaxSize = cfg.GetMaxMessageSize()
+			t.Bootstrap = cfg.Bootstrap
+			transports[i] = t
+		case "tcp":
+			t := NewTCPTransport(server, cfg.Timeout)
+			t.Bootstrap = cfg.Bootstrap
+			transports[i] = t
+		default:
+			return nil, fmt.Errorf("unsupported protocol '%s'", cfg.Protocol)
+		}
+	}
+
+	if len(transports) == 1 && cfg.RetryCount == 0 {
+		return transports[0], nil
+	}
+	return NewFailoverTransport(cfg.RetryCount, cfg.Rotate, transports...), nil
+}
+
+// dial connects to the server and applies the exchange deadline, which is
+// the earlier of the context deadline and now+timeout. A server hostname
+// is resolved through bootstrap within the same deadline, and its
+// addresses are tried in order. Cancelling the context interrupts pending
+// reads and writes. The returned function closes the connection. Dialing
+// is reported to the Trace of the context.
+func dial(ctx context.Context, network, server string, timeout time.Duration, bootstrap config.BootstrapResolver) (net.Conn, func(), error) {
+	trace := ContextTrace(ctx)
+	trace.dialStart(network, server)
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	lookupCtx, cancel := context.WithDeadline(ctx, deadline)
+	addrs, err := config.ResolveServer(lookupCtx, bootstrap, server)
+	cancel()
+	if err != nil {
+		trace.dialDone(network, nil, err)
+		return nil, nil, fmt.Errorf("failed to connect to DNS server: %w", err)
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	for _, addr := range addrs {
+		conn, err = dialer.DialContext(ctx, network, addr)
+		if err == nil {
+			break
+		}
+	}
+	trace.dialDone(network, conn, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to DNS server: %w", err)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	return conn, func() {
+		stop()
+		conn.Close()
+	}, nil
+}
+
+// writeFull writes the whole buffer to the connection
+func writeFull(conn net.Conn, data []byte) error {
+	n, err := conn.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write query: %w", err)
+	}
+	if n != len(data) {
+		return fmt.Errorf("incomplete write: wrote %d bytes, expected %d", n, len(data))
+	}
+	return nil
+}
+
+// parseResponse parses a response and checks that it answers the query
+// with the given ID
+func parseResponse(data []byte, expectedID uint16) (*dns.Message, error) {
+	response, err := ParseMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Verify query ID matches
+	if response.Header.ID != expectedID {
+		return nil, fmt.Errorf("response ID %d does not match query ID %d", response.Header.ID, expectedID)
+	}
+
+	return response, nil
+}
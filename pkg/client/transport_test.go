@@ -0,0 +1,481 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/internal/config"
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// fakeTransport answers queries in memory using the handler function
+type fakeTransport struct {
+	handler func(query *dns.Message) (*dns.Message, error)
+	queries []*dns.Message
+	mu      sync.Mutex
+}
+
+func (f *fakeTransport) Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.mu.Unlock()
+	return f.handler(query)
+}
+
+// answerA returns a handler answering every question with the given IPv4 address
+func answerA(addr string) func(*dns.Message) (*dns.Message, error) {
+	return func(query *dns.Message) (*dns.Message, error) {
+		record, err := records.NewARecordFromString(addr)
+		if err != nil {
+			return nil, err
+		}
+		return &dns.Message{
+			Header: dns.Header{
+				ID:      query.Header.ID,
+				Flags:   dns.HeaderQRResponse | dns.HeaderRD | dns.HeaderRA,
+				QDCount: 1,
+				ANCount: 1,
+			},
+			Question: query.Question,
+			Answer: []dns.ResourceRecord{
+				{
+					Name:     query.Question[0].Name,
+					Type:     dns.TypeA,
+					Class:    dns.ClassIN,
+					TTL:      300,
+					RDLength: 4,
+					RData:    record,
+				},
+			},
+		}, nil
+	}
+}
+
+func TestNewWithTransport(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fake := &fakeTransport{handler: answerA("192.0.2.1")}
+
+	client, err := NewWithTransport(config.DefaultConfig(), logger, fake)
+	if err != nil {
+		t.Fatalf("NewWithTransport() returned error: %v", err)
+	}
+
+	response, err := client.Query("example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if len(fake.queries) != 1 {
+		t.Fatalf("transport received %d queries, want 1", len(fake.queries))
+	}
+	if len(response.Answer) != 1 || response.Answer[0].RData.String() != "ADDRESS: 192.0.2.1" {
+		t.Errorf("Query() answer = %v, want ADDRESS: 192.0.2.1", response.Answer)
+	}
+}
+
+func TestNewWithTransportNil(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if _, err := NewWithTransport(config.DefaultConfig(), logger, nil); err == nil {
+		t.Error("NewWithTransport() should return error for nil transport")
+	}
+}
+
+// serveUDP answers a single UDP query with the handler's response
+func serveUDP(t *testing.T, handler func(*dns.Message) (*dns.Message, error)) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query, err := ParseMessage(buf[:n])
+		if err != nil {
+			return
+		}
+		response, err := handler(query)
+		if err != nil {
+			return
+		}
+		data, err := response.ToBytes()
+		if err != nil {
+			return
+		}
+		conn.WriteTo(data, addr)
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// serveTCP answers a single length-prefixed TCP query with the handler's response
+func serveTCP(t *testing.T, handler func(*dns.Message) (*dns.Message, error)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on TCP: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var prefix [2]byte
+		if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+			return
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		query, err := ParseMessage(buf)
+		if err != nil {
+			return
+		}
+		response, err := handler(query)
+		if err != nil {
+			return
+		}
+		data, err := response.ToBytes()
+		if err != nil {
+			return
+		}
+		binary.BigEndian.PutUint16(prefix[:], uint16(len(data)))
+		conn.Write(append(prefix[:], data...))
+	}()
+
+	return listener.Addr().String()
+}
+
+func testQuery(id uint16) *dns.Message {
+	return &dns.Message{
+		Header: dns.Header{ID: id, Flags: dns.HeaderRD, QDCount: 1},
+		Question: []dns.Question{
+			{Name: dns.MustParseName("example.com"), Type: dns.TypeA, Class: dns.ClassIN},
+		},
+	}
+}
+
+func TestTransports(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport func(t *testing.T) Transport
+	}{
+		{"udp", func(t *testing.T) Transport {
+			return NewUDPTransport(serveUDP(t, answerA("192.0.2.53")), 2*time.Second)
+		}},
+		{"tcp", func(t *testing.T) Transport {
+			return NewTCPTransport(serveTCP(t, answerA("192.0.2.53")), 2*time.Second)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := test.transport(t)
+
+			response, err := transport.Exchange(context.Background(), testQuery(0x4242))
+			if err != nil {
+				t.Fatalf("Exchange() returned error: %v", err)
+			}
+			if response.Header.ID != 0x4242 {
+				t.Errorf("response ID = %04X, want 4242", response.Header.ID)
+			}
+			if len(response.Answer) != 1 || response.Answer[0].RData.String() != "ADDRESS: 192.0.2.53" {
+				t.Errorf("response answer = %v, want ADDRESS: 192.0.2.53", response.Answer)
+			}
+		})
+	}
+}
+
+func TestTransportIDMismatch(t *testing.T) {
+	handler := func(query *dns.Message) (*dns.Message, error) {
+		response, err := answerA("192.0.2.53")(query)
+		if err != nil {
+			return nil, err
+		}
+		response.Header.ID++
+		return response, nil
+	}
+	transport := NewUDPTransport(serveUDP(t, handler), 100*time.Millisecond)
+
+	if _, err := transport.Exchange(context.Background(), testQuery(1)); err == nil {
+		t.Error("Exchange() should return error for mismatched response ID")
+	}
+}
+
+func TestUDPTransportDropsMismatchedID(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	// A late answer to an earlier query arrives before the response
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query, err := ParseMessage(buf[:n])
+		if err != nil {
+			return
+		}
+		for _, id := range []uint16{query.Header.ID - 1, query.Header.ID} {
+			response, err := answerA("192.0.2.53")(query)
+			if err != nil {
+				return
+			}
+			response.Header.ID = id
+			data, err := response.ToBytes()
+			if err != nil {
+				return
+			}
+			conn.WriteTo(data, addr)
+		}
+	}()
+
+	transport := NewUDPTransport(conn.LocalAddr().String(), 2*time.Second)
+	response, err := transport.Exchange(context.Background(), testQuery(0x4242))
+	if err != nil {
+		t.Fatalf("Exchange() returned error: %v", err)
+	}
+	if response.Header.ID != 0x4242 {
+		t.Errorf("response ID = %04X, want 4242", response.Header.ID)
+	}
+}
+
+// serveTruncated answers a UDP query with a truncated, empty response and
+// a TCP query on the same port with the handler's response
+func serveTruncated(t *testing.T, handler func(*dns.Message) (*dns.Message, error)) string {
+	t.Helper()
+
+	tcpAddr := serveTCP(t, handler)
+	conn, err := net.ListenPacket("udp", tcpAddr)
+	if err != nil {
+		t.Skipf("cannot listen on UDP port of %s: %v", tcpAddr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query, err := ParseMessage(buf[:n])
+		if err != nil {
+			return
+		}
+		response, err := dns.NewResponse(query).Build()
+		if err != nil {
+			return
+		}
+		response.Header.SetTC(true)
+		data, err := response.ToBytes()
+		if err != nil {
+			return
+		}
+		conn.WriteTo(data, addr)
+	}()
+
+	return tcpAddr
+}
+
+func TestUDPTransportTruncated(t *testing.T) {
+	transport := NewUDPTransport(serveTruncated(t, answerA("192.0.2.53")), 2*time.Second)
+	response, err := transport.Exchange(context.Background(), testQuery(0x4242))
+	if err != nil {
+		t.Fatalf("Exchange() returned error: %v", err)
+	}
+	if response.Header.TC() || len(response.Answer) != 1 {
+		t.Errorf("response TC = %v with %d answers, want the full TCP response", response.Header.TC(), len(response.Answer))
+	}
+
+	transport = NewUDPTransport(serveTruncated(t, answerA("192.0.2.53")), 2*time.Second)
+	transport.IgnoreTruncation = true
+	response, err = transport.Exchange(context.Background(), testQuery(0x4242))
+	if err != nil {
+		t.Fatalf("Exchange() returned error: %v", err)
+	}
+	if !response.Header.TC() {
+		t.Error("response TC = false, want the truncated UDP response with IgnoreTruncation")
+	}
+}
+
+func TestTransportContextCancel(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	transport := NewUDPTransport(conn.LocalAddr().String(), 5*time.Second)
+	start := time.Now()
+	if _, err := transport.Exchange(ctx, testQuery(1)); err == nil {
+		t.Fatal("Exchange() should return error when the server never answers")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Exchange() took %v, should stop at the context deadline", elapsed)
+	}
+}
+
+// failingTransport fails every exchange and counts the attempts
+type failingTransport struct {
+	attempts int
+}
+
+func (f *failingTransport) Exchange(ctx context.Context, query *dns.Message) (*dns.Message, error) {
+	f.attempts++
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestFailoverTransport(t *testing.T) {
+	servfail := &fakeTransport{handler: func(query *dns.Message) (*dns.Message, error) {
+		return dns.NewResponse(query).WithRcode(dns.RcodeServFail).Build()
+	}}
+	down := &failingTransport{}
+	working := &fakeTransport{handler: answerA("192.0.2.53")}
+
+	transport := NewFailoverTransport(1, false, down, servfail, working)
+	response, err := transport.Exchange(context.Background(), testQuery(1))
+	if err != nil {
+		t.Fatalf("Exchange() returned error: %v", err)
+	}
+	if len(response.Answer) != 1 {
+		t.Errorf("Exchange() answer = %v, want the working server's", response.Answer)
+	}
+	if down.attempts != 1 || len(servfail.queries) != 1 || len(working.queries) != 1 {
+		t.Errorf("attempts = %d, %d, %d, want 1 each", down.attempts, len(servfail.queries), len(working.queries))
+	}
+
+	// Without a working server, the server failure is returned after all retries
+	transport = NewFailoverTransport(2, false, down, servfail)
+	response, err = transport.Exchange(context.Background(), testQuery(2))
+	if err != nil || response.Rcode() != dns.RcodeServFail {
+		t.Errorf("Exchange() = %v, %v, want SERVFAIL response", response, err)
+	}
+	if down.attempts != 4 {
+		t.Errorf("down server tried %d times, want 4", down.attempts)
+	}
+
+	transport = NewFailoverTransport(0, false, down)
+	if _, err := transport.Exchange(context.Background(), testQuery(3)); err == nil {
+		t.Error("Exchange() should return the error when every server fails")
+	}
+}
+
+func TestFailoverTransportRotate(t *testing.T) {
+	first := &fakeTransport{handler: answerA("192.0.2.1")}
+	second := &fakeTransport{handler: answerA("192.0.2.2")}
+	transport := NewFailoverTransport(0, true, first, second)
+
+	for i := 0; i < 4; i++ {
+		if _, err := transport.Exchange(context.Background(), testQuery(uint16(i))); err != nil {
+			t.Fatalf("Exchange() returned error: %v", err)
+		}
+	}
+	if len(first.queries) != 2 || len(second.queries) != 2 {
+		t.Errorf("queries per server = %d, %d, want 2, 2", len(first.queries), len(second.queries))
+	}
+}
+
+func TestNewTransportFailover(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RetryCount = 0
+	if transport, err := newTransport(cfg); err != nil {
+		t.Fatalf("newTransport() returned error: %v", err)
+	} else if _, ok := transport.(*UDPTransport); !ok {
+		t.Errorf("newTransport() = %T, want *UDPTransport for one server without retries", transport)
+	}
+
+	cfg.Protocol = "tcp"
+	cfg.FallbackServers = []string{"192.0.2.2:53"}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		t.Fatalf("newTransport() returned error: %v", err)
+	}
+	failover, ok := transport.(*FailoverTransport)
+	if !ok || len(failover.Transports) != 2 {
+		t.Fatalf("newTransport() = %#v, want a failover over 2 servers", transport)
+	}
+	if tcp, ok := failover.Transports[1].(*TCPTransport); !ok || tcp.Server != "192.0.2.2:53" {
+		t.Errorf("second transport = %#v, want TCP to 192.0.2.2:53", failover.Transports[1])
+	}
+}
+
+// bootstrapFunc adapts a function to config.BootstrapResolver
+type bootstrapFunc func(ctx context.Context, network, host string) ([]netip.Addr, error)
+
+func (f bootstrapFunc) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return f(ctx, network, host)
+}
+
+func TestClientBootstrap(t *testing.T) {
+	server := serveUDP(t, answerA("192.0.2.53"))
+	_, port, err := net.SplitHostPort(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lookups []string
+	cfg := config.DefaultConfig()
+	cfg.NameServer = net.JoinHostPort("ns.example", port)
+	cfg.RetryCount = 0
+	cfg.Bootstrap = bootstrapFunc(func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		lookups = append(lookups, host)
+		return []netip.Addr{netip.MustParseAddr("127.0.0.1")}, nil
+	})
+
+	// Creating the client does no lookup
+	client, err := New(cfg, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if len(lookups) != 0 {
+		t.Fatalf("New() resolved %v, want no lookups", lookups)
+	}
+
+	response, err := client.Query("example.com", dns.TypeA)
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if len(response.Answer) != 1 || strings.Join(lookups, " ") != "ns.example" {
+		t.Errorf("Query() answer = %v, lookups = %v, want one answer after resolving ns.example", response.Answer, lookups)
+	}
+
+	failing := NewUDPTransport(cfg.NameServer, time.Second)
+	failing.Bootstrap = bootstrapFunc(func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		return nil, errors.New("offline")
+	})
+	if _, err := failing.Exchange(context.Background(), testQuery(1)); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Errorf("Exchange() error = %v, want the bootstrap failure", err)
+	}
+}
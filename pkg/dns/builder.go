@@ -0,0 +1,213 @@
+package dns
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// MessageBuilder constructs a Message step by step. Header counts are kept
+// in sync with the sections, so the result always serializes consistently.
+// The first error encountered is remembered and returned by Build.
+type MessageBuilder struct {
+	msg Message
+	err error
+}
+
+// NewMessage returns a builder for an empty message with a random ID
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{
+		msg: Message{
+			Header: Header{ID: uint16(rand.Intn(65536))},
+		},
+	}
+}
+
+// NewQuery returns a builder for a standard query with a single IN question
+func NewQuery(name string, qtype QType) *MessageBuilder {
+	return NewMessage().Question(name, qtype, ClassIN)
+}
+
+// NewResponse returns a builder for a response to the given query. The ID,
+// opcode, RD and CD bits and the question section are copied from the query.
+func NewResponse(query *Message) *MessageBuilder {
+	b := &MessageBuilder{}
+	if query == nil {
+		b.err = fmt.Errorf("cannot build response to nil query")
+		return b
+	}
+
+	const copied = HeaderOpcodeMask | HeaderRD | HeaderCD
+	b.msg.Header.ID = query.Header.ID
+	b.msg.Header.Flags = HeaderQRResponse | query.Header.Flags&copied
+	b.msg.Question = append([]Question(nil), query.Question...)
+	return b
+}
+
+// Question appends a question to the message. An invalid name is an
+// error returned by Build.
+func (b *MessageBuilder) Question(name string, qtype QType, qclass QClass) *MessageBuilder {
+	labels, err := StringToLabels(name)
+	if err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("invalid question name: %w", err)
+		}
+		return b
+	}
+	b.msg.Question = append(b.msg.Question, Question{
+		Name:  labels,
+		Type:  qtype,
+		Class: qclass,
+	})
+	return b
+}
+
+// WithID sets the message ID
+func (b *MessageBuilder) WithID(id uint16) *MessageBuilder {
+	b.msg.Header.ID = id
+	return b
+}
+
+// WithFlags sets the given header bits in addition to the current ones
+func (b *MessageBuilder) WithFlags(flags HeaderBitfield) *MessageBuilder {
+	b.msg.Header.Flags |= flags
+	return b
+}
+
+// WithRD sets the Recursion Desired bit
+func (b *MessageBuilder) WithRD() *MessageBuilder {
+	return b.WithFlags(HeaderRD)
+}
+
+// WithAA sets the Authoritative Answer bit
+func (b *MessageBuilder) WithAA() *MessageBuilder {
+	return b.WithFlags(HeaderAA)
+}
+
+// WithRA sets the Recursion Available bit
+func (b *MessageBuilder) WithRA() *MessageBuilder {
+	return b.WithFlags(HeaderRA)
+}
+
+// WithAD sets the Authentic Data bit
+func (b *MessageBuilder) WithAD() *MessageBuilder {
+	return b.WithFlags(HeaderAD)
+}
+
+// WithCD sets the Checking Disabled bit
+func (b *MessageBuilder) WithCD() *MessageBuilder {
+	return b.WithFlags(HeaderCD)
+}
+
+// WithOpcode sets the operation code
+func (b *MessageBuilder) WithOpcode(op Opcode) *MessageBuilder {
+	b.msg.Header.SetOpcode(op)
+	return b
+}
+
+// WithRcode sets the response code. Extended codes above 15 are stored
+// in the OPT record, which WithEDNS must have added beforehand.
+func (b *MessageBuilder) WithRcode(rc Rcode) *MessageBuilder {
+	if err := b.msg.SetRcode(rc); err != nil && b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// WithClass sets the class of every question added so far
+func (b *MessageBuilder) WithClass(qclass QClass) *MessageBuilder {
+	for i := range b.msg.Question {
+		b.msg.Question[i].Class = qclass
+	}
+	return b
+}
+
+// WithEDNS adds an OPT pseudo-record advertising the given UDP payload
+// size, or updates the size of an existing one
+func (b *MessageBuilder) WithEDNS(udpSize uint16) *MessageBuilder {
+	b.opt().Class = QClass(udpSize)
+	return b
+}
+
+// WithDO sets the DNSSEC OK bit, adding an OPT record if necessary
+func (b *MessageBuilder) WithDO() *MessageBuilder {
+	opt := b.opt()
+	opt.TTL = int32(uint32(opt.TTL) | ednsDO)
+	return b
+}
+
+// WithOption adds an EDNS option, adding an OPT record if necessary
+func (b *MessageBuilder) WithOption(code uint16, data []byte) *MessageBuilder {
+	opt, ok := b.opt().RData.(*OPT)
+	if !ok {
+		if b.err == nil {
+			b.err = fmt.Errorf("cannot add EDNS option %d: OPT record has no option data", code)
+		}
+		return b
+	}
+	opt.Options = append(opt.Options, EDNSOption{Code: code, Data: append([]byte(nil), data...)})
+	return b
+}
+
+// opt returns the OPT record of the message, creating it if needed
+func (b *MessageBuilder) opt() *ResourceRecord {
+	if opt := b.msg.OPTRecord(); opt != nil {
+		return opt
+	}
+	b.msg.Additional = append(b.msg.Additional, ResourceRecord{
+		Name:  []Label{{Length: 0, Data: nil}},
+		Type:  TypeOPT,
+		Class: QClass(DefaultEDNSSize),
+		RData: &OPT{},
+	})
+	return &b.msg.Additional[len(b.msg.Additional)-1]
+}
+
+// Answer appends records to the answer section
+func (b *MessageBuilder) Answer(rrs ...ResourceRecord) *MessageBuilder {
+	b.msg.Answer = b.appendRecords(b.msg.Answer, rrs)
+	return b
+}
+
+// Authority appends records to the authority section
+func (b *MessageBuilder) Authority(rrs ...ResourceRecord) *MessageBuilder {
+	b.msg.Authority = b.appendRecords(b.msg.Authority, rrs)
+	return b
+}
+
+// Additional appends records to the additional section
+func (b *MessageBuilder) Additional(rrs ...ResourceRecord) *MessageBuilder {
+	b.msg.Additional = b.appendRecords(b.msg.Additional, rrs)
+	return b
+}
+
+// appendRecords appends records to a section, filling in RDLength
+func (b *MessageBuilder) appendRecords(section, rrs []ResourceRecord) []ResourceRecord {
+	for _, rr := range rrs {
+		if rr.RData == nil {
+			if b.err == nil {
+				b.err = fmt.Errorf("resource record %s %s has no data", LabelsToString(rr.Name), rr.Type)
+			}
+			continue
+		}
+		rr.RDLength = uint16(len(rr.RData.Bytes()))
+		section = append(section, rr)
+	}
+	return section
+}
+
+// Build returns the finished message with its header counts set
+func (b *MessageBuilder) Build() (*Message, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	msg := b.msg
+	msg.Question = append([]Question(nil), b.msg.Question...)
+	msg.Answer = append([]ResourceRecord(nil), b.msg.Answer...)
+	msg.Authority = append([]ResourceRecord(nil), b.msg.Authority...)
+	msg.Additional = append([]ResourceRecord(nil), b.msg.Additional...)
+	if err := msg.UpdateCounts(); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
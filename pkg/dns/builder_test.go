@@ -0,0 +1,120 @@
+package dns
+
+import (
+	"testing"
+)
+
+func TestNewQueryBuilder(t *testing.T) {
+	msg, err := NewQuery("example.com", TypeAAAA).WithID(0x1234).WithRD().WithEDNS(4096).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if msg.Header.ID != 0x1234 {
+		t.Errorf("Header.ID = %04X, want 1234", msg.Header.ID)
+	}
+	if msg.Header.Flags != HeaderRD {
+		t.Errorf("Header.Flags = %04X, want %04X", msg.Header.Flags, HeaderRD)
+	}
+	if msg.Header.QDCount != 1 || msg.Header.ANCount != 0 || msg.Header.NSCount != 0 || msg.Header.ARCount != 1 {
+		t.Errorf("Header counts = %d/%d/%d/%d, want 1/0/0/1",
+			msg.Header.QDCount, msg.Header.ANCount, msg.Header.NSCount, msg.Header.ARCount)
+	}
+	if q := msg.Question[0]; LabelsToString(q.Name) != "example.com" || q.Type != TypeAAAA || q.Class != ClassIN {
+		t.Errorf("Question = %v, want example.com AAAA IN", q.String())
+	}
+	if msg.UDPSize() != 4096 {
+		t.Errorf("UDPSize() = %d, want 4096", msg.UDPSize())
+	}
+
+	if _, err := msg.ToBytes(); err != nil {
+		t.Errorf("ToBytes() returned error: %v", err)
+	}
+}
+
+func TestBuilderEDNSOptions(t *testing.T) {
+	msg, err := NewQuery("example.com", TypeA).WithDO().WithOption(10, []byte{1, 2}).WithEDNS(1400).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if len(msg.Additional) != 1 {
+		t.Fatalf("len(Additional) = %d, want a single OPT record", len(msg.Additional))
+	}
+	if !msg.DNSSECOK() {
+		t.Error("DNSSECOK() = false, want true")
+	}
+	if msg.UDPSize() != 1400 {
+		t.Errorf("UDPSize() = %d, want 1400", msg.UDPSize())
+	}
+	opt := msg.OPTRecord().RData.(*OPT)
+	if len(opt.Options) != 1 || opt.Options[0].Code != 10 {
+		t.Errorf("OPT options = %v, want one option with code 10", opt.Options)
+	}
+}
+
+func TestBuilderOptionWithoutOPTData(t *testing.T) {
+	for _, rdata := range []ResourceData{nil, rawData{0, 10, 0, 0}} {
+		opt := ResourceRecord{Name: Name{}, Type: TypeOPT, Class: QClass(DefaultEDNSSize), RData: rdata}
+		_, err := NewQuery("example.com", TypeA).Additional(opt).WithOption(10, []byte{1, 2}).Build()
+		if err == nil {
+			t.Errorf("Build() with OPT data %#v returned no error", rdata)
+		}
+	}
+}
+
+func TestNewResponseBuilder(t *testing.T) {
+	query, err := NewQuery("example.com", TypeA).WithID(0xBEEF).WithRD().Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	rr := ResourceRecord{
+		Name:     MustParseName("example.com"),
+		Type:     TypeNULL,
+		Class:    ClassIN,
+		TTL:      300,
+		RDLength: 42, // recomputed by the builder
+		RData:    rawData{1, 2, 3, 4},
+	}
+	response, err := NewResponse(query).WithAA().Answer(rr).Authority(rr, rr).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if response.Header.ID != 0xBEEF {
+		t.Errorf("Header.ID = %04X, want BEEF", response.Header.ID)
+	}
+	if want := HeaderQRResponse | HeaderRD | HeaderAA; response.Header.Flags != want {
+		t.Errorf("Header.Flags = %04X, want %04X", response.Header.Flags, want)
+	}
+	if response.Header.QDCount != 1 || response.Header.ANCount != 1 || response.Header.NSCount != 2 {
+		t.Errorf("Header counts = %d/%d/%d, want 1/1/2",
+			response.Header.QDCount, response.Header.ANCount, response.Header.NSCount)
+	}
+	if response.Answer[0].RDLength != 4 {
+		t.Errorf("Answer RDLength = %d, want 4", response.Answer[0].RDLength)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	if _, err := NewResponse(nil).Build(); err == nil {
+		t.Error("NewResponse(nil).Build() should return error")
+	}
+
+	rr := ResourceRecord{Name: MustParseName("example.com"), Type: TypeA, Class: ClassIN}
+	if _, err := NewQuery("example.com", TypeA).Answer(rr).Build(); err == nil {
+		t.Error("Build() should return error for a record without data")
+	}
+}
+
+func TestBuilderWithClass(t *testing.T) {
+	msg, err := NewQuery("version.bind", TypeTXT).WithClass(ClassCH).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if msg.Question[0].Class != ClassCH {
+		t.Errorf("Question class = %v, want CH", msg.Question[0].Class)
+	}
+}
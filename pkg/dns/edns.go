@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// DefaultEDNSSize is the UDP payload size advertised when none is given,
+// as recommended by the DNS Flag Day 2020 guidance
+const DefaultEDNSSize = 1232
+
+// ednsDO is the DNSSEC OK bit in the TTL field of an OPT record (RFC 3225)
+const ednsDO = 1 << 15
+
+// EDNSOption is a single option carried in an OPT record (RFC 6891 Section 6.1.2)
+type EDNSOption struct {
+	Code uint16
+	Data []byte
+}
+
+// OPT is the resource data of an OPT pseudo-record (RFC 6891 Section 6.1.2).
+// The UDP payload size, extended RCODE, version and flags live in the
+// class and TTL fields of the enclosing ResourceRecord.
+type OPT struct {
+	Options []EDNSOption
+}
+
+// ParseOPT decodes OPT resource data from wire format
+func ParseOPT(data []byte) (*OPT, error) {
+	opt := &OPT{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("EDNS option header truncated")
+		}
+		code := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			return nil, fmt.Errorf("EDNS option %d data truncated", code)
+		}
+		option := EDNSOption{Code: code, Data: make([]byte, length)}
+		copy(option.Data, data[4:4+length])
+		opt.Options = append(opt.Options, option)
+		data = data[4+length:]
+	}
+	return opt, nil
+}
+
+// Bytes returns the wire format representation of the OPT data
+func (o *OPT) Bytes() []byte {
+	var result []byte
+	for _, option := range o.Options {
+		result = binary.BigEndian.AppendUint16(result, option.Code)
+		result = binary.BigEndian.AppendUint16(result, uint16(len(option.Data)))
+		result = append(result, option.Data...)
+	}
+	return result
+}
+
+// String returns the string representation of the OPT data
+func (o *OPT) String() string {
+	if len(o.Options) == 0 {
+		return "OPTIONS: none"
+	}
+	parts := make([]string, len(o.Options))
+	for i, option := range o.Options {
+		parts[i] = fmt.Sprintf("%d:% 02X", option.Code, option.Data)
+	}
+	return "OPTIONS: " + strings.Join(parts, ", ")
+}
+
+// Type returns the DNS record type
+func (o *OPT) Type() QType {
+	return TypeOPT
+}
+
+// OPTRecord returns the OPT pseudo-record from the additional section,
+// or nil if the message does not use EDNS
+func (m *Message) OPTRecord() *ResourceRecord {
+	for i := range m.Additional {
+		if m.Additional[i].Type == TypeOPT {
+			return &m.Additional[i]
+		}
+	}
+	return nil
+}
+
+// UDPSize returns the UDP payload size advertised in the OPT record,
+// or 512 if the message does not use EDNS
+func (m *Message) UDPSize() uint16 {
+	opt := m.OPTRecord()
+	if opt == nil || opt.Class < 512 {
+		return 512
+	}
+	return uint16(opt.Class)
+}
+
+// DNSSECOK reports whether the DO bit is set in the OPT record
+func (m *Message) DNSSECOK() bool {
+	opt := m.OPTRecord()
+	return opt != nil && uint32(opt.TTL)&ednsDO != 0
+}
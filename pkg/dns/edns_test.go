@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOPTRoundTrip(t *testing.T) {
+	opt := &OPT{Options: []EDNSOption{
+		{Code: 10, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		{Code: 12, Data: []byte{}},
+	}}
+
+	parsed, err := ParseOPT(opt.Bytes())
+	if err != nil {
+		t.Fatalf("ParseOPT() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, opt) {
+		t.Errorf("ParseOPT() = %v, want %v", parsed, opt)
+	}
+}
+
+func TestParseOPTTruncated(t *testing.T) {
+	tests := [][]byte{
+		{0x00, 0x0A, 0x00},
+		{0x00, 0x0A, 0x00, 0x04, 0x01},
+	}
+
+	for _, data := range tests {
+		if _, err := ParseOPT(data); err == nil {
+			t.Errorf("ParseOPT(% 02X) should return error", data)
+		}
+	}
+}
+
+func TestOPTString(t *testing.T) {
+	tests := []struct {
+		opt      *OPT
+		expected string
+	}{
+		{&OPT{}, "OPTIONS: none"},
+		{&OPT{Options: []EDNSOption{{Code: 10, Data: []byte{0xAB}}}}, "OPTIONS: 10:AB"},
+	}
+
+	for _, test := range tests {
+		if result := test.opt.String(); result != test.expected {
+			t.Errorf("OPT.String() = %q, want %q", result, test.expected)
+		}
+	}
+}
+
+func TestMessageWithoutEDNS(t *testing.T) {
+	msg := &Message{}
+
+	if msg.OPTRecord() != nil {
+		t.Error("OPTRecord() should be nil without an OPT record")
+	}
+	if msg.UDPSize() != 512 {
+		t.Errorf("UDPSize() = %d, want 512", msg.UDPSize())
+	}
+	if msg.DNSSECOK() {
+		t.Error("DNSSECOK() should be false without an OPT record")
+	}
+}
@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// headerFlagNames lists the single-bit flags in the order dig prints them
+var headerFlagNames = []struct {
+	flag HeaderBitfield
+	name string
+}{
+	{HeaderQRResponse, "qr"},
+	{HeaderAA, "aa"},
+	{HeaderTC, "tc"},
+	{HeaderRD, "rd"},
+	{HeaderRA, "ra"},
+	{HeaderAD, "ad"},
+	{HeaderCD, "cd"},
+}
+
+// IsResponse reports whether the QR bit is set
+func (h *Header) IsResponse() bool {
+	return h.Flags&HeaderQRResponse != 0
+}
+
+// Opcode returns the operation code of the message
+func (h *Header) Opcode() Opcode {
+	return Opcode((h.Flags & HeaderOpcodeMask) >> 11)
+}
+
+// Rcode returns the response code carried in the header. Extended RCODEs
+// also need the OPT record; see Message.Rcode.
+func (h *Header) Rcode() Rcode {
+	return Rcode(h.Flags & HeaderRcodeMask)
+}
+
+// AA reports whether the Authoritative Answer bit is set
+func (h *Header) AA() bool { return h.Flags&HeaderAA != 0 }
+
+// TC reports whether the Truncation bit is set
+func (h *Header) TC() bool { return h.Flags&HeaderTC != 0 }
+
+// RD reports whether the Recursion Desired bit is set
+func (h *Header) RD() bool { return h.Flags&HeaderRD != 0 }
+
+// RA reports whether the Recursion Available bit is set
+func (h *Header) RA() bool { return h.Flags&HeaderRA != 0 }
+
+// AD reports whether the Authentic Data bit is set
+func (h *Header) AD() bool { return h.Flags&HeaderAD != 0 }
+
+// CD reports whether the Checking Disabled bit is set
+func (h *Header) CD() bool { return h.Flags&HeaderCD != 0 }
+
+// SetResponse sets or clears the QR bit
+func (h *Header) SetResponse(v bool) { h.setFlag(HeaderQRResponse, v) }
+
+// SetAA sets or clears the Authoritative Answer bit
+func (h *Header) SetAA(v bool) { h.setFlag(HeaderAA, v) }
+
+// SetTC sets or clears the Truncation bit
+func (h *Header) SetTC(v bool) { h.setFlag(HeaderTC, v) }
+
+// SetRD sets or clears the Recursion Desired bit
+func (h *Header) SetRD(v bool) { h.setFlag(HeaderRD, v) }
+
+// SetRA sets or clears the Recursion Available bit
+func (h *Header) SetRA(v bool) { h.setFlag(HeaderRA, v) }
+
+// SetAD sets or clears the Authentic Data bit
+func (h *Header) SetAD(v bool) { h.setFlag(HeaderAD, v) }
+
+// SetCD sets or clears the Checking Disabled bit
+func (h *Header) SetCD(v bool) { h.setFlag(HeaderCD, v) }
+
+// SetOpcode sets the operation code
+func (h *Header) SetOpcode(op Opcode) {
+	h.Flags = h.Flags&^HeaderOpcodeMask | HeaderBitfield(op&0xF)<<11
+}
+
+// SetRcode sets the four header bits of the response code. The upper bits
+// of extended RCODEs are dropped; see Message.SetRcode.
+func (h *Header) SetRcode(rc Rcode) {
+	h.Flags = h.Flags&^HeaderRcodeMask | HeaderBitfield(rc)&HeaderRcodeMask
+}
+
+// setFlag sets or clears a single header bit
+func (h *Header) setFlag(flag HeaderBitfield, v bool) {
+	if v {
+		h.Flags |= flag
+	} else {
+		h.Flags &^= flag
+	}
+}
+
+// FlagsString returns the flags in the style of dig, e.g.
+// "qr rd ra; status: NXDOMAIN". Non-standard opcodes are included.
+func (h *Header) FlagsString() string {
+	return flagsString(h, h.Rcode())
+}
+
+// flagsString formats the header flags with the given response code
+func flagsString(h *Header, rc Rcode) string {
+	var flags []string
+	for _, f := range headerFlagNames {
+		if h.Flags&f.flag != 0 {
+			flags = append(flags, f.name)
+		}
+	}
+
+	var buf strings.Builder
+	buf.WriteString(strings.Join(flags, " "))
+	if len(flags) > 0 {
+		buf.WriteString("; ")
+	}
+	if op := h.Opcode(); op != OpcodeQuery {
+		buf.WriteString("opcode: " + op.String() + "; ")
+	}
+	buf.WriteString("status: " + rc.String())
+	return buf.String()
+}
+
+// Rcode returns the full response code, combining the header bits with the
+// extended RCODE bits of the OPT record when present
+func (m *Message) Rcode() Rcode {
+	rc := m.Header.Rcode()
+	if opt := m.OPTRecord(); opt != nil {
+		rc |= Rcode(uint32(opt.TTL)>>24) << 4
+	}
+	return rc
+}
+
+// SetRcode sets the full response code. Extended codes above 15 are split
+// between the header and the OPT record, which is required for them.
+func (m *Message) SetRcode(rc Rcode) error {
+	opt := m.OPTRecord()
+	if rc > 15 && opt == nil {
+		return fmt.Errorf("extended RCODE %s requires an OPT record", rc)
+	}
+	if rc > 0xFFF {
+		return fmt.Errorf("RCODE %d out of range", rc)
+	}
+	m.Header.SetRcode(rc)
+	if opt != nil {
+		opt.TTL = int32(uint32(opt.TTL)&0x00FFFFFF | uint32(rc>>4)<<24)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package dns
+
+import (
+	"testing"
+)
+
+func TestHeaderAccessors(t *testing.T) {
+	h := Header{Flags: HeaderQRResponse | HeaderOpcodeNotify | HeaderAA | HeaderRD | HeaderCD | HeaderRcodeName}
+
+	if !h.IsResponse() {
+		t.Error("IsResponse() = false, want true")
+	}
+	if h.Opcode() != OpcodeNotify {
+		t.Errorf("Opcode() = %v, want NOTIFY", h.Opcode())
+	}
+	if h.Rcode() != RcodeNXDomain {
+		t.Errorf("Rcode() = %v, want NXDOMAIN", h.Rcode())
+	}
+	if !h.AA() || h.TC() || !h.RD() || h.RA() || h.AD() || !h.CD() {
+		t.Errorf("flag accessors disagree with flags %04X", h.Flags)
+	}
+}
+
+func TestHeaderSetters(t *testing.T) {
+	var h Header
+
+	h.SetResponse(true)
+	h.SetAA(true)
+	h.SetRA(true)
+	h.SetAD(true)
+	h.SetOpcode(OpcodeUpdate)
+	h.SetRcode(RcodeRefused)
+
+	want := HeaderQRResponse | HeaderOpcodeUpdate | HeaderAA | HeaderRA | HeaderAD | HeaderRcodeRef
+	if h.Flags != want {
+		t.Errorf("Flags = %04X, want %04X", h.Flags, want)
+	}
+
+	h.SetAA(false)
+	h.SetOpcode(OpcodeQuery)
+	h.SetRcode(RcodeSuccess)
+	want = HeaderQRResponse | HeaderRA | HeaderAD
+	if h.Flags != want {
+		t.Errorf("Flags after clearing = %04X, want %04X", h.Flags, want)
+	}
+}
+
+func TestHeaderFlagsString(t *testing.T) {
+	tests := []struct {
+		flags    HeaderBitfield
+		expected string
+	}{
+		{HeaderQRResponse | HeaderRD | HeaderRA | HeaderRcodeName, "qr rd ra; status: NXDOMAIN"},
+		{HeaderRD, "rd; status: NOERROR"},
+		{0, "status: NOERROR"},
+		{HeaderQRResponse | HeaderAA | HeaderOpcodeNotify, "qr aa; opcode: NOTIFY; status: NOERROR"},
+		{HeaderQRResponse | HeaderAD | HeaderCD | HeaderRcodeSrvr, "qr ad cd; status: SERVFAIL"},
+	}
+
+	for _, test := range tests {
+		h := Header{Flags: test.flags}
+		if result := h.FlagsString(); result != test.expected {
+			t.Errorf("FlagsString() for %04X = %q, want %q", test.flags, result, test.expected)
+		}
+	}
+}
+
+func TestMessageExtendedRcode(t *testing.T) {
+	msg, err := NewQuery("example.com", TypeA).WithEDNS(1232).WithRcode(RcodeBadVers).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if msg.Header.Rcode() != RcodeSuccess {
+		t.Errorf("Header.Rcode() = %v, want NOERROR", msg.Header.Rcode())
+	}
+	if msg.Rcode() != RcodeBadVers {
+		t.Errorf("Message.Rcode() = %v, want BADVERS", msg.Rcode())
+	}
+
+	if _, err := NewQuery("example.com", TypeA).WithRcode(RcodeBadVers).Build(); err == nil {
+		t.Error("Build() should return error for an extended RCODE without EDNS")
+	}
+}
+
+func TestOpcodeString(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		expected string
+	}{
+		{OpcodeQuery, "QUERY"},
+		{OpcodeIQuery, "IQUERY"},
+		{OpcodeStatus, "STATUS"},
+		{OpcodeNotify, "NOTIFY"},
+		{OpcodeUpdate, "UPDATE"},
+		{Opcode(9), "UNKNOWN"},
+	}
+
+	for _, test := range tests {
+		if got := test.op.String(); got != test.expected {
+			t.Errorf("Opcode.String() = %v, want %v", got, test.expected)
+		}
+	}
+}
+
+func TestRcodeString(t *testing.T) {
+	tests := []struct {
+		rc       Rcode
+		expected string
+	}{
+		{RcodeSuccess, "NOERROR"},
+		{RcodeFormErr, "FORMERR"},
+		{RcodeServFail, "SERVFAIL"},
+		{RcodeNXDomain, "NXDOMAIN"},
+		{RcodeNotImp, "NOTIMP"},
+		{RcodeRefused, "REFUSED"},
+		{RcodeNotZone, "NOTZONE"},
+		{RcodeBadVers, "BADVERS"},
+		{Rcode(99), "UNKNOWN"},
+	}
+
+	for _, test := range tests {
+		if got := test.rc.String(); got != test.expected {
+			t.Errorf("Rcode.String() = %v, want %v", got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ACEPrefix marks a Punycode-encoded A-label (RFC 5890 Section 2.3.2.5)
+const ACEPrefix = "xn--"
+
+// Punycode parameters (RFC 3492 Section 5)
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// EncodePunycode encodes a Unicode string with Punycode (RFC 3492),
+// without the ACE prefix
+func EncodePunycode(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("invalid UTF-8 in %q", s)
+	}
+	runes := []rune(s)
+
+	var out strings.Builder
+	basic := 0
+	for _, r := range runes {
+		if r < 0x80 {
+			out.WriteRune(r)
+			basic++
+		}
+	}
+	handled := basic
+	if basic > 0 {
+		out.WriteByte('-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		// Next code point to insert: the smallest one not yet handled
+		m := rune(unicode.MaxRune + 1)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		if int(m-n) > (1<<31-1-delta)/(handled+1) {
+			return "", fmt.Errorf("punycode overflow encoding %q", s)
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return out.String(), nil
+}
+
+// DecodePunycode decodes a Punycode string (RFC 3492) without the ACE
+// prefix
+func DecodePunycode(s string) (string, error) {
+	var output []rune
+	input := s
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for _, c := range s[:i] {
+			if c >= 0x80 {
+				return "", fmt.Errorf("non-basic code point in punycode %q", s)
+			}
+			output = append(output, c)
+		}
+		input = s[i+1:]
+	}
+
+	n, i, bias := rune(punyInitialN), 0, punyInitialBias
+	for pos := 0; pos < len(input); {
+		oldI, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if pos >= len(input) {
+				return "", fmt.Errorf("truncated punycode %q", s)
+			}
+			digit, ok := punyValue(input[pos])
+			pos++
+			if !ok {
+				return "", fmt.Errorf("invalid punycode digit %q in %q", input[pos-1], s)
+			}
+			if digit > (1<<31-1-i)/w {
+				return "", fmt.Errorf("punycode overflow decoding %q", s)
+			}
+			i += digit * w
+			t := punyThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			w *= punyBase - t
+		}
+
+		length := len(output) + 1
+		bias = punyAdapt(i-oldI, length, oldI == 0)
+		n += rune(i / length)
+		i %= length
+		if n > unicode.MaxRune || (n >= 0xD800 && n <= 0xDFFF) {
+			return "", fmt.Errorf("invalid code point in punycode %q", s)
+		}
+		output = append(output[:i], append([]rune{n}, output[i:]...)...)
+		i++
+	}
+	return string(output), nil
+}
+
+// punyThreshold returns the threshold t for the digit position k
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	default:
+		return k - bias
+	}
+}
+
+// punyAdapt is the bias adaptation function of RFC 3492 Section 6.1
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit returns the lowercase basic code point of a digit value
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// punyValue returns the digit value of a basic code point
+func punyValue(c byte) (int, bool) {
+	switch {
+	case 'a' <= c && c <= 'z':
+		return int(c - 'a'), true
+	case 'A' <= c && c <= 'Z':
+		return int(c - 'A'), true
+	case '0' <= c && c <= '9':
+		return int(c-'0') + 26, true
+	default:
+		return 0, false
+	}
+}
+
+// ToASCII converts a domain name that may contain Unicode labels to its
+// ASCII form, encoding each non-ASCII label as an A-label. The name is in
+// presentation format, so escaped dots do not separate labels. Labels are
+// mapped first: letters are lowercased, fullwidth ASCII is narrowed and
+// the ideographic full stops separate labels (UTS #46 Section 4). Input is
+// expected in Unicode Normalization Form C; the contextual and
+// bidirectional rules of IDNA2008 are not checked.
+func ToASCII(name string) (string, error) {
+	if isASCII(name) {
+		return name, nil
+	}
+	if !utf8.ValidString(name) {
+		return "", &DomainError{Domain: name, Reason: "invalid UTF-8"}
+	}
+	data, absolute, err := splitLabels(mapIDNA(name))
+	if err != nil {
+		return "", &DomainError{Domain: name, Reason: err.Error()}
+	}
+	labels := make([]Label, len(data))
+	for i, label := range data {
+		if !isASCII(string(label)) {
+			if err := checkULabel(string(label)); err != nil {
+				return "", &DomainError{Domain: name, Reason: err.Error()}
+			}
+			encoded, err := EncodePunycode(string(label))
+			if err != nil {
+				return "", &DomainError{Domain: name, Reason: err.Error()}
+			}
+			label = []byte(ACEPrefix + encoded)
+			if len(label) > MaxLabelLength {
+				return "", &DomainError{Domain: name, Reason: "label too long (max 63 characters)"}
+			}
+		}
+		labels[i] = Label{Length: byte(len(label)), Data: label}
+	}
+	ascii := LabelsToString(labels)
+	if absolute {
+		ascii += "."
+	}
+	return ascii, nil
+}
+
+// ToUnicode converts the A-labels of a domain name to U-labels. A-labels
+// compare case-insensitively; those that do not decode to a valid U-label
+// which encodes back to the same A-label are an error.
+func ToUnicode(name string) (string, error) {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		label = strings.ToLower(label)
+		if !strings.HasPrefix(label, ACEPrefix) {
+			continue
+		}
+		decoded, err := DecodePunycode(label[len(ACEPrefix):])
+		if err != nil {
+			return "", &DomainError{Domain: name, Reason: err.Error()}
+		}
+		if isASCII(decoded) {
+			return "", &DomainError{Domain: name, Reason: "A-label " + label + " decodes to ASCII"}
+		}
+		if err := checkULabel(decoded); err != nil {
+			return "", &DomainError{Domain: name, Reason: err.Error()}
+		}
+		if encoded, err := EncodePunycode(decoded); err != nil || encoded != label[len(ACEPrefix):] {
+			return "", &DomainError{Domain: name, Reason: "A-label " + label + " does not round-trip"}
+		}
+		labels[i] = decoded
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// UnicodeString returns the name with A-labels shown as U-labels, or the
+// presentation format if the name has no valid A-labels
+func (n Name) UnicodeString() string {
+	ascii := n.String()
+	if !strings.Contains(strings.ToLower(ascii), ACEPrefix) {
+		return ascii
+	}
+	unicodeName, err := ToUnicode(ascii)
+	if err != nil {
+		return ascii
+	}
+	return unicodeName
+}
+
+// DisplayString returns the presentation format of the name, followed by
+// its Unicode form in parentheses if the name has A-labels
+func (n Name) DisplayString() string {
+	ascii, unicodeName := n.String(), n.UnicodeString()
+	if unicodeName == ascii {
+		return ascii
+	}
+	return ascii + " (" + unicodeName + ")"
+}
+
+// mapIDNA lowercases a name, narrows fullwidth ASCII and turns the
+// ideographic full stops into dots
+func mapIDNA(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u3002' || r == '\uFF0E' || r == '\uFF61':
+			return '.'
+		case r >= '\uFF01' && r <= '\uFF5E':
+			r -= 0xFEE0
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// checkULabel rejects U-labels with code points other than letters, marks,
+// digits and hyphens, or with hyphens where RFC 5891 Section 4.2.3.1
+// forbids them
+func checkULabel(label string) error {
+	if label == "" {
+		return fmt.Errorf("empty label not allowed")
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return fmt.Errorf("label %q starts or ends with a hyphen", label)
+	}
+	if len(label) >= 4 && label[2:4] == "--" {
+		return fmt.Errorf("label %q has hyphens in the third and fourth position", label)
+	}
+	if r, _ := utf8.DecodeRuneInString(label); unicode.IsMark(r) {
+		return fmt.Errorf("label %q starts with a combining mark", label)
+	}
+	for _, r := range label {
+		if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+			return fmt.Errorf("invalid character %q in label %q", r, label)
+		}
+		if unicode.IsUpper(r) {
+			return fmt.Errorf("uppercase character %q in label %q", r, label)
+		}
+	}
+	return nil
+}
+
+// isASCII reports whether s consists of ASCII characters only
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
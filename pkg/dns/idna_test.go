@@ -0,0 +1,131 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+)
+
+// Sample strings from RFC 3492 Section 7.1 and common names
+var punycodeTests = []struct {
+	unicode, encoded string
+}{
+	{"ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+	{"почемужеонинеговорятпорусски", "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+	{"3年B組金八先生", "3B-ww4c5e180e575a65lsy2b"},
+	{"そのスピードで", "d9juau41awczczp"},
+	{"münchen", "mnchen-3ya"},
+	{"bücher", "bcher-kva"},
+	{"abc", "abc-"},
+}
+
+func TestEncodePunycode(t *testing.T) {
+	for _, test := range punycodeTests {
+		result, err := EncodePunycode(test.unicode)
+		if err != nil || result != test.encoded {
+			t.Errorf("EncodePunycode(%q) = %q, %v, want %q", test.unicode, result, err, test.encoded)
+		}
+	}
+	if _, err := EncodePunycode("\xff"); err == nil {
+		t.Error("EncodePunycode() should reject invalid UTF-8")
+	}
+}
+
+func TestDecodePunycode(t *testing.T) {
+	for _, test := range punycodeTests {
+		result, err := DecodePunycode(test.encoded)
+		if err != nil || result != test.unicode {
+			t.Errorf("DecodePunycode(%q) = %q, %v, want %q", test.encoded, result, err, test.unicode)
+		}
+	}
+	for _, input := range []string{"mnchen-3y", "mnchen-3y!", "ü-3ya", "99999999999"} {
+		if _, err := DecodePunycode(input); err == nil {
+			t.Errorf("DecodePunycode(%q) should return error", input)
+		}
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM", "Example.COM"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"MÜNCHEN.de.", "xn--mnchen-3ya.de."},
+		{"bücher。example", "xn--bcher-kva.example"},
+		{"ｅｘａｍｐｌｅ.ü", "example.xn--tda"},
+		{"straße.de", "xn--strae-oqa.de"},
+		{`a\.b.münchen.de`, `a\.b.xn--mnchen-3ya.de`},
+		{`a\032b.münchen.de.`, `a\032b.xn--mnchen-3ya.de.`},
+	}
+	for _, test := range tests {
+		result, err := ToASCII(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("ToASCII(%q) = %q, %v, want %q", test.input, result, err, test.expected)
+		}
+	}
+
+	for _, input := range []string{"-ü.de", "ü-.de", "ü☃.de", "́ü.de", `ü\.de`, "ü..de", `ü.de\`, `a\.ü.de`} {
+		if _, err := ToASCII(input); err == nil {
+			t.Errorf("ToASCII(%q) should return error", input)
+		}
+	}
+
+	// A U-label longer than MaxLabelLength bytes may still fit as A-label
+	long := strings.Repeat("ü", 40) + ".de"
+	if result, err := ToASCII(long); err != nil || !strings.HasPrefix(result, ACEPrefix) {
+		t.Errorf("ToASCII(%q) = %q, %v, want an A-label", long, result, err)
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"example.com", "example.com"},
+		{"xn--mnchen-3ya.de", "münchen.de"},
+		{"XN--MNCHEN-3YA.de.", "münchen.de."},
+	}
+	for _, test := range tests {
+		result, err := ToUnicode(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("ToUnicode(%q) = %q, %v, want %q", test.input, result, err, test.expected)
+		}
+	}
+
+	// Invalid punycode, ASCII-only and disallowed U-labels
+	for _, input := range []string{"xn--mnchen-3y.de", "xn--abc-.de", "xn--ls8h.de"} {
+		if _, err := ToUnicode(input); err == nil {
+			t.Errorf("ToUnicode(%q) should return error", input)
+		}
+	}
+}
+
+func TestNameDisplayString(t *testing.T) {
+	tests := []struct {
+		name, expected string
+	}{
+		{"xn--mnchen-3ya.de", "xn--mnchen-3ya.de (münchen.de)"},
+		{"www.example.com", "www.example.com"},
+	}
+	for _, test := range tests {
+		if result := MustParseName(test.name).DisplayString(); result != test.expected {
+			t.Errorf("DisplayString(%q) = %q, want %q", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestNameUnicodeString(t *testing.T) {
+	tests := []struct {
+		name, expected string
+	}{
+		{"xn--mnchen-3ya.de", "münchen.de"},
+		{"www.example.com", "www.example.com"},
+		{"xn--invalid-.de", "xn--invalid-.de"},
+	}
+	for _, test := range tests {
+		if result := MustParseName(test.name).UnicodeString(); result != test.expected {
+			t.Errorf("UnicodeString(%q) = %q, want %q", test.name, result, test.expected)
+		}
+	}
+}
@@ -1,86 +1,129 @@
 package dns
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 )
 
-// StringToLabels converts a domain name string to DNS labels
-func StringToLabels(domain string) []Label {
-	// Remove trailing dot if present
-	domain = strings.TrimSuffix(domain, ".")
-	
-	if domain == "" {
-		return []Label{{Length: 0, Data: nil}}
+// Limits of names in wire format (RFC 1035 Section 2.3.4)
+const (
+	MaxLabelLength = 63  // Bytes of label data
+	MaxNameLength  = 255 // Bytes of the whole name including length octets
+)
+
+// StringToLabels converts a domain name in presentation format to DNS
+// labels. Characters may be escaped as \X or \DDD (RFC 1035 Section 5.1).
+// A trailing unescaped dot is optional. It fails for empty labels and for
+// labels or names that exceed MaxLabelLength or MaxNameLength.
+func StringToLabels(domain string) ([]Label, error) {
+	if domain == "" || domain == "." {
+		return []Label{{Length: 0, Data: nil}}, nil
+	}
+
+	data, _, err := splitLabels(domain)
+	if err != nil {
+		return nil, &DomainError{Domain: domain, Reason: err.Error()}
 	}
-	
-	parts := strings.Split(domain, ".")
-	labels := make([]Label, len(parts)+1)
-	
-	for i, part := range parts {
-		labels[i] = Label{
-			Length: byte(len(part)),
-			Data:   []byte(part),
+	labels := make([]Label, 0, len(data)+1)
+	wireLength := 1 // Root label
+	for _, label := range data {
+		if len(label) > MaxLabelLength {
+			return nil, &DomainError{Domain: domain, Reason: "label too long (max 63 bytes)"}
 		}
+		wireLength += 1 + len(label)
+		labels = append(labels, Label{Length: byte(len(label)), Data: label})
 	}
-	
-	// Add null terminator
-	labels[len(parts)] = Label{Length: 0, Data: nil}
-	
-	return labels
-}
 
-// LabelsToString converts DNS labels to a domain name string
-func LabelsToString(labels []Label) string {
-	if len(labels) == 0 {
-		return ""
+	if wireLength > MaxNameLength {
+		return nil, &DomainError{Domain: domain, Reason: "name too long (max 255 bytes)"}
 	}
-	
-	var parts []string
-	for _, label := range labels {
-		if label.Length == 0 {
-			break // Null terminator
+	return append(labels, Label{Length: 0, Data: nil}), nil
+}
+
+// splitLabels splits a non-root name in presentation format into the
+// unescaped data of its labels and reports whether it ends with an
+// unescaped dot. It fails for empty labels and invalid escapes; label and
+// name lengths are not checked.
+func splitLabels(domain string) ([][]byte, bool, error) {
+	var labels [][]byte
+	var current []byte
+	endLabel := func() error {
+		if len(current) == 0 {
+			return fmt.Errorf("empty label not allowed")
 		}
-		parts = append(parts, string(label.Data))
+		labels = append(labels, current)
+		current = nil
+		return nil
 	}
-	
-	return strings.Join(parts, ".")
-}
 
-// ValidateDomain validates a domain name according to RFC standards
-func ValidateDomain(domain string) error {
-	if len(domain) == 0 {
-		return &DomainError{Domain: domain, Reason: "domain cannot be empty"}
+	for i := 0; i < len(domain); i++ {
+		c := domain[i]
+		switch {
+		case c == '.':
+			if err := endLabel(); err != nil {
+				return nil, false, err
+			}
+		case c != '\\':
+			current = append(current, c)
+		case i+3 < len(domain) && isDigit(domain[i+1]) && isDigit(domain[i+2]) && isDigit(domain[i+3]):
+			value := int(domain[i+1]-'0')*100 + int(domain[i+2]-'0')*10 + int(domain[i+3]-'0')
+			if value > 255 {
+				return nil, false, fmt.Errorf("escaped value out of range")
+			}
+			current = append(current, byte(value))
+			i += 3
+		case i+1 < len(domain) && !isDigit(domain[i+1]):
+			current = append(current, domain[i+1])
+			i++
+		default:
+			return nil, false, fmt.Errorf("invalid escape sequence")
+		}
 	}
-	
-	if len(domain) > 253 {
-		return &DomainError{Domain: domain, Reason: "domain too long (max 253 characters)"}
+	if current == nil {
+		return labels, true, nil
 	}
-	
-	// Remove trailing dot if present
-	domain = strings.TrimSuffix(domain, ".")
-	
-	// Check each label
-	labels := strings.Split(domain, ".")
-	if len(labels) < 1 {
-		return &DomainError{Domain: domain, Reason: "domain must have at least one label"}
+	if err := endLabel(); err != nil {
+		return nil, false, err
 	}
-	
-	labelRegex := regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?$`)
-	
-	for _, label := range labels {
-		if len(label) == 0 {
-			return &DomainError{Domain: domain, Reason: "empty label not allowed"}
+	return labels, false, nil
+}
+
+// LabelsToString converts DNS labels to a domain name in presentation
+// format without a trailing dot. Dots and characters special in master
+// files are escaped as \X, unprintable bytes as \DDD.
+func LabelsToString(labels []Label) string {
+	var b strings.Builder
+	for i, label := range labels {
+		if label.Length == 0 {
+			break // Null terminator
 		}
-		if len(label) > 63 {
-			return &DomainError{Domain: domain, Reason: "label too long (max 63 characters)"}
+		if i > 0 {
+			b.WriteByte('.')
 		}
-		if !labelRegex.MatchString(label) {
-			return &DomainError{Domain: domain, Reason: "invalid characters in label: " + label}
+		for _, c := range label.Data {
+			switch {
+			case c <= ' ' || c >= 0x7F:
+				fmt.Fprintf(&b, "\\%03d", c)
+			case strings.IndexByte(`."\();@$`, c) >= 0:
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			default:
+				b.WriteByte(c)
+			}
 		}
 	}
-	
-	return nil
+	return b.String()
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// ValidateDomain validates a domain name as a hostname according to RFC
+// standards; see ValidationPolicy for other kinds of names
+func ValidateDomain(domain string) error {
+	return HostnamePolicy.Validate(domain)
 }
 
 // DomainError represents a domain validation error
@@ -2,6 +2,7 @@ package dns
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -41,7 +42,10 @@ func TestStringToLabels(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := StringToLabels(test.domain)
+		result, err := StringToLabels(test.domain)
+		if err != nil {
+			t.Errorf("StringToLabels(%q) returned error: %v", test.domain, err)
+		}
 		if !reflect.DeepEqual(result, test.expected) {
 			t.Errorf("StringToLabels(%q) = %v, want %v", test.domain, result, test.expected)
 		}
@@ -88,6 +92,80 @@ func TestLabelsToString(t *testing.T) {
 	}
 }
 
+func TestStringToLabelsEscapes(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected [][]byte
+	}{
+		{`a\.b.example`, [][]byte{[]byte("a.b"), []byte("example")}},
+		{`\065bc.com.`, [][]byte{[]byte("Abc"), []byte("com")}},
+		{`sp\ ace.\\.x`, [][]byte{[]byte("sp ace"), []byte(`\`), []byte("x")}},
+		{`\000\255`, [][]byte{{0, 255}}},
+		{`ends\.`, [][]byte{[]byte("ends.")}},
+	}
+
+	for _, test := range tests {
+		labels, err := StringToLabels(test.domain)
+		if err != nil {
+			t.Errorf("StringToLabels(%q) returned error: %v", test.domain, err)
+			continue
+		}
+		if len(labels) != len(test.expected)+1 {
+			t.Errorf("StringToLabels(%q) = %v, want %d labels", test.domain, labels, len(test.expected))
+			continue
+		}
+		for i, data := range test.expected {
+			if !reflect.DeepEqual(labels[i].Data, data) || int(labels[i].Length) != len(data) {
+				t.Errorf("StringToLabels(%q) label %d = %q, want %q", test.domain, i, labels[i].Data, data)
+			}
+		}
+	}
+}
+
+func TestStringToLabelsErrors(t *testing.T) {
+	long := strings.Repeat("a", 63)
+	tests := []string{
+		"example..com",
+		".example.com",
+		long + "a.com",
+		strings.Repeat(long+".", 4) + "com",
+		`bad\25`,
+		`bad\256`,
+		`trailing\`,
+	}
+
+	for _, domain := range tests {
+		if _, err := StringToLabels(domain); err == nil {
+			t.Errorf("StringToLabels(%q) should return error", domain)
+		}
+	}
+
+	// 63-byte labels and 255-byte names are allowed
+	if _, err := StringToLabels(strings.Repeat(long+".", 3) + strings.Repeat("a", 61)); err != nil {
+		t.Errorf("StringToLabels() of a 255-byte name returned error: %v", err)
+	}
+}
+
+func TestLabelsToStringEscapes(t *testing.T) {
+	tests := []string{
+		`a\.b.example`,
+		`sp\032ace.\\.x`,
+		`\000\255\127`,
+		`\"\(\)\;\@\$`,
+		"*.example.com",
+	}
+
+	for _, domain := range tests {
+		labels, err := StringToLabels(domain)
+		if err != nil {
+			t.Fatalf("StringToLabels(%q) returned error: %v", domain, err)
+		}
+		if result := LabelsToString(labels); result != domain {
+			t.Errorf("LabelsToString(StringToLabels(%q)) = %q", domain, result)
+		}
+	}
+}
+
 func TestValidateDomain(t *testing.T) {
 	tests := []struct {
 		domain      string
@@ -27,9 +27,9 @@ type Header struct {
 
 // Question represents a DNS question according to RFC 1035 Section 4.1.2
 type Question struct {
-	Name  []Label // Domain name as sequence of labels
-	Type  QType   // Query type
-	Class QClass  // Query class
+	Name  Name   // Domain name as sequence of labels
+	Type  QType  // Query type
+	Class QClass // Query class
 }
 
 // Label represents a DNS label according to RFC 1035
@@ -40,7 +40,7 @@ type Label struct {
 
 // ResourceRecord represents a DNS resource record according to RFC 1035 Section 4.1.3
 type ResourceRecord struct {
-	Name     []Label      // Domain name
+	Name     Name         // Domain name
 	Type     QType        // RR type
 	Class    QClass       // RR class
 	TTL      int32        // Time to live
@@ -55,8 +55,54 @@ type ResourceData interface {
 	Type() QType
 }
 
-// ToBytes converts the DNS message to wire format
+// UpdateCounts sets the header section counts from the section lengths
+func (m *Message) UpdateCounts() error {
+	sections := []struct {
+		name  string
+		count *uint16
+		len   int
+	}{
+		{"question", &m.Header.QDCount, len(m.Question)},
+		{"answer", &m.Header.ANCount, len(m.Answer)},
+		{"authority", &m.Header.NSCount, len(m.Authority)},
+		{"additional", &m.Header.ARCount, len(m.Additional)},
+	}
+	for _, section := range sections {
+		if section.len > 65535 {
+			return fmt.Errorf("too many %s entries: %d", section.name, section.len)
+		}
+		*section.count = uint16(section.len)
+	}
+	return nil
+}
+
+// checkCounts verifies that the header counts match the section lengths
+func (m *Message) checkCounts() error {
+	sections := []struct {
+		name  string
+		count uint16
+		len   int
+	}{
+		{"QDCount", m.Header.QDCount, len(m.Question)},
+		{"ANCount", m.Header.ANCount, len(m.Answer)},
+		{"NSCount", m.Header.NSCount, len(m.Authority)},
+		{"ARCount", m.Header.ARCount, len(m.Additional)},
+	}
+	for _, section := range sections {
+		if int(section.count) != section.len {
+			return fmt.Errorf("header %s is %d but section has %d entries", section.name, section.count, section.len)
+		}
+	}
+	return nil
+}
+
+// ToBytes converts the DNS message to wire format. It fails if the header
+// counts do not match the section lengths; see UpdateCounts.
 func (m *Message) ToBytes() ([]byte, error) {
+	if err := m.checkCounts(); err != nil {
+		return nil, err
+	}
+
 	buf := new(bytes.Buffer)
 
 	// Write header
@@ -112,6 +158,8 @@ func (m *Message) String() string {
 	buf.WriteString("; DNS Message\n")
 	buf.WriteString("; Header:\n")
 	buf.WriteString(m.Header.String())
+	buf.WriteString("\n\t")
+	buf.WriteString(flagsString(&m.Header, m.Rcode()))
 	
 	if m.Header.QDCount > 0 {
 		buf.WriteString("\n; Question:\n")
@@ -190,7 +238,7 @@ func (q *Question) toBytes() ([]byte, error) {
 
 // String returns a human-readable representation of the question
 func (q *Question) String() string {
-	domain := LabelsToString(q.Name)
+	domain := q.Name.DisplayString()
 	return fmt.Sprintf("\t%s\t%s\t%s", domain, q.Type.String(), q.Class.String())
 }
 
@@ -208,8 +256,17 @@ func (rr *ResourceRecord) toBytes() ([]byte, error) {
 		buf.Write(label.ToBytes())
 	}
 	
-	// Write type, class, TTL, and RDLength
-	fields := []interface{}{rr.Type, rr.Class, rr.TTL, rr.RDLength}
+	if rr.RData == nil {
+		return nil, fmt.Errorf("resource record %s has no data", LabelsToString(rr.Name))
+	}
+	rdata := rr.RData.Bytes()
+	if len(rdata) > 65535 {
+		return nil, fmt.Errorf("resource data too long: %d bytes", len(rdata))
+	}
+	
+	// Write type, class, TTL, and RDLength (derived from the data so that
+	// hand-built records cannot go out of sync)
+	fields := []interface{}{rr.Type, rr.Class, rr.TTL, uint16(len(rdata))}
 	for _, field := range fields {
 		if err := binary.Write(buf, binary.BigEndian, field); err != nil {
 			return nil, fmt.Errorf("failed to write RR field: %w", err)
@@ -217,14 +274,14 @@ func (rr *ResourceRecord) toBytes() ([]byte, error) {
 	}
 	
 	// Write resource data
-	buf.Write(rr.RData.Bytes())
+	buf.Write(rdata)
 	
 	return buf.Bytes(), nil
 }
 
 // String returns a human-readable representation of the resource record
 func (rr *ResourceRecord) String() string {
-	domain := LabelsToString(rr.Name)
+	domain := rr.Name.DisplayString()
 	return fmt.Sprintf("\t%s\t%s\t%s\tTTL: %d\t%s",
 		domain, rr.Type.String(), rr.Class.String(), rr.TTL, rr.RData.String())
 }
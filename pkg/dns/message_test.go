@@ -49,7 +49,7 @@ func TestHeaderToBytes(t *testing.T) {
 
 func TestQuestionString(t *testing.T) {
 	question := Question{
-		Name:  StringToLabels("example.com"),
+		Name:  MustParseName("example.com"),
 		Type:  TypeA,
 		Class: ClassIN,
 	}
@@ -62,9 +62,17 @@ func TestQuestionString(t *testing.T) {
 	}
 }
 
+func TestQuestionStringUnicode(t *testing.T) {
+	question := Question{Name: MustParseName("xn--mnchen-3ya.de"), Type: TypeA, Class: ClassIN}
+	expected := "\txn--mnchen-3ya.de (münchen.de)\tA\tIN"
+	if result := question.String(); result != expected {
+		t.Errorf("Question.String() = %q, want %q", result, expected)
+	}
+}
+
 func TestQuestionToBytes(t *testing.T) {
 	question := Question{
-		Name:  StringToLabels("example.com"),
+		Name:  MustParseName("example.com"),
 		Type:  TypeA,
 		Class: ClassIN,
 	}
@@ -93,7 +101,7 @@ func TestMessageString(t *testing.T) {
 		},
 		Question: []Question{
 			{
-				Name:  StringToLabels("example.com"),
+				Name:  MustParseName("example.com"),
 				Type:  TypeA,
 				Class: ClassIN,
 			},
@@ -129,7 +137,7 @@ func TestMessageToBytes(t *testing.T) {
 		},
 		Question: []Question{
 			{
-				Name:  StringToLabels("example.com"),
+				Name:  MustParseName("example.com"),
 				Type:  TypeA,
 				Class: ClassIN,
 			},
@@ -160,3 +168,62 @@ func findSubstring(s, substr string) int {
 	}
 	return -1
 }
+
+// rawData is a minimal ResourceData used to build records in tests
+type rawData []byte
+
+func (r rawData) Bytes() []byte  { return r }
+func (r rawData) String() string { return string(r) }
+func (r rawData) Type() QType    { return TypeNULL }
+
+func TestResourceRecordToBytesRDLength(t *testing.T) {
+	rr := ResourceRecord{
+		Name:     MustParseName("example.com"),
+		Type:     TypeNULL,
+		Class:    ClassIN,
+		TTL:      60,
+		RDLength: 99, // stale value must be ignored
+		RData:    rawData{1, 2, 3},
+	}
+
+	result, err := rr.toBytes()
+	if err != nil {
+		t.Fatalf("ResourceRecord.toBytes() returned error: %v", err)
+	}
+
+	// name (13) + type (2) + class (2) + TTL (4) => RDLength at offset 21
+	if result[21] != 0 || result[22] != 3 {
+		t.Errorf("RDLength bytes = [%02x %02x], want [00 03]", result[21], result[22])
+	}
+}
+
+func TestResourceRecordToBytesNilData(t *testing.T) {
+	rr := ResourceRecord{Name: MustParseName("example.com"), Type: TypeA, Class: ClassIN}
+
+	if _, err := rr.toBytes(); err == nil {
+		t.Error("ResourceRecord.toBytes() should return error for nil RData")
+	}
+}
+
+func TestMessageToBytesCountMismatch(t *testing.T) {
+	msg := &Message{
+		Header: Header{ID: 0x1234, QDCount: 2},
+		Question: []Question{
+			{Name: MustParseName("example.com"), Type: TypeA, Class: ClassIN},
+		},
+	}
+
+	if _, err := msg.ToBytes(); err == nil {
+		t.Error("Message.ToBytes() should return error when QDCount does not match")
+	}
+
+	if err := msg.UpdateCounts(); err != nil {
+		t.Fatalf("Message.UpdateCounts() returned error: %v", err)
+	}
+	if msg.Header.QDCount != 1 {
+		t.Errorf("QDCount after UpdateCounts() = %d, want 1", msg.Header.QDCount)
+	}
+	if _, err := msg.ToBytes(); err != nil {
+		t.Errorf("Message.ToBytes() returned error after UpdateCounts(): %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package dns
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Name is a domain name as a sequence of labels, normally terminated by
+// the root label. Names compare case-insensitively: ASCII letters are
+// folded, all other bytes compare as is (RFC 4343).
+type Name []Label
+
+// RootName returns the root name
+func RootName() Name {
+	return Name{{Length: 0, Data: nil}}
+}
+
+// ParseName parses a domain name in presentation format; see
+// StringToLabels
+func ParseName(s string) (Name, error) {
+	return StringToLabels(s)
+}
+
+// MustParseName is like ParseName but panics if the name is invalid. It
+// is intended for names fixed at compile time.
+func MustParseName(s string) Name {
+	name, err := ParseName(s)
+	if err != nil {
+		panic(err)
+	}
+	return name
+}
+
+// String returns the name in presentation format without a trailing dot
+func (n Name) String() string {
+	return LabelsToString(n)
+}
+
+// Bytes returns the uncompressed wire format of the name
+func (n Name) Bytes() []byte {
+	var result []byte
+	for _, label := range n {
+		result = append(result, label.ToBytes()...)
+	}
+	return result
+}
+
+// labels returns the labels of the name without the root label
+func (n Name) labels() []Label {
+	for i, label := range n {
+		if label.Length == 0 {
+			return n[:i]
+		}
+	}
+	return n
+}
+
+// LabelCount returns the number of labels, not counting the root
+func (n Name) LabelCount() int {
+	return len(n.labels())
+}
+
+// IsRoot reports whether the name is the root
+func (n Name) IsRoot() bool {
+	return n.LabelCount() == 0
+}
+
+// Canonical returns a copy of the name with ASCII letters lowercased
+// (RFC 4034 Section 6.2)
+func (n Name) Canonical() Name {
+	result := make(Name, len(n))
+	for i, label := range n {
+		result[i] = Label{Length: label.Length}
+		if label.Data != nil {
+			result[i].Data = asciiLower(label.Data)
+		}
+	}
+	return result
+}
+
+// Equal reports whether both names are the same, ignoring case
+func (n Name) Equal(other Name) bool {
+	a, b := n.labels(), other.labels()
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalLabels(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders names in canonical DNS name order (RFC 4034 Section 6.1):
+// label by label from the root, comparing lowercased labels as unsigned
+// byte strings, with a name sorting before the names below it. The result
+// is negative, zero or positive like bytes.Compare.
+func (n Name) Compare(other Name) int {
+	a, b := n.labels(), other.labels()
+	for i, j := len(a)-1, len(b)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := bytes.Compare(asciiLower(a[i].Data), asciiLower(b[j].Data)); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// IsSubdomainOf reports whether the name equals parent or lies below it
+func (n Name) IsSubdomainOf(parent Name) bool {
+	c, p := n.labels(), parent.labels()
+	if len(p) > len(c) {
+		return false
+	}
+	return Name(c[len(c)-len(p):]).Equal(p)
+}
+
+// Parent returns the name without its first label. The parent of the
+// root is the root.
+func (n Name) Parent() Name {
+	labels := n.labels()
+	if len(labels) == 0 {
+		return RootName()
+	}
+	return withRoot(labels[1:])
+}
+
+// Child returns the name with label prepended, such as "*" for the
+// wildcard name below n. The label is taken as is, without escapes. It
+// fails for an empty label, a label longer than MaxLabelLength or a result
+// longer than MaxNameLength.
+func (n Name) Child(label string) (Name, error) {
+	result := append(Name{{Length: byte(len(label)), Data: []byte(label)}}, withRoot(n.labels())...)
+	switch {
+	case label == "":
+		return nil, &DomainError{Domain: n.String(), Reason: "empty label not allowed"}
+	case len(label) > MaxLabelLength:
+		return nil, &DomainError{Domain: n.String(), Reason: "label too long (max 63 bytes)"}
+	case len(result.Bytes()) > MaxNameLength:
+		return nil, &DomainError{Domain: n.String(), Reason: "name too long (max 255 bytes)"}
+	}
+	return result, nil
+}
+
+// ReplaceSuffix returns the name with suffix replaced by replacement, as
+// in DNAME substitution (RFC 6672 Section 2.2). It fails if the name does
+// not lie below suffix or the result exceeds MaxNameLength.
+func (n Name) ReplaceSuffix(suffix, replacement Name) (Name, error) {
+	if !n.IsSubdomainOf(suffix) {
+		return nil, fmt.Errorf("%s is not below %s", n, suffix)
+	}
+	labels := n.labels()
+	prefix := labels[:len(labels)-suffix.LabelCount()]
+	result := append(append(Name(nil), prefix...), withRoot(replacement.labels())...)
+	if len(result.Bytes()) > MaxNameLength {
+		return nil, &DomainError{Domain: result.String(), Reason: "name too long (max 255 bytes)"}
+	}
+	return result, nil
+}
+
+// CommonAncestor returns the longest name that both names are equal to or
+// below
+func (n Name) CommonAncestor(other Name) Name {
+	a, b := n.labels(), other.labels()
+	common := 0
+	for common < len(a) && common < len(b) && equalLabels(a[len(a)-1-common], b[len(b)-1-common]) {
+		common++
+	}
+	return withRoot(a[len(a)-common:])
+}
+
+// IsWildcard reports whether the first label of the name is "*"
+func (n Name) IsWildcard() bool {
+	labels := n.labels()
+	return len(labels) > 0 && labels[0].Length == 1 && labels[0].Data[0] == '*'
+}
+
+// MatchesWildcard reports whether the name lies below the parent of the
+// wildcard name "*.<parent>". Whether the wildcard actually applies also
+// depends on the names that exist in the zone (RFC 4592 Section 2.2).
+func (n Name) MatchesWildcard(wildcard Name) bool {
+	if !wildcard.IsWildcard() {
+		return false
+	}
+	parent := wildcard.Parent()
+	return n.LabelCount() > parent.LabelCount() && n.IsSubdomainOf(parent)
+}
+
+// Key returns a case-insensitive map key for the name, equal for names
+// that differ only in ASCII case or in a trailing root label
+func (n Name) Key() string {
+	var key []byte
+	for _, label := range n.labels() {
+		key = append(key, label.Length)
+		key = append(key, asciiLower(label.Data)...)
+	}
+	return string(key)
+}
+
+// withRoot returns a copy of labels terminated by the root label
+func withRoot(labels []Label) Name {
+	result := append(Name(nil), labels...)
+	return append(result, Label{Length: 0, Data: nil})
+}
+
+// equalLabels compares two labels, ignoring ASCII case
+func equalLabels(a, b Label) bool {
+	return bytes.Equal(asciiLower(a.Data), asciiLower(b.Data))
+}
+
+// asciiLower returns a copy of data with ASCII letters lowercased. Other
+// bytes, including those of non-ASCII labels, are left unchanged.
+func asciiLower(data []byte) []byte {
+	result := make([]byte, len(data))
+	for i, c := range data {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		result[i] = c
+	}
+	return result
+}
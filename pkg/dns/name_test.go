@@ -0,0 +1,197 @@
+package dns
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNameString(t *testing.T) {
+	name := Name(MustParseName("www.example.com."))
+	if result := name.String(); result != "www.example.com" {
+		t.Errorf("Name.String() = %q, want %q", result, "www.example.com")
+	}
+	if !RootName().IsRoot() || RootName().String() != "" {
+		t.Error("RootName() should be the empty root name")
+	}
+	if result := name.Bytes(); !bytes.Equal(result, []byte("\x03www\x07example\x03com\x00")) {
+		t.Errorf("Name.Bytes() = %q", result)
+	}
+}
+
+func TestNameCanonical(t *testing.T) {
+	name := Name(MustParseName("WWW.Example.COM")).Canonical()
+	if result := name.String(); result != "www.example.com" {
+		t.Errorf("Name.Canonical() = %q, want %q", result, "www.example.com")
+	}
+
+	// Bytes outside ASCII are left alone
+	label := Name{{Length: 2, Data: []byte{0xC3, 0x84}}, {Length: 0}}
+	if result := label.Canonical(); !bytes.Equal(result[0].Data, []byte{0xC3, 0x84}) {
+		t.Errorf("Name.Canonical() changed non-ASCII bytes to % x", result[0].Data)
+	}
+}
+
+func TestNameEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"example.com", "EXAMPLE.com.", true},
+		{"example.com", "example.org", false},
+		{"www.example.com", "example.com", false},
+		{"", ".", true},
+		{"a.bc", "ab.c", false},
+	}
+	for _, tt := range tests {
+		a, b := Name(MustParseName(tt.a)), Name(MustParseName(tt.b))
+		if result := a.Equal(b); result != tt.expected {
+			t.Errorf("Name(%q).Equal(%q) = %v, want %v", tt.a, tt.b, result, tt.expected)
+		}
+		if result := a.Key() == b.Key(); result != tt.expected {
+			t.Errorf("Name(%q).Key() == Name(%q).Key() is %v, want %v", tt.a, tt.b, result, tt.expected)
+		}
+	}
+
+	// A name without the root label equals its terminated form
+	unterminated := Name(MustParseName("example.com")[:2])
+	if !unterminated.Equal(MustParseName("example.com")) {
+		t.Error("Name.Equal() should ignore a missing root label")
+	}
+	if unterminated.Key() != Name(MustParseName("example.com")).Key() {
+		t.Error("Name.Key() should ignore a missing root label")
+	}
+}
+
+func TestNameCompare(t *testing.T) {
+	// The ordered example of RFC 4034 Section 6.1
+	ordered := []string{
+		"example",
+		"a.example",
+		"yljkjljk.a.example",
+		"Z.a.example",
+		"zABC.a.EXAMPLE",
+		"z.example",
+		"\x01.z.example",
+		"*.z.example",
+		"\x80.z.example",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			c := Name(MustParseName(ordered[i])).Compare(MustParseName(ordered[j]))
+			if (i < j && c >= 0) || (i > j && c <= 0) || (i == j && c != 0) {
+				t.Errorf("Compare(%q, %q) = %d", ordered[i], ordered[j], c)
+			}
+		}
+	}
+}
+
+func TestNameHierarchy(t *testing.T) {
+	name := Name(MustParseName("www.Example.com"))
+
+	if count := name.LabelCount(); count != 3 {
+		t.Errorf("LabelCount() = %d, want 3", count)
+	}
+	if parent := name.Parent(); parent.String() != "Example.com" {
+		t.Errorf("Parent() = %q, want %q", parent, "Example.com")
+	}
+	if parent := RootName().Parent(); !parent.IsRoot() {
+		t.Errorf("Parent() of the root = %q, want the root", parent)
+	}
+
+	subdomains := []struct {
+		parent   string
+		expected bool
+	}{
+		{"example.COM", true},
+		{"www.example.com", true},
+		{"", true},
+		{"ample.com", false},
+		{"mail.example.com", false},
+	}
+	for _, tt := range subdomains {
+		if result := name.IsSubdomainOf(MustParseName(tt.parent)); result != tt.expected {
+			t.Errorf("IsSubdomainOf(%q) = %v, want %v", tt.parent, result, tt.expected)
+		}
+	}
+
+	ancestors := []struct {
+		other, expected string
+	}{
+		{"mail.example.com", "Example.com"},
+		{"www.example.com", "www.Example.com"},
+		{"example.org", ""},
+	}
+	for _, tt := range ancestors {
+		result := name.CommonAncestor(MustParseName(tt.other))
+		if result.String() != tt.expected || result[len(result)-1].Length != 0 {
+			t.Errorf("CommonAncestor(%q) = %q, want %q", tt.other, result, tt.expected)
+		}
+	}
+}
+
+func TestNameReplaceSuffix(t *testing.T) {
+	name := MustParseName("www.sub.Example.com")
+
+	result, err := name.ReplaceSuffix(MustParseName("example.COM"), MustParseName("example.net"))
+	if err != nil || result.String() != "www.sub.example.net" || result[len(result)-1].Length != 0 {
+		t.Errorf("ReplaceSuffix() = %q, %v, want %q", result, err, "www.sub.example.net")
+	}
+	if result, err := name.ReplaceSuffix(MustParseName("sub.example.com"), RootName()); err != nil || result.String() != "www" {
+		t.Errorf("ReplaceSuffix() to the root = %q, %v, want %q", result, err, "www")
+	}
+
+	if _, err := name.ReplaceSuffix(MustParseName("example.org"), RootName()); err == nil {
+		t.Error("ReplaceSuffix() should fail for a name outside the suffix")
+	}
+	long := MustParseName(strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 60))
+	if _, err := MustParseName("x.y.com").ReplaceSuffix(MustParseName("com"), long); err == nil {
+		t.Error("ReplaceSuffix() should fail for a result over 255 bytes")
+	}
+}
+
+func TestNameChild(t *testing.T) {
+	result, err := MustParseName("example.com").Child("*")
+	if err != nil || result.String() != "*.example.com" || result[len(result)-1].Length != 0 {
+		t.Errorf("Child() = %q, %v, want %q", result, err, "*.example.com")
+	}
+	if result, err := RootName().Child("com"); err != nil || result.String() != "com" {
+		t.Errorf("Child() of the root = %q, %v, want %q", result, err, "com")
+	}
+
+	if _, err := MustParseName("example.com").Child(""); err == nil {
+		t.Error("Child() should fail for an empty label")
+	}
+	if _, err := MustParseName("example.com").Child(strings.Repeat("a", 64)); err == nil {
+		t.Error("Child() should fail for a label over 63 bytes")
+	}
+	long := MustParseName(strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 61))
+	if _, err := long.Child("*"); err == nil {
+		t.Error("Child() should fail for a result over 255 bytes")
+	}
+}
+
+func TestNameWildcard(t *testing.T) {
+	wildcard := Name(MustParseName("*.example.com"))
+	if !wildcard.IsWildcard() || Name(MustParseName("a.example.com")).IsWildcard() {
+		t.Error("IsWildcard() should only accept names starting with *")
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"a.example.com", true},
+		{"a.b.EXAMPLE.com", true},
+		{"example.com", false},
+		{"a.example.org", false},
+	}
+	for _, tt := range tests {
+		if result := Name(MustParseName(tt.name)).MatchesWildcard(wildcard); result != tt.expected {
+			t.Errorf("MatchesWildcard(%q) = %v, want %v", tt.name, result, tt.expected)
+		}
+	}
+	if Name(MustParseName("a.example.com")).MatchesWildcard(MustParseName("www.example.com")) {
+		t.Error("MatchesWildcard() should reject a pattern that is not a wildcard")
+	}
+}
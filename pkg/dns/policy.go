@@ -0,0 +1,75 @@
+package dns
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Label patterns, compiled once. Hostname labels follow the LDH rule of
+// RFC 1123 Section 2.1; the underscore variant also admits the service
+// labels of RFC 8552 such as _dmarc and _tcp.
+var (
+	hostnameLabelRegex   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?$`)
+	underscoreLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9_]([a-zA-Z0-9_\-]{0,61}[a-zA-Z0-9_])?$`)
+)
+
+// ValidationPolicy selects which domain names Validate accepts. The zero
+// value is the strict hostname policy.
+type ValidationPolicy struct {
+	// AnyOctet accepts labels of arbitrary bytes, escaped as in
+	// StringToLabels (RFC 2181 Section 11), and the root name. Only the
+	// length limits are checked; the other options have no effect.
+	AnyOctet bool
+	// AllowUnderscore accepts underscores in labels, as in SRV owner names,
+	// DKIM selectors and _acme-challenge
+	AllowUnderscore bool
+	// AllowWildcard accepts "*" as the first label
+	AllowWildcard bool
+}
+
+// Common validation policies
+var (
+	HostnamePolicy   = ValidationPolicy{}
+	UnderscorePolicy = ValidationPolicy{AllowUnderscore: true}
+	WildcardPolicy   = ValidationPolicy{AllowWildcard: true}
+	DNSPolicy        = ValidationPolicy{AnyOctet: true}
+)
+
+// Validate checks a domain name in presentation format against the policy
+func (p ValidationPolicy) Validate(domain string) error {
+	if len(domain) == 0 {
+		return &DomainError{Domain: domain, Reason: "domain cannot be empty"}
+	}
+	if p.AnyOctet {
+		_, err := StringToLabels(domain)
+		return err
+	}
+
+	if len(domain) > 253 {
+		return &DomainError{Domain: domain, Reason: "domain too long (max 253 characters)"}
+	}
+
+	// Remove trailing dot if present
+	domain = strings.TrimSuffix(domain, ".")
+
+	labelRegex := hostnameLabelRegex
+	if p.AllowUnderscore {
+		labelRegex = underscoreLabelRegex
+	}
+	for i, label := range strings.Split(domain, ".") {
+		if len(label) == 0 {
+			return &DomainError{Domain: domain, Reason: "empty label not allowed"}
+		}
+		if len(label) > 63 {
+			return &DomainError{Domain: domain, Reason: "label too long (max 63 characters)"}
+		}
+		if i == 0 && label == "*" && p.AllowWildcard {
+			continue
+		}
+		if !labelRegex.MatchString(label) {
+			return &DomainError{Domain: domain, Reason: "invalid characters in label: " + label}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	tests := []struct {
+		domain                                   string
+		hostname, underscore, wildcard, anyOctet bool
+	}{
+		{"example.com", true, true, true, true},
+		{"example.com.", true, true, true, true},
+		{"_dmarc.example.com", false, true, false, true},
+		{"_sip._tcp.example.com", false, true, false, true},
+		{"selector_1._domainkey.example.com", false, true, false, true},
+		{"*.example.com", false, false, true, true},
+		{"www.*.example.com", false, false, false, true},
+		{`sp\032ace.example.com`, false, false, false, true},
+		{".", false, false, false, true},
+		{"", false, false, false, false},
+		{"example..com", false, false, false, false},
+		{"-example.com", false, false, false, true},
+	}
+
+	policies := []struct {
+		name   string
+		policy ValidationPolicy
+		accept func(test int) bool
+	}{
+		{"HostnamePolicy", HostnamePolicy, func(i int) bool { return tests[i].hostname }},
+		{"UnderscorePolicy", UnderscorePolicy, func(i int) bool { return tests[i].underscore }},
+		{"WildcardPolicy", WildcardPolicy, func(i int) bool { return tests[i].wildcard }},
+		{"DNSPolicy", DNSPolicy, func(i int) bool { return tests[i].anyOctet }},
+	}
+
+	for _, p := range policies {
+		for i, test := range tests {
+			err := p.policy.Validate(test.domain)
+			if (err == nil) != p.accept(i) {
+				t.Errorf("%s.Validate(%q) error = %v, want accepted = %v", p.name, test.domain, err, p.accept(i))
+			}
+		}
+	}
+}
+
+func TestValidationPolicyCombined(t *testing.T) {
+	policy := ValidationPolicy{AllowUnderscore: true, AllowWildcard: true}
+	if err := policy.Validate("*._tcp.example.com"); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	// Label and name limits hold for the permissive policy too
+	for _, domain := range []string{strings.Repeat("a", 64) + ".com", strings.Repeat("abc.", 64) + "com"} {
+		if err := DNSPolicy.Validate(domain); err == nil {
+			t.Errorf("DNSPolicy.Validate(%q) should return error", domain)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// Reverse mapping zones (RFC 1035 Section 3.5, RFC 3596 Section 2.5)
+const (
+	ReverseZoneIPv4 = "in-addr.arpa"
+	ReverseZoneIPv6 = "ip6.arpa"
+)
+
+// ReverseName returns the in-addr.arpa or ip6.arpa name for an address.
+// IPv4-mapped IPv6 addresses map into in-addr.arpa.
+func ReverseName(addr netip.Addr) Name {
+	addr = addr.Unmap()
+	var labels []string
+	if addr.Is4() {
+		ip := addr.As4()
+		for i := len(ip) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(ip[i])))
+		}
+		labels = append(labels, "in-addr", "arpa")
+	} else {
+		ip := addr.As16()
+		for i := len(ip) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.FormatUint(uint64(ip[i]&0x0F), 16), strconv.FormatUint(uint64(ip[i]>>4), 16))
+		}
+		labels = append(labels, "ip6", "arpa")
+	}
+
+	name := make(Name, 0, len(labels)+1)
+	for _, label := range labels {
+		name = append(name, Label{Length: byte(len(label)), Data: []byte(label)})
+	}
+	return append(name, Label{Length: 0, Data: nil})
+}
+
+// ReverseNameFromIP is like ReverseName for a net.IP
+func ReverseNameFromIP(ip net.IP) (Name, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil, fmt.Errorf("invalid IP address: %v", ip)
+	}
+	return ReverseName(addr), nil
+}
+
+// ParseReverseName returns the address of a complete in-addr.arpa or
+// ip6.arpa name. The zone suffix compares case-insensitively.
+func ParseReverseName(name Name) (netip.Addr, error) {
+	labels := name.labels()
+	switch {
+	case name.IsSubdomainOf(MustParseName(ReverseZoneIPv4)):
+		if len(labels) != 6 {
+			return netip.Addr{}, fmt.Errorf("%s is not a complete %s name", name, ReverseZoneIPv4)
+		}
+		var ip [4]byte
+		for i, label := range labels[:4] {
+			octet, err := strconv.ParseUint(string(label.Data), 10, 8)
+			if err != nil || (len(label.Data) > 1 && label.Data[0] == '0') {
+				return netip.Addr{}, fmt.Errorf("invalid octet %q in %s", label.Data, name)
+			}
+			ip[3-i] = byte(octet)
+		}
+		return netip.AddrFrom4(ip), nil
+
+	case name.IsSubdomainOf(MustParseName(ReverseZoneIPv6)):
+		if len(labels) != 34 {
+			return netip.Addr{}, fmt.Errorf("%s is not a complete %s name", name, ReverseZoneIPv6)
+		}
+		var ip [16]byte
+		for i, label := range labels[:32] {
+			nibble, err := strconv.ParseUint(string(label.Data), 16, 4)
+			if err != nil || len(label.Data) != 1 {
+				return netip.Addr{}, fmt.Errorf("invalid nibble %q in %s", label.Data, name)
+			}
+			// Labels run from the least significant nibble up
+			ip[15-i/2] |= byte(nibble) << (4 * (i % 2))
+		}
+		return netip.AddrFrom16(ip), nil
+
+	default:
+		return netip.Addr{}, fmt.Errorf("%s is not a reverse mapping name", name)
+	}
+}
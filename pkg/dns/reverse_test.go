@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa"},
+		{"10.0.0.255", "255.0.0.10.in-addr.arpa"},
+		{"::ffff:192.0.2.1", "1.2.0.192.in-addr.arpa"},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+
+	for _, test := range tests {
+		addr := netip.MustParseAddr(test.addr)
+		name := ReverseName(addr)
+		if result := name.String(); result != test.expected {
+			t.Errorf("ReverseName(%s) = %q, want %q", test.addr, result, test.expected)
+		}
+
+		parsed, err := ParseReverseName(name)
+		if err != nil || parsed != addr.Unmap() {
+			t.Errorf("ParseReverseName(%q) = %v, %v, want %s", test.expected, parsed, err, addr.Unmap())
+		}
+	}
+}
+
+func TestReverseNameFromIP(t *testing.T) {
+	name, err := ReverseNameFromIP(net.ParseIP("192.0.2.1"))
+	if err != nil || name.String() != "1.2.0.192.in-addr.arpa" {
+		t.Errorf("ReverseNameFromIP() = %s, %v, want 1.2.0.192.in-addr.arpa", name, err)
+	}
+	if _, err := ReverseNameFromIP(net.IP{1, 2, 3}); err == nil {
+		t.Error("ReverseNameFromIP() should return error for an invalid address")
+	}
+}
+
+func TestParseReverseName(t *testing.T) {
+	parsed, err := ParseReverseName(MustParseName("1.2.0.192.IN-ADDR.ARPA."))
+	if err != nil || parsed != netip.MustParseAddr("192.0.2.1") {
+		t.Errorf("ParseReverseName() = %v, %v, want 192.0.2.1", parsed, err)
+	}
+
+	for _, name := range []string{
+		"2.0.192.in-addr.arpa",
+		"256.2.0.192.in-addr.arpa",
+		"01.2.0.192.in-addr.arpa",
+		"x.2.0.192.in-addr.arpa",
+		"1.0.ip6.arpa",
+		"g.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		"www.example.com",
+	} {
+		if _, err := ParseReverseName(MustParseName(name)); err == nil {
+			t.Errorf("ParseReverseName(%q) should return error", name)
+		}
+	}
+}
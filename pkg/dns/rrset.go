@@ -0,0 +1,191 @@
+package dns
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CanonicalData is implemented by resource data whose canonical form
+// differs from its wire form, such as types with embedded domain names
+// that RFC 4034 Section 6.2 lowercases
+type CanonicalData interface {
+	CanonicalBytes() []byte
+}
+
+// CanonicalRData returns the canonical wire form of resource data
+func CanonicalRData(rdata ResourceData) []byte {
+	if c, ok := rdata.(CanonicalData); ok {
+		return c.CanonicalBytes()
+	}
+	return rdata.Bytes()
+}
+
+// RRset is a set of resource records with the same owner name, type and
+// class (RFC 2181 Section 5)
+type RRset struct {
+	Name    Name
+	Type    QType
+	Class   QClass
+	Records []ResourceRecord
+}
+
+// NewRRset creates an RRset from records sharing owner name, type and class
+func NewRRset(records []ResourceRecord) (*RRset, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty RRset")
+	}
+	first := records[0]
+	rrset := &RRset{Name: first.Name, Type: first.Type, Class: first.Class}
+	for _, rr := range records {
+		if err := rrset.Add(rr); err != nil {
+			return nil, err
+		}
+	}
+	return rrset, nil
+}
+
+// GroupRRsets splits a section into RRsets in order of first appearance.
+// Owner names are compared case-insensitively. OPT pseudo-records are not
+// part of any RRset.
+func GroupRRsets(section []ResourceRecord) []*RRset {
+	var rrsets []*RRset
+	index := make(map[string]int)
+	for _, rr := range section {
+		if rr.Type == TypeOPT {
+			continue
+		}
+		key := fmt.Sprintf("%s/%d/%d", rr.Name.Key(), rr.Type, rr.Class)
+		if i, ok := index[key]; ok {
+			rrsets[i].Records = append(rrsets[i].Records, rr)
+			continue
+		}
+		index[key] = len(rrsets)
+		rrsets = append(rrsets, &RRset{Name: rr.Name, Type: rr.Type, Class: rr.Class, Records: []ResourceRecord{rr}})
+	}
+	return rrsets
+}
+
+// AnswerRRsets returns the answer section grouped into RRsets
+func (m *Message) AnswerRRsets() []*RRset {
+	return GroupRRsets(m.Answer)
+}
+
+// Add appends a record, which must match the owner name, type and class
+// of the RRset
+func (s *RRset) Add(rr ResourceRecord) error {
+	if rr.Type != s.Type || rr.Class != s.Class || !rr.Name.Equal(s.Name) {
+		return fmt.Errorf("record %s %s does not belong to RRset %s %s",
+			rr.Name, rr.Type, s.Name, s.Type)
+	}
+	s.Records = append(s.Records, rr)
+	return nil
+}
+
+// Len returns the number of records in the RRset
+func (s *RRset) Len() int {
+	return len(s.Records)
+}
+
+// TTL returns the lowest TTL of the records, which RFC 2181 Section 5.2
+// prescribes for the whole RRset
+func (s *RRset) TTL() int32 {
+	if len(s.Records) == 0 {
+		return 0
+	}
+	ttl := s.Records[0].TTL
+	for _, rr := range s.Records[1:] {
+		if rr.TTL < ttl {
+			ttl = rr.TTL
+		}
+	}
+	return ttl
+}
+
+// ConsistentTTL reports whether all records have the same TTL
+func (s *RRset) ConsistentTTL() bool {
+	for _, rr := range s.Records {
+		if rr.TTL != s.Records[0].TTL {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTTL sets the TTL of every record to the lowest TTL in the RRset
+func (s *RRset) NormalizeTTL() {
+	ttl := s.TTL()
+	for i := range s.Records {
+		s.Records[i].TTL = ttl
+	}
+}
+
+// Sort orders the records by their canonical resource data
+// (RFC 4034 Section 6.3)
+func (s *RRset) Sort() {
+	sort.SliceStable(s.Records, func(i, j int) bool {
+		return bytes.Compare(CanonicalRData(s.Records[i].RData), CanonicalRData(s.Records[j].RData)) < 0
+	})
+}
+
+// Dedupe sorts the records canonically and removes records with the same
+// canonical resource data, keeping the first of each
+func (s *RRset) Dedupe() {
+	s.Sort()
+	result := s.Records[:0]
+	var last []byte
+	for i, rr := range s.Records {
+		data := CanonicalRData(rr.RData)
+		if i > 0 && bytes.Equal(data, last) {
+			continue
+		}
+		result = append(result, rr)
+		last = data
+	}
+	s.Records = result
+}
+
+// Equal reports whether both RRsets have the same owner name, type, class
+// and set of resource data. Record order, duplicates and TTLs are ignored.
+func (s *RRset) Equal(other *RRset) bool {
+	if s.Type != other.Type || s.Class != other.Class || !s.Name.Equal(other.Name) {
+		return false
+	}
+	a, b := s.canonicalData(), other.canonicalData()
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalData returns the sorted, distinct canonical resource data
+func (s *RRset) canonicalData() [][]byte {
+	data := make([][]byte, 0, len(s.Records))
+	for _, rr := range s.Records {
+		data = append(data, CanonicalRData(rr.RData))
+	}
+	sort.Slice(data, func(i, j int) bool { return bytes.Compare(data[i], data[j]) < 0 })
+
+	result := data[:0]
+	for i, d := range data {
+		if i == 0 || !bytes.Equal(d, data[i-1]) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// String returns the records of the RRset, one per line
+func (s *RRset) String() string {
+	lines := make([]string, len(s.Records))
+	for i := range s.Records {
+		lines[i] = s.Records[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"bytes"
+	"testing"
+)
+
+// foldedData is resource data whose canonical form is lowercase
+type foldedData string
+
+func (f foldedData) Bytes() []byte          { return []byte(f) }
+func (f foldedData) String() string         { return string(f) }
+func (f foldedData) Type() QType            { return TypeNULL }
+func (f foldedData) CanonicalBytes() []byte { return bytes.ToLower([]byte(f)) }
+
+func testRecord(name string, qtype QType, ttl int32, data ResourceData) ResourceRecord {
+	return ResourceRecord{
+		Name:     MustParseName(name),
+		Type:     qtype,
+		Class:    ClassIN,
+		TTL:      ttl,
+		RDLength: uint16(len(data.Bytes())),
+		RData:    data,
+	}
+}
+
+func TestGroupRRsets(t *testing.T) {
+	section := []ResourceRecord{
+		testRecord("example.com", TypeA, 300, rawData{192, 0, 2, 1}),
+		testRecord("www.example.com", TypeA, 300, rawData{192, 0, 2, 2}),
+		testRecord("EXAMPLE.com", TypeA, 300, rawData{192, 0, 2, 3}),
+		testRecord("example.com", TypeNS, 300, rawData("ns")),
+		testRecord("", TypeOPT, 0, rawData{}),
+	}
+
+	rrsets := GroupRRsets(section)
+	if len(rrsets) != 3 {
+		t.Fatalf("GroupRRsets() returned %d RRsets, want 3", len(rrsets))
+	}
+	if rrsets[0].Len() != 2 || rrsets[0].Type != TypeA || LabelsToString(rrsets[0].Name) != "example.com" {
+		t.Errorf("first RRset = %v, want both example.com A records", rrsets[0])
+	}
+	if rrsets[1].Len() != 1 || LabelsToString(rrsets[1].Name) != "www.example.com" {
+		t.Errorf("second RRset = %v, want www.example.com A", rrsets[1])
+	}
+	if rrsets[2].Type != TypeNS {
+		t.Errorf("third RRset type = %v, want NS", rrsets[2].Type)
+	}
+
+	msg := &Message{Answer: section[:2]}
+	if answers := msg.AnswerRRsets(); len(answers) != 2 {
+		t.Errorf("AnswerRRsets() returned %d RRsets, want 2", len(answers))
+	}
+}
+
+func TestNewRRset(t *testing.T) {
+	rrset, err := NewRRset([]ResourceRecord{
+		testRecord("example.com", TypeA, 300, rawData{1}),
+		testRecord("Example.COM.", TypeA, 300, rawData{2}),
+	})
+	if err != nil {
+		t.Fatalf("NewRRset() returned error: %v", err)
+	}
+	if rrset.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", rrset.Len())
+	}
+
+	mismatched := [][]ResourceRecord{
+		nil,
+		{testRecord("example.com", TypeA, 300, rawData{1}), testRecord("example.org", TypeA, 300, rawData{2})},
+		{testRecord("example.com", TypeA, 300, rawData{1}), testRecord("example.com", TypeNS, 300, rawData{2})},
+	}
+	for _, records := range mismatched {
+		if _, err := NewRRset(records); err == nil {
+			t.Errorf("NewRRset(%v) should return error", records)
+		}
+	}
+}
+
+func TestRRsetTTL(t *testing.T) {
+	rrset, _ := NewRRset([]ResourceRecord{
+		testRecord("example.com", TypeA, 300, rawData{1}),
+		testRecord("example.com", TypeA, 60, rawData{2}),
+	})
+	if rrset.ConsistentTTL() {
+		t.Error("ConsistentTTL() = true for TTLs 300 and 60")
+	}
+	if ttl := rrset.TTL(); ttl != 60 {
+		t.Errorf("TTL() = %d, want 60", ttl)
+	}
+
+	rrset.NormalizeTTL()
+	if !rrset.ConsistentTTL() || rrset.Records[0].TTL != 60 {
+		t.Errorf("NormalizeTTL() left TTLs %d and %d", rrset.Records[0].TTL, rrset.Records[1].TTL)
+	}
+}
+
+func TestRRsetSortDedupe(t *testing.T) {
+	rrset, _ := NewRRset([]ResourceRecord{
+		testRecord("example.com", TypeNULL, 300, foldedData("b")),
+		testRecord("example.com", TypeNULL, 300, foldedData("C")),
+		testRecord("example.com", TypeNULL, 300, foldedData("a")),
+		testRecord("example.com", TypeNULL, 300, foldedData("B")),
+	})
+
+	// Canonical order compares lowercased data, so "C" sorts after "b"
+	rrset.Sort()
+	var order string
+	for _, rr := range rrset.Records {
+		order += rr.RData.String()
+	}
+	if order != "abBC" {
+		t.Errorf("Sort() order = %q, want %q", order, "abBC")
+	}
+
+	rrset.Dedupe()
+	if rrset.Len() != 3 || rrset.Records[1].RData.String() != "b" {
+		t.Errorf("Dedupe() left %v, want a, b and C", rrset.Records)
+	}
+}
+
+func TestRRsetEqual(t *testing.T) {
+	a, _ := NewRRset([]ResourceRecord{
+		testRecord("example.com", TypeNULL, 300, foldedData("x")),
+		testRecord("example.com", TypeNULL, 300, foldedData("y")),
+	})
+	b, _ := NewRRset([]ResourceRecord{
+		testRecord("EXAMPLE.com", TypeNULL, 60, foldedData("Y")),
+		testRecord("EXAMPLE.com", TypeNULL, 60, foldedData("x")),
+		testRecord("EXAMPLE.com", TypeNULL, 60, foldedData("x")),
+	})
+	if !a.Equal(b) {
+		t.Error("Equal() = false for RRsets differing only in case, order, duplicates and TTL")
+	}
+
+	c, _ := NewRRset([]ResourceRecord{testRecord("example.com", TypeNULL, 300, foldedData("x"))})
+	if a.Equal(c) {
+		t.Error("Equal() = true for RRsets with different data")
+	}
+	d, _ := NewRRset([]ResourceRecord{
+		testRecord("example.org", TypeNULL, 300, foldedData("x")),
+		testRecord("example.org", TypeNULL, 300, foldedData("y")),
+	})
+	if a.Equal(d) {
+		t.Error("Equal() = true for RRsets with different owners")
+	}
+}
@@ -1,6 +1,12 @@
 // Package dns provides DNS protocol types and constants according to RFC 1035
 package dns
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // QType represents DNS query types according to RFC 1035
 type QType uint16
 
@@ -26,6 +32,19 @@ const (
 	TypeMX    QType = 15 // Mail exchange
 	TypeTXT   QType = 16 // Text strings
 	TypeAAAA  QType = 28 // IPv6 address (RFC 3596)
+	TypeSRV   QType = 33 // Service location (RFC 2782)
+	TypeDNAME QType = 39 // Delegation name (RFC 6672)
+	TypeOPT   QType = 41 // EDNS(0) pseudo-record (RFC 6891)
+
+	// DNSSEC - See RFC 4034 and RFC 5155
+	TypeDS         QType = 43 // Delegation signer
+	TypeRRSIG      QType = 46 // RRset signature
+	TypeNSEC       QType = 47 // Next secure record
+	TypeDNSKEY     QType = 48 // DNS public key
+	TypeNSEC3      QType = 50 // Hashed next secure record
+	TypeNSEC3PARAM QType = 51 // NSEC3 parameters
+	TypeCDS        QType = 59 // Child DS (RFC 7344)
+	TypeCDNSKEY    QType = 60 // Child DNSKEY (RFC 7344)
 )
 
 // DNS Query Types (QType only) - See RFC 1035 Section 3.2.3
@@ -82,6 +101,28 @@ func (qt QType) String() string {
 		return "TXT"
 	case TypeAAAA:
 		return "AAAA"
+	case TypeSRV:
+		return "SRV"
+	case TypeDNAME:
+		return "DNAME"
+	case TypeOPT:
+		return "OPT"
+	case TypeDS:
+		return "DS"
+	case TypeRRSIG:
+		return "RRSIG"
+	case TypeNSEC:
+		return "NSEC"
+	case TypeDNSKEY:
+		return "DNSKEY"
+	case TypeNSEC3:
+		return "NSEC3"
+	case TypeNSEC3PARAM:
+		return "NSEC3PARAM"
+	case TypeCDS:
+		return "CDS"
+	case TypeCDNSKEY:
+		return "CDNSKEY"
 	case TypeAXFR:
 		return "AXFR"
 	case TypeMAILB:
@@ -95,6 +136,41 @@ func (qt QType) String() string {
 	}
 }
 
+// ParseQType returns the type with the given mnemonic, matched
+// case-insensitively. The generic TYPEnnn form of RFC 3597 Section 5 is
+// accepted for any type.
+func ParseQType(s string) (QType, error) {
+	upper := strings.ToUpper(s)
+	if number, ok := strings.CutPrefix(upper, "TYPE"); ok {
+		if n, err := strconv.ParseUint(number, 10, 16); err == nil {
+			return QType(n), nil
+		}
+	}
+	for qt := QType(1); qt <= TypeASTERISK; qt++ {
+		if name := qt.String(); name == upper && name != "UNKNOWN" {
+			return qt, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown record type %q", s)
+}
+
+// ParseQClass returns the class with the given mnemonic, matched
+// case-insensitively. The generic CLASSnnn form is accepted for any class.
+func ParseQClass(s string) (QClass, error) {
+	upper := strings.ToUpper(s)
+	if number, ok := strings.CutPrefix(upper, "CLASS"); ok {
+		if n, err := strconv.ParseUint(number, 10, 16); err == nil {
+			return QClass(n), nil
+		}
+	}
+	for _, qc := range []QClass{ClassIN, ClassCS, ClassCH, ClassHS, ClassASTERISK} {
+		if qc.String() == upper {
+			return qc, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown class %q", s)
+}
+
 // String returns the string representation of a QClass
 func (qc QClass) String() string {
 	switch qc {
@@ -125,13 +201,18 @@ const (
 	HeaderOpcodeQuery  HeaderBitfield = 0 << 11 // Standard query
 	HeaderOpcodeIQuery HeaderBitfield = 1 << 11 // Inverse query
 	HeaderOpcodeStatus HeaderBitfield = 2 << 11 // Server status request
+	HeaderOpcodeNotify HeaderBitfield = 4 << 11 // Zone change notification (RFC 1996)
+	HeaderOpcodeUpdate HeaderBitfield = 5 << 11 // Dynamic update (RFC 2136)
+	HeaderOpcodeMask   HeaderBitfield = 0xF << 11
 
 	// Flags
 	HeaderAA HeaderBitfield = 1 << 10 // Authoritative Answer
 	HeaderTC HeaderBitfield = 1 << 9  // Truncation
 	HeaderRD HeaderBitfield = 1 << 8  // Recursion Desired
 	HeaderRA HeaderBitfield = 1 << 7  // Recursion Available
-	HeaderZ  HeaderBitfield = 0 << 4  // Reserved (must be zero)
+	HeaderZ  HeaderBitfield = 1 << 6  // Reserved (must be zero)
+	HeaderAD HeaderBitfield = 1 << 5  // Authentic Data (RFC 4035)
+	HeaderCD HeaderBitfield = 1 << 4  // Checking Disabled (RFC 4035)
 
 	// RCODE - Response code
 	HeaderRcodeOK   HeaderBitfield = 0 // No error
@@ -140,4 +221,87 @@ const (
 	HeaderRcodeName HeaderBitfield = 3 // Name error
 	HeaderRcodeNImpl HeaderBitfield = 4 // Not implemented
 	HeaderRcodeRef  HeaderBitfield = 5 // Refused
+	HeaderRcodeMask HeaderBitfield = 0xF
+)
+
+// Opcode represents the kind of operation in a message header
+type Opcode uint8
+
+// DNS Opcodes - See RFC 1035 Section 4.1.1
+const (
+	OpcodeQuery  Opcode = 0 // Standard query
+	OpcodeIQuery Opcode = 1 // Inverse query (Obsolete - RFC 3425)
+	OpcodeStatus Opcode = 2 // Server status request
+	OpcodeNotify Opcode = 4 // Zone change notification (RFC 1996)
+	OpcodeUpdate Opcode = 5 // Dynamic update (RFC 2136)
 )
+
+// String returns the string representation of an Opcode
+func (op Opcode) String() string {
+	switch op {
+	case OpcodeQuery:
+		return "QUERY"
+	case OpcodeIQuery:
+		return "IQUERY"
+	case OpcodeStatus:
+		return "STATUS"
+	case OpcodeNotify:
+		return "NOTIFY"
+	case OpcodeUpdate:
+		return "UPDATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Rcode represents a response code. Values above 15 are only expressible
+// with the EDNS extended RCODE (RFC 6891 Section 6.1.3).
+type Rcode uint16
+
+// DNS Response codes - See RFC 1035 Section 4.1.1, RFC 2136 and RFC 6891
+const (
+	RcodeSuccess  Rcode = 0  // No error
+	RcodeFormErr  Rcode = 1  // Format error
+	RcodeServFail Rcode = 2  // Server failure
+	RcodeNXDomain Rcode = 3  // Non-existent domain
+	RcodeNotImp   Rcode = 4  // Not implemented
+	RcodeRefused  Rcode = 5  // Query refused
+	RcodeYXDomain Rcode = 6  // Name exists when it should not
+	RcodeYXRRSet  Rcode = 7  // RR set exists when it should not
+	RcodeNXRRSet  Rcode = 8  // RR set that should exist does not
+	RcodeNotAuth  Rcode = 9  // Server not authoritative for zone
+	RcodeNotZone  Rcode = 10 // Name not contained in zone
+	RcodeBadVers  Rcode = 16 // Bad OPT version
+)
+
+// String returns the string representation of an Rcode
+func (rc Rcode) String() string {
+	switch rc {
+	case RcodeSuccess:
+		return "NOERROR"
+	case RcodeFormErr:
+		return "FORMERR"
+	case RcodeServFail:
+		return "SERVFAIL"
+	case RcodeNXDomain:
+		return "NXDOMAIN"
+	case RcodeNotImp:
+		return "NOTIMP"
+	case RcodeRefused:
+		return "REFUSED"
+	case RcodeYXDomain:
+		return "YXDOMAIN"
+	case RcodeYXRRSet:
+		return "YXRRSET"
+	case RcodeNXRRSet:
+		return "NXRRSET"
+	case RcodeNotAuth:
+		return "NOTAUTH"
+	case RcodeNotZone:
+		return "NOTZONE"
+	case RcodeBadVers:
+		return "BADVERS"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -17,6 +17,8 @@ func TestQTypeString(t *testing.T) {
 		{TypeMX, "MX"},
 		{TypeTXT, "TXT"},
 		{TypeAAAA, "AAAA"},
+		{TypeSRV, "SRV"},
+		{TypeDNAME, "DNAME"},
 		{QType(999), "UNKNOWN"}, // Test unknown type
 	}
 
@@ -81,4 +83,54 @@ func TestHeaderBitfields(t *testing.T) {
 	if HeaderRD != (1 << 8) {
 		t.Errorf("HeaderRD = %d, want %d", HeaderRD, 1<<8)
 	}
+	if HeaderZ != (1 << 6) {
+		t.Errorf("HeaderZ = %d, want %d", HeaderZ, 1<<6)
+	}
+	if HeaderAD != (1<<5) || HeaderCD != (1<<4) {
+		t.Errorf("HeaderAD/HeaderCD = %d/%d, want %d/%d", HeaderAD, HeaderCD, 1<<5, 1<<4)
+	}
+}
+
+func TestParseQType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected QType
+	}{
+		{"A", TypeA},
+		{"aaaa", TypeAAAA},
+		{"NSEC3PARAM", TypeNSEC3PARAM},
+		{"CDNSKEY", TypeCDNSKEY},
+		{"dname", TypeDNAME},
+		{"TYPE65", QType(65)},
+		{"type1", TypeA},
+	}
+
+	for _, test := range tests {
+		result, err := ParseQType(test.input)
+		if err != nil {
+			t.Errorf("ParseQType(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("ParseQType(%q) = %v, want %v", test.input, result, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "UNKNOWN", "FOO", "TYPE", "TYPE70000"} {
+		if _, err := ParseQType(input); err == nil {
+			t.Errorf("ParseQType(%q) should return error", input)
+		}
+	}
+}
+
+func TestParseQClass(t *testing.T) {
+	if result, err := ParseQClass("in"); err != nil || result != ClassIN {
+		t.Errorf("ParseQClass(\"in\") = %v, %v, want IN", result, err)
+	}
+	if result, err := ParseQClass("CLASS3"); err != nil || result != ClassCH {
+		t.Errorf("ParseQClass(\"CLASS3\") = %v, %v, want CH", result, err)
+	}
+	if _, err := ParseQClass("XX"); err == nil {
+		t.Error("ParseQClass(\"XX\") should return error")
+	}
 }
@@ -0,0 +1,86 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// RootAnchors are the DS records of the root zone key signing keys
+// published by IANA: KSK-2017 and KSK-2024
+var RootAnchors = []string{
+	"20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	"38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// ParseTrustAnchors turns DS records of the root zone in presentation
+// format into trust anchors for a ChainValidator. Without any records the
+// IANA RootAnchors are used.
+func ParseTrustAnchors(ds []string) ([]dns.ResourceRecord, error) {
+	if len(ds) == 0 {
+		ds = RootAnchors
+	}
+
+	anchors := make([]dns.ResourceRecord, 0, len(ds))
+	for _, s := range ds {
+		record, err := records.NewDSRecordFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust anchor: %w", err)
+		}
+		anchors = append(anchors, dns.ResourceRecord{
+			Name:  dns.RootName(),
+			Type:  dns.TypeDS,
+			Class: dns.ClassIN,
+			RData: record,
+		})
+	}
+	return anchors, nil
+}
+
+// ComputeDS returns the DS record referring to the DNSKEY owned by owner
+// (RFC 4034 Section 5.1.4)
+func ComputeDS(owner dns.Name, key *records.DNSKEYRecord, digestType uint8) (*records.DSRecord, error) {
+	data := append(owner.Canonical().Bytes(), key.Bytes()...)
+
+	var digest []byte
+	switch digestType {
+	case records.DigestSHA1:
+		sum := sha1.Sum(data)
+		digest = sum[:]
+	case records.DigestSHA256:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case records.DigestSHA384:
+		sum := sha512.Sum384(data)
+		digest = sum[:]
+	default:
+		return nil, fmt.Errorf("unsupported DS digest type %d", digestType)
+	}
+
+	return records.NewDSRecord(key.KeyTag(), key.Algorithm, digestType, digest), nil
+}
+
+// matchesDS reports whether the DS record refers to the DNSKEY
+func matchesDS(ds *records.DSRecord, owner dns.Name, key *records.DNSKEYRecord) bool {
+	if ds.KeyTag != key.KeyTag() || ds.Algorithm != key.Algorithm {
+		return false
+	}
+	computed, err := ComputeDS(owner, key, ds.DigestType)
+	return err == nil && bytes.Equal(computed.Digest, ds.Digest)
+}
+
+// supportedDigest reports whether DS records of the digest type can be
+// checked. SHA-1 is accepted for validation only (RFC 8624 Section 3.3).
+func supportedDigest(digestType uint8) bool {
+	switch digestType {
+	case records.DigestSHA1, records.DigestSHA256, records.DigestSHA384:
+		return true
+	default:
+		return false
+	}
+}
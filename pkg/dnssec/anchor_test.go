@@ -0,0 +1,63 @@
+package dnssec
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// rootKSK2017 is the public key of the root zone KSK with key tag 20326
+const rootKSK2017 = "AwEAAaz/tAm8yTn4Mfeh5eyI96WSVexTBAvkMgJzkKTOiW1vkIbzxeF3+/4RgWOq7HrxRixHlFlExOLAJr5emLvN7SWXgnLh4+B5xQlNVz8Og8kvArMtNROxVQuCaSnIDdD5LKyWbRd2n9WGe2R8PzgCmr3EgVLrjyBxWezF0jLHwVN8efS3rCj/EWgvIWgb9tarpVUDK/b58Da+sqqls3eNbuv7pr+eoZG+SrDK6nWeL3c6H5Apxz7LjVc1uTIdsIXxuOLYA4/ilBmSVIzuDWfdRUfhHdY6+cn8HFRm+2hM8AnXGXws9555KrUB5qihylGa8subX2Nn6UwNR1AkUTV74bU="
+
+func TestComputeDS(t *testing.T) {
+	public, err := base64.StdEncoding.DecodeString(rootKSK2017)
+	if err != nil {
+		t.Fatalf("failed to decode root key: %v", err)
+	}
+	key := records.NewDNSKEYRecord(records.DNSKEYFlagZone|records.DNSKEYFlagSEP, records.AlgorithmRSASHA256, public)
+	root := dns.MustParseName("")
+
+	ds, err := ComputeDS(root, key, records.DigestSHA256)
+	if err != nil {
+		t.Fatalf("ComputeDS() returned error: %v", err)
+	}
+	if result := ds.String(); result != RootAnchors[0] {
+		t.Errorf("ComputeDS() = %q, want %q", result, RootAnchors[0])
+	}
+
+	anchors, err := ParseTrustAnchors(nil)
+	if err != nil {
+		t.Fatalf("ParseTrustAnchors() returned error: %v", err)
+	}
+	if !matchesDS(anchors[0].RData.(*records.DSRecord), root, key) {
+		t.Error("root KSK-2017 should match its trust anchor")
+	}
+	if matchesDS(anchors[1].RData.(*records.DSRecord), root, key) {
+		t.Error("root KSK-2017 should not match the KSK-2024 trust anchor")
+	}
+
+	if _, err := ComputeDS(root, key, 3); err == nil {
+		t.Error("ComputeDS() should return error for unsupported digest type")
+	}
+}
+
+func TestParseTrustAnchors(t *testing.T) {
+	anchors, err := ParseTrustAnchors(nil)
+	if err != nil {
+		t.Fatalf("ParseTrustAnchors() returned error: %v", err)
+	}
+	if len(anchors) != len(RootAnchors) {
+		t.Fatalf("ParseTrustAnchors() returned %d anchors, want %d", len(anchors), len(RootAnchors))
+	}
+	for _, anchor := range anchors {
+		if anchor.Type != dns.TypeDS || dns.LabelsToString(anchor.Name) != "" {
+			t.Errorf("trust anchor %v should be a DS record of the root", anchor)
+		}
+	}
+
+	if _, err := ParseTrustAnchors([]string{"20326 8 2 not-hex"}); err == nil {
+		t.Error("ParseTrustAnchors() should return error for invalid DS record")
+	}
+}
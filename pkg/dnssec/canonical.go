@@ -0,0 +1,60 @@
+// Package dnssec implements DNSSEC signature verification according to
+// RFC 4033, RFC 4034 and RFC 4035
+package dnssec
+
+import (
+	"encoding/binary"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// canonicalRRset returns the canonical wire form of each record of the
+// RRset as signed by sig: lowercased owner name (expanded back to the
+// wildcard owner if needed), the original TTL and canonical RDATA, sorted
+// by RDATA with duplicates removed (RFC 4034 Section 6.3)
+func canonicalRRset(rrset []dns.ResourceRecord, sig *records.RRSIGRecord) [][]byte {
+	owner := rrset[0].Name.Canonical()
+	if labelCount(owner) > int(sig.Labels) {
+		// Wildcard expansion: rebuild "*.<closest encloser>"
+		keep := owner[len(owner)-int(sig.Labels)-1:]
+		owner = append(dns.Name{{Length: 1, Data: []byte("*")}}, keep...)
+	}
+	prefix := owner.Bytes()
+	prefix = binary.BigEndian.AppendUint16(prefix, uint16(rrset[0].Type))
+	prefix = binary.BigEndian.AppendUint16(prefix, uint16(rrset[0].Class))
+	prefix = binary.BigEndian.AppendUint32(prefix, sig.OriginalTTL)
+
+	sorted := &dns.RRset{Records: append([]dns.ResourceRecord(nil), rrset...)}
+	sorted.Dedupe()
+
+	result := make([][]byte, 0, sorted.Len())
+	for _, rr := range sorted.Records {
+		rdata := dns.CanonicalRData(rr.RData)
+		wire := append([]byte(nil), prefix...)
+		wire = binary.BigEndian.AppendUint16(wire, uint16(len(rdata)))
+		result = append(result, append(wire, rdata...))
+	}
+	return result
+}
+
+// signedData returns the data covered by an RRSIG over the RRset
+// (RFC 4034 Section 3.1.8.1)
+func signedData(rrset []dns.ResourceRecord, sig *records.RRSIGRecord) []byte {
+	canonical := *sig
+	canonical.SignerName = sig.SignerName.Canonical()
+	data := canonical.SignedBytes()
+	for _, rr := range canonicalRRset(rrset, sig) {
+		data = append(data, rr...)
+	}
+	return data
+}
+
+// labelCount returns the number of labels of a name for the RRSIG Labels
+// field: the root and a leading wildcard label are not counted
+func labelCount(name dns.Name) int {
+	if name.IsWildcard() {
+		return name.LabelCount() - 1
+	}
+	return name.LabelCount()
+}
@@ -0,0 +1,65 @@
+package dnssec
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestCanonicalRRset(t *testing.T) {
+	rrset := testRRset(t, "WWW.Example.com", "192.0.2.9", "192.0.2.1", "192.0.2.9")
+	sig := &records.RRSIGRecord{TypeCovered: dns.TypeA, Labels: 3, OriginalTTL: 600}
+
+	result := canonicalRRset(rrset, sig)
+	if len(result) != 2 {
+		t.Fatalf("canonicalRRset() returned %d records, want 2 after removing duplicates", len(result))
+	}
+
+	owner := []byte("\x03www\x07example\x03com\x00")
+	for _, rr := range result {
+		if !bytes.HasPrefix(rr, owner) {
+			t.Errorf("canonical record %q does not start with lowercased owner", rr)
+		}
+		// type (2) + class (2) => TTL follows the owner name
+		if ttl := rr[len(owner)+4 : len(owner)+8]; !bytes.Equal(ttl, []byte{0, 0, 2, 0x58}) {
+			t.Errorf("canonical record TTL = % 02x, want original TTL 600", ttl)
+		}
+	}
+	if result[0][len(result[0])-1] != 1 || result[1][len(result[1])-1] != 9 {
+		t.Error("canonicalRRset() records are not sorted by RDATA")
+	}
+}
+
+func TestCanonicalRData(t *testing.T) {
+	ns := records.NewNSRecord(dns.MustParseName("NS1.Example.COM"))
+	expected := []byte("\x03ns1\x07example\x03com\x00")
+	if result := dns.CanonicalRData(ns); !bytes.Equal(result, expected) {
+		t.Errorf("CanonicalRData(NS) = %q, want %q", result, expected)
+	}
+
+	// NSEC next domain names keep their case (RFC 6840 Section 5.1)
+	nsec := records.NewNSECRecord(dns.MustParseName("B.example.com"), nil)
+	if result := dns.CanonicalRData(nsec); !bytes.Equal(result, nsec.Bytes()) {
+		t.Errorf("CanonicalRData(NSEC) = %q, want %q", result, nsec.Bytes())
+	}
+}
+
+func TestLabelCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"", 0},
+		{"com", 1},
+		{"www.example.com", 3},
+		{"*.example.com", 2},
+	}
+
+	for _, test := range tests {
+		if result := labelCount(dns.MustParseName(test.name)); result != test.expected {
+			t.Errorf("labelCount(%q) = %d, want %d", test.name, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package dnssec
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Exchanger sends a query and returns the response. *client.Client
+// implements it.
+type Exchanger interface {
+	Exchange(msg *dns.Message) (*dns.Message, error)
+}
+
+// ChainValidator validates responses by building the chain of trust from
+// a trust anchor through the DS and DNSKEY RRsets of every zone down to the
+// signer of the data (RFC 4035 Section 5). The DS and DNSKEY lookups go
+// through the exchanger, which must reach a recursive resolver. Zone keys
+// are looked up once per validator; a ChainValidator is not safe for
+// concurrent use.
+type ChainValidator struct {
+	exchanger Exchanger
+	anchors   []dns.ResourceRecord
+	now       func() time.Time
+	zones     map[string]*trust // Keys by zone name
+	unsigned  map[string]*trust // Status of unsigned data by owner name
+}
+
+// trust is the validation outcome of a zone's keys or of unsigned data
+type trust struct {
+	status Status
+	keys   []dns.ResourceRecord
+	err    error
+}
+
+// NewChainValidator creates a validator that sends its DS and DNSKEY
+// queries through exchanger and trusts the given DS or DNSKEY records,
+// usually obtained from ParseTrustAnchors
+func NewChainValidator(exchanger Exchanger, anchors []dns.ResourceRecord) *ChainValidator {
+	return &ChainValidator{
+		exchanger: exchanger,
+		anchors:   anchors,
+		now:       time.Now,
+		zones:     make(map[string]*trust),
+		unsigned:  make(map[string]*trust),
+	}
+}
+
+// SetClock replaces the clock used for signature validity checks
+func (c *ChainValidator) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Validate checks every RRset in the answer and authority sections of the
+// response and, for NXDOMAIN and NODATA responses, the proof of
+// non-existence of the question. The denial is reported as an extra
+// RRsetResult for the question name and type. Answers synthesized from a
+// wildcard also need a proof that their owner name does not exist.
+func (c *ChainValidator) Validate(msg *dns.Message) *Result {
+	result := &Result{Status: Indeterminate}
+
+	var denialRecords []dns.ResourceRecord
+	var expanded map[int]int // RRsetResult index to wildcard RRSIG labels
+	denialStatus := Indeterminate
+	for i, section := range [][]dns.ResourceRecord{msg.Answer, msg.Authority} {
+		for _, rrset := range groupRRsets(section) {
+			status, sig, err := c.verifyRRset(rrset.Records, coveringSignatures(section, rrset.Records[0]))
+			if i == 0 && sig != nil && int(sig.Labels) < labelCount(rrset.Name) {
+				if expanded == nil {
+					expanded = make(map[int]int)
+				}
+				expanded[len(result.RRsets)] = int(sig.Labels)
+			}
+			result.RRsets = append(result.RRsets, RRsetResult{
+				Name:   rrset.Name,
+				Type:   rrset.Type,
+				Status: status,
+				Err:    err,
+			})
+
+			// The authority section of a negative response carries the
+			// SOA and the NSEC or NSEC3 records of the denial; the worst
+			// status among them (Secure < Insecure < Bogus) decides
+			if i == 1 && status > denialStatus {
+				denialStatus = status
+			}
+			if i == 1 && status == Secure && (rrset.Type == dns.TypeNSEC || rrset.Type == dns.TypeNSEC3) {
+				denialRecords = append(denialRecords, rrset.Records...)
+			}
+		}
+	}
+
+	for i, labels := range expanded {
+		r := &result.RRsets[i]
+		if status, err := proveWildcardExpansion(r.Name, labels, denialRecords); status != Secure {
+			r.Status, r.Err = status, err
+		}
+	}
+
+	if len(msg.Question) == 1 && isNegative(msg) {
+		q := msg.Question[0]
+		status, err := denialStatus, error(nil)
+		switch denialStatus {
+		case Secure, Indeterminate:
+			status, err = proveDenial(q.Name, q.Type, msg.Rcode() == dns.RcodeNXDomain, denialRecords)
+		case Insecure:
+			err = fmt.Errorf("unsigned negative response")
+		case Bogus:
+			err = fmt.Errorf("%w: authority records are bogus", ErrNoDenial)
+		}
+		result.RRsets = append(result.RRsets, RRsetResult{Name: q.Name, Type: q.Type, Status: status, Err: err})
+	}
+
+	result.Status = aggregate(result.RRsets)
+	return result
+}
+
+// isNegative reports whether the response is an NXDOMAIN or NODATA answer
+func isNegative(msg *dns.Message) bool {
+	switch msg.Rcode() {
+	case dns.RcodeNXDomain:
+		return true
+	case dns.RcodeSuccess:
+		return len(msg.Answer) == 0
+	default:
+		return false
+	}
+}
+
+// verifyRRset checks the signatures of an RRset with the keys of its
+// signer, or decides whether unsigned data is expected. The signature
+// that verified is returned with Secure.
+func (c *ChainValidator) verifyRRset(rrset, sigs []dns.ResourceRecord) (Status, *records.RRSIGRecord, error) {
+	if len(sigs) == 0 {
+		t := c.unsignedStatus(rrset[0].Name)
+		return t.status, nil, t.err
+	}
+
+	var signer dns.Name
+	for _, sigRR := range sigs {
+		if sig, ok := sigRR.RData.(*records.RRSIGRecord); ok && SupportedAlgorithm(sig.Algorithm) {
+			signer = sig.SignerName
+			break
+		}
+	}
+	if signer == nil {
+		return Insecure, nil, ErrUnsupportedAlgorithm
+	}
+
+	t := c.zoneKeys(signer)
+	if t.status != Secure {
+		return t.status, nil, t.err
+	}
+	v := NewValidator(t.keys)
+	v.SetClock(c.now)
+	return v.verify(rrset, sigs)
+}
+
+// zoneKeys returns the validated DNSKEY RRset of a zone. While a zone is
+// being looked up it is recorded as bogus, which breaks referral loops.
+func (c *ChainValidator) zoneKeys(zone dns.Name) *trust {
+	key := zone.Key()
+	if t, ok := c.zones[key]; ok {
+		return t
+	}
+	c.zones[key] = &trust{status: Bogus, err: fmt.Errorf("validation loop at %s", dns.LabelsToString(zone))}
+	t := c.lookupZoneKeys(zone)
+	c.zones[key] = t
+	return t
+}
+
+// lookupZoneKeys authenticates the DNSKEY RRset of a zone against its
+// trust anchor or its DS RRset in the parent zone
+func (c *ChainValidator) lookupZoneKeys(zone dns.Name) *trust {
+	var dsSet, anchorKeys []dns.ResourceRecord
+	for _, anchor := range c.anchors {
+		if !anchor.Name.Equal(zone) {
+			continue
+		}
+		if anchor.Type == dns.TypeDNSKEY {
+			anchorKeys = append(anchorKeys, anchor)
+		} else {
+			dsSet = append(dsSet, anchor)
+		}
+	}
+
+	if dsSet == nil && anchorKeys == nil {
+		if !c.underAnchor(zone) {
+			return &trust{status: Indeterminate, err: fmt.Errorf("no trust anchor for %s", dns.LabelsToString(zone))}
+		}
+
+		resp, err := c.query(zone, dns.TypeDS)
+		if err != nil {
+			return &trust{status: Bogus, err: err}
+		}
+		result := c.Validate(resp)
+		dsSet = recordsOf(resp.Answer, zone, dns.TypeDS)
+		if len(dsSet) == 0 {
+			if result.Status == Secure {
+				return &trust{status: Insecure, err: fmt.Errorf("no DS records for %s", dns.LabelsToString(zone))}
+			}
+			return &trust{status: result.Status, err: result.Err()}
+		}
+		if result.Status != Secure {
+			return &trust{status: result.Status, err: fmt.Errorf("DS records for %s: %w", dns.LabelsToString(zone), result.Err())}
+		}
+	}
+
+	resp, err := c.query(zone, dns.TypeDNSKEY)
+	if err != nil {
+		return &trust{status: Bogus, err: err}
+	}
+	keys := recordsOf(resp.Answer, zone, dns.TypeDNSKEY)
+	if len(keys) == 0 {
+		return &trust{status: Bogus, err: fmt.Errorf("no DNSKEY records for %s", dns.LabelsToString(zone))}
+	}
+
+	// Entry points are the keys referenced by a usable DS record or
+	// configured as anchors. DS records with only unsupported algorithms
+	// leave the zone insecure (RFC 4035 Section 5.2).
+	var entry []dns.ResourceRecord
+	usable := len(anchorKeys) > 0
+	for _, keyRR := range keys {
+		// DNSKEY records with malformed RDATA are parsed as generic records
+		key, ok := keyRR.RData.(*records.DNSKEYRecord)
+		if !ok {
+			continue
+		}
+		for _, anchor := range anchorKeys {
+			if bytes.Equal(anchor.RData.Bytes(), key.Bytes()) {
+				entry = append(entry, keyRR)
+			}
+		}
+		for _, dsRR := range dsSet {
+			ds, ok := dsRR.RData.(*records.DSRecord)
+			if !ok || !supportedDigest(ds.DigestType) || !SupportedAlgorithm(ds.Algorithm) {
+				continue
+			}
+			usable = true
+			if matchesDS(ds, zone, key) {
+				entry = append(entry, keyRR)
+			}
+		}
+	}
+	if !usable {
+		return &trust{status: Insecure, err: fmt.Errorf("%w: no supported DS records for %s", ErrUnsupportedAlgorithm, dns.LabelsToString(zone))}
+	}
+	if len(entry) == 0 {
+		return &trust{status: Bogus, err: fmt.Errorf("%w: no DNSKEY of %s matches its DS records", ErrKeyMismatch, dns.LabelsToString(zone))}
+	}
+
+	v := NewValidator(entry)
+	v.SetClock(c.now)
+	if status, err := v.VerifyRRset(keys, coveringSignatures(resp.Answer, keys[0])); status != Secure {
+		return &trust{status: Bogus, err: fmt.Errorf("DNSKEY records for %s: %w", dns.LabelsToString(zone), err)}
+	}
+	return &trust{status: Secure, keys: keys}
+}
+
+// unsignedStatus decides whether unsigned data at name is acceptable. It
+// is insecure only below a delegation that is provably unsigned; walking
+// up from name, the first signed DS response must prove the absence of a
+// DS RRset at a delegation point.
+func (c *ChainValidator) unsignedStatus(name dns.Name) *trust {
+	key := name.Key()
+	if t, ok := c.unsigned[key]; ok {
+		return t
+	}
+	c.unsigned[key] = &trust{status: Bogus, err: fmt.Errorf("validation loop at %s", dns.LabelsToString(name))}
+	t := c.lookupUnsigned(name)
+	c.unsigned[key] = t
+	return t
+}
+
+// lookupUnsigned searches the insecure delegation above unsigned data
+func (c *ChainValidator) lookupUnsigned(name dns.Name) *trust {
+	for candidate := name; ; candidate = candidate.Parent() {
+		if !c.underAnchor(candidate) {
+			return &trust{status: Indeterminate, err: fmt.Errorf("no trust anchor for %s", dns.LabelsToString(name))}
+		}
+		if c.isAnchor(candidate) {
+			break
+		}
+
+		resp, err := c.query(candidate, dns.TypeDS)
+		if err != nil {
+			return &trust{status: Bogus, err: err}
+		}
+		if !hasSignatures(resp) {
+			// Answered from inside the unsigned zone; keep walking up
+			continue
+		}
+
+		result := c.Validate(resp)
+		if result.Status != Secure {
+			return &trust{status: result.Status, err: result.Err()}
+		}
+		if len(recordsOf(resp.Answer, candidate, dns.TypeDS)) == 0 && delegationWithoutDS(candidate, resp.Authority) {
+			return &trust{status: Insecure, err: fmt.Errorf("insecure delegation at %s", dns.LabelsToString(candidate))}
+		}
+		break
+	}
+	return &trust{status: Bogus, err: fmt.Errorf("unsigned data at %s in a signed zone", dns.LabelsToString(name))}
+}
+
+// query sends a DNSSEC query for name and type with checking disabled, so
+// that the resolver passes on data it considers bogus
+func (c *ChainValidator) query(name dns.Name, qtype dns.QType) (*dns.Message, error) {
+	msg, err := dns.NewQuery(dns.LabelsToString(name), qtype).
+		WithRD().WithCD().WithEDNS(dns.DefaultEDNSSize).WithDO().Build()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.exchanger.Exchange(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s %s: %w", dns.LabelsToString(name), qtype, err)
+	}
+	if rc := resp.Rcode(); rc != dns.RcodeSuccess && rc != dns.RcodeNXDomain {
+		return nil, fmt.Errorf("query for %s %s returned %s", dns.LabelsToString(name), qtype, rc)
+	}
+	return resp, nil
+}
+
+// isAnchor reports whether a trust anchor is configured for the name
+func (c *ChainValidator) isAnchor(name dns.Name) bool {
+	for _, anchor := range c.anchors {
+		if anchor.Name.Equal(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// underAnchor reports whether the name is at or below a trust anchor
+func (c *ChainValidator) underAnchor(name dns.Name) bool {
+	for _, anchor := range c.anchors {
+		if name.IsSubdomainOf(anchor.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordsOf returns the records of the section with the owner and type
+func recordsOf(section []dns.ResourceRecord, owner dns.Name, rrType dns.QType) []dns.ResourceRecord {
+	var result []dns.ResourceRecord
+	for _, rr := range section {
+		if rr.Type == rrType && rr.Name.Equal(owner) {
+			result = append(result, rr)
+		}
+	}
+	return result
+}
+
+// hasSignatures reports whether the response carries any RRSIG records
+func hasSignatures(msg *dns.Message) bool {
+	for _, section := range [][]dns.ResourceRecord{msg.Answer, msg.Authority} {
+		for _, rr := range section {
+			if rr.Type == dns.TypeRRSIG {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package dnssec
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// testSignedZone is a zone with a single key signing all its data
+type testSignedZone struct {
+	name   string
+	key    dns.ResourceRecord
+	signer crypto.Signer
+}
+
+// sign returns the RRset followed by its signature
+func (z *testSignedZone) sign(t *testing.T, rrset ...dns.ResourceRecord) []dns.ResourceRecord {
+	t.Helper()
+	sig, err := SignRRset(rrset, z.key.Name, z.key.RData.(*records.DNSKEYRecord), z.signer, testInception, testExpiration)
+	if err != nil {
+		t.Fatalf("SignRRset() returned error: %v", err)
+	}
+	return append(append([]dns.ResourceRecord{}, rrset...), sig)
+}
+
+// ds returns the DS record of the zone key
+func (z *testSignedZone) ds(t *testing.T) dns.ResourceRecord {
+	t.Helper()
+	ds, err := ComputeDS(z.key.Name, z.key.RData.(*records.DNSKEYRecord), records.DigestSHA256)
+	if err != nil {
+		t.Fatalf("ComputeDS() returned error: %v", err)
+	}
+	return dns.ResourceRecord{Name: z.key.Name, Type: dns.TypeDS, Class: dns.ClassIN, TTL: 3600, RData: ds}
+}
+
+// soa returns the SOA record of the zone
+func (z *testSignedZone) soa() dns.ResourceRecord {
+	return dns.ResourceRecord{
+		Name:  dns.MustParseName(z.name),
+		Type:  dns.TypeSOA,
+		Class: dns.ClassIN,
+		TTL:   3600,
+		RData: records.NewSOARecord(dns.MustParseName("ns."+z.name), dns.MustParseName("admin."+z.name), 1, 7200, 900, 86400, 300),
+	}
+}
+
+// testResponse holds the sections served for a query
+type testResponse struct {
+	rcode     dns.Rcode
+	answer    []dns.ResourceRecord
+	authority []dns.ResourceRecord
+}
+
+// fakeResolver answers queries from a fixed table keyed by "name. TYPE"
+type fakeResolver struct {
+	responses map[string]testResponse
+	queries   []string
+}
+
+func (r *fakeResolver) Exchange(msg *dns.Message) (*dns.Message, error) {
+	q := msg.Question[0]
+	key := strings.ToLower(dns.LabelsToString(q.Name)) + ". " + q.Type.String()
+	r.queries = append(r.queries, key)
+
+	resp, ok := r.responses[key]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query %s", key)
+	}
+	return dns.NewResponse(msg).WithRcode(resp.rcode).Answer(resp.answer...).Authority(resp.authority...).Build()
+}
+
+// testHierarchy builds a signed root, com and example.com, an unsigned
+// delegation insecure.com, and returns a resolver serving them together
+// with the root trust anchor
+func testHierarchy(t *testing.T) (*fakeResolver, []dns.ResourceRecord, *testSignedZone) {
+	t.Helper()
+
+	root := &testSignedZone{name: ""}
+	root.key, root.signer = testKey(t, "", records.AlgorithmECDSAP256SHA256)
+	com := &testSignedZone{name: "com"}
+	com.key, com.signer = testKey(t, "com", records.AlgorithmED25519)
+	example := &testSignedZone{name: "example.com"}
+	example.key, example.signer = testKey(t, "example.com", records.AlgorithmED25519)
+
+	nsecSub := nsecRR("insecure.com", "zzz.com", dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC)
+	nsecWWW := nsecRR("www.example.com", "example.com", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)
+
+	resolver := &fakeResolver{responses: map[string]testResponse{
+		". DNSKEY":            {answer: root.sign(t, root.key)},
+		"com. DS":             {answer: root.sign(t, com.ds(t))},
+		"com. DNSKEY":         {answer: com.sign(t, com.key)},
+		"example.com. DS":     {answer: com.sign(t, example.ds(t))},
+		"example.com. DNSKEY": {answer: example.sign(t, example.key)},
+		"insecure.com. DS": {authority: append(com.sign(t, com.soa()),
+			com.sign(t, nsecSub)...)},
+		"www.insecure.com. DS": {authority: []dns.ResourceRecord{
+			(&testSignedZone{name: "insecure.com"}).soa(),
+		}},
+		"www.example.com. DS": {authority: append(example.sign(t, example.soa()),
+			example.sign(t, nsecWWW)...)},
+	}}
+
+	anchor := root.ds(t)
+	return resolver, []dns.ResourceRecord{anchor}, example
+}
+
+func TestChainValidator(t *testing.T) {
+	resolver, anchors, example := testHierarchy(t)
+
+	www := testRRset(t, "www.example.com", "192.0.2.1")
+	signedWWW := example.sign(t, www...)
+	forged := append(testRRset(t, "www.example.com", "192.0.2.66"), signedWWW[1])
+
+	nxdomainProof := append(example.sign(t, example.soa()),
+		example.sign(t, nsecRR("example.com", "www.example.com", dns.TypeSOA, dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY))...)
+	nodataProof := append(example.sign(t, example.soa()),
+		example.sign(t, nsecRR("www.example.com", "example.com", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))...)
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     dns.QType
+		rcode     dns.Rcode
+		answer    []dns.ResourceRecord
+		authority []dns.ResourceRecord
+		expected  Status
+	}{
+		{"signed answer", "www.example.com", dns.TypeA, dns.RcodeSuccess, signedWWW, nil, Secure},
+		{"forged answer", "www.example.com", dns.TypeA, dns.RcodeSuccess, forged, nil, Bogus},
+		{"stripped signature", "www.example.com", dns.TypeA, dns.RcodeSuccess, www, nil, Bogus},
+		{"insecure delegation", "www.insecure.com", dns.TypeA, dns.RcodeSuccess, testRRset(t, "www.insecure.com", "192.0.2.2"), nil, Insecure},
+		{"nxdomain", "missing.example.com", dns.TypeA, dns.RcodeNXDomain, nil, nxdomainProof, Secure},
+		{"nodata", "www.example.com", dns.TypeAAAA, dns.RcodeSuccess, nil, nodataProof, Secure},
+		{"nxdomain with nodata proof", "missing.example.com", dns.TypeA, dns.RcodeNXDomain, nil, nodataProof, Bogus},
+		{"missing proof", "missing.example.com", dns.TypeA, dns.RcodeNXDomain, nil, example.sign(t, example.soa()), Bogus},
+		// The signed NSEC of com for the delegation only proves that there
+		// is no DS RRset
+		{"replayed delegation NSEC", "insecure.com", dns.TypeA, dns.RcodeSuccess, nil, resolver.responses["insecure.com. DS"].authority, Bogus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewChainValidator(resolver, anchors)
+			validator.SetClock(func() time.Time { return testNow })
+
+			msg, err := dns.NewQuery(test.qname, test.qtype).WithRcode(test.rcode).
+				Answer(test.answer...).Authority(test.authority...).Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			result := validator.Validate(msg)
+			if result.Status != test.expected {
+				t.Errorf("Validate() status = %v, want %v (error: %v)", result.Status, test.expected, result.Err())
+			}
+		})
+	}
+}
+
+func TestChainValidatorWrongAnchor(t *testing.T) {
+	resolver, _, example := testHierarchy(t)
+
+	other, _ := testKey(t, "", records.AlgorithmED25519)
+	validator := NewChainValidator(resolver, []dns.ResourceRecord{other})
+	validator.SetClock(func() time.Time { return testNow })
+
+	msg, err := dns.NewQuery("www.example.com", dns.TypeA).
+		Answer(example.sign(t, testRRset(t, "www.example.com", "192.0.2.1")...)...).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	result := validator.Validate(msg)
+	if result.Status != Bogus || result.Err() == nil {
+		t.Errorf("Validate() = %v (%v), want BOGUS with error", result.Status, result.Err())
+	}
+}
+
+func TestChainValidatorCachesZoneKeys(t *testing.T) {
+	resolver, anchors, example := testHierarchy(t)
+	validator := NewChainValidator(resolver, anchors)
+	validator.SetClock(func() time.Time { return testNow })
+
+	msg, err := dns.NewQuery("www.example.com", dns.TypeA).
+		Answer(example.sign(t, testRRset(t, "www.example.com", "192.0.2.1")...)...).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	validator.Validate(msg)
+	queries := len(resolver.queries)
+	if result := validator.Validate(msg); result.Status != Secure {
+		t.Fatalf("Validate() status = %v, want SECURE (error: %v)", result.Status, result.Err())
+	}
+	if len(resolver.queries) != queries {
+		t.Errorf("second Validate() sent %d queries, want 0", len(resolver.queries)-queries)
+	}
+}
+
+func TestChainValidatorMalformedKey(t *testing.T) {
+	resolver, anchors, example := testHierarchy(t)
+
+	// A DNSKEY with malformed RDATA reaches the validator as a generic record
+	malformed := dns.ResourceRecord{
+		Name:  example.key.Name,
+		Type:  dns.TypeDNSKEY,
+		Class: dns.ClassIN,
+		TTL:   3600,
+		RData: records.NewGenericRecord(dns.TypeDNSKEY, []byte{0x01}),
+	}
+	resolver.responses["example.com. DNSKEY"] = testResponse{answer: example.sign(t, example.key, malformed)}
+
+	validator := NewChainValidator(resolver, anchors)
+	validator.SetClock(func() time.Time { return testNow })
+	msg, err := dns.NewQuery("www.example.com", dns.TypeA).
+		Answer(example.sign(t, testRRset(t, "www.example.com", "192.0.2.1")...)...).Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	if result := validator.Validate(msg); result.Status != Secure {
+		t.Errorf("Validate() status = %v, want SECURE (error: %v)", result.Status, result.Err())
+	}
+}
+
+func TestChainValidatorWildcardExpansion(t *testing.T) {
+	resolver, anchors, example := testHierarchy(t)
+
+	// An answer for www.example.com synthesized from *.example.com keeps
+	// the signature of the wildcard, with one label less than its owner
+	expanded := example.sign(t, testRRset(t, "*.example.com", "192.0.2.1")...)
+	for i := range expanded {
+		expanded[i].Name = dns.MustParseName("www.example.com")
+	}
+	noName := example.sign(t, nsecRR("example.com", "zzz.example.com", dns.TypeSOA, dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY))
+
+	tests := []struct {
+		name      string
+		authority []dns.ResourceRecord
+		expected  Status
+	}{
+		{"with proof", noName, Secure},
+		{"without proof", nil, Bogus},
+		{"proof not covering the name", example.sign(t, nsecRR("example.com", "mail.example.com", dns.TypeSOA, dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY)), Bogus},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := NewChainValidator(resolver, anchors)
+			validator.SetClock(func() time.Time { return testNow })
+
+			msg, err := dns.NewQuery("www.example.com", dns.TypeA).Answer(expanded...).Authority(test.authority...).Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			if result := validator.Validate(msg); result.Status != test.expected {
+				t.Errorf("Validate() status = %v, want %v (error: %v)", result.Status, test.expected, result.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// MaxNSEC3Iterations is the highest NSEC3 iteration count accepted. Denial
+// proofs using more iterations are treated as insecure (RFC 9276 Section 3.2).
+const MaxNSEC3Iterations = 150
+
+// ErrNoDenial is returned when a negative response lacks a valid proof of
+// non-existence
+var ErrNoDenial = errors.New("missing proof of non-existence")
+
+// NSEC3Hash returns the SHA-1 NSEC3 hash of a name (RFC 5155 Section 5)
+func NSEC3Hash(name dns.Name, iterations uint16, salt []byte) []byte {
+	h := sha1.New()
+	h.Write(name.Canonical().Bytes())
+	h.Write(salt)
+	digest := h.Sum(nil)
+	for i := uint16(0); i < iterations; i++ {
+		h.Reset()
+		h.Write(digest)
+		h.Write(salt)
+		digest = h.Sum(digest[:0])
+	}
+	return digest
+}
+
+// nsecRecord is an NSEC record together with its owner name
+type nsecRecord struct {
+	owner dns.Name
+	nsec  *records.NSECRecord
+}
+
+// nsec3Record is an NSEC3 record with its decoded owner hash and zone
+type nsec3Record struct {
+	hash  []byte
+	zone  dns.Name
+	nsec3 *records.NSEC3Record
+}
+
+// proveDenial checks that the NSEC or NSEC3 records in the authority
+// section prove that qname does not exist (nxdomain) or has no data of
+// qtype. Opt-out NSEC3 proofs and unsupported NSEC3 parameters yield
+// Insecure.
+func proveDenial(qname dns.Name, qtype dns.QType, nxdomain bool, authority []dns.ResourceRecord) (Status, error) {
+	nsecs, nsec3s := denialRecords(authority)
+	switch {
+	case len(nsec3s) > 0:
+		return proveNSEC3(qname, qtype, nxdomain, nsec3s)
+	case len(nsecs) > 0:
+		return proveNSEC(qname, qtype, nxdomain, nsecs)
+	default:
+		return Bogus, ErrNoDenial
+	}
+}
+
+// proveWildcardExpansion checks that an answer at name synthesized from a
+// wildcard, whose RRSIG has the given label count, is not replayed over
+// an existing name: an NSEC or NSEC3 record must cover the next closer
+// name (RFC 4035 Section 5.3.4, RFC 5155 Section 8.8)
+func proveWildcardExpansion(name dns.Name, labels int, authority []dns.ResourceRecord) (Status, error) {
+	// The next closer name is the owner name shortened to one label
+	// below the closest encloser, the parent of the wildcard
+	nextCloser := name
+	for labelCount(nextCloser) > labels+1 {
+		nextCloser = nextCloser.Parent()
+	}
+
+	nsecs, nsec3s := denialRecords(authority)
+	switch {
+	case len(nsec3s) > 0:
+		if status, err := checkNSEC3Params(nsec3s[0].nsec3); status != Secure {
+			return status, err
+		}
+		if coverNSEC3(nsec3s, nextCloser) != nil {
+			return Secure, nil
+		}
+	case len(nsecs) > 0:
+		if coveringNSEC(nsecs, nextCloser) != nil {
+			return Secure, nil
+		}
+	}
+	return Bogus, fmt.Errorf("%w: wildcard answer for %s without proof that %s does not exist",
+		ErrNoDenial, dns.LabelsToString(name), dns.LabelsToString(nextCloser))
+}
+
+// denialRecords returns the NSEC and NSEC3 records of the authority section
+func denialRecords(authority []dns.ResourceRecord) ([]nsecRecord, []nsec3Record) {
+	var nsecs []nsecRecord
+	var nsec3s []nsec3Record
+	for _, rr := range authority {
+		switch rd := rr.RData.(type) {
+		case *records.NSECRecord:
+			nsecs = append(nsecs, nsecRecord{owner: rr.Name, nsec: rd})
+		case *records.NSEC3Record:
+			hash, ok := nsec3OwnerHash(rr.Name)
+			if !ok {
+				continue
+			}
+			nsec3s = append(nsec3s, nsec3Record{hash: hash, zone: rr.Name.Parent(), nsec3: rd})
+		}
+	}
+	return nsecs, nsec3s
+}
+
+// proveNSEC checks a denial of existence with NSEC records
+// (RFC 4035 Section 5.4)
+func proveNSEC(qname dns.Name, qtype dns.QType, nxdomain bool, nsecs []nsecRecord) (Status, error) {
+	if !nxdomain {
+		for _, n := range nsecs {
+			if n.owner.Equal(qname) {
+				if qtype != dns.TypeDS && isDelegation(n.nsec) {
+					return Bogus, fmt.Errorf("%w: NSEC at %s is from the parent side of a delegation", ErrNoDenial, dns.LabelsToString(qname))
+				}
+				if n.nsec.HasType(qtype) || n.nsec.HasType(dns.TypeCNAME) {
+					return Bogus, fmt.Errorf("%w: NSEC at %s lists %s", ErrNoDenial, dns.LabelsToString(qname), qtype)
+				}
+				return Secure, nil
+			}
+		}
+	}
+
+	cover := coveringNSEC(nsecs, qname)
+	if cover == nil {
+		return Bogus, fmt.Errorf("%w: no NSEC covers %s", ErrNoDenial, dns.LabelsToString(qname))
+	}
+	if cover.nsec.NextDomain.IsSubdomainOf(qname) {
+		// qname is an empty non-terminal, so it does exist
+		return Bogus, fmt.Errorf("%w: %s has descendants", ErrNoDenial, dns.LabelsToString(qname))
+	}
+
+	// The closest encloser is the longest ancestor shared with either end
+	// of the covering NSEC; a wildcard below it must not exist either
+	encloser := qname.CommonAncestor(cover.owner)
+	if next := qname.CommonAncestor(cover.nsec.NextDomain); labelCount(next) > labelCount(encloser) {
+		encloser = next
+	}
+	wildcard, err := encloser.Child("*")
+	if err != nil {
+		return Bogus, fmt.Errorf("%w: %v", ErrNoDenial, err)
+	}
+
+	if nxdomain {
+		if coveringNSEC(nsecs, wildcard) == nil {
+			return Bogus, fmt.Errorf("%w: no NSEC covers %s", ErrNoDenial, dns.LabelsToString(wildcard))
+		}
+		return Secure, nil
+	}
+
+	// NODATA for a name synthesized from a wildcard (RFC 4035 Section 3.1.3.4)
+	for _, n := range nsecs {
+		if n.owner.Equal(wildcard) && !n.nsec.HasType(qtype) && !n.nsec.HasType(dns.TypeCNAME) {
+			return Secure, nil
+		}
+	}
+	return Bogus, fmt.Errorf("%w: no NSEC matches %s", ErrNoDenial, dns.LabelsToString(qname))
+}
+
+// coveringNSEC returns the NSEC record whose span contains name. An NSEC
+// at a delegation does not cover names below it, which belong to the
+// child zone.
+func coveringNSEC(nsecs []nsecRecord, name dns.Name) *nsecRecord {
+	for i, n := range nsecs {
+		if isDelegation(n.nsec) && name.IsSubdomainOf(n.owner) {
+			continue
+		}
+		if nsecCovers(n.owner, n.nsec.NextDomain, name) {
+			return &nsecs[i]
+		}
+	}
+	return nil
+}
+
+// nsecCovers reports whether name sorts strictly between owner and next.
+// The last NSEC of a zone points back to the apex and covers every name
+// of the zone after its owner.
+func nsecCovers(owner, next, name dns.Name) bool {
+	if owner.Compare(name) >= 0 {
+		return false
+	}
+	if owner.Compare(next) < 0 {
+		return name.Compare(next) < 0
+	}
+	return name.IsSubdomainOf(next)
+}
+
+// proveNSEC3 checks a denial of existence with NSEC3 records
+// (RFC 5155 Section 8)
+func proveNSEC3(qname dns.Name, qtype dns.QType, nxdomain bool, nsec3s []nsec3Record) (Status, error) {
+	if status, err := checkNSEC3Params(nsec3s[0].nsec3); status != Secure {
+		return status, err
+	}
+
+	if !nxdomain {
+		if n := matchNSEC3(nsec3s, qname); n != nil {
+			if qtype != dns.TypeDS && isDelegation(n.nsec3) {
+				return Bogus, fmt.Errorf("%w: NSEC3 for %s is from the parent side of a delegation", ErrNoDenial, dns.LabelsToString(qname))
+			}
+			if n.nsec3.HasType(qtype) || n.nsec3.HasType(dns.TypeCNAME) {
+				return Bogus, fmt.Errorf("%w: NSEC3 for %s lists %s", ErrNoDenial, dns.LabelsToString(qname), qtype)
+			}
+			return Secure, nil
+		}
+	}
+
+	// Closest encloser proof (RFC 5155 Section 8.3)
+	var encloser, nextCloser dns.Name
+	for child := qname; !child.IsRoot(); child = child.Parent() {
+		candidate := child.Parent()
+		n := matchNSEC3(nsec3s, candidate)
+		if n == nil {
+			continue
+		}
+		// Names below a delegation are not in this zone (RFC 5155 Section 8.3)
+		if isDelegation(n.nsec3) {
+			return Bogus, fmt.Errorf("%w: closest encloser %s is a delegation", ErrNoDenial, dns.LabelsToString(candidate))
+		}
+		encloser, nextCloser = candidate, child
+		break
+	}
+	if encloser == nil {
+		return Bogus, fmt.Errorf("%w: no closest encloser for %s", ErrNoDenial, dns.LabelsToString(qname))
+	}
+	covering := coverNSEC3(nsec3s, nextCloser)
+	if covering == nil {
+		return Bogus, fmt.Errorf("%w: no NSEC3 covers %s", ErrNoDenial, dns.LabelsToString(nextCloser))
+	}
+
+	// An opt-out span may hide unsigned delegations (RFC 5155 Section 6)
+	if covering.nsec3.OptOut() && (nxdomain || qtype == dns.TypeDS) {
+		return Insecure, fmt.Errorf("%s is covered by an opt-out NSEC3", dns.LabelsToString(nextCloser))
+	}
+
+	wildcard, err := encloser.Child("*")
+	if err != nil {
+		return Bogus, fmt.Errorf("%w: %v", ErrNoDenial, err)
+	}
+	if nxdomain {
+		if coverNSEC3(nsec3s, wildcard) == nil {
+			return Bogus, fmt.Errorf("%w: no NSEC3 covers %s", ErrNoDenial, dns.LabelsToString(wildcard))
+		}
+		return Secure, nil
+	}
+
+	// NODATA for a name synthesized from a wildcard (RFC 5155 Section 8.7)
+	if n := matchNSEC3(nsec3s, wildcard); n != nil && !n.nsec3.HasType(qtype) && !n.nsec3.HasType(dns.TypeCNAME) {
+		return Secure, nil
+	}
+	return Bogus, fmt.Errorf("%w: no NSEC3 matches %s", ErrNoDenial, dns.LabelsToString(qname))
+}
+
+// checkNSEC3Params returns Insecure for NSEC3 hash parameters that are
+// not supported, and Secure otherwise
+func checkNSEC3Params(params *records.NSEC3Record) (Status, error) {
+	if params.HashAlgorithm != records.NSEC3HashSHA1 {
+		return Insecure, fmt.Errorf("unsupported NSEC3 hash algorithm %d", params.HashAlgorithm)
+	}
+	if params.Iterations > MaxNSEC3Iterations {
+		return Insecure, fmt.Errorf("NSEC3 iterations %d exceed %d", params.Iterations, MaxNSEC3Iterations)
+	}
+	return Secure, nil
+}
+
+// matchNSEC3 returns the NSEC3 record whose owner is the hash of name,
+// hashed with the parameters of the first record
+func matchNSEC3(nsec3s []nsec3Record, name dns.Name) *nsec3Record {
+	params := nsec3s[0].nsec3
+	h := NSEC3Hash(name, params.Iterations, params.Salt)
+	for i, n := range nsec3s {
+		if name.IsSubdomainOf(n.zone) && bytes.Equal(n.hash, h) {
+			return &nsec3s[i]
+		}
+	}
+	return nil
+}
+
+// coverNSEC3 returns the NSEC3 record whose span contains the hash of name
+func coverNSEC3(nsec3s []nsec3Record, name dns.Name) *nsec3Record {
+	params := nsec3s[0].nsec3
+	h := NSEC3Hash(name, params.Iterations, params.Salt)
+	for i, n := range nsec3s {
+		if name.IsSubdomainOf(n.zone) && nsec3Covers(n.hash, n.nsec3.NextHashed, h) {
+			return &nsec3s[i]
+		}
+	}
+	return nil
+}
+
+// nsec3OwnerHash decodes the hash in the first label of an NSEC3 owner name
+func nsec3OwnerHash(owner dns.Name) ([]byte, bool) {
+	if owner.IsRoot() {
+		return nil, false
+	}
+	hash, err := records.Base32Hex.DecodeString(strings.ToUpper(string(owner[0].Data)))
+	return hash, err == nil
+}
+
+// nsec3Covers reports whether hash sorts strictly between owner and next,
+// wrapping around at the end of the hash chain
+func nsec3Covers(owner, next, hash []byte) bool {
+	if bytes.Compare(owner, next) < 0 {
+		return bytes.Compare(owner, hash) < 0 && bytes.Compare(hash, next) < 0
+	}
+	return bytes.Compare(owner, hash) < 0 || bytes.Compare(hash, next) < 0
+}
+
+// typeBitmap is the type bitmap of an NSEC or NSEC3 record
+type typeBitmap interface {
+	HasType(t dns.QType) bool
+}
+
+// isDelegation reports whether the bitmap is that of the parent side of a
+// delegation, with NS but no SOA. Such a record proves nothing about the
+// child zone except the absence of a DS RRset (RFC 4035 Section 5.4, RFC
+// 5155 Section 8.5).
+func isDelegation(b typeBitmap) bool {
+	return b.HasType(dns.TypeNS) && !b.HasType(dns.TypeSOA)
+}
+
+// delegationWithoutDS reports whether the NSEC or NSEC3 records prove
+// that name is a delegation point without a DS RRset
+func delegationWithoutDS(name dns.Name, authority []dns.ResourceRecord) bool {
+	for _, rr := range authority {
+		switch rd := rr.RData.(type) {
+		case *records.NSECRecord:
+			if rr.Name.Equal(name) {
+				return isDelegation(rd) && !rd.HasType(dns.TypeDS)
+			}
+		case *records.NSEC3Record:
+			hash, ok := nsec3OwnerHash(rr.Name)
+			if !ok || !bytes.Equal(hash, NSEC3Hash(name, rd.Iterations, rd.Salt)) {
+				continue
+			}
+			return isDelegation(rd) && !rd.HasType(dns.TypeDS)
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package dnssec
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestNSEC3Hash(t *testing.T) {
+	// RFC 5155 Appendix A
+	salt, _ := hex.DecodeString("aabbccdd")
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"example", "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom"},
+		{"a.example", "35mthgpgcu1qg68fab165klnsnk3dpvl"},
+		{"A.EXAMPLE", "35mthgpgcu1qg68fab165klnsnk3dpvl"},
+	}
+
+	for _, test := range tests {
+		hash := NSEC3Hash(dns.MustParseName(test.name), 12, salt)
+		if result := strings.ToLower(records.Base32Hex.EncodeToString(hash)); result != test.expected {
+			t.Errorf("NSEC3Hash(%q) = %s, want %s", test.name, result, test.expected)
+		}
+	}
+}
+
+// nsecRR returns an NSEC record with the given owner, next name and types
+func nsecRR(owner, next string, types ...dns.QType) dns.ResourceRecord {
+	return dns.ResourceRecord{
+		Name:  dns.MustParseName(owner),
+		Type:  dns.TypeNSEC,
+		Class: dns.ClassIN,
+		RData: records.NewNSECRecord(dns.MustParseName(next), types),
+	}
+}
+
+func TestProveDenialNSEC(t *testing.T) {
+	authority := []dns.ResourceRecord{
+		nsecRR("example.com", "mail.example.com", dns.TypeSOA, dns.TypeNS, dns.TypeNSEC),
+		nsecRR("mail.example.com", "sub.example.com", dns.TypeA, dns.TypeNSEC),
+		nsecRR("sub.example.com", "example.com", dns.TypeNS, dns.TypeNSEC),
+	}
+
+	tests := []struct {
+		name     string
+		qname    string
+		qtype    dns.QType
+		nxdomain bool
+		expected Status
+	}{
+		{"nxdomain", "b.example.com", dns.TypeA, true, Secure},
+		{"nxdomain after last NSEC", "zzz.example.com", dns.TypeA, true, Secure},
+		{"nodata", "mail.example.com", dns.TypeAAAA, false, Secure},
+		{"insecure delegation", "sub.example.com", dns.TypeDS, false, Secure},
+		{"delegation NSEC for child data", "sub.example.com", dns.TypeA, false, Bogus},
+		{"delegation NSEC below delegation", "www.sub.example.com", dns.TypeA, true, Bogus},
+		{"type exists", "mail.example.com", dns.TypeA, false, Bogus},
+		{"name exists", "mail.example.com", dns.TypeA, true, Bogus},
+		{"outside zone", "example.org", dns.TypeA, true, Bogus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := proveDenial(dns.MustParseName(test.qname), test.qtype, test.nxdomain, authority)
+			if status != test.expected {
+				t.Errorf("proveDenial() = %v (%v), want %v", status, err, test.expected)
+			}
+		})
+	}
+
+	if status, _ := proveDenial(dns.MustParseName("b.example.com"), dns.TypeA, true, nil); status != Bogus {
+		t.Errorf("proveDenial() without records = %v, want BOGUS", status)
+	}
+	if !delegationWithoutDS(dns.MustParseName("sub.example.com"), authority) {
+		t.Error("delegationWithoutDS() should detect the unsigned delegation")
+	}
+	if delegationWithoutDS(dns.MustParseName("mail.example.com"), authority) {
+		t.Error("delegationWithoutDS() should not report a name without NS")
+	}
+}
+
+// nsec3RR returns an NSEC3 record in zone for the hash of owner, spanning
+// to the raw next hash
+func nsec3RR(zone string, owner []byte, next []byte, flags uint8, iterations uint16, types ...dns.QType) dns.ResourceRecord {
+	return dns.ResourceRecord{
+		Name:  dns.MustParseName(records.Base32Hex.EncodeToString(owner) + "." + zone),
+		Type:  dns.TypeNSEC3,
+		Class: dns.ClassIN,
+		RData: &records.NSEC3Record{
+			HashAlgorithm: records.NSEC3HashSHA1,
+			Flags:         flags,
+			Iterations:    iterations,
+			NextHashed:    next,
+			Types:         types,
+		},
+	}
+}
+
+func TestProveDenialNSEC3(t *testing.T) {
+	hash := func(name string) []byte { return NSEC3Hash(dns.MustParseName(name), 0, nil) }
+	low, high := make([]byte, 20), make([]byte, 20)
+	for i := range high {
+		high[i] = 0xFF
+	}
+
+	// One record matches the apex and covers nothing; a second spans every
+	// other hash
+	apex := hash("example")
+	apexNext := append([]byte(nil), apex...)
+	apexNext[len(apexNext)-1]++
+	proof := func(flags uint8, iterations uint16) []dns.ResourceRecord {
+		return []dns.ResourceRecord{
+			nsec3RR("example", apex, apexNext, 0, iterations, dns.TypeSOA, dns.TypeNS),
+			nsec3RR("example", low, high, flags, iterations),
+		}
+	}
+
+	// The parent side of the delegation sub.example, with NS but no SOA
+	sub := hash("sub.example")
+	subNext := append([]byte(nil), sub...)
+	subNext[len(subNext)-1]++
+	delegation := append(proof(0, 0), nsec3RR("example", sub, subNext, 0, 0, dns.TypeNS))
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     dns.QType
+		nxdomain  bool
+		authority []dns.ResourceRecord
+		expected  Status
+	}{
+		{"nxdomain", "a.b.example", dns.TypeA, true, proof(0, 0), Secure},
+		{"nxdomain opt-out", "a.b.example", dns.TypeA, true, proof(records.NSEC3FlagOptOut, 0), Insecure},
+		{"nodata", "example", dns.TypeA, false, proof(0, 0), Secure},
+		{"type exists", "example", dns.TypeSOA, false, proof(0, 0), Bogus},
+		{"DS opt-out", "sub.example", dns.TypeDS, false, proof(records.NSEC3FlagOptOut, 0), Insecure},
+		{"DS without opt-out", "sub.example", dns.TypeDS, false, proof(0, 0), Bogus},
+		{"too many iterations", "a.example", dns.TypeA, true, proof(0, MaxNSEC3Iterations+1), Insecure},
+		{"no closest encloser", "a.example", dns.TypeA, true, proof(0, 0)[1:], Bogus},
+		{"delegation DS", "sub.example", dns.TypeDS, false, delegation, Secure},
+		{"delegation NSEC3 for child data", "sub.example", dns.TypeA, false, delegation, Bogus},
+		{"delegation as closest encloser", "a.sub.example", dns.TypeA, true, delegation, Bogus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := proveDenial(dns.MustParseName(test.qname), test.qtype, test.nxdomain, test.authority)
+			if status != test.expected {
+				t.Errorf("proveDenial() = %v (%v), want %v", status, err, test.expected)
+			}
+		})
+	}
+
+	// *.example expanded to a.b.example: the next closer b.example must
+	// be covered
+	name := dns.MustParseName("a.b.example")
+	if status, err := proveWildcardExpansion(name, 1, proof(0, 0)); status != Secure {
+		t.Errorf("proveWildcardExpansion() = %v (%v), want SECURE", status, err)
+	}
+	if status, _ := proveWildcardExpansion(name, 1, proof(0, 0)[:1]); status != Bogus {
+		t.Errorf("proveWildcardExpansion() without covering NSEC3 = %v, want BOGUS", status)
+	}
+}
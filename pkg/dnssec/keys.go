@@ -0,0 +1,155 @@
+package dnssec
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// RSAKeyBits is the modulus size of generated RSA keys
+const RSAKeyBits = 2048
+
+// PrivateKey is a DNSSEC signing key: the public DNSKEY data together with
+// the private key that signs for it
+type PrivateKey struct {
+	DNSKEY *records.DNSKEYRecord
+	Signer crypto.Signer
+}
+
+// GenerateKey creates a new key for the algorithm. Flags are the DNSKEY
+// flags, usually records.DNSKEYFlagZone for a zone signing key and
+// additionally records.DNSKEYFlagSEP for a key signing key.
+func GenerateKey(algorithm uint8, flags uint16) (*PrivateKey, error) {
+	var signer crypto.Signer
+	var err error
+	switch algorithm {
+	case records.AlgorithmRSASHA256, records.AlgorithmRSASHA512:
+		signer, err = rsa.GenerateKey(rand.Reader, RSAKeyBits)
+	case records.AlgorithmECDSAP256SHA256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case records.AlgorithmECDSAP384SHA384:
+		signer, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case records.AlgorithmED25519:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return NewPrivateKey(algorithm, flags, signer)
+}
+
+// NewPrivateKey wraps an existing private key, deriving its DNSKEY data
+func NewPrivateKey(algorithm uint8, flags uint16, signer crypto.Signer) (*PrivateKey, error) {
+	public, err := encodePublicKey(algorithm, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{
+		DNSKEY: records.NewDNSKEYRecord(flags, algorithm, public),
+		Signer: signer,
+	}, nil
+}
+
+// encodePublicKey returns the DNSKEY public key field for a key: RFC 3110
+// for RSA, RFC 6605 for ECDSA and RFC 8080 for Ed25519
+func encodePublicKey(algorithm uint8, public crypto.PublicKey) ([]byte, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if algorithm != records.AlgorithmRSASHA256 && algorithm != records.AlgorithmRSASHA512 {
+			break
+		}
+		exponent := big.NewInt(int64(pub.E)).Bytes()
+		var result []byte
+		if len(exponent) < 256 {
+			result = append(result, byte(len(exponent)))
+		} else {
+			result = append(result, 0, byte(len(exponent)>>8), byte(len(exponent)))
+		}
+		result = append(result, exponent...)
+		return append(result, pub.N.Bytes()...), nil
+
+	case *ecdsa.PublicKey:
+		if (algorithm == records.AlgorithmECDSAP256SHA256 && pub.Curve != elliptic.P256()) ||
+			(algorithm == records.AlgorithmECDSAP384SHA384 && pub.Curve != elliptic.P384()) ||
+			(algorithm != records.AlgorithmECDSAP256SHA256 && algorithm != records.AlgorithmECDSAP384SHA384) {
+			break
+		}
+		point, err := pub.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECDSA key: %w", err)
+		}
+		// Drop the 0x04 prefix of the uncompressed point encoding
+		return point.Bytes()[1:], nil
+
+	case ed25519.PublicKey:
+		if algorithm != records.AlgorithmED25519 {
+			break
+		}
+		return append([]byte(nil), pub...), nil
+	}
+	return nil, fmt.Errorf("%T cannot be used with algorithm %d", public, algorithm)
+}
+
+// PEM headers carrying the DNSKEY parameters of a private key file
+const (
+	pemAlgorithm = "Algorithm"
+	pemFlags     = "Flags"
+)
+
+// MarshalPEM encodes the key as a PKCS #8 PEM block whose headers record
+// the DNSKEY algorithm and flags
+func (k *PrivateKey) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			pemAlgorithm: strconv.Itoa(int(k.DNSKEY.Algorithm)),
+			pemFlags:     strconv.Itoa(int(k.DNSKEY.Flags)),
+		},
+		Bytes: der,
+	}), nil
+}
+
+// ParsePrivateKey decodes a key written by MarshalPEM
+func ParsePrivateKey(data []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+
+	algorithm, err := strconv.ParseUint(block.Headers[pemAlgorithm], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", pemAlgorithm, err)
+	}
+	flags, err := strconv.ParseUint(block.Headers[pemFlags], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", pemFlags, err)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	return NewPrivateKey(uint8(algorithm), uint16(flags), signer)
+}
@@ -0,0 +1,74 @@
+package dnssec
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestGenerateKey(t *testing.T) {
+	tests := []struct {
+		algorithm uint8
+		keyLength int
+	}{
+		{records.AlgorithmECDSAP256SHA256, 64},
+		{records.AlgorithmECDSAP384SHA384, 96},
+		{records.AlgorithmED25519, 32},
+		{records.AlgorithmRSASHA256, 1 + 3 + RSAKeyBits/8}, // Exponent 65537
+	}
+
+	for _, test := range tests {
+		key, err := GenerateKey(test.algorithm, records.DNSKEYFlagZone|records.DNSKEYFlagSEP)
+		if err != nil {
+			t.Fatalf("GenerateKey(%d) returned error: %v", test.algorithm, err)
+		}
+		if key.DNSKEY.Algorithm != test.algorithm || !key.DNSKEY.IsSEP() || !key.DNSKEY.IsZoneKey() {
+			t.Errorf("GenerateKey(%d) DNSKEY = %v", test.algorithm, key.DNSKEY)
+		}
+		if len(key.DNSKEY.PublicKey) != test.keyLength {
+			t.Errorf("GenerateKey(%d) public key length = %d, want %d", test.algorithm, len(key.DNSKEY.PublicKey), test.keyLength)
+		}
+	}
+
+	if _, err := GenerateKey(records.AlgorithmRSASHA1, records.DNSKEYFlagZone); err == nil {
+		t.Error("GenerateKey() should reject RSASHA1")
+	}
+}
+
+func TestPrivateKeyPEM(t *testing.T) {
+	for _, algorithm := range []uint8{records.AlgorithmECDSAP384SHA384, records.AlgorithmED25519} {
+		key, err := GenerateKey(algorithm, records.DNSKEYFlagZone|records.DNSKEYFlagSEP)
+		if err != nil {
+			t.Fatalf("GenerateKey(%d) returned error: %v", algorithm, err)
+		}
+
+		data, err := key.MarshalPEM()
+		if err != nil {
+			t.Fatalf("MarshalPEM() returned error: %v", err)
+		}
+		parsed, err := ParsePrivateKey(data)
+		if err != nil {
+			t.Fatalf("ParsePrivateKey() returned error: %v", err)
+		}
+		if !bytes.Equal(parsed.DNSKEY.Bytes(), key.DNSKEY.Bytes()) {
+			t.Errorf("ParsePrivateKey() DNSKEY = %v, want %v", parsed.DNSKEY, key.DNSKEY)
+		}
+	}
+
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("ParsePrivateKey() should return error for invalid data")
+	}
+}
+
+func TestNewPrivateKeyAlgorithmMismatch(t *testing.T) {
+	key, err := GenerateKey(records.AlgorithmECDSAP256SHA256, records.DNSKEYFlagZone)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	for _, algorithm := range []uint8{records.AlgorithmECDSAP384SHA384, records.AlgorithmED25519, records.AlgorithmRSASHA256} {
+		if _, err := NewPrivateKey(algorithm, records.DNSKEYFlagZone, key.Signer); err == nil {
+			t.Errorf("NewPrivateKey(%d) with a P-256 key should return error", algorithm)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package dnssec
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// SignRRset signs an RRset with the private key belonging to the DNSKEY key
+// of the zone signerName. The signature is valid from inception until
+// expiration. The returned RRSIG record has the owner name, class and TTL
+// of the RRset.
+func SignRRset(rrset []dns.ResourceRecord, signerName dns.Name, key *records.DNSKEYRecord, signer crypto.Signer, inception, expiration time.Time) (dns.ResourceRecord, error) {
+	if len(rrset) == 0 {
+		return dns.ResourceRecord{}, fmt.Errorf("empty RRset")
+	}
+	first := rrset[0]
+
+	sig := &records.RRSIGRecord{
+		TypeCovered: first.Type,
+		Algorithm:   key.Algorithm,
+		Labels:      uint8(labelCount(first.Name)),
+		OriginalTTL: uint32(first.TTL),
+		Expiration:  uint32(expiration.Unix()),
+		Inception:   uint32(inception.Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  signerName.Canonical(),
+	}
+
+	signature, err := sign(key.Algorithm, signer, signedData(rrset, sig))
+	if err != nil {
+		return dns.ResourceRecord{}, err
+	}
+	sig.Signature = signature
+
+	return dns.ResourceRecord{
+		Name:     first.Name,
+		Type:     dns.TypeRRSIG,
+		Class:    first.Class,
+		TTL:      first.TTL,
+		RDLength: uint16(len(sig.Bytes())),
+		RData:    sig,
+	}, nil
+}
+
+// sign produces a raw DNSSEC signature over data
+func sign(algorithm uint8, signer crypto.Signer, data []byte) ([]byte, error) {
+	switch algorithm {
+	case records.AlgorithmRSASHA256, records.AlgorithmRSASHA512:
+		if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("algorithm %d requires an RSA key", algorithm)
+		}
+		hash, digest := digestFor(algorithm, data)
+		return signer.Sign(rand.Reader, digest, hash)
+
+	case records.AlgorithmECDSAP256SHA256, records.AlgorithmECDSAP384SHA384:
+		pub, ok := signer.Public().(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("algorithm %d requires an ECDSA key", algorithm)
+		}
+		hash, digest := digestFor(algorithm, data)
+		der, err := signer.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, err
+		}
+		// DNSSEC uses the fixed-size r || s form (RFC 6605 Section 4)
+		r, s, err := parseECDSASignature(der)
+		if err != nil {
+			return nil, err
+		}
+		size := pub.Params().BitSize / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+
+	case records.AlgorithmED25519:
+		if _, ok := signer.Public().(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("algorithm %d requires an Ed25519 key", algorithm)
+		}
+		return signer.Sign(rand.Reader, data, crypto.Hash(0))
+
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+// parseECDSASignature decodes the ASN.1 signature produced by crypto/ecdsa
+func parseECDSASignature(der []byte) (*big.Int, *big.Int, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, nil, fmt.Errorf("invalid ECDSA signature encoding: %w", err)
+	}
+	return sig.R, sig.S, nil
+}
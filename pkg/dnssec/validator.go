@@ -0,0 +1,206 @@
+package dnssec
+
+import (
+	"fmt"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Status is the outcome of DNSSEC validation (RFC 4033 Section 5)
+type Status int
+
+const (
+	Indeterminate Status = iota // Nothing could be validated
+	Secure                      // Signatures verified with a trusted key
+	Insecure                    // Data is provably or effectively unsigned
+	Bogus                       // Signatures are present but do not verify
+)
+
+// String returns the string representation of a Status
+func (s Status) String() string {
+	switch s {
+	case Secure:
+		return "SECURE"
+	case Insecure:
+		return "INSECURE"
+	case Bogus:
+		return "BOGUS"
+	default:
+		return "INDETERMINATE"
+	}
+}
+
+// RRsetResult is the validation outcome of a single RRset
+type RRsetResult struct {
+	Name   dns.Name
+	Type   dns.QType
+	Status Status
+	Err    error // Reason for an Insecure or Bogus status
+}
+
+// Result is the validation outcome of a response
+type Result struct {
+	Status Status
+	RRsets []RRsetResult
+}
+
+// Err returns the first error among RRsets with the overall status
+func (r *Result) Err() error {
+	for _, rrset := range r.RRsets {
+		if rrset.Status == r.Status && rrset.Err != nil {
+			return fmt.Errorf("%s %s: %w", dns.LabelsToString(rrset.Name), rrset.Type, rrset.Err)
+		}
+	}
+	return nil
+}
+
+// Validator verifies the RRSIGs of responses against a set of trusted
+// DNSKEY records
+type Validator struct {
+	keys []dns.ResourceRecord
+	now  func() time.Time
+}
+
+// NewValidator creates a validator trusting the given DNSKEY records.
+// Records of other types are ignored.
+func NewValidator(keys []dns.ResourceRecord) *Validator {
+	v := &Validator{now: time.Now}
+	v.AddKeys(keys)
+	return v
+}
+
+// AddKeys adds DNSKEY records to the trusted set
+func (v *Validator) AddKeys(keys []dns.ResourceRecord) {
+	for _, rr := range keys {
+		if _, ok := rr.RData.(*records.DNSKEYRecord); ok {
+			v.keys = append(v.keys, rr)
+		}
+	}
+}
+
+// SetClock replaces the clock used for signature validity checks
+func (v *Validator) SetClock(now func() time.Time) {
+	v.now = now
+}
+
+// VerifyRRset checks the RRset against the RRSIG records covering it and
+// returns Secure as soon as one signature verifies with a trusted key
+func (v *Validator) VerifyRRset(rrset []dns.ResourceRecord, sigs []dns.ResourceRecord) (Status, error) {
+	status, _, err := v.verify(rrset, sigs)
+	return status, err
+}
+
+// verify is VerifyRRset, also returning the signature that verified
+func (v *Validator) verify(rrset []dns.ResourceRecord, sigs []dns.ResourceRecord) (Status, *records.RRSIGRecord, error) {
+	if len(sigs) == 0 {
+		return Insecure, nil, fmt.Errorf("no signatures")
+	}
+
+	var lastErr error
+	usable := false
+	for _, sigRR := range sigs {
+		sig, ok := sigRR.RData.(*records.RRSIGRecord)
+		if !ok {
+			continue
+		}
+		if !SupportedAlgorithm(sig.Algorithm) {
+			lastErr = fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, sig.Algorithm)
+			continue
+		}
+		usable = true
+
+		matched := false
+		for _, keyRR := range v.keys {
+			key := keyRR.RData.(*records.DNSKEYRecord)
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm || !keyRR.Name.Equal(sig.SignerName) {
+				continue
+			}
+			matched = true
+			err := VerifyRRSIG(rrset, sig, keyRR.Name, key, v.now())
+			if err == nil {
+				return Secure, sig, nil
+			}
+			lastErr = err
+		}
+		if !matched {
+			lastErr = fmt.Errorf("no trusted key %d for signer %s", sig.KeyTag, dns.LabelsToString(sig.SignerName))
+		}
+	}
+
+	// Signatures that only use unsupported algorithms are treated as
+	// unsigned (RFC 4035 Section 5.2)
+	if !usable {
+		return Insecure, nil, lastErr
+	}
+	return Bogus, nil, lastErr
+}
+
+// Validate checks every RRset in the answer and authority sections of the
+// response. The overall status is Bogus if any RRset is bogus, Insecure if
+// any RRset is unsigned, and Secure if all RRsets verified.
+func (v *Validator) Validate(msg *dns.Message) *Result {
+	result := &Result{Status: Indeterminate}
+
+	for _, section := range [][]dns.ResourceRecord{msg.Answer, msg.Authority} {
+		for _, rrset := range groupRRsets(section) {
+			status, err := v.VerifyRRset(rrset.Records, coveringSignatures(section, rrset.Records[0]))
+			result.RRsets = append(result.RRsets, RRsetResult{
+				Name:   rrset.Name,
+				Type:   rrset.Type,
+				Status: status,
+				Err:    err,
+			})
+		}
+	}
+
+	result.Status = aggregate(result.RRsets)
+	return result
+}
+
+// aggregate combines RRset results: Bogus if any RRset is bogus, Insecure
+// if any is unsigned, Indeterminate if any could not be decided and Secure
+// if all verified
+func aggregate(rrsets []RRsetResult) Status {
+	if len(rrsets) == 0 {
+		return Indeterminate
+	}
+	status := Secure
+	for _, rrset := range rrsets {
+		switch {
+		case rrset.Status == Bogus:
+			return Bogus
+		case rrset.Status == Insecure:
+			status = Insecure
+		case rrset.Status == Indeterminate && status == Secure:
+			status = Indeterminate
+		}
+	}
+	return status
+}
+
+// groupRRsets splits a section into RRsets in order of first appearance.
+// RRSIG records are not part of any RRset.
+func groupRRsets(section []dns.ResourceRecord) []*dns.RRset {
+	var rrsets []*dns.RRset
+	for _, rrset := range dns.GroupRRsets(section) {
+		if rrset.Type != dns.TypeRRSIG {
+			rrsets = append(rrsets, rrset)
+		}
+	}
+	return rrsets
+}
+
+// coveringSignatures returns the RRSIG records in the section that cover
+// the RRset of the given record
+func coveringSignatures(section []dns.ResourceRecord, rr dns.ResourceRecord) []dns.ResourceRecord {
+	var sigs []dns.ResourceRecord
+	for _, candidate := range section {
+		sig, ok := candidate.RData.(*records.RRSIGRecord)
+		if ok && sig.TypeCovered == rr.Type && candidate.Class == rr.Class && candidate.Name.Equal(rr.Name) {
+			sigs = append(sigs, candidate)
+		}
+	}
+	return sigs
+}
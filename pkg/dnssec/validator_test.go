@@ -0,0 +1,96 @@
+package dnssec
+
+import (
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestValidatorValidate(t *testing.T) {
+	keyRR, signer := testKey(t, "example.com", records.AlgorithmECDSAP256SHA256)
+	key := keyRR.RData.(*records.DNSKEYRecord)
+
+	rrset := testRRset(t, "www.example.com", "192.0.2.1", "192.0.2.2")
+	sigRR, err := SignRRset(rrset, keyRR.Name, key, signer, testInception, testExpiration)
+	if err != nil {
+		t.Fatalf("SignRRset() returned error: %v", err)
+	}
+
+	tampered := testRRset(t, "www.example.com", "192.0.2.1", "192.0.2.3")
+
+	unsupported := *sigRR.RData.(*records.RRSIGRecord)
+	unsupported.Algorithm = 253 // PRIVATEDNS
+	unsupportedRR := sigRR
+	unsupportedRR.RData = &unsupported
+
+	tests := []struct {
+		name     string
+		answer   []dns.ResourceRecord
+		expected Status
+	}{
+		{"signed", append(append([]dns.ResourceRecord{}, rrset...), sigRR), Secure},
+		{"unsigned", rrset, Insecure},
+		{"tampered", append(append([]dns.ResourceRecord{}, tampered...), sigRR), Bogus},
+		{"unsupported algorithm", append(append([]dns.ResourceRecord{}, rrset...), unsupportedRR), Insecure},
+		{"empty", nil, Indeterminate},
+	}
+
+	validator := NewValidator([]dns.ResourceRecord{keyRR})
+	validator.SetClock(func() time.Time { return testNow })
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := dns.NewMessage().Answer(test.answer...).Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			result := validator.Validate(msg)
+			if result.Status != test.expected {
+				t.Errorf("Validate() status = %v, want %v (error: %v)", result.Status, test.expected, result.Err())
+			}
+			if test.expected == Bogus && result.Err() == nil {
+				t.Error("Validate() of bogus response should report an error")
+			}
+		})
+	}
+}
+
+func TestValidatorUntrustedKey(t *testing.T) {
+	keyRR, signer := testKey(t, "example.com", records.AlgorithmED25519)
+	otherRR, _ := testKey(t, "example.com", records.AlgorithmED25519)
+
+	rrset := testRRset(t, "example.com", "192.0.2.1")
+	sigRR, err := SignRRset(rrset, keyRR.Name, keyRR.RData.(*records.DNSKEYRecord), signer, testInception, testExpiration)
+	if err != nil {
+		t.Fatalf("SignRRset() returned error: %v", err)
+	}
+
+	validator := NewValidator([]dns.ResourceRecord{otherRR})
+	validator.SetClock(func() time.Time { return testNow })
+
+	status, err := validator.VerifyRRset(rrset, []dns.ResourceRecord{sigRR})
+	if status != Bogus || err == nil {
+		t.Errorf("VerifyRRset() = %v, %v, want BOGUS with error", status, err)
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	tests := []struct {
+		status   Status
+		expected string
+	}{
+		{Secure, "SECURE"},
+		{Insecure, "INSECURE"},
+		{Bogus, "BOGUS"},
+		{Indeterminate, "INDETERMINATE"},
+	}
+
+	for _, test := range tests {
+		if result := test.status.String(); result != test.expected {
+			t.Errorf("Status.String() = %q, want %q", result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package dnssec
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Errors returned by signature verification
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported DNSSEC algorithm")
+	ErrSignatureExpired     = errors.New("signature outside its validity period")
+	ErrKeyMismatch          = errors.New("key does not match signature")
+	ErrInvalidSignature     = errors.New("signature verification failed")
+)
+
+// VerifyRRSIG checks the signature sig over rrset with the DNSKEY key owned
+// by keyOwner, at time now. All records of the RRset must share owner name,
+// type and class.
+func VerifyRRSIG(rrset []dns.ResourceRecord, sig *records.RRSIGRecord, keyOwner dns.Name, key *records.DNSKEYRecord, now time.Time) error {
+	if len(rrset) == 0 {
+		return fmt.Errorf("empty RRset")
+	}
+	first := rrset[0]
+	for _, rr := range rrset[1:] {
+		if rr.Type != first.Type || rr.Class != first.Class || !rr.Name.Equal(first.Name) {
+			return fmt.Errorf("records do not form a single RRset")
+		}
+	}
+
+	// RFC 4035 Section 5.3.1
+	if sig.TypeCovered != first.Type {
+		return fmt.Errorf("signature covers %s, RRset is %s", sig.TypeCovered, first.Type)
+	}
+	if !sig.SignerName.Equal(keyOwner) {
+		return fmt.Errorf("%w: signer %s is not key owner %s", ErrKeyMismatch,
+			dns.LabelsToString(sig.SignerName), dns.LabelsToString(keyOwner))
+	}
+	if !first.Name.IsSubdomainOf(sig.SignerName) {
+		return fmt.Errorf("signer %s is not an ancestor of %s",
+			dns.LabelsToString(sig.SignerName), dns.LabelsToString(first.Name))
+	}
+	if int(sig.Labels) > labelCount(first.Name) {
+		return fmt.Errorf("signature labels %d exceed owner name labels %d", sig.Labels, labelCount(first.Name))
+	}
+	if key.Protocol != 3 || !key.IsZoneKey() || key.IsRevoked() {
+		return fmt.Errorf("%w: key %d is not a usable zone key", ErrKeyMismatch, key.KeyTag())
+	}
+	if key.Algorithm != sig.Algorithm || key.KeyTag() != sig.KeyTag {
+		return fmt.Errorf("%w: key %d/%d, signature %d/%d", ErrKeyMismatch,
+			key.KeyTag(), key.Algorithm, sig.KeyTag, sig.Algorithm)
+	}
+	if !sig.ValidAt(now) {
+		return ErrSignatureExpired
+	}
+
+	return verifySignature(key, signedData(rrset, sig), sig.Signature)
+}
+
+// SupportedAlgorithm reports whether signatures of the algorithm can be
+// verified and created
+func SupportedAlgorithm(algorithm uint8) bool {
+	switch algorithm {
+	case records.AlgorithmRSASHA256, records.AlgorithmRSASHA512,
+		records.AlgorithmECDSAP256SHA256, records.AlgorithmECDSAP384SHA384,
+		records.AlgorithmED25519:
+		return true
+	default:
+		return false
+	}
+}
+
+// verifySignature checks a raw signature over data with a DNSKEY
+func verifySignature(key *records.DNSKEYRecord, data, signature []byte) error {
+	switch key.Algorithm {
+	case records.AlgorithmRSASHA256, records.AlgorithmRSASHA512:
+		pub, err := parseRSAKey(key.PublicKey)
+		if err != nil {
+			return err
+		}
+		hash, digest := digestFor(key.Algorithm, data)
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case records.AlgorithmECDSAP256SHA256, records.AlgorithmECDSAP384SHA384:
+		pub, err := parseECDSAKey(key.Algorithm, key.PublicKey)
+		if err != nil {
+			return err
+		}
+		if len(signature) != 2*pub.Params().BitSize/8 {
+			return ErrInvalidSignature
+		}
+		half := len(signature) / 2
+		r := new(big.Int).SetBytes(signature[:half])
+		s := new(big.Int).SetBytes(signature[half:])
+		_, digest := digestFor(key.Algorithm, data)
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case records.AlgorithmED25519:
+		if len(key.PublicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid Ed25519 key length %d", len(key.PublicKey))
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key.PublicKey), data, signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, key.Algorithm)
+	}
+}
+
+// digestFor hashes data with the hash function of the algorithm
+func digestFor(algorithm uint8, data []byte) (crypto.Hash, []byte) {
+	switch algorithm {
+	case records.AlgorithmRSASHA512:
+		sum := sha512.Sum512(data)
+		return crypto.SHA512, sum[:]
+	case records.AlgorithmECDSAP384SHA384:
+		sum := sha512.Sum384(data)
+		return crypto.SHA384, sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return crypto.SHA256, sum[:]
+	}
+}
+
+// parseRSAKey decodes an RSA public key in the format of RFC 3110 Section 2
+func parseRSAKey(data []byte) (*rsa.PublicKey, error) {
+	if len(data) < 3 {
+		return nil, fmt.Errorf("RSA key too short")
+	}
+	expLength, index := int(data[0]), 1
+	if expLength == 0 {
+		expLength, index = int(data[1])<<8|int(data[2]), 3
+	}
+	if expLength == 0 || expLength > 4 || len(data) <= index+expLength {
+		return nil, fmt.Errorf("invalid RSA key exponent")
+	}
+
+	exponent := 0
+	for _, b := range data[index : index+expLength] {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(data[index+expLength:]),
+		E: exponent,
+	}, nil
+}
+
+// parseECDSAKey decodes an ECDSA public key in the format of RFC 6605
+// Section 4: the concatenated X and Y coordinates
+func parseECDSAKey(algorithm uint8, data []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	if algorithm == records.AlgorithmECDSAP384SHA384 {
+		curve = elliptic.P384()
+	}
+	size := curve.Params().BitSize / 8
+	if len(data) != 2*size {
+		return nil, fmt.Errorf("invalid ECDSA key length %d", len(data))
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(data[:size]),
+		Y:     new(big.Int).SetBytes(data[size:]),
+	}, nil
+}
@@ -0,0 +1,168 @@
+package dnssec
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+var (
+	testInception  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testExpiration = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	testNow        = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+)
+
+// testKey generates a zone key for the algorithm and returns its DNSKEY
+// record owned by zone together with the private key
+func testKey(t *testing.T, zone string, algorithm uint8) (dns.ResourceRecord, crypto.Signer) {
+	t.Helper()
+
+	key, err := GenerateKey(algorithm, records.DNSKEYFlagZone)
+	if err != nil {
+		t.Fatalf("GenerateKey(%d) returned error: %v", algorithm, err)
+	}
+	return dns.ResourceRecord{
+		Name:  dns.MustParseName(zone),
+		Type:  dns.TypeDNSKEY,
+		Class: dns.ClassIN,
+		TTL:   3600,
+		RData: key.DNSKEY,
+	}, key.Signer
+}
+
+// testRRset returns an A RRset with the given owner and addresses
+func testRRset(t *testing.T, owner string, addrs ...string) []dns.ResourceRecord {
+	t.Helper()
+
+	var rrset []dns.ResourceRecord
+	for _, addr := range addrs {
+		record, err := records.NewARecordFromString(addr)
+		if err != nil {
+			t.Fatalf("NewARecordFromString(%q) returned error: %v", addr, err)
+		}
+		rrset = append(rrset, dns.ResourceRecord{
+			Name:  dns.MustParseName(owner),
+			Type:  dns.TypeA,
+			Class: dns.ClassIN,
+			TTL:   300,
+			RData: record,
+		})
+	}
+	return rrset
+}
+
+func TestSignAndVerify(t *testing.T) {
+	algorithms := []uint8{
+		records.AlgorithmRSASHA256,
+		records.AlgorithmRSASHA512,
+		records.AlgorithmECDSAP256SHA256,
+		records.AlgorithmECDSAP384SHA384,
+		records.AlgorithmED25519,
+	}
+
+	for _, algorithm := range algorithms {
+		keyRR, signer := testKey(t, "example.com", algorithm)
+		key := keyRR.RData.(*records.DNSKEYRecord)
+		rrset := testRRset(t, "www.example.com", "192.0.2.1", "192.0.2.2")
+
+		sigRR, err := SignRRset(rrset, keyRR.Name, key, signer, testInception, testExpiration)
+		if err != nil {
+			t.Fatalf("SignRRset() with algorithm %d returned error: %v", algorithm, err)
+		}
+		sig := sigRR.RData.(*records.RRSIGRecord)
+
+		if err := VerifyRRSIG(rrset, sig, keyRR.Name, key, testNow); err != nil {
+			t.Errorf("VerifyRRSIG() with algorithm %d returned error: %v", algorithm, err)
+		}
+
+		// Record order and owner name case must not matter
+		reordered := testRRset(t, "WWW.Example.COM", "192.0.2.2", "192.0.2.1")
+		if err := VerifyRRSIG(reordered, sig, keyRR.Name, key, testNow); err != nil {
+			t.Errorf("VerifyRRSIG() of reordered RRset with algorithm %d returned error: %v", algorithm, err)
+		}
+
+		tampered := testRRset(t, "www.example.com", "192.0.2.1", "192.0.2.3")
+		if err := VerifyRRSIG(tampered, sig, keyRR.Name, key, testNow); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifyRRSIG() of tampered RRset with algorithm %d = %v, want %v", algorithm, err, ErrInvalidSignature)
+		}
+	}
+}
+
+func TestVerifyRRSIGChecks(t *testing.T) {
+	keyRR, signer := testKey(t, "example.com", records.AlgorithmED25519)
+	key := keyRR.RData.(*records.DNSKEYRecord)
+	rrset := testRRset(t, "www.example.com", "192.0.2.1")
+
+	sigRR, err := SignRRset(rrset, keyRR.Name, key, signer, testInception, testExpiration)
+	if err != nil {
+		t.Fatalf("SignRRset() returned error: %v", err)
+	}
+	sig := sigRR.RData.(*records.RRSIGRecord)
+
+	otherRR, _ := testKey(t, "example.com", records.AlgorithmED25519)
+	revoked := *key
+	revoked.Flags |= records.DNSKEYFlagRevoke
+
+	tests := []struct {
+		name     string
+		rrset    []dns.ResourceRecord
+		owner    string
+		key      *records.DNSKEYRecord
+		now      time.Time
+		expected error
+	}{
+		{"expired", rrset, "example.com", key, testExpiration.Add(time.Hour), ErrSignatureExpired},
+		{"not yet valid", rrset, "example.com", key, testInception.Add(-time.Hour), ErrSignatureExpired},
+		{"other key", rrset, "example.com", otherRR.RData.(*records.DNSKEYRecord), testNow, ErrKeyMismatch},
+		{"wrong key owner", rrset, "example.org", key, testNow, ErrKeyMismatch},
+		{"revoked key", rrset, "example.com", &revoked, testNow, ErrKeyMismatch},
+		{"signer not ancestor", testRRset(t, "www.example.org", "192.0.2.1"), "example.com", key, testNow, nil},
+		{"empty RRset", nil, "example.com", key, testNow, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyRRSIG(test.rrset, sig, dns.MustParseName(test.owner), test.key, test.now)
+			if err == nil {
+				t.Fatal("VerifyRRSIG() should return error")
+			}
+			if test.expected != nil && !errors.Is(err, test.expected) {
+				t.Errorf("VerifyRRSIG() = %v, want %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestVerifyWildcardExpansion(t *testing.T) {
+	keyRR, signer := testKey(t, "example.com", records.AlgorithmECDSAP256SHA256)
+	key := keyRR.RData.(*records.DNSKEYRecord)
+
+	sigRR, err := SignRRset(testRRset(t, "*.example.com", "192.0.2.1"), keyRR.Name, key, signer, testInception, testExpiration)
+	if err != nil {
+		t.Fatalf("SignRRset() returned error: %v", err)
+	}
+	sig := sigRR.RData.(*records.RRSIGRecord)
+	if sig.Labels != 2 {
+		t.Errorf("RRSIG labels = %d, want 2", sig.Labels)
+	}
+
+	expanded := testRRset(t, "host.sub.example.com", "192.0.2.1")
+	if err := VerifyRRSIG(expanded, sig, keyRR.Name, key, testNow); err != nil {
+		t.Errorf("VerifyRRSIG() of wildcard expansion returned error: %v", err)
+	}
+}
+
+func TestSignAlgorithmMismatch(t *testing.T) {
+	keyRR, _ := testKey(t, "example.com", records.AlgorithmED25519)
+	_, ecdsaSigner := testKey(t, "example.com", records.AlgorithmECDSAP256SHA256)
+
+	_, err := SignRRset(testRRset(t, "example.com", "192.0.2.1"), keyRR.Name,
+		keyRR.RData.(*records.DNSKEYRecord), ecdsaSigner, testInception, testExpiration)
+	if err == nil {
+		t.Error("SignRRset() should return error when the private key does not match the algorithm")
+	}
+}
@@ -0,0 +1,179 @@
+package dnstap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ContentType is the Frame Streams content type of dnstap data frames
+const ContentType = "protobuf:dnstap.Dnstap"
+
+// Frame Streams control frame types and fields
+// (https://farsightsec.github.io/fstrm/)
+const (
+	controlAccept = 0x01
+	controlStart  = 0x02
+	controlStop   = 0x03
+	controlReady  = 0x04
+	controlFinish = 0x05
+
+	controlFieldContentType = 0x01
+
+	// maxControlFrame bounds the size of control frames read from the
+	// receiver
+	maxControlFrame = 512
+)
+
+// FrameWriter writes data frames of one content type as a Frame Stream.
+// A unidirectional stream, as written to files, starts with a START
+// frame; a bidirectional stream, as spoken over sockets, first negotiates
+// the content type with the receiver.
+type FrameWriter struct {
+	w             io.Writer
+	r             io.Reader     // Receiver of a bidirectional stream, or nil
+	timeout       time.Duration // Bound of each wait for a control frame, or zero
+	contentType   string
+	bidirectional bool
+}
+
+// deadliner is implemented by connections supporting read deadlines,
+// such as net.Conn
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// NewFrameWriter starts a unidirectional Frame Stream of the given content
+// type on w
+func NewFrameWriter(w io.Writer, contentType string) (*FrameWriter, error) {
+	fw := &FrameWriter{w: w, contentType: contentType}
+	if err := fw.writeControl(controlStart, contentType); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+// NewBidirectionalFrameWriter starts a bidirectional Frame Stream of the
+// given content type on rw: it sends READY, waits for an ACCEPT listing
+// the content type and sends START. If rw supports read deadlines, as a
+// net.Conn does, the waits for ACCEPT and for FINISH in Close each give
+// up after timeout; zero waits indefinitely.
+func NewBidirectionalFrameWriter(rw io.ReadWriter, contentType string, timeout time.Duration) (*FrameWriter, error) {
+	fw := &FrameWriter{w: rw, r: rw, timeout: timeout, contentType: contentType, bidirectional: true}
+	if err := fw.writeControl(controlReady, contentType); err != nil {
+		return nil, err
+	}
+
+	frameType, types, err := fw.readControl()
+	if err != nil {
+		return nil, err
+	}
+	if frameType != controlAccept {
+		return nil, fmt.Errorf("frame stream: expected ACCEPT, got control frame 0x%02X", frameType)
+	}
+	accepted := false
+	for _, t := range types {
+		accepted = accepted || t == contentType
+	}
+	if !accepted {
+		return nil, fmt.Errorf("frame stream: receiver does not accept content type %q", contentType)
+	}
+
+	if err := fw.writeControl(controlStart, contentType); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+// WriteFrame writes a data frame
+func (fw *FrameWriter) WriteFrame(data []byte) error {
+	if len(data) == 0 || uint64(len(data)) > 0xFFFFFFFF {
+		return fmt.Errorf("frame stream: invalid data frame length %d", len(data))
+	}
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	frame = append(frame, data...)
+	if _, err := fw.w.Write(frame); err != nil {
+		return fmt.Errorf("frame stream: %w", err)
+	}
+	return nil
+}
+
+// Close ends the stream with a STOP frame and, on a bidirectional
+// stream, waits for the receiver's FINISH. The underlying writer is not
+// closed.
+func (fw *FrameWriter) Close() error {
+	if err := fw.writeControl(controlStop, ""); err != nil {
+		return err
+	}
+	if !fw.bidirectional {
+		return nil
+	}
+	frameType, _, err := fw.readControl()
+	if err != nil {
+		return err
+	}
+	if frameType != controlFinish {
+		return fmt.Errorf("frame stream: expected FINISH, got control frame 0x%02X", frameType)
+	}
+	return nil
+}
+
+// writeControl writes a control frame with an optional content type field
+func (fw *FrameWriter) writeControl(frameType uint32, contentType string) error {
+	body := binary.BigEndian.AppendUint32(nil, frameType)
+	if contentType != "" {
+		body = binary.BigEndian.AppendUint32(body, controlFieldContentType)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(contentType)))
+		body = append(body, contentType...)
+	}
+
+	// An escape sequence, a zero-length data frame, introduces the frame
+	frame := binary.BigEndian.AppendUint32(make([]byte, 4), uint32(len(body)))
+	frame = append(frame, body...)
+	if _, err := fw.w.Write(frame); err != nil {
+		return fmt.Errorf("frame stream: %w", err)
+	}
+	return nil
+}
+
+// readControl reads a control frame and returns its type and content
+// types, within the timeout when the receiver supports deadlines
+func (fw *FrameWriter) readControl() (uint32, []string, error) {
+	if d, ok := fw.r.(deadliner); ok && fw.timeout > 0 {
+		if err := d.SetReadDeadline(time.Now().Add(fw.timeout)); err != nil {
+			return 0, nil, fmt.Errorf("frame stream: %w", err)
+		}
+		defer d.SetReadDeadline(time.Time{})
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(fw.r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("frame stream: reading control frame: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	if binary.BigEndian.Uint32(header[0:4]) != 0 || length < 4 || length > maxControlFrame {
+		return 0, nil, fmt.Errorf("frame stream: invalid control frame % x", header)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(fw.r, body); err != nil {
+		return 0, nil, fmt.Errorf("frame stream: reading control frame: %w", err)
+	}
+
+	frameType := binary.BigEndian.Uint32(body[0:4])
+	var types []string
+	fields := body[4:]
+	for len(fields) >= 8 {
+		fieldType := binary.BigEndian.Uint32(fields[0:4])
+		fieldLen := binary.BigEndian.Uint32(fields[4:8])
+		if uint64(fieldLen) > uint64(len(fields)-8) {
+			return 0, nil, fmt.Errorf("frame stream: control field of %d bytes truncated", fieldLen)
+		}
+		if fieldType == controlFieldContentType {
+			types = append(types, string(bytes.Clone(fields[8:8+fieldLen])))
+		}
+		fields = fields[8+fieldLen:]
+	}
+	return frameType, types, nil
+}
@@ -0,0 +1,246 @@
+package dnstap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame is a Frame Streams frame as read by readFrame
+type frame struct {
+	control     uint32 // Control frame type; 0 for data frames
+	contentType string
+	data        []byte
+}
+
+// readFrame reads one frame
+func readFrame(r io.Reader) (frame, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return frame{}, err
+	}
+	if n := binary.BigEndian.Uint32(length[:]); n != 0 {
+		data := make([]byte, n)
+		_, err := io.ReadFull(r, data)
+		return frame{data: data}, err
+	}
+
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return frame{}, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(length[:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return frame{}, err
+	}
+	f := frame{control: binary.BigEndian.Uint32(body[0:4])}
+	if len(body) > 12 && binary.BigEndian.Uint32(body[4:8]) == controlFieldContentType {
+		f.contentType = string(body[12 : 12+binary.BigEndian.Uint32(body[8:12])])
+	}
+	return f, nil
+}
+
+// mustReadFrame reads one frame, failing the test on errors
+func mustReadFrame(t *testing.T, r io.Reader) frame {
+	t.Helper()
+	f, err := readFrame(r)
+	if err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	return f
+}
+
+// writeControlFrame writes a control frame as a receiver would
+func writeControlFrame(w io.Writer, frameType uint32, contentTypes ...string) {
+	body := binary.BigEndian.AppendUint32(nil, frameType)
+	for _, ct := range contentTypes {
+		body = binary.BigEndian.AppendUint32(body, controlFieldContentType)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(ct)))
+		body = append(body, ct...)
+	}
+	w.Write(binary.BigEndian.AppendUint32(make([]byte, 4), uint32(len(body))))
+	w.Write(body)
+}
+
+func TestFrameWriterUnidirectional(t *testing.T) {
+	var buf bytes.Buffer
+	fw, err := NewFrameWriter(&buf, ContentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.WriteFrame([]byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.WriteFrame(nil); err == nil {
+		t.Error("WriteFrame(nil) returned no error")
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := mustReadFrame(t, &buf); f.control != controlStart || f.contentType != ContentType {
+		t.Errorf("first frame = %+v, want START with the dnstap content type", f)
+	}
+	if f := mustReadFrame(t, &buf); string(f.data) != "one" {
+		t.Errorf("second frame = %+v, want data \"one\"", f)
+	}
+	if f := mustReadFrame(t, &buf); f.control != controlStop {
+		t.Errorf("third frame = %+v, want STOP", f)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d bytes after STOP", buf.Len())
+	}
+}
+
+// serveFrames accepts one bidirectional stream on the listener, accepting
+// the given content types, and sends the data frames it receives
+func serveFrames(t *testing.T, listener net.Listener, accept ...string) <-chan []byte {
+	frames := make(chan []byte, 10)
+	go func() {
+		defer close(frames)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		if f, err := readFrame(conn); err != nil || f.control != controlReady || f.contentType != ContentType {
+			t.Errorf("first frame = %+v, %v, want READY", f, err)
+			return
+		}
+		writeControlFrame(conn, controlAccept, accept...)
+		for {
+			f, err := readFrame(conn)
+			switch {
+			case err != nil:
+				return
+			case f.control == controlStop:
+				writeControlFrame(conn, controlFinish)
+				return
+			case f.control == 0:
+				frames <- f.data
+			}
+		}
+	}()
+	return frames
+}
+
+func TestOpenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnstap.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("cannot listen on a unix socket: %v", err)
+	}
+	defer listener.Close()
+	frames := serveFrames(t, listener, "protobuf:other", ContentType)
+
+	logger, err := Open("unix:" + path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	if err := logger.Log(&Message{Type: ClientQuery, QueryMessage: []byte{1}}); err != nil {
+		t.Fatalf("Log() returned error: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	var received [][]byte
+	for data := range frames {
+		received = append(received, data)
+	}
+	if len(received) != 1 || !bytes.Equal(received[0], Marshal(nil, nil, &Message{Type: ClientQuery, QueryMessage: []byte{1}})) {
+		t.Errorf("received frames %x, want the logged message", received)
+	}
+}
+
+func TestOpenUnixSocketRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnstap.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("cannot listen on a unix socket: %v", err)
+	}
+	defer listener.Close()
+	serveFrames(t, listener, "protobuf:other")
+
+	if _, err := Open("unix:" + path); err == nil || !strings.Contains(err.Error(), "does not accept") {
+		t.Errorf("Open() error = %v, want a rejected content type", err)
+	}
+}
+
+func TestFrameWriterUnresponsiveReceiver(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept bool // Whether the receiver answers READY before going silent
+	}{
+		{"no ACCEPT", false},
+		{"no FINISH", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, receiver := net.Pipe()
+			defer client.Close()
+			defer receiver.Close()
+			go func() {
+				if _, err := readFrame(receiver); err != nil {
+					return
+				}
+				if tt.accept {
+					writeControlFrame(receiver, controlAccept, ContentType)
+				}
+				// Read the remaining frames without answering
+				io.Copy(io.Discard, receiver)
+			}()
+
+			fw, err := NewBidirectionalFrameWriter(client, ContentType, 50*time.Millisecond)
+			if !tt.accept {
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					t.Errorf("NewBidirectionalFrameWriter() error = %v, want a timeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewBidirectionalFrameWriter() returned error: %v", err)
+			}
+			if err := fw.Close(); !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Errorf("Close() error = %v, want a timeout", err)
+			}
+		})
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.tap")
+	logger, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	logger.Identity = []byte("host")
+	if err := logger.Log(&Message{Type: ClientResponse}); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(data)
+	mustReadFrame(t, buf) // START
+	f := mustReadFrame(t, buf)
+	if outer := decodeProto(t, f.data); string(outer[fieldIdentity].bytes) != "host" {
+		t.Errorf("logged frame = % x, want identity host", f.data)
+	}
+	if f := mustReadFrame(t, buf); f.control != controlStop {
+		t.Errorf("last frame = %+v, want STOP", f)
+	}
+}
@@ -0,0 +1,89 @@
+package dnstap
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// socketTimeout bounds connecting to a dnstap socket and each wait for a
+// control frame of the collector
+const socketTimeout = 5 * time.Second
+
+// Logger writes dnstap messages to a Frame Stream. It is safe for
+// concurrent use.
+type Logger struct {
+	Identity []byte // Name of the logging host, sent with every message; may be nil
+	Version  []byte // Software version sent with every message; may be nil
+
+	mu     sync.Mutex
+	fw     *FrameWriter
+	closer io.Closer // Closed by Close, or nil
+}
+
+// NewLogger starts a unidirectional dnstap Frame Stream on w, as for a
+// file
+func NewLogger(w io.Writer) (*Logger, error) {
+	fw, err := NewFrameWriter(w, ContentType)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{fw: fw}, nil
+}
+
+// Open returns a logger writing to target: a unix socket given as
+// "unix:/path", where a dnstap collector listens, or else a file, which is
+// created or truncated. A collector that does not answer the handshake or
+// Close within five seconds is given up on.
+func Open(target string) (*Logger, error) {
+	if path, ok := strings.CutPrefix(target, "unix:"); ok {
+		conn, err := net.DialTimeout("unix", path, socketTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to dnstap socket: %w", err)
+		}
+		fw, err := NewBidirectionalFrameWriter(conn, ContentType, socketTimeout)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return &Logger{fw: fw, closer: conn}, nil
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dnstap file: %w", err)
+	}
+	logger, err := NewLogger(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	logger.closer = file
+	return logger, nil
+}
+
+// Log writes a message as a dnstap frame
+func (l *Logger) Log(m *Message) error {
+	frame := Marshal(l.Identity, l.Version, m)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fw.WriteFrame(frame)
+}
+
+// Close ends the Frame Stream and closes the file or socket opened by
+// Open
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.fw.Close()
+	if l.closer != nil {
+		if closeErr := l.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+// Package dnstap encodes dnstap messages (https://dnstap.info) and writes
+// them as Frame Streams to files and unix sockets. The protobuf encoding
+// of dnstap.proto is written by hand.
+package dnstap
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"time"
+)
+
+// MessageType is the kind of a logged DNS message (dnstap.Message.Type)
+type MessageType uint8
+
+// Message types of dnstap.proto
+const (
+	AuthQuery         MessageType = 1
+	AuthResponse      MessageType = 2
+	ResolverQuery     MessageType = 3
+	ResolverResponse  MessageType = 4
+	ClientQuery       MessageType = 5
+	ClientResponse    MessageType = 6
+	ForwarderQuery    MessageType = 7
+	ForwarderResponse MessageType = 8
+	StubQuery         MessageType = 9
+	StubResponse      MessageType = 10
+	ToolQuery         MessageType = 11
+	ToolResponse      MessageType = 12
+)
+
+// String returns the dnstap.proto name of the message type
+func (t MessageType) String() string {
+	switch t {
+	case AuthQuery:
+		return "AUTH_QUERY"
+	case AuthResponse:
+		return "AUTH_RESPONSE"
+	case ResolverQuery:
+		return "RESOLVER_QUERY"
+	case ResolverResponse:
+		return "RESOLVER_RESPONSE"
+	case ClientQuery:
+		return "CLIENT_QUERY"
+	case ClientResponse:
+		return "CLIENT_RESPONSE"
+	case ForwarderQuery:
+		return "FORWARDER_QUERY"
+	case ForwarderResponse:
+		return "FORWARDER_RESPONSE"
+	case StubQuery:
+		return "STUB_QUERY"
+	case StubResponse:
+		return "STUB_RESPONSE"
+	case ToolQuery:
+		return "TOOL_QUERY"
+	case ToolResponse:
+		return "TOOL_RESPONSE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Socket families and protocols of dnstap.proto
+const (
+	familyINET  = 1
+	familyINET6 = 2
+
+	protocolUDP = 1
+	protocolTCP = 2
+)
+
+// Message is a logged DNS message with its transport details. Zero
+// addresses, times and nil messages are left out of the encoding.
+type Message struct {
+	Type            MessageType
+	Network         string         // "udp" or "tcp"; empty if unknown
+	QueryAddress    netip.AddrPort // Address of the querying side
+	ResponseAddress netip.AddrPort // Address of the responding side
+	QueryTime       time.Time
+	QueryMessage    []byte // Wire-format query
+	ResponseTime    time.Time
+	ResponseMessage []byte // Wire-format response
+}
+
+// Field numbers of dnstap.proto
+const (
+	fieldIdentity = 1
+	fieldVersion  = 2
+	fieldMessage  = 14
+	fieldType     = 15
+
+	fieldMessageType      = 1
+	fieldSocketFamily     = 2
+	fieldSocketProtocol   = 3
+	fieldQueryAddress     = 4
+	fieldResponseAddress  = 5
+	fieldQueryPort        = 6
+	fieldResponsePort     = 7
+	fieldQueryTimeSec     = 8
+	fieldQueryTimeNsec    = 9
+	fieldQueryMessage     = 10
+	fieldResponseTimeSec  = 12
+	fieldResponseTimeNsec = 13
+	fieldResponseMessage  = 14
+
+	typeMessage = 1 // dnstap.Dnstap.Type MESSAGE
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Marshal returns the protobuf encoding of a dnstap.Dnstap message of
+// type MESSAGE carrying m. Identity and version are optional.
+func Marshal(identity, version []byte, m *Message) []byte {
+	var b []byte
+	if identity != nil {
+		b = appendBytes(b, fieldIdentity, identity)
+	}
+	if version != nil {
+		b = appendBytes(b, fieldVersion, version)
+	}
+	b = appendBytes(b, fieldMessage, m.marshal())
+	return appendVarint(b, fieldType, typeMessage)
+}
+
+// marshal returns the protobuf encoding of the dnstap.Message
+func (m *Message) marshal() []byte {
+	b := appendVarint(nil, fieldMessageType, uint64(m.Type))
+
+	addr := m.QueryAddress
+	if !addr.IsValid() {
+		addr = m.ResponseAddress
+	}
+	if addr.IsValid() {
+		family := uint64(familyINET6)
+		if addr.Addr().Unmap().Is4() {
+			family = familyINET
+		}
+		b = appendVarint(b, fieldSocketFamily, family)
+	}
+	switch m.Network {
+	case "udp":
+		b = appendVarint(b, fieldSocketProtocol, protocolUDP)
+	case "tcp":
+		b = appendVarint(b, fieldSocketProtocol, protocolTCP)
+	}
+
+	if m.QueryAddress.IsValid() {
+		b = appendBytes(b, fieldQueryAddress, m.QueryAddress.Addr().Unmap().AsSlice())
+	}
+	if m.ResponseAddress.IsValid() {
+		b = appendBytes(b, fieldResponseAddress, m.ResponseAddress.Addr().Unmap().AsSlice())
+	}
+	if m.QueryAddress.IsValid() {
+		b = appendVarint(b, fieldQueryPort, uint64(m.QueryAddress.Port()))
+	}
+	if m.ResponseAddress.IsValid() {
+		b = appendVarint(b, fieldResponsePort, uint64(m.ResponseAddress.Port()))
+	}
+
+	if !m.QueryTime.IsZero() {
+		b = appendVarint(b, fieldQueryTimeSec, uint64(m.QueryTime.Unix()))
+		b = appendFixed32(b, fieldQueryTimeNsec, uint32(m.QueryTime.Nanosecond()))
+	}
+	if m.QueryMessage != nil {
+		b = appendBytes(b, fieldQueryMessage, m.QueryMessage)
+	}
+	if !m.ResponseTime.IsZero() {
+		b = appendVarint(b, fieldResponseTimeSec, uint64(m.ResponseTime.Unix()))
+		b = appendFixed32(b, fieldResponseTimeNsec, uint32(m.ResponseTime.Nanosecond()))
+	}
+	if m.ResponseMessage != nil {
+		b = appendBytes(b, fieldResponseMessage, m.ResponseMessage)
+	}
+	return b
+}
+
+// appendTag appends a field key
+func appendTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// appendVarint appends a varint field
+func appendVarint(b []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendTag(b, field, wireVarint), v)
+}
+
+// appendFixed32 appends a fixed32 field
+func appendFixed32(b []byte, field int, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(appendTag(b, field, wireFixed32), v)
+}
+
+// appendBytes appends a length-delimited field
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,122 @@
+package dnstap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// protoField is a decoded protobuf field
+type protoField struct {
+	wireType int
+	varint   uint64
+	bytes    []byte
+}
+
+// decodeProto decodes the fields of a protobuf message by field number
+func decodeProto(t *testing.T, b []byte) map[int]protoField {
+	t.Helper()
+	fields := make(map[int]protoField)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid field key in % x", b)
+		}
+		b = b[n:]
+		field := protoField{wireType: int(key & 7)}
+		switch field.wireType {
+		case wireVarint:
+			field.varint, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("invalid varint in % x", b)
+			}
+			b = b[n:]
+		case wireFixed32:
+			field.varint = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				t.Fatalf("invalid length in % x", b)
+			}
+			field.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", field.wireType)
+		}
+		fields[int(key>>3)] = field
+	}
+	return fields
+}
+
+func TestMarshal(t *testing.T) {
+	queryTime := time.Unix(1714564800, 123456789)
+	responseTime := time.Unix(1714564801, 5)
+	m := &Message{
+		Type:            ClientResponse,
+		Network:         "tcp",
+		QueryAddress:    netip.MustParseAddrPort("[2001:db8::10]:40000"),
+		ResponseAddress: netip.MustParseAddrPort("[2001:db8::53]:53"),
+		QueryTime:       queryTime,
+		ResponseTime:    responseTime,
+		ResponseMessage: []byte{0x12, 0x34},
+	}
+
+	outer := decodeProto(t, Marshal([]byte("host"), []byte("goDNS"), m))
+	if string(outer[fieldIdentity].bytes) != "host" || string(outer[fieldVersion].bytes) != "goDNS" {
+		t.Errorf("identity, version = %q, %q, want host, goDNS", outer[fieldIdentity].bytes, outer[fieldVersion].bytes)
+	}
+	if outer[fieldType].varint != typeMessage {
+		t.Errorf("type = %d, want MESSAGE", outer[fieldType].varint)
+	}
+
+	inner := decodeProto(t, outer[fieldMessage].bytes)
+	for _, want := range []struct {
+		field int
+		value uint64
+	}{
+		{fieldMessageType, uint64(ClientResponse)},
+		{fieldSocketFamily, familyINET6},
+		{fieldSocketProtocol, protocolTCP},
+		{fieldQueryPort, 40000},
+		{fieldResponsePort, 53},
+		{fieldQueryTimeSec, 1714564800},
+		{fieldQueryTimeNsec, 123456789},
+		{fieldResponseTimeSec, 1714564801},
+		{fieldResponseTimeNsec, 5},
+	} {
+		if got := inner[want.field].varint; got != want.value {
+			t.Errorf("field %d = %d, want %d", want.field, got, want.value)
+		}
+	}
+	if inner[fieldQueryTimeNsec].wireType != wireFixed32 {
+		t.Errorf("query_time_nsec wire type = %d, want fixed32", inner[fieldQueryTimeNsec].wireType)
+	}
+	if addr := netip.MustParseAddr("2001:db8::53").As16(); !bytes.Equal(inner[fieldResponseAddress].bytes, addr[:]) {
+		t.Errorf("response_address = % x, want 2001:db8::53", inner[fieldResponseAddress].bytes)
+	}
+	if !bytes.Equal(inner[fieldResponseMessage].bytes, []byte{0x12, 0x34}) {
+		t.Errorf("response_message = % x, want 12 34", inner[fieldResponseMessage].bytes)
+	}
+	if _, ok := inner[fieldQueryMessage]; ok {
+		t.Error("query_message encoded for a nil query")
+	}
+}
+
+func TestMarshalMinimal(t *testing.T) {
+	outer := decodeProto(t, Marshal(nil, nil, &Message{Type: ClientQuery, QueryAddress: netip.MustParseAddrPort("192.0.2.1:5353")}))
+	if _, ok := outer[fieldIdentity]; ok {
+		t.Error("identity encoded although nil")
+	}
+	inner := decodeProto(t, outer[fieldMessage].bytes)
+	if inner[fieldSocketFamily].varint != familyINET || len(inner[fieldQueryAddress].bytes) != 4 {
+		t.Errorf("socket family, query address = %d, % x, want INET and 4 bytes", inner[fieldSocketFamily].varint, inner[fieldQueryAddress].bytes)
+	}
+	for _, field := range []int{fieldSocketProtocol, fieldResponseAddress, fieldQueryTimeSec, fieldResponseMessage} {
+		if _, ok := inner[field]; ok {
+			t.Errorf("field %d encoded although unset", field)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package metrics records counters and histograms and exports them in the
+// Prometheus text exposition format
+// (https://prometheus.io/docs/instrumenting/exposition_formats/), written
+// against the standard library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram upper bounds in seconds suited to DNS
+// latencies, from one millisecond to ten seconds
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds metrics and writes them in the text exposition format.
+// It is an http.Handler serving them, as for a /metrics endpoint, and is
+// safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// metric is a counter or histogram of a registry
+type metric interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a metric, panicking on a duplicate or invalid name as
+// registration happens at startup
+func (r *Registry) register(name string, labels []string, m metric) {
+	if !validName(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !validName(label) || strings.Contains(label, ":") || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q of %s", label, name))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %s", name))
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes all metrics in the order they were registered, with
+// their series sorted by label values
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP writes the metrics as the response
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	if req.Method == http.MethodHead {
+		return
+	}
+	r.WriteText(w)
+}
+
+// family holds the series of a metric, keyed by their label values
+type family[S any] struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*S
+	values map[string][]string // Label values of each key
+}
+
+// get returns the series with the given label values, creating it
+func (f *family[S]) get(values []string, create func() *S) *S {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+		f.values[key] = slices.Clone(values)
+	}
+	return s
+}
+
+// sortedKeys returns the series keys in order
+func (f *family[S]) sortedKeys() []string {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// writeHeader writes the HELP and TYPE lines
+func (f *family[S]) writeHeader(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, kind)
+	return err
+}
+
+// Counter is a metric counting events, split into series by its labels
+type Counter struct {
+	family[float64]
+}
+
+// NewCounter registers a counter. By convention its name ends in _total.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family[float64]{
+		name:   name,
+		help:   help,
+		labels: slices.Clone(labels),
+		series: make(map[string]*float64),
+		values: make(map[string][]string),
+	}}
+	r.register(name, labels, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s decreased by %v", c.name, v))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.get(labelValues, func() *float64 { return new(float64) }) += v
+}
+
+// Value returns the value of the series with the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[strings.Join(labelValues, "\xff")]; ok {
+		return *s
+	}
+	return 0
+}
+
+// write writes the counter in the text exposition format
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.writeHeader(w, "counter"); err != nil {
+		return err
+	}
+	for _, key := range c.sortedKeys() {
+		labels := formatLabels(c.labels, c.values[key], "")
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatValue(*c.series[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram is a metric counting observations, such as latencies, in
+// buckets by upper bound, split into series by its labels
+type Histogram struct {
+	family[histogramSeries]
+	buckets []float64
+}
+
+// histogramSeries holds the non-cumulative bucket counts of a series; the
+// last count is that of the +Inf bucket
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds,
+// which must be increasing. A nil buckets uses DefaultBuckets.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic(fmt.Sprintf("metrics: buckets of %s are not increasing", name))
+		}
+	}
+	h := &Histogram{
+		family: family[histogramSeries]{
+			name:   name,
+			help:   help,
+			labels: slices.Clone(labels),
+			series: make(map[string]*histogramSeries),
+			values: make(map[string][]string),
+		},
+		buckets: slices.Clone(buckets),
+	}
+	r.register(name, labels, h)
+	return h
+}
+
+// Observe adds an observation to the series with the given label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	i, _ := slices.BinarySearch(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues, func() *histogramSeries {
+		return &histogramSeries{counts: make([]uint64, len(h.buckets)+1)}
+	})
+	s.counts[i]++
+	s.sum += v
+}
+
+// Count returns the number of observations of the series with the given
+// label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[strings.Join(labelValues, "\xff")]
+	if !ok {
+		return 0
+	}
+	var count uint64
+	for _, n := range s.counts {
+		count += n
+	}
+	return count
+}
+
+// write writes the histogram in the text exposition format, with
+// cumulative buckets
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.writeHeader(w, "histogram"); err != nil {
+		return err
+	}
+	for _, key := range h.sortedKeys() {
+		s, values := h.series[key], h.values[key]
+		var count uint64
+		for i, n := range s.counts {
+			count += n
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			labels := formatLabels(h.labels, values, formatValue(le))
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels, count); err != nil {
+				return err
+			}
+		}
+		labels := formatLabels(h.labels, values, "")
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", h.name, labels, formatValue(s.sum), h.name, labels, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatLabels returns the label set of a sample, with an le label for
+// histogram buckets unless le is empty
+func formatLabels(names, values []string, le string) string {
+	if len(names) == 0 && le == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", name, escapeLabel(values[i]))
+	}
+	if le != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "le=\"%s\"", le)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatValue formats a sample value, spelling infinities as +Inf and -Inf
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeHelp escapes backslashes and line feeds of a HELP text
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabel escapes backslashes, double quotes and line feeds of a label
+// value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// validName reports whether s matches [a-zA-Z_:][a-zA-Z0-9_:]*
+func validName(s string) bool {
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
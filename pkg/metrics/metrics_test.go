@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	queries := r.NewCounter("test_queries_total", "Queries sent.\nBy server.", "server", "type")
+	duration := r.NewHistogram("test_duration_seconds", "Exchange time.", []float64{0.1, 1})
+	r.NewCounter("test_unused_total", "Never incremented.")
+
+	queries.Inc("b", "A")
+	queries.Add(2, "a", "AAAA")
+	queries.Inc(`c"\`, "A")
+	duration.Observe(0.05)
+	duration.Observe(0.1)
+	duration.Observe(3)
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_queries_total Queries sent.\nBy server.
+# TYPE test_queries_total counter
+test_queries_total{server="a",type="AAAA"} 2
+test_queries_total{server="b",type="A"} 1
+test_queries_total{server="c\"\\",type="A"} 1
+# HELP test_duration_seconds Exchange time.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 2
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 3.15
+test_duration_seconds_count 3
+# HELP test_unused_total Never incremented.
+# TYPE test_unused_total counter
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+	if got := duration.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+	if got := queries.Value("z", "A"); got != 0 {
+		t.Errorf("Value() of missing series = %v, want 0", got)
+	}
+}
+
+func TestHistogramLabels(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("test_seconds", "Help.", []float64{1}, "server")
+	h.Observe(0.5, "x")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`test_seconds_bucket{server="x",le="1"} 1`,
+		`test_seconds_bucket{server="x",le="+Inf"} 1`,
+		`test_seconds_sum{server="x"} 0.5`,
+		`test_seconds_count{server="x"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("WriteText() = %q, missing %q", b.String(), line)
+		}
+	}
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"invalid name", func(r *Registry) { r.NewCounter("1_total", "Help.") }},
+		{"invalid label", func(r *Registry) { r.NewCounter("a_total", "Help.", "a-b") }},
+		{"reserved label", func(r *Registry) { r.NewHistogram("a_seconds", "Help.", nil, "le") }},
+		{"duplicate", func(r *Registry) { r.NewCounter("a_total", "Help."); r.NewCounter("a_total", "Help.") }},
+		{"unsorted buckets", func(r *Registry) { r.NewHistogram("a_seconds", "Help.", []float64{1, 1}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registration did not panic")
+				}
+			}()
+			tt.register(NewRegistry())
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Help.").Inc()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if !strings.Contains(string(body), "test_total 1\n") {
+		t.Errorf("body = %q, want test_total 1", body)
+	}
+
+	resp, err = http.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
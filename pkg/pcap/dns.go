@@ -0,0 +1,211 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// DefaultPort is the port DNSReader looks for
+const DefaultPort = 53
+
+// maxStreamBuffer bounds the bytes buffered for one TCP direction,
+// in order and out of order, before the stream is dropped
+const maxStreamBuffer = 1 << 20
+
+// Message is a DNS message found in a capture, in wire format
+type Message struct {
+	Timestamp time.Time // Time of the packet completing the message
+	Network   string    // "udp" or "tcp"
+	Src, Dst  netip.AddrPort
+	Data      []byte
+}
+
+// DNSReader extracts the DNS messages of a capture: the payloads of UDP
+// datagrams and the length-prefixed messages of reassembled TCP streams
+// from or to Port. Packets that cannot be decoded are counted in Skipped.
+type DNSReader struct {
+	Port    uint16 // Server port; DefaultPort unless changed before the first Next
+	Skipped int    // Packets skipped because Decode failed
+
+	r       *Reader
+	streams map[flow]*stream
+	pending []*Message
+}
+
+// stream reassembles one direction of a TCP connection
+type stream struct {
+	next     uint32            // Sequence number of the next in-order byte
+	data     []byte            // In-order bytes not yet consumed as messages
+	early    map[uint32][]byte // Out-of-order segments by sequence number
+	buffered int               // Bytes in early
+}
+
+// NewDNSReader returns a reader of the DNS messages in the capture read
+// by r
+func NewDNSReader(r *Reader) *DNSReader {
+	return &DNSReader{Port: DefaultPort, r: r, streams: make(map[flow]*stream)}
+}
+
+// Next returns the next DNS message, or io.EOF at the end of the capture.
+// Messages are returned in the order their last byte was captured.
+func (d *DNSReader) Next() (*Message, error) {
+	for len(d.pending) == 0 {
+		packet, err := d.r.Next()
+		if err != nil {
+			return nil, err
+		}
+		segment, err := Decode(packet)
+		if errors.Is(err, ErrUnsupported) {
+			d.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if segment.Src.Port() != d.Port && segment.Dst.Port() != d.Port {
+			continue
+		}
+
+		switch segment.Network {
+		case "udp":
+			if len(segment.Payload) > 0 {
+				d.pending = append(d.pending, messageOf(segment, segment.Payload))
+			}
+		case "tcp":
+			d.reassemble(segment)
+		}
+	}
+
+	msg := d.pending[0]
+	d.pending = d.pending[1:]
+	return msg, nil
+}
+
+// ReadAll returns the remaining DNS messages of the capture
+func (d *DNSReader) ReadAll() ([]*Message, error) {
+	var messages []*Message
+	for {
+		msg, err := d.Next()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+}
+
+// messageOf returns the message with the given data carried by a segment
+func messageOf(segment *Segment, data []byte) *Message {
+	return &Message{
+		Timestamp: segment.Timestamp,
+		Network:   segment.Network,
+		Src:       segment.Src,
+		Dst:       segment.Dst,
+		Data:      data,
+	}
+}
+
+// reassemble adds a TCP segment to its stream and queues the messages it
+// completes. Streams without a SYN start at their first segment.
+// Retransmitted bytes are dropped and later segments are held until the
+// gap before them is filled.
+func (d *DNSReader) reassemble(segment *Segment) {
+	key := flow{segment.Network, segment.Src, segment.Dst}
+	s := d.streams[key]
+	if segment.Flags&tcpRST != 0 {
+		delete(d.streams, key)
+		return
+	}
+
+	seq := segment.Seq
+	if segment.Flags&tcpSYN != 0 {
+		// A new connection on the same ports replaces the old stream
+		s = &stream{next: seq + 1}
+		d.streams[key] = s
+		seq++
+	}
+	if s == nil {
+		s = &stream{next: seq}
+		d.streams[key] = s
+	}
+
+	if len(segment.Payload) > 0 {
+		s.add(seq, segment.Payload)
+		for _, data := range s.messages() {
+			d.pending = append(d.pending, messageOf(segment, data))
+		}
+		if len(s.data)+s.buffered > maxStreamBuffer {
+			delete(d.streams, key)
+			return
+		}
+	}
+
+	if segment.Flags&tcpFIN != 0 {
+		delete(d.streams, key)
+	}
+}
+
+// add adds the payload starting at sequence number seq
+func (s *stream) add(seq uint32, payload []byte) {
+	// Sequence numbers wrap; compare them as signed differences
+	diff := int32(seq - s.next)
+	if diff > 0 {
+		if s.early == nil {
+			s.early = make(map[uint32][]byte)
+		}
+		if _, ok := s.early[seq]; !ok {
+			s.early[seq] = payload
+			s.buffered += len(payload)
+		}
+		return
+	}
+	if -int(diff) >= len(payload) {
+		return // Retransmission of consumed bytes
+	}
+	payload = payload[-diff:]
+
+	s.data = append(s.data, payload...)
+	s.next += uint32(len(payload))
+
+	// Move held segments that are now in order
+	for len(s.early) > 0 {
+		moved := false
+		for seq, early := range s.early {
+			if diff := int32(seq - s.next); diff <= 0 {
+				delete(s.early, seq)
+				s.buffered -= len(early)
+				if -int(diff) < len(early) {
+					s.data = append(s.data, early[-diff:]...)
+					s.next += uint32(len(early) + int(diff))
+				}
+				moved = true
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
+
+// messages consumes and returns the complete length-prefixed messages at
+// the start of the in-order bytes
+func (s *stream) messages() [][]byte {
+	var messages [][]byte
+	for len(s.data) >= 2 {
+		length := int(binary.BigEndian.Uint16(s.data[0:2]))
+		if len(s.data) < 2+length {
+			break
+		}
+		messages = append(messages, append([]byte(nil), s.data[2:2+length]...))
+		s.data = s.data[2+length:]
+	}
+	if len(s.data) == 0 {
+		s.data = nil
+	}
+	return messages
+}
@@ -0,0 +1,117 @@
+package pcap
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// tcpCapture returns a RAW capture of TCP segments from the client to the
+// server at port 53, each given by its sequence number, flags and payload
+func tcpCapture(t *testing.T, segments ...*Segment) *Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, LinkTypeRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range segments {
+		tcp := tcpSegment(s.Src, s.Dst, s.Seq, 0, s.Payload)
+		tcp[13] = s.Flags | tcpACK
+		packet := ipPacket(s.Src.Addr(), s.Dst.Addr(), protoTCP, tcp)
+		if err := w.WritePacket(time.Unix(int64(i), 0), packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+var (
+	clientAddr = netip.MustParseAddrPort("192.0.2.10:40000")
+	serverAddr = netip.MustParseAddrPort("192.0.2.53:53")
+)
+
+// toServer returns a segment from the client to the server
+func toServer(seq uint32, flags uint8, payload string) *Segment {
+	return &Segment{Src: clientAddr, Dst: serverAddr, Seq: seq, Flags: flags, Payload: []byte(payload)}
+}
+
+func TestDNSReaderTCPReassembly(t *testing.T) {
+	r := tcpCapture(t,
+		toServer(999, tcpSYN, ""),
+		// "\x00\x03abc" split over two segments, the second arriving first
+		toServer(1004, 0, "c\x00\x02d"),
+		toServer(1000, 0, "\x00\x03ab"),
+		// Retransmission of bytes already seen, then the rest
+		toServer(1003, 0, "bc\x00\x02de"),
+		toServer(1009, 0, "\x00\x01f\x00\x01g"),
+		toServer(1015, tcpFIN, ""),
+	)
+
+	messages, err := NewDNSReader(r).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned error: %v", err)
+	}
+	want := []string{"abc", "de", "f", "g"}
+	if len(messages) != len(want) {
+		t.Fatalf("ReadAll() returned %d messages, want %d", len(messages), len(want))
+	}
+	for i, m := range messages {
+		if string(m.Data) != want[i] || m.Network != "tcp" || m.Src != clientAddr || m.Dst != serverAddr {
+			t.Errorf("message %d = %s %s > %s %q, want tcp %s > %s %q", i, m.Network, m.Src, m.Dst, m.Data, clientAddr, serverAddr, want[i])
+		}
+	}
+	// The first message is completed by the third packet
+	if !messages[0].Timestamp.Equal(time.Unix(2, 0)) {
+		t.Errorf("first message timestamp = %v, want %v", messages[0].Timestamp, time.Unix(2, 0))
+	}
+}
+
+func TestDNSReaderMidStream(t *testing.T) {
+	// A capture started after the handshake, with sequence numbers wrapping
+	r := tcpCapture(t,
+		toServer(0xFFFFFFFE, 0, "\x00\x04"),
+		toServer(0, 0, "wrap"),
+	)
+	messages, err := NewDNSReader(r).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned error: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Data) != "wrap" {
+		t.Errorf("ReadAll() = %v, want the message \"wrap\"", messages)
+	}
+}
+
+func TestDNSReaderUDP(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := netip.MustParseAddrPort("192.0.2.53:5353")
+	w.WriteDNS(time.Unix(1, 0), "udp", clientAddr, serverAddr, []byte("query"))
+	w.WriteDNS(time.Unix(2, 0), "udp", clientAddr, other, []byte("mdns"))
+	w.WritePacket(time.Unix(3, 0), make([]byte, 20)) // Not IP
+	w.WriteDNS(time.Unix(4, 0), "udp", serverAddr, clientAddr, []byte("response"))
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dnsReader := NewDNSReader(r)
+	messages, err := dnsReader.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned error: %v", err)
+	}
+	if len(messages) != 2 || string(messages[0].Data) != "query" || string(messages[1].Data) != "response" || messages[1].Src != serverAddr {
+		t.Errorf("ReadAll() = %v, want the query and the response", messages)
+	}
+	if dnsReader.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", dnsReader.Skipped)
+	}
+}
@@ -0,0 +1,301 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// Header sizes and protocol numbers
+const (
+	ethernetHeaderLen = 14
+	sllHeaderLen      = 16
+	ipv4HeaderLen     = 20
+	ipv6HeaderLen     = 40
+	udpHeaderLen      = 8
+	tcpHeaderLen      = 20
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86DD
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88A8
+
+	protoTCP = 6
+	protoUDP = 17
+)
+
+// TCP flags
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpRST = 0x04
+	tcpPSH = 0x08
+	tcpACK = 0x10
+)
+
+// ErrUnsupported is wrapped by the errors Decode returns for packets that
+// are not UDP or TCP over IPv4 or IPv6, and for IP fragments
+var ErrUnsupported = errors.New("unsupported packet")
+
+// Segment is the transport-layer content of a packet
+type Segment struct {
+	Timestamp time.Time
+	Network   string // "udp" or "tcp"
+	Src, Dst  netip.AddrPort
+	Payload   []byte
+
+	// TCP only
+	Seq   uint32
+	Flags uint8
+}
+
+// Decode returns the UDP datagram or TCP segment carried by a packet.
+// Packets truncated by the snapshot length are decoded as far as they were
+// captured.
+func Decode(p *Packet) (*Segment, error) {
+	ip, err := linkPayload(p.LinkType, p.Data)
+	if err != nil {
+		return nil, err
+	}
+	src, dst, proto, payload, err := ipPayload(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	segment := &Segment{Timestamp: p.Timestamp}
+	switch proto {
+	case protoUDP:
+		if len(payload) < udpHeaderLen {
+			return nil, fmt.Errorf("%w: UDP header truncated", ErrUnsupported)
+		}
+		segment.Network = "udp"
+		length := int(binary.BigEndian.Uint16(payload[4:6]))
+		if length >= udpHeaderLen && length < len(payload) {
+			payload = payload[:length]
+		}
+		segment.Payload = payload[udpHeaderLen:]
+	case protoTCP:
+		if len(payload) < tcpHeaderLen {
+			return nil, fmt.Errorf("%w: TCP header truncated", ErrUnsupported)
+		}
+		offset := int(payload[12]>>4) * 4
+		if offset < tcpHeaderLen || offset > len(payload) {
+			return nil, fmt.Errorf("%w: TCP data offset %d", ErrUnsupported, offset)
+		}
+		segment.Network = "tcp"
+		segment.Seq = binary.BigEndian.Uint32(payload[4:8])
+		segment.Flags = payload[13]
+		segment.Payload = payload[offset:]
+	default:
+		return nil, fmt.Errorf("%w: IP protocol %d", ErrUnsupported, proto)
+	}
+	segment.Src = netip.AddrPortFrom(src, binary.BigEndian.Uint16(payload[0:2]))
+	segment.Dst = netip.AddrPortFrom(dst, binary.BigEndian.Uint16(payload[2:4]))
+	return segment, nil
+}
+
+// linkPayload strips the link-layer header and returns the IP packet
+func linkPayload(linkType LinkType, data []byte) ([]byte, error) {
+	switch linkType {
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		return data, nil
+	case LinkTypeNull:
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%w: loopback header truncated", ErrUnsupported)
+		}
+		// The family is in host byte order; AF_INET is 2 everywhere and
+		// AF_INET6 is 10, 24, 28 or 30. The IP version nibble tells.
+		return data[4:], nil
+	case LinkTypeEthernet:
+		if len(data) < ethernetHeaderLen {
+			return nil, fmt.Errorf("%w: Ethernet header truncated", ErrUnsupported)
+		}
+		etherType := binary.BigEndian.Uint16(data[12:14])
+		data = data[ethernetHeaderLen:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < 4 {
+				return nil, fmt.Errorf("%w: VLAN tag truncated", ErrUnsupported)
+			}
+			etherType = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+		return etherPayload(etherType, data)
+	case LinkTypeLinuxSLL:
+		if len(data) < sllHeaderLen {
+			return nil, fmt.Errorf("%w: SLL header truncated", ErrUnsupported)
+		}
+		return etherPayload(binary.BigEndian.Uint16(data[14:16]), data[sllHeaderLen:])
+	default:
+		return nil, fmt.Errorf("%w: link type %s", ErrUnsupported, linkType)
+	}
+}
+
+// etherPayload returns data if the EtherType is IPv4 or IPv6
+func etherPayload(etherType uint16, data []byte) ([]byte, error) {
+	if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+		return nil, fmt.Errorf("%w: EtherType 0x%04X", ErrUnsupported, etherType)
+	}
+	return data, nil
+}
+
+// ipPayload returns the addresses, protocol and payload of an IPv4 or
+// IPv6 packet, skipping IPv6 extension headers. Fragments are not
+// reassembled.
+func ipPayload(data []byte) (src, dst netip.Addr, proto uint8, payload []byte, err error) {
+	if len(data) < 1 {
+		return src, dst, 0, nil, fmt.Errorf("%w: empty IP packet", ErrUnsupported)
+	}
+
+	switch data[0] >> 4 {
+	case 4:
+		if len(data) < ipv4HeaderLen {
+			return src, dst, 0, nil, fmt.Errorf("%w: IPv4 header truncated", ErrUnsupported)
+		}
+		headerLen := int(data[0]&0x0F) * 4
+		total := int(binary.BigEndian.Uint16(data[2:4]))
+		if headerLen < ipv4HeaderLen || headerLen > len(data) || total < headerLen {
+			return src, dst, 0, nil, fmt.Errorf("%w: IPv4 header length %d", ErrUnsupported, headerLen)
+		}
+		if fragment := binary.BigEndian.Uint16(data[6:8]); fragment&0x3FFF != 0 {
+			return src, dst, 0, nil, fmt.Errorf("%w: IPv4 fragment", ErrUnsupported)
+		}
+		if total < len(data) {
+			data = data[:total] // Ethernet padding
+		}
+		src = netip.AddrFrom4([4]byte(data[12:16]))
+		dst = netip.AddrFrom4([4]byte(data[16:20]))
+		return src, dst, data[9], data[headerLen:], nil
+
+	case 6:
+		if len(data) < ipv6HeaderLen {
+			return src, dst, 0, nil, fmt.Errorf("%w: IPv6 header truncated", ErrUnsupported)
+		}
+		if total := ipv6HeaderLen + int(binary.BigEndian.Uint16(data[4:6])); total < len(data) {
+			data = data[:total]
+		}
+		src = netip.AddrFrom16([16]byte(data[8:24]))
+		dst = netip.AddrFrom16([16]byte(data[24:40]))
+		next, payload := data[6], data[ipv6HeaderLen:]
+		for {
+			switch next {
+			case 0, 43, 60: // Hop-by-hop, routing and destination options
+				if len(payload) < 8 || len(payload) < (int(payload[1])+1)*8 {
+					return src, dst, 0, nil, fmt.Errorf("%w: IPv6 extension header truncated", ErrUnsupported)
+				}
+				next, payload = payload[0], payload[(int(payload[1])+1)*8:]
+			case 44: // Fragment; only atomic fragments are decoded
+				if len(payload) < 8 {
+					return src, dst, 0, nil, fmt.Errorf("%w: IPv6 fragment header truncated", ErrUnsupported)
+				}
+				if binary.BigEndian.Uint16(payload[2:4])&0xFFF9 != 0 {
+					return src, dst, 0, nil, fmt.Errorf("%w: IPv6 fragment", ErrUnsupported)
+				}
+				next, payload = payload[0], payload[8:]
+			default:
+				return src, dst, next, payload, nil
+			}
+		}
+
+	default:
+		return src, dst, 0, nil, fmt.Errorf("%w: IP version %d", ErrUnsupported, data[0]>>4)
+	}
+}
+
+// ipPacket builds an IPv4 or IPv6 packet around a transport segment
+func ipPacket(src, dst netip.Addr, proto uint8, segment []byte) []byte {
+	src, dst = src.Unmap(), dst.Unmap()
+	if src.Is4() {
+		packet := make([]byte, ipv4HeaderLen, ipv4HeaderLen+len(segment))
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:4], uint16(ipv4HeaderLen+len(segment)))
+		binary.BigEndian.PutUint16(packet[6:8], 0x4000) // Don't fragment
+		packet[8] = 64
+		packet[9] = proto
+		src4, dst4 := src.As4(), dst.As4()
+		copy(packet[12:16], src4[:])
+		copy(packet[16:20], dst4[:])
+		binary.BigEndian.PutUint16(packet[10:12], checksum(0, packet))
+		return append(packet, segment...)
+	}
+
+	packet := make([]byte, ipv6HeaderLen, ipv6HeaderLen+len(segment))
+	packet[0] = 0x60
+	binary.BigEndian.PutUint16(packet[4:6], uint16(len(segment)))
+	packet[6] = proto
+	packet[7] = 64
+	src16, dst16 := src.As16(), dst.As16()
+	copy(packet[8:24], src16[:])
+	copy(packet[24:40], dst16[:])
+	return append(packet, segment...)
+}
+
+// udpSegment builds a UDP datagram with its checksum
+func udpSegment(src, dst netip.AddrPort, payload []byte) []byte {
+	segment := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(segment[0:2], src.Port())
+	binary.BigEndian.PutUint16(segment[2:4], dst.Port())
+	binary.BigEndian.PutUint16(segment[4:6], uint16(udpHeaderLen+len(payload)))
+	segment = append(segment, payload...)
+	sum := checksum(pseudoHeaderSum(src.Addr(), dst.Addr(), protoUDP, len(segment)), segment)
+	if sum == 0 {
+		sum = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(segment[6:8], sum)
+	return segment
+}
+
+// tcpSegment builds a PSH/ACK TCP segment with its checksum
+func tcpSegment(src, dst netip.AddrPort, seq, ack uint32, payload []byte) []byte {
+	segment := make([]byte, tcpHeaderLen, tcpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(segment[0:2], src.Port())
+	binary.BigEndian.PutUint16(segment[2:4], dst.Port())
+	binary.BigEndian.PutUint32(segment[4:8], seq)
+	binary.BigEndian.PutUint32(segment[8:12], ack)
+	segment[12] = (tcpHeaderLen / 4) << 4
+	segment[13] = tcpPSH | tcpACK
+	binary.BigEndian.PutUint16(segment[14:16], 65535)
+	segment = append(segment, payload...)
+	binary.BigEndian.PutUint16(segment[16:18], checksum(pseudoHeaderSum(src.Addr(), dst.Addr(), protoTCP, len(segment)), segment))
+	return segment
+}
+
+// pseudoHeaderSum returns the unfolded sum of the pseudo-header of a UDP
+// or TCP checksum (RFC 768, RFC 8200 Section 8.1)
+func pseudoHeaderSum(src, dst netip.Addr, proto uint8, length int) uint32 {
+	src, dst = src.Unmap(), dst.Unmap()
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(b[i : i+2]))
+		}
+	}
+	if src.Is4() {
+		s, d := src.As4(), dst.As4()
+		add(s[:])
+		add(d[:])
+	} else {
+		s, d := src.As16(), dst.As16()
+		add(s[:])
+		add(d[:])
+	}
+	sum += uint32(proto)
+	sum += uint32(length>>16) + uint32(length&0xFFFF)
+	return sum
+}
+
+// checksum returns the Internet checksum (RFC 1071) of data, starting
+// from an unfolded partial sum
+func checksum(sum uint32, data []byte) uint16 {
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i : i+2]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xFFFF {
+		sum = sum>>16 + sum&0xFFFF
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,164 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestDecodeWrittenPackets(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		src, dst string
+		linkType LinkType
+	}{
+		{"udp4 raw", "udp", "192.0.2.10:40000", "192.0.2.53:53", LinkTypeRaw},
+		{"tcp4 ethernet", "tcp", "192.0.2.10:40000", "192.0.2.53:53", LinkTypeEthernet},
+		{"udp6 ethernet", "udp", "[2001:db8::10]:40000", "[2001:db8::53]:53", LinkTypeEthernet},
+		{"tcp6 raw", "tcp", "[2001:db8::10]:40000", "[2001:db8::53]:53", LinkTypeRaw},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, test.linkType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, dst := netip.MustParseAddrPort(test.src), netip.MustParseAddrPort(test.dst)
+			msg := []byte("odd-length payload")
+			if err := w.WriteDNS(time.Unix(1, 0), test.network, src, dst, msg); err != nil {
+				t.Fatalf("WriteDNS() returned error: %v", err)
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := r.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			segment, err := Decode(p)
+			if err != nil {
+				t.Fatalf("Decode() returned error: %v", err)
+			}
+			if segment.Network != test.network || segment.Src != src || segment.Dst != dst {
+				t.Errorf("Decode() = %s %s > %s, want %s %s > %s", segment.Network, segment.Src, segment.Dst, test.network, src, dst)
+			}
+			want := msg
+			if test.network == "tcp" {
+				want = append([]byte{0, byte(len(msg))}, msg...)
+			}
+			if !bytes.Equal(segment.Payload, want) {
+				t.Errorf("Decode() payload = %q, want %q", segment.Payload, want)
+			}
+
+			// Checksums verify to zero over the pseudo-header and segment
+			ip := p.Data
+			if test.linkType == LinkTypeEthernet {
+				ip = ip[ethernetHeaderLen:]
+			}
+			headerLen, proto := ipv6HeaderLen, uint8(protoUDP)
+			if src.Addr().Is4() {
+				headerLen = ipv4HeaderLen
+				if checksum(0, ip[:headerLen]) != 0 {
+					t.Error("IPv4 header checksum does not verify")
+				}
+			}
+			if test.network == "tcp" {
+				proto = protoTCP
+			}
+			transport := ip[headerLen:]
+			if checksum(pseudoHeaderSum(src.Addr(), dst.Addr(), proto, len(transport)), transport) != 0 {
+				t.Errorf("%s checksum does not verify", test.network)
+			}
+		})
+	}
+}
+
+// udpPacket returns an IPv4 UDP packet from 192.0.2.1:53 to 192.0.2.2:1053
+func udpPacket(payload []byte) []byte {
+	src, dst := netip.MustParseAddrPort("192.0.2.1:53"), netip.MustParseAddrPort("192.0.2.2:1053")
+	return ipPacket(src.Addr(), dst.Addr(), protoUDP, udpSegment(src, dst, payload))
+}
+
+func TestDecodeLinkTypes(t *testing.T) {
+	packet := udpPacket([]byte{0xAB})
+	vlan := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x81, 0x00, 0x00, 0x05, 0x08, 0x00}
+	sll := make([]byte, sllHeaderLen)
+	binary.BigEndian.PutUint16(sll[14:16], etherTypeIPv4)
+	// Ethernet frames are padded to 60 bytes
+	padded := append(append(append([]byte(nil), vlan...), packet...), make([]byte, 20)...)
+
+	tests := []struct {
+		name     string
+		linkType LinkType
+		data     []byte
+	}{
+		{"null", LinkTypeNull, append([]byte{2, 0, 0, 0}, packet...)},
+		{"vlan with padding", LinkTypeEthernet, padded},
+		{"linux sll", LinkTypeLinuxSLL, append(sll, packet...)},
+		{"ipv4", LinkTypeIPv4, packet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segment, err := Decode(&Packet{LinkType: test.linkType, Data: test.data})
+			if err != nil {
+				t.Fatalf("Decode() returned error: %v", err)
+			}
+			if segment.Network != "udp" || segment.Src.String() != "192.0.2.1:53" || !bytes.Equal(segment.Payload, []byte{0xAB}) {
+				t.Errorf("Decode() = %+v, want UDP from 192.0.2.1:53 carrying ab", segment)
+			}
+		})
+	}
+}
+
+func TestDecodeIPv6ExtensionHeaders(t *testing.T) {
+	src, dst := netip.MustParseAddrPort("[2001:db8::1]:53"), netip.MustParseAddrPort("[2001:db8::2]:1053")
+	udp := udpSegment(src, dst, []byte{0xAB})
+	// Hop-by-hop options, then an atomic fragment header
+	ext := append([]byte{44, 0, 1, 4, 0, 0, 0, 0}, []byte{protoUDP, 0, 0, 0, 0, 0, 0, 1}...)
+	packet := ipPacket(src.Addr(), dst.Addr(), 0, append(ext, udp...))
+
+	segment, err := Decode(&Packet{LinkType: LinkTypeRaw, Data: packet})
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if segment.Src != src || !bytes.Equal(segment.Payload, []byte{0xAB}) {
+		t.Errorf("Decode() = %+v, want UDP from %s carrying ab", segment, src)
+	}
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	fragment := udpPacket([]byte{0xAB})
+	binary.BigEndian.PutUint16(fragment[6:8], 0x2000) // More fragments
+	icmp := udpPacket(nil)
+	icmp[9] = 1
+	arp := append(make([]byte, 12), 0x08, 0x06, 0)
+
+	tests := []struct {
+		name     string
+		linkType LinkType
+		data     []byte
+	}{
+		{"ipv4 fragment", LinkTypeRaw, fragment},
+		{"icmp", LinkTypeRaw, icmp},
+		{"arp", LinkTypeEthernet, arp},
+		{"truncated", LinkTypeRaw, []byte{0x45, 0}},
+		{"unknown link type", LinkType(147), udpPacket(nil)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decode(&Packet{LinkType: test.linkType, Data: test.data}); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("Decode() error = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+// Package pcap reads and writes packet captures in the pcap and pcapng
+// formats and extracts the DNS messages carried over UDP and TCP
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+)
+
+// LinkType identifies the link-layer header of the packets in a capture
+// (https://www.tcpdump.org/linktypes.html)
+type LinkType uint16
+
+// Link types understood by Decode
+const (
+	LinkTypeNull     LinkType = 0   // BSD loopback, 4-byte address family in host byte order
+	LinkTypeEthernet LinkType = 1   // Ethernet II, with optional 802.1Q VLAN tags
+	LinkTypeRaw      LinkType = 101 // Raw IPv4 or IPv6
+	LinkTypeLinuxSLL LinkType = 113 // Linux "any" device cooked capture
+	LinkTypeIPv4     LinkType = 228 // Raw IPv4
+	LinkTypeIPv6     LinkType = 229 // Raw IPv6
+)
+
+// String returns the LINKTYPE_ name of the link type
+func (lt LinkType) String() string {
+	switch lt {
+	case LinkTypeNull:
+		return "NULL"
+	case LinkTypeEthernet:
+		return "ETHERNET"
+	case LinkTypeRaw:
+		return "RAW"
+	case LinkTypeLinuxSLL:
+		return "LINUX_SLL"
+	case LinkTypeIPv4:
+		return "IPV4"
+	case LinkTypeIPv6:
+		return "IPV6"
+	default:
+		return fmt.Sprintf("LINKTYPE_%d", uint16(lt))
+	}
+}
+
+// Packet is a captured packet
+type Packet struct {
+	Timestamp time.Time
+	LinkType  LinkType
+	Data      []byte // Captured bytes, starting with the link-layer header
+	Length    int    // Original length on the wire; larger than len(Data) if truncated
+}
+
+// File format magic numbers
+const (
+	pcapMagicMicro   = 0xA1B2C3D4 // pcap with microsecond timestamps
+	pcapMagicNano    = 0xA1B23C4D // pcap with nanosecond timestamps
+	pcapngBlockSHB   = 0x0A0D0D0A // pcapng Section Header Block
+	pcapngByteOrder  = 0x1A2B3C4D // pcapng byte-order magic
+	pcapngBlockIDB   = 0x00000001 // Interface Description Block
+	pcapngBlockPB    = 0x00000002 // Packet Block (obsolete)
+	pcapngBlockSPB   = 0x00000003 // Simple Packet Block
+	pcapngBlockEPB   = 0x00000006 // Enhanced Packet Block
+	pcapngOptEnd     = 0          // opt_endofopt
+	pcapngOptTsResol = 9          // if_tsresol
+)
+
+// maxRecordSize bounds the size of a packet record or pcapng block, so
+// that a corrupt length does not cause a huge allocation
+const maxRecordSize = 16 << 20
+
+// ErrFormat is wrapped by the errors returned for malformed captures
+var ErrFormat = errors.New("invalid capture format")
+
+// Reader reads packets from a pcap or pcapng capture
+type Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	ng    bool
+
+	// pcap
+	linkType LinkType
+	nano     bool
+
+	// pcapng: the interfaces of the current section
+	interfaces []pcapngInterface
+}
+
+// pcapngInterface is an interface described by an IDB
+type pcapngInterface struct {
+	linkType LinkType
+	resol    uint64 // Timestamp units per second
+}
+
+// NewReader reads the file header of a pcap or pcapng capture, detecting
+// the format and byte order
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading file header: %v", ErrFormat, err)
+	}
+
+	reader := &Reader{r: br}
+	if binary.LittleEndian.Uint32(magic) == pcapngBlockSHB {
+		reader.ng = true
+		if err := reader.readSectionHeader(); err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+	if err := reader.readFileHeader(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// LinkType returns the link type of a pcap capture, or of the first
+// interface of a pcapng section. Packet.LinkType holds the link type of
+// each packet.
+func (r *Reader) LinkType() LinkType {
+	if r.ng && len(r.interfaces) > 0 {
+		return r.interfaces[0].linkType
+	}
+	return r.linkType
+}
+
+// readFileHeader reads the 24-byte header of a pcap file
+func (r *Reader) readFileHeader() error {
+	var header [24]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return fmt.Errorf("%w: reading file header: %v", ErrFormat, err)
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(header[0:4]) == pcapMagicMicro:
+		r.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header[0:4]) == pcapMagicMicro:
+		r.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(header[0:4]) == pcapMagicNano:
+		r.order, r.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header[0:4]) == pcapMagicNano:
+		r.order, r.nano = binary.BigEndian, true
+	default:
+		return fmt.Errorf("%w: unknown magic number % x", ErrFormat, header[0:4])
+	}
+
+	if major := r.order.Uint16(header[4:6]); major != 2 {
+		return fmt.Errorf("%w: unsupported pcap version %d", ErrFormat, major)
+	}
+	// The upper bits of the link type field carry FCS information
+	r.linkType = LinkType(r.order.Uint32(header[20:24]) & 0xFFFF)
+	return nil
+}
+
+// Next returns the next packet, or io.EOF at the end of the capture.
+// pcapng blocks other than packets are skipped.
+func (r *Reader) Next() (*Packet, error) {
+	if r.ng {
+		return r.nextBlock()
+	}
+
+	var header [16]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: reading packet header: %v", ErrFormat, err)
+	}
+	sec := int64(r.order.Uint32(header[0:4]))
+	frac := int64(r.order.Uint32(header[4:8]))
+	capLen := r.order.Uint32(header[8:12])
+	origLen := r.order.Uint32(header[12:16])
+	if capLen > maxRecordSize {
+		return nil, fmt.Errorf("%w: packet of %d bytes", ErrFormat, capLen)
+	}
+
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, fmt.Errorf("%w: reading packet data: %v", ErrFormat, err)
+	}
+
+	if !r.nano {
+		frac *= 1000
+	}
+	return &Packet{
+		Timestamp: time.Unix(sec, frac).UTC(),
+		LinkType:  r.linkType,
+		Data:      data,
+		Length:    int(origLen),
+	}, nil
+}
+
+// readSectionHeader reads a pcapng Section Header Block, which sets the
+// byte order and starts a new list of interfaces
+func (r *Reader) readSectionHeader() error {
+	var header [12]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return fmt.Errorf("%w: reading section header: %v", ErrFormat, err)
+	}
+	switch {
+	case binary.LittleEndian.Uint32(header[8:12]) == pcapngByteOrder:
+		r.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header[8:12]) == pcapngByteOrder:
+		r.order = binary.BigEndian
+	default:
+		return fmt.Errorf("%w: unknown pcapng byte-order magic % x", ErrFormat, header[8:12])
+	}
+
+	length := r.order.Uint32(header[4:8])
+	if length < 28 || length%4 != 0 || length > maxRecordSize {
+		return fmt.Errorf("%w: section header of %d bytes", ErrFormat, length)
+	}
+	// Skip the version, section length, options and trailing length
+	if _, err := r.r.Discard(int(length) - 12); err != nil {
+		return fmt.Errorf("%w: reading section header: %v", ErrFormat, err)
+	}
+	r.interfaces = nil
+	return nil
+}
+
+// nextBlock reads pcapng blocks up to the next packet
+func (r *Reader) nextBlock() (*Packet, error) {
+	for {
+		header, err := r.r.Peek(8)
+		if err != nil {
+			if err == io.EOF && len(header) == 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("%w: reading block header: %v", ErrFormat, err)
+		}
+
+		// The byte order of a new section is only known after reading it
+		if binary.LittleEndian.Uint32(header[0:4]) == pcapngBlockSHB {
+			if err := r.readSectionHeader(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		blockType := r.order.Uint32(header[0:4])
+		length := r.order.Uint32(header[4:8])
+		if length < 12 || length%4 != 0 || length > maxRecordSize {
+			return nil, fmt.Errorf("%w: block of %d bytes", ErrFormat, length)
+		}
+		block := make([]byte, length)
+		if _, err := io.ReadFull(r.r, block); err != nil {
+			return nil, fmt.Errorf("%w: reading block: %v", ErrFormat, err)
+		}
+		body := block[8 : length-4]
+
+		switch blockType {
+		case pcapngBlockIDB:
+			if err := r.addInterface(body); err != nil {
+				return nil, err
+			}
+		case pcapngBlockEPB, pcapngBlockPB:
+			return r.packetBlock(blockType, body)
+		case pcapngBlockSPB:
+			return r.simplePacketBlock(body)
+		}
+	}
+}
+
+// addInterface records the link type and timestamp resolution of an
+// Interface Description Block
+func (r *Reader) addInterface(body []byte) error {
+	if len(body) < 8 {
+		return fmt.Errorf("%w: interface description of %d bytes", ErrFormat, len(body))
+	}
+	iface := pcapngInterface{
+		linkType: LinkType(r.order.Uint16(body[0:2])),
+		resol:    1e6,
+	}
+
+	options := body[8:]
+	for len(options) >= 4 {
+		code := r.order.Uint16(options[0:2])
+		length := int(r.order.Uint16(options[2:4]))
+		if code == pcapngOptEnd || 4+length > len(options) {
+			break
+		}
+		if code == pcapngOptTsResol && length >= 1 {
+			resol, err := tsResolution(options[4])
+			if err != nil {
+				return err
+			}
+			iface.resol = resol
+		}
+		options = options[4+(length+3)&^3:]
+	}
+
+	r.interfaces = append(r.interfaces, iface)
+	return nil
+}
+
+// tsResolution returns the timestamp units per second of an if_tsresol
+// value: a negative power of 10, or of 2 if the top bit is set
+func tsResolution(v byte) (uint64, error) {
+	exp := uint64(v & 0x7F)
+	if v&0x80 != 0 {
+		if exp > 63 {
+			return 0, fmt.Errorf("%w: timestamp resolution 2^-%d", ErrFormat, exp)
+		}
+		return 1 << exp, nil
+	}
+	if exp > 19 {
+		return 0, fmt.Errorf("%w: timestamp resolution 10^-%d", ErrFormat, exp)
+	}
+	resol := uint64(1)
+	for i := uint64(0); i < exp; i++ {
+		resol *= 10
+	}
+	return resol, nil
+}
+
+// packetBlock decodes an Enhanced Packet Block or an obsolete Packet Block
+func (r *Reader) packetBlock(blockType uint32, body []byte) (*Packet, error) {
+	if len(body) < 20 {
+		return nil, fmt.Errorf("%w: packet block of %d bytes", ErrFormat, len(body))
+	}
+	var id uint32
+	if blockType == pcapngBlockEPB {
+		id = r.order.Uint32(body[0:4])
+	} else {
+		id = uint32(r.order.Uint16(body[0:2]))
+	}
+	if int(id) >= len(r.interfaces) {
+		return nil, fmt.Errorf("%w: packet of undescribed interface %d", ErrFormat, id)
+	}
+	iface := r.interfaces[id]
+
+	ts := uint64(r.order.Uint32(body[4:8]))<<32 | uint64(r.order.Uint32(body[8:12]))
+	capLen := r.order.Uint32(body[12:16])
+	origLen := r.order.Uint32(body[16:20])
+	if uint64(capLen) > uint64(len(body)-20) {
+		return nil, fmt.Errorf("%w: packet of %d bytes in a block of %d", ErrFormat, capLen, len(body))
+	}
+
+	return &Packet{
+		Timestamp: timestamp(ts, iface.resol),
+		LinkType:  iface.linkType,
+		Data:      body[20 : 20+capLen],
+		Length:    int(origLen),
+	}, nil
+}
+
+// simplePacketBlock decodes a Simple Packet Block, which belongs to the
+// first interface and has no timestamp
+func (r *Reader) simplePacketBlock(body []byte) (*Packet, error) {
+	if len(body) < 4 {
+		return nil, fmt.Errorf("%w: simple packet block of %d bytes", ErrFormat, len(body))
+	}
+	if len(r.interfaces) == 0 {
+		return nil, fmt.Errorf("%w: simple packet block without interface", ErrFormat)
+	}
+	origLen := r.order.Uint32(body[0:4])
+	data := body[4:]
+	if uint64(origLen) < uint64(len(data)) {
+		data = data[:origLen]
+	}
+	return &Packet{
+		LinkType: r.interfaces[0].linkType,
+		Data:     data,
+		Length:   int(origLen),
+	}, nil
+}
+
+// timestamp converts a pcapng timestamp in units of 1/resol seconds
+func timestamp(ts, resol uint64) time.Time {
+	sec, rem := ts/resol, ts%resol
+	// rem*1e9/resol without overflow
+	hi, lo := bits.Mul64(rem, 1e9)
+	nsec, _ := bits.Div64(hi, lo, resol)
+	return time.Unix(int64(sec), int64(nsec)).UTC()
+}
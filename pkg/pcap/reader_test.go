@@ -0,0 +1,179 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	if err := w.WritePacket(ts, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader() returned error: %v", err)
+	}
+	if r.LinkType() != LinkTypeEthernet {
+		t.Errorf("LinkType() = %s, want ETHERNET", r.LinkType())
+	}
+	p, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next() returned error: %v", err)
+	}
+	if !p.Timestamp.Equal(ts) || !bytes.Equal(p.Data, []byte{1, 2, 3}) || p.Length != 3 || p.LinkType != LinkTypeEthernet {
+		t.Errorf("Next() = %+v, want the written packet", p)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReaderBigEndianNano(t *testing.T) {
+	var buf bytes.Buffer
+	header := make([]byte, 24)
+	binary.BigEndian.PutUint32(header[0:4], pcapMagicNano)
+	binary.BigEndian.PutUint16(header[4:6], 2)
+	binary.BigEndian.PutUint16(header[6:8], 4)
+	binary.BigEndian.PutUint32(header[20:24], uint32(LinkTypeRaw))
+	buf.Write(header)
+	record := make([]byte, 16)
+	binary.BigEndian.PutUint32(record[0:4], 1714564800)
+	binary.BigEndian.PutUint32(record[4:8], 987654321)
+	binary.BigEndian.PutUint32(record[8:12], 2)
+	binary.BigEndian.PutUint32(record[12:16], 100)
+	buf.Write(record)
+	buf.Write([]byte{0x45, 0x00})
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader() returned error: %v", err)
+	}
+	p, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next() returned error: %v", err)
+	}
+	want := time.Unix(1714564800, 987654321).UTC()
+	if !p.Timestamp.Equal(want) || p.Length != 100 || len(p.Data) != 2 || p.LinkType != LinkTypeRaw {
+		t.Errorf("Next() = %+v, want a truncated RAW packet at %v", p, want)
+	}
+}
+
+// pcapngBlock returns a little-endian pcapng block
+func pcapngBlock(blockType uint32, body []byte) []byte {
+	for len(body)%4 != 0 {
+		body = append(body, 0)
+	}
+	block := make([]byte, 8, 12+len(body))
+	binary.LittleEndian.PutUint32(block[0:4], blockType)
+	binary.LittleEndian.PutUint32(block[4:8], uint32(12+len(body)))
+	block = append(block, body...)
+	return binary.LittleEndian.AppendUint32(block, uint32(12+len(body)))
+}
+
+// sectionHeader returns the body of a little-endian SHB without options
+func sectionHeader() []byte {
+	body := binary.LittleEndian.AppendUint32(nil, pcapngByteOrder)
+	body = binary.LittleEndian.AppendUint16(body, 1)
+	body = binary.LittleEndian.AppendUint16(body, 0)
+	return binary.LittleEndian.AppendUint64(body, ^uint64(0))
+}
+
+// interfaceDescription returns the body of an IDB with an if_tsresol option
+func interfaceDescription(linkType LinkType, tsresol byte) []byte {
+	body := binary.LittleEndian.AppendUint16(nil, uint16(linkType))
+	body = binary.LittleEndian.AppendUint16(body, 0)
+	body = binary.LittleEndian.AppendUint32(body, 65535)
+	body = binary.LittleEndian.AppendUint16(body, pcapngOptTsResol)
+	body = binary.LittleEndian.AppendUint16(body, 1)
+	body = append(body, tsresol, 0, 0, 0)
+	return binary.LittleEndian.AppendUint32(body, 0) // opt_endofopt
+}
+
+// enhancedPacket returns the body of an EPB
+func enhancedPacket(iface uint32, ts uint64, data []byte) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, iface)
+	body = binary.LittleEndian.AppendUint32(body, uint32(ts>>32))
+	body = binary.LittleEndian.AppendUint32(body, uint32(ts))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data)))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data)))
+	return append(body, data...)
+}
+
+func TestReaderPcapng(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(pcapngBlock(pcapngBlockSHB, sectionHeader()))
+	buf.Write(pcapngBlock(pcapngBlockIDB, interfaceDescription(LinkTypeEthernet, 6)))
+	buf.Write(pcapngBlock(pcapngBlockIDB, interfaceDescription(LinkTypeRaw, 9)))
+	buf.Write(pcapngBlock(0x00000005, []byte{1, 2, 3, 4})) // Interface Statistics, skipped
+	buf.Write(pcapngBlock(pcapngBlockEPB, enhancedPacket(0, 1714564800123456, []byte{0xAA})))
+	buf.Write(pcapngBlock(pcapngBlockEPB, enhancedPacket(1, 1714564800987654321, []byte{0xBB, 0xCC})))
+	buf.Write(pcapngBlock(pcapngBlockSPB, append(binary.LittleEndian.AppendUint32(nil, 3), 0xDD, 0xEE, 0xFF)))
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader() returned error: %v", err)
+	}
+
+	tests := []struct {
+		ts       time.Time
+		linkType LinkType
+		data     []byte
+	}{
+		{time.Unix(1714564800, 123456000).UTC(), LinkTypeEthernet, []byte{0xAA}},
+		{time.Unix(1714564800, 987654321).UTC(), LinkTypeRaw, []byte{0xBB, 0xCC}},
+		{time.Time{}, LinkTypeEthernet, []byte{0xDD, 0xEE, 0xFF}},
+	}
+	for i, want := range tests {
+		p, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() #%d returned error: %v", i, err)
+		}
+		if !p.Timestamp.Equal(want.ts) || p.LinkType != want.linkType || !bytes.Equal(p.Data, want.data) {
+			t.Errorf("Next() #%d = %v %s % x, want %v %s % x", i, p.Timestamp, p.LinkType, p.Data, want.ts, want.linkType, want.data)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	var truncated bytes.Buffer
+	w, _ := NewWriter(&truncated, LinkTypeRaw)
+	w.WritePacket(time.Unix(0, 0), []byte{1, 2, 3, 4})
+	data := truncated.Bytes()[:truncated.Len()-2]
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unknown magic", []byte("not a capture file at all")},
+		{"truncated packet", data},
+		{"undescribed interface", append(pcapngBlock(pcapngBlockSHB, sectionHeader()),
+			pcapngBlock(pcapngBlockEPB, enhancedPacket(0, 0, []byte{1}))...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(test.data))
+			for err == nil {
+				_, err = r.Next()
+			}
+			if !errors.Is(err, ErrFormat) {
+				t.Errorf("error = %v, want ErrFormat", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// snapLen is the snapshot length declared by Writer; packets are never
+// truncated
+const snapLen = 262144
+
+// maxSegment is the largest TCP payload WriteDNS puts in one packet
+const maxSegment = 65000
+
+// Writer writes packets to a pcap capture with microsecond timestamps.
+// It is safe for concurrent use.
+type Writer struct {
+	w        io.Writer
+	linkType LinkType
+
+	mu  sync.Mutex
+	seq map[flow]uint32 // Next TCP sequence number of each direction
+}
+
+// flow identifies one direction of a connection
+type flow struct {
+	network  string
+	src, dst netip.AddrPort
+}
+
+// NewWriter writes the file header of a pcap capture of the given link
+// type to w
+func NewWriter(w io.Writer, linkType LinkType) (*Writer, error) {
+	var header [24]byte
+	binary.LittleEndian.PutUint32(header[0:4], pcapMagicMicro)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], snapLen)
+	binary.LittleEndian.PutUint32(header[20:24], uint32(linkType))
+	if _, err := w.Write(header[:]); err != nil {
+		return nil, fmt.Errorf("failed to write pcap header: %w", err)
+	}
+	return &Writer{w: w, linkType: linkType, seq: make(map[flow]uint32)}, nil
+}
+
+// WritePacket writes a packet with the link-layer header of the capture
+func (w *Writer) WritePacket(ts time.Time, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writePacket(ts, data)
+}
+
+// writePacket writes a packet record; w.mu must be held
+func (w *Writer) writePacket(ts time.Time, data []byte) error {
+	if len(data) > snapLen {
+		return fmt.Errorf("packet of %d bytes exceeds the snapshot length", len(data))
+	}
+	record := make([]byte, 16, 16+len(data))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:12], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(data)))
+	record = append(record, data...)
+	if _, err := w.w.Write(record); err != nil {
+		return fmt.Errorf("failed to write packet: %w", err)
+	}
+	return nil
+}
+
+// WriteDNS writes a DNS message sent from src to dst over network ("udp"
+// or "tcp") as synthesized IP packets. Over TCP, the message is
+// length-prefixed and the sequence numbers continue those of earlier
+// messages between the same endpoints. The capture must have link type
+// LinkTypeRaw or LinkTypeEthernet.
+func (w *Writer) WriteDNS(ts time.Time, network string, src, dst netip.AddrPort, msg []byte) error {
+	if src.Addr().Unmap().Is4() != dst.Addr().Unmap().Is4() {
+		return fmt.Errorf("mixed address families %s and %s", src, dst)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch network {
+	case "udp":
+		if len(msg) > 65535-udpHeaderLen-ipv6HeaderLen {
+			return fmt.Errorf("message of %d bytes too large for UDP", len(msg))
+		}
+		return w.writeIP(ts, src, dst, protoUDP, udpSegment(src, dst, msg))
+	case "tcp":
+		if len(msg) > 65535 {
+			return fmt.Errorf("message of %d bytes too large for TCP", len(msg))
+		}
+		payload := make([]byte, 2, 2+len(msg))
+		binary.BigEndian.PutUint16(payload, uint16(len(msg)))
+		payload = append(payload, msg...)
+
+		out, in := flow{network, src, dst}, flow{network, dst, src}
+		for len(payload) > 0 {
+			chunk := payload[:min(len(payload), maxSegment)]
+			segment := tcpSegment(src, dst, w.seq[out], w.seq[in], chunk)
+			if err := w.writeIP(ts, src, dst, protoTCP, segment); err != nil {
+				return err
+			}
+			w.seq[out] += uint32(len(chunk))
+			payload = payload[len(chunk):]
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported network %q", network)
+	}
+}
+
+// writeIP writes a transport segment in an IP packet, framed for the link
+// type of the capture
+func (w *Writer) writeIP(ts time.Time, src, dst netip.AddrPort, proto uint8, segment []byte) error {
+	packet := ipPacket(src.Addr(), dst.Addr(), proto, segment)
+	switch w.linkType {
+	case LinkTypeRaw:
+	case LinkTypeEthernet:
+		frame := make([]byte, ethernetHeaderLen, ethernetHeaderLen+len(packet))
+		etherType := uint16(etherTypeIPv6)
+		if src.Addr().Unmap().Is4() {
+			etherType = etherTypeIPv4
+		}
+		binary.BigEndian.PutUint16(frame[12:14], etherType)
+		packet = append(frame, packet...)
+	default:
+		return fmt.Errorf("cannot write DNS messages to a capture of link type %s", w.linkType)
+	}
+	return w.writePacket(ts, packet)
+}
@@ -0,0 +1,56 @@
+package records
+
+import (
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// CDSRecord represents a CDS (child DS) record, which a child zone
+// publishes to request DS changes from its parent (RFC 7344 Section 3.1).
+// Its data is that of a DS record.
+type CDSRecord struct {
+	DSRecord
+}
+
+// NewCDSRecord creates a new CDS record
+func NewCDSRecord(keyTag uint16, algorithm, digestType uint8, digest []byte) *CDSRecord {
+	return &CDSRecord{DSRecord: *NewDSRecord(keyTag, algorithm, digestType, digest)}
+}
+
+// ParseCDSRecord decodes a CDS record from its wire format resource data
+func ParseCDSRecord(data []byte) (*CDSRecord, error) {
+	ds, err := ParseDSRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	return &CDSRecord{DSRecord: *ds}, nil
+}
+
+// Type returns the DNS record type
+func (c *CDSRecord) Type() dns.QType {
+	return dns.TypeCDS
+}
+
+// CDNSKEYRecord represents a CDNSKEY (child DNSKEY) record
+// (RFC 7344 Section 3.2). Its data is that of a DNSKEY record.
+type CDNSKEYRecord struct {
+	DNSKEYRecord
+}
+
+// NewCDNSKEYRecord creates a new CDNSKEY record
+func NewCDNSKEYRecord(flags uint16, algorithm uint8, publicKey []byte) *CDNSKEYRecord {
+	return &CDNSKEYRecord{DNSKEYRecord: *NewDNSKEYRecord(flags, algorithm, publicKey)}
+}
+
+// ParseCDNSKEYRecord decodes a CDNSKEY record from its wire format resource data
+func ParseCDNSKEYRecord(data []byte) (*CDNSKEYRecord, error) {
+	key, err := ParseDNSKEYRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	return &CDNSKEYRecord{DNSKEYRecord: *key}, nil
+}
+
+// Type returns the DNS record type
+func (c *CDNSKEYRecord) Type() dns.QType {
+	return dns.TypeCDNSKEY
+}
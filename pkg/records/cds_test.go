@@ -0,0 +1,50 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestCDSRecord(t *testing.T) {
+	cds := NewCDSRecord(20326, AlgorithmRSASHA256, DigestSHA256, []byte{0xE0, 0x6D})
+	if cds.Type() != dns.TypeCDS {
+		t.Errorf("CDSRecord.Type() = %v, want %v", cds.Type(), dns.TypeCDS)
+	}
+	if result := cds.String(); result != "20326 8 2 E06D" {
+		t.Errorf("CDSRecord.String() = %q, want %q", result, "20326 8 2 E06D")
+	}
+
+	parsed, err := ParseCDSRecord(cds.Bytes())
+	if err != nil {
+		t.Fatalf("ParseCDSRecord() returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), cds.Bytes()) || parsed.Type() != dns.TypeCDS {
+		t.Errorf("ParseCDSRecord() round trip = %v, want %v", parsed, cds)
+	}
+	if _, err := ParseCDSRecord([]byte{1}); err == nil {
+		t.Error("ParseCDSRecord() should return error for truncated data")
+	}
+}
+
+func TestCDNSKEYRecord(t *testing.T) {
+	key := NewCDNSKEYRecord(DNSKEYFlagZone|DNSKEYFlagSEP, AlgorithmED25519, []byte{1, 2, 3, 4})
+	if key.Type() != dns.TypeCDNSKEY {
+		t.Errorf("CDNSKEYRecord.Type() = %v, want %v", key.Type(), dns.TypeCDNSKEY)
+	}
+	if result := key.String(); result != "257 3 15 AQIDBA==" {
+		t.Errorf("CDNSKEYRecord.String() = %q, want %q", result, "257 3 15 AQIDBA==")
+	}
+
+	parsed, err := ParseCDNSKEYRecord(key.Bytes())
+	if err != nil {
+		t.Fatalf("ParseCDNSKEYRecord() returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), key.Bytes()) || parsed.KeyTag() != key.KeyTag() {
+		t.Errorf("ParseCDNSKEYRecord() round trip = %v, want %v", parsed, key)
+	}
+	if _, err := ParseCDNSKEYRecord([]byte{1}); err == nil {
+		t.Error("ParseCDNSKEYRecord() should return error for truncated data")
+	}
+}
@@ -0,0 +1,72 @@
+package records
+
+import (
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// CNAMERecord represents a CNAME (canonical name) record
+type CNAMERecord struct {
+	Target dns.Name
+}
+
+// NewCNAMERecord creates a new CNAME record pointing to target
+func NewCNAMERecord(target dns.Name) *CNAMERecord {
+	return &CNAMERecord{Target: target}
+}
+
+// Bytes returns the wire format representation of the CNAME record
+func (c *CNAMERecord) Bytes() []byte {
+	return c.Target.Bytes()
+}
+
+// CanonicalBytes returns the wire format with the target lowercased
+// (RFC 4034 Section 6.2)
+func (c *CNAMERecord) CanonicalBytes() []byte {
+	return c.Target.Canonical().Bytes()
+}
+
+// String returns the string representation of the CNAME record
+func (c *CNAMERecord) String() string {
+	return fmt.Sprintf("NAME: %s", c.Target.DisplayString())
+}
+
+// Type returns the DNS record type
+func (c *CNAMERecord) Type() dns.QType {
+	return dns.TypeCNAME
+}
+
+// DNAMERecord represents a DNAME record, which redirects all names below
+// its owner to the same names below the target (RFC 6672)
+type DNAMERecord struct {
+	Target dns.Name
+}
+
+// NewDNAMERecord creates a new DNAME record redirecting to target
+func NewDNAMERecord(target dns.Name) *DNAMERecord {
+	return &DNAMERecord{Target: target}
+}
+
+// Bytes returns the wire format representation of the DNAME record. The
+// target is never compressed (RFC 6672 Section 2.5).
+func (d *DNAMERecord) Bytes() []byte {
+	return d.Target.Bytes()
+}
+
+// CanonicalBytes returns the wire format with the case of the target
+// preserved: DNAME is not among the types whose RDATA names are lowercased
+// (RFC 6840 Section 5.1)
+func (d *DNAMERecord) CanonicalBytes() []byte {
+	return d.Target.Bytes()
+}
+
+// String returns the string representation of the DNAME record
+func (d *DNAMERecord) String() string {
+	return fmt.Sprintf("NAME: %s", d.Target.DisplayString())
+}
+
+// Type returns the DNS record type
+func (d *DNAMERecord) Type() dns.QType {
+	return dns.TypeDNAME
+}
@@ -0,0 +1,91 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNewCNAMERecord(t *testing.T) {
+	target := dns.MustParseName("www.example.com")
+	record := NewCNAMERecord(target)
+
+	if !record.Target.Equal(target) {
+		t.Errorf("CNAMERecord.Target = %s, want %s", record.Target, target)
+	}
+}
+
+func TestCNAMERecordString(t *testing.T) {
+	record := NewCNAMERecord(dns.MustParseName("WWW.Example.com"))
+
+	expected := "NAME: WWW.Example.com"
+	if result := record.String(); result != expected {
+		t.Errorf("CNAMERecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestCNAMERecordBytes(t *testing.T) {
+	record := NewCNAMERecord(dns.MustParseName("WWW.Example.com"))
+
+	expected := []byte("\x03WWW\x07Example\x03com\x00")
+	if result := record.Bytes(); !bytes.Equal(result, expected) {
+		t.Errorf("CNAMERecord.Bytes() = %q, want %q", result, expected)
+	}
+}
+
+func TestCNAMERecordType(t *testing.T) {
+	record := NewCNAMERecord(dns.MustParseName("www.example.com"))
+
+	if record.Type() != dns.TypeCNAME {
+		t.Errorf("CNAMERecord.Type() = %v, want %v", record.Type(), dns.TypeCNAME)
+	}
+}
+
+func TestCNAMERecordCanonicalBytes(t *testing.T) {
+	record := NewCNAMERecord(dns.MustParseName("WWW.Example.com"))
+
+	expected := []byte("\x03www\x07example\x03com\x00")
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("CNAMERecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+	if record.Target.String() != "WWW.Example.com" {
+		t.Error("CNAMERecord.CanonicalBytes() modified the record")
+	}
+}
+
+func TestDNAMERecordString(t *testing.T) {
+	record := NewDNAMERecord(dns.MustParseName("Example.NET"))
+
+	expected := "NAME: Example.NET"
+	if result := record.String(); result != expected {
+		t.Errorf("DNAMERecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestDNAMERecordBytes(t *testing.T) {
+	record := NewDNAMERecord(dns.MustParseName("Example.NET"))
+
+	expected := []byte("\x07Example\x03NET\x00")
+	if result := record.Bytes(); !bytes.Equal(result, expected) {
+		t.Errorf("DNAMERecord.Bytes() = %q, want %q", result, expected)
+	}
+}
+
+func TestDNAMERecordType(t *testing.T) {
+	record := NewDNAMERecord(dns.MustParseName("example.net"))
+
+	if record.Type() != dns.TypeDNAME {
+		t.Errorf("DNAMERecord.Type() = %v, want %v", record.Type(), dns.TypeDNAME)
+	}
+}
+
+func TestDNAMERecordCanonicalBytes(t *testing.T) {
+	record := NewDNAMERecord(dns.MustParseName("Example.NET"))
+
+	// The target keeps its case (RFC 6840 Section 5.1)
+	expected := []byte("\x07Example\x03NET\x00")
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("DNAMERecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+}
@@ -0,0 +1,104 @@
+package records
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// DNSKEY flags - See RFC 4034 Section 2.1.1 and RFC 5011 Section 3
+const (
+	DNSKEYFlagZone   uint16 = 1 << 8 // Key may sign zone data
+	DNSKEYFlagRevoke uint16 = 1 << 7 // Key has been revoked
+	DNSKEYFlagSEP    uint16 = 1      // Secure entry point (key signing key)
+)
+
+// DNSKEYRecord represents a DNSKEY (DNS public key) record
+type DNSKEYRecord struct {
+	Flags     uint16
+	Protocol  uint8 // Always 3
+	Algorithm uint8
+	PublicKey []byte
+}
+
+// NewDNSKEYRecord creates a new DNSKEY record
+func NewDNSKEYRecord(flags uint16, algorithm uint8, publicKey []byte) *DNSKEYRecord {
+	return &DNSKEYRecord{
+		Flags:     flags,
+		Protocol:  3,
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+	}
+}
+
+// ParseDNSKEYRecord decodes a DNSKEY record from its wire format resource data
+func ParseDNSKEYRecord(data []byte) (*DNSKEYRecord, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("DNSKEY record too short: %d bytes", len(data))
+	}
+	return &DNSKEYRecord{
+		Flags:     binary.BigEndian.Uint16(data[0:2]),
+		Protocol:  data[2],
+		Algorithm: data[3],
+		PublicKey: append([]byte(nil), data[4:]...),
+	}, nil
+}
+
+// Bytes returns the wire format representation of the DNSKEY record
+func (k *DNSKEYRecord) Bytes() []byte {
+	result := appendUint16(nil, k.Flags)
+	result = append(result, k.Protocol, k.Algorithm)
+	return append(result, k.PublicKey...)
+}
+
+// String returns the presentation format of the DNSKEY record
+func (k *DNSKEYRecord) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.Flags, k.Protocol, k.Algorithm,
+		base64.StdEncoding.EncodeToString(k.PublicKey))
+}
+
+// Type returns the DNS record type
+func (k *DNSKEYRecord) Type() dns.QType {
+	return dns.TypeDNSKEY
+}
+
+// IsZoneKey reports whether the Zone Key flag is set
+func (k *DNSKEYRecord) IsZoneKey() bool {
+	return k.Flags&DNSKEYFlagZone != 0
+}
+
+// IsSEP reports whether the Secure Entry Point flag is set
+func (k *DNSKEYRecord) IsSEP() bool {
+	return k.Flags&DNSKEYFlagSEP != 0
+}
+
+// IsRevoked reports whether the Revoke flag is set
+func (k *DNSKEYRecord) IsRevoked() bool {
+	return k.Flags&DNSKEYFlagRevoke != 0
+}
+
+// KeyTag computes the key tag of the key (RFC 4034 Appendix B)
+func (k *DNSKEYRecord) KeyTag() uint16 {
+	rdata := k.Bytes()
+
+	// Algorithm 1 (RSA/MD5) uses the low bits of the modulus instead
+	if k.Algorithm == 1 {
+		if len(rdata) < 4 {
+			return 0
+		}
+		return binary.BigEndian.Uint16(rdata[len(rdata)-3 : len(rdata)-1])
+	}
+
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
@@ -0,0 +1,65 @@
+package records
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// rootKSK2017 is the public key of the root zone KSK with key tag 20326
+const rootKSK2017 = "AwEAAaz/tAm8yTn4Mfeh5eyI96WSVexTBAvkMgJzkKTOiW1vkIbzxeF3+/4RgWOq7HrxRixHlFlExOLAJr5emLvN7SWXgnLh4+B5xQlNVz8Og8kvArMtNROxVQuCaSnIDdD5LKyWbRd2n9WGe2R8PzgCmr3EgVLrjyBxWezF0jLHwVN8efS3rCj/EWgvIWgb9tarpVUDK/b58Da+sqqls3eNbuv7pr+eoZG+SrDK6nWeL3c6H5Apxz7LjVc1uTIdsIXxuOLYA4/ilBmSVIzuDWfdRUfhHdY6+cn8HFRm+2hM8AnXGXws9555KrUB5qihylGa8subX2Nn6UwNR1AkUTV74bU="
+
+func testRootKey(t *testing.T) *DNSKEYRecord {
+	t.Helper()
+	key, err := base64.StdEncoding.DecodeString(rootKSK2017)
+	if err != nil {
+		t.Fatalf("failed to decode root key: %v", err)
+	}
+	return NewDNSKEYRecord(DNSKEYFlagZone|DNSKEYFlagSEP, AlgorithmRSASHA256, key)
+}
+
+func TestDNSKEYRecordKeyTag(t *testing.T) {
+	key := testRootKey(t)
+
+	if tag := key.KeyTag(); tag != 20326 {
+		t.Errorf("DNSKEYRecord.KeyTag() = %d, want 20326", tag)
+	}
+}
+
+func TestDNSKEYRecordRoundTrip(t *testing.T) {
+	key := testRootKey(t)
+
+	parsed, err := ParseDNSKEYRecord(key.Bytes())
+	if err != nil {
+		t.Fatalf("ParseDNSKEYRecord() returned error: %v", err)
+	}
+	if parsed.Flags != 257 || parsed.Protocol != 3 || parsed.Algorithm != 8 || !bytes.Equal(parsed.PublicKey, key.PublicKey) {
+		t.Errorf("ParseDNSKEYRecord() = %v, want %v", parsed, key)
+	}
+
+	if _, err := ParseDNSKEYRecord([]byte{1, 1, 3}); err == nil {
+		t.Error("ParseDNSKEYRecord() should return error for truncated data")
+	}
+}
+
+func TestDNSKEYRecordString(t *testing.T) {
+	key := testRootKey(t)
+
+	expected := "257 3 8 " + rootKSK2017
+	if result := key.String(); result != expected {
+		t.Errorf("DNSKEYRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestDNSKEYRecordFlags(t *testing.T) {
+	key := testRootKey(t)
+
+	if !key.IsZoneKey() || !key.IsSEP() || key.IsRevoked() {
+		t.Errorf("DNSKEYRecord flags %d decoded incorrectly", key.Flags)
+	}
+	if key.Type() != dns.TypeDNSKEY {
+		t.Errorf("DNSKEYRecord.Type() = %v, want %v", key.Type(), dns.TypeDNSKEY)
+	}
+}
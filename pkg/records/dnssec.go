@@ -0,0 +1,146 @@
+package records
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// DNSSEC algorithm numbers - See RFC 8624 Section 3.1
+const (
+	AlgorithmRSASHA1         uint8 = 5
+	AlgorithmRSASHA256       uint8 = 8
+	AlgorithmRSASHA512       uint8 = 10
+	AlgorithmECDSAP256SHA256 uint8 = 13
+	AlgorithmECDSAP384SHA384 uint8 = 14
+	AlgorithmED25519         uint8 = 15
+)
+
+// DS digest types - See RFC 8624 Section 3.3
+const (
+	DigestSHA1   uint8 = 1
+	DigestSHA256 uint8 = 2
+	DigestSHA384 uint8 = 4
+)
+
+// readName reads an uncompressed domain name from resource data. DNSSEC
+// records must not use name compression (RFC 4034 Section 3.1.7 and 4.1.1).
+func readName(data []byte, index int) (dns.Name, int, error) {
+	var labels dns.Name
+	for index < len(data) {
+		length := int(data[index])
+		if length&0xC0 != 0 {
+			return nil, 0, fmt.Errorf("compressed or invalid label at offset %d", index)
+		}
+		if length == 0 {
+			labels = append(labels, dns.Label{Length: 0, Data: nil})
+			return labels, index + 1, nil
+		}
+		if index+1+length > len(data) {
+			return nil, 0, fmt.Errorf("label data truncated")
+		}
+		label := dns.Label{Length: byte(length), Data: make([]byte, length)}
+		copy(label.Data, data[index+1:index+1+length])
+		labels = append(labels, label)
+		index += 1 + length
+	}
+	return nil, 0, fmt.Errorf("name not properly terminated")
+}
+
+// fqdn returns the presentation form of a name with a trailing dot
+func fqdn(name dns.Name) string {
+	return name.String() + "."
+}
+
+// typeName returns the mnemonic of a type, or TYPEnnn for unknown types
+// (RFC 3597 Section 5)
+func typeName(t dns.QType) string {
+	if name := t.String(); name != "UNKNOWN" {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", t)
+}
+
+// encodeTypeBitmap encodes a set of types in the window block format of
+// RFC 4034 Section 4.1.2
+func encodeTypeBitmap(types []dns.QType) []byte {
+	sorted := append([]dns.QType(nil), types...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var result []byte
+	for i := 0; i < len(sorted); {
+		window := byte(sorted[i] >> 8)
+		var bitmap [32]byte
+		length := 0
+		for ; i < len(sorted) && byte(sorted[i]>>8) == window; i++ {
+			low := byte(sorted[i])
+			bitmap[low/8] |= 0x80 >> (low % 8)
+			if int(low/8)+1 > length {
+				length = int(low/8) + 1
+			}
+		}
+		result = append(result, window, byte(length))
+		result = append(result, bitmap[:length]...)
+	}
+	return result
+}
+
+// decodeTypeBitmap decodes a type bitmap in the window block format
+func decodeTypeBitmap(data []byte) ([]dns.QType, error) {
+	var types []dns.QType
+	lastWindow := -1
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("type bitmap window truncated")
+		}
+		window, length := int(data[0]), int(data[1])
+		if window <= lastWindow {
+			return nil, fmt.Errorf("type bitmap windows out of order")
+		}
+		if length < 1 || length > 32 || len(data) < 2+length {
+			return nil, fmt.Errorf("invalid type bitmap length %d", length)
+		}
+		for i, b := range data[2 : 2+length] {
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) != 0 {
+					types = append(types, dns.QType(window<<8|i*8+bit))
+				}
+			}
+		}
+		lastWindow = window
+		data = data[2+length:]
+	}
+	return types, nil
+}
+
+// typeListString returns the presentation form of a type list
+func typeListString(types []dns.QType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = typeName(t)
+	}
+	return strings.Join(names, " ")
+}
+
+// hasType reports whether the type list contains the type
+func hasType(types []dns.QType, t dns.QType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// appendUint16 appends a big-endian 16-bit value
+func appendUint16(b []byte, v uint16) []byte {
+	return binary.BigEndian.AppendUint16(b, v)
+}
+
+// appendUint32 appends a big-endian 32-bit value
+func appendUint32(b []byte, v uint32) []byte {
+	return binary.BigEndian.AppendUint32(b, v)
+}
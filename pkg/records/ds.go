@@ -0,0 +1,89 @@
+package records
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// DSRecord represents a DS (delegation signer) record
+type DSRecord struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     []byte
+}
+
+// NewDSRecord creates a new DS record
+func NewDSRecord(keyTag uint16, algorithm, digestType uint8, digest []byte) *DSRecord {
+	return &DSRecord{
+		KeyTag:     keyTag,
+		Algorithm:  algorithm,
+		DigestType: digestType,
+		Digest:     digest,
+	}
+}
+
+// NewDSRecordFromString creates a DS record from its presentation format,
+// e.g. "20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D".
+// The digest may be split by whitespace.
+func NewDSRecordFromString(s string) (*DSRecord, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid DS record %q: expected key tag, algorithm, digest type and digest", s)
+	}
+
+	keyTag, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS key tag %q: %w", fields[0], err)
+	}
+	algorithm, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS algorithm %q: %w", fields[1], err)
+	}
+	digestType, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS digest type %q: %w", fields[2], err)
+	}
+	digest, err := hex.DecodeString(strings.Join(fields[3:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS digest: %w", err)
+	}
+
+	return NewDSRecord(uint16(keyTag), uint8(algorithm), uint8(digestType), digest), nil
+}
+
+// ParseDSRecord decodes a DS record from its wire format resource data
+func ParseDSRecord(data []byte) (*DSRecord, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("DS record too short: %d bytes", len(data))
+	}
+	return &DSRecord{
+		KeyTag:     binary.BigEndian.Uint16(data[0:2]),
+		Algorithm:  data[2],
+		DigestType: data[3],
+		Digest:     append([]byte(nil), data[4:]...),
+	}, nil
+}
+
+// Bytes returns the wire format representation of the DS record
+func (ds *DSRecord) Bytes() []byte {
+	result := appendUint16(nil, ds.KeyTag)
+	result = append(result, ds.Algorithm, ds.DigestType)
+	return append(result, ds.Digest...)
+}
+
+// String returns the presentation format of the DS record
+func (ds *DSRecord) String() string {
+	return fmt.Sprintf("%d %d %d %s", ds.KeyTag, ds.Algorithm, ds.DigestType,
+		strings.ToUpper(hex.EncodeToString(ds.Digest)))
+}
+
+// Type returns the DNS record type
+func (ds *DSRecord) Type() dns.QType {
+	return dns.TypeDS
+}
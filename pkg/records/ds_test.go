@@ -0,0 +1,63 @@
+package records
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestDSRecordRoundTrip(t *testing.T) {
+	digest, _ := hex.DecodeString("E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D")
+	ds := NewDSRecord(20326, AlgorithmRSASHA256, DigestSHA256, digest)
+
+	parsed, err := ParseDSRecord(ds.Bytes())
+	if err != nil {
+		t.Fatalf("ParseDSRecord() returned error: %v", err)
+	}
+	if parsed.KeyTag != 20326 || parsed.Algorithm != 8 || parsed.DigestType != 2 || !bytes.Equal(parsed.Digest, digest) {
+		t.Errorf("ParseDSRecord() = %v, want %v", parsed, ds)
+	}
+
+	if _, err := ParseDSRecord([]byte{0x4F}); err == nil {
+		t.Error("ParseDSRecord() should return error for truncated data")
+	}
+}
+
+func TestDSRecordString(t *testing.T) {
+	ds := NewDSRecord(20326, AlgorithmRSASHA256, DigestSHA256, []byte{0xE0, 0x6D})
+
+	expected := "20326 8 2 E06D"
+	if result := ds.String(); result != expected {
+		t.Errorf("DSRecord.String() = %q, want %q", result, expected)
+	}
+	if ds.Type() != dns.TypeDS {
+		t.Errorf("DSRecord.Type() = %v, want %v", ds.Type(), dns.TypeDS)
+	}
+}
+
+func TestNewDSRecordFromString(t *testing.T) {
+	ds, err := NewDSRecordFromString("20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D084 58E880409BBC683457104237C7F8EC8D")
+	if err != nil {
+		t.Fatalf("NewDSRecordFromString() returned error: %v", err)
+	}
+	expected := "20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+	if result := ds.String(); result != expected {
+		t.Errorf("NewDSRecordFromString().String() = %q, want %q", result, expected)
+	}
+
+	invalid := []string{
+		"",
+		"20326 8 2",
+		"70000 8 2 E06D",
+		"20326 300 2 E06D",
+		"20326 8 x E06D",
+		"20326 8 2 XYZ",
+	}
+	for _, s := range invalid {
+		if _, err := NewDSRecordFromString(s); err == nil {
+			t.Errorf("NewDSRecordFromString(%q) should return error", s)
+		}
+	}
+}
@@ -14,10 +14,12 @@ type GenericRecord struct {
 
 // NewGenericRecord creates a new generic record
 func NewGenericRecord(recordType dns.QType, data []byte) *GenericRecord {
-	return &GenericRecord{
+	record := &GenericRecord{
 		RecordType: recordType,
-		Data:       make([]byte, len(data)), // Copy the data
+		Data:       make([]byte, len(data)),
 	}
+	copy(record.Data, data) // Copy the data
+	return record
 }
 
 // Bytes returns the wire format representation of the generic record
@@ -0,0 +1,40 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNewGenericRecord(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03}
+	record := NewGenericRecord(dns.TypeTXT, data)
+
+	if !bytes.Equal(record.Bytes(), data) {
+		t.Errorf("GenericRecord.Bytes() = %v, want %v", record.Bytes(), data)
+	}
+
+	// The record must own a copy of the data
+	data[0] = 0xFF
+	if record.Bytes()[0] != 0x01 {
+		t.Error("NewGenericRecord should copy the data")
+	}
+}
+
+func TestGenericRecordString(t *testing.T) {
+	record := NewGenericRecord(dns.TypeTXT, []byte{0xAB, 0xCD})
+
+	expected := "RDLength: 2\tRData: AB CD"
+	if result := record.String(); result != expected {
+		t.Errorf("GenericRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestGenericRecordType(t *testing.T) {
+	record := NewGenericRecord(dns.TypeMX, nil)
+
+	if record.Type() != dns.TypeMX {
+		t.Errorf("GenericRecord.Type() = %v, want %v", record.Type(), dns.TypeMX)
+	}
+}
@@ -0,0 +1,40 @@
+package records
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// MXRecord represents an MX (mail exchange) record
+type MXRecord struct {
+	Preference uint16 // Lower values are preferred
+	Exchange   dns.Name
+}
+
+// NewMXRecord creates a new MX record
+func NewMXRecord(preference uint16, exchange dns.Name) *MXRecord {
+	return &MXRecord{Preference: preference, Exchange: exchange}
+}
+
+// Bytes returns the wire format representation of the MX record
+func (mx *MXRecord) Bytes() []byte {
+	return append(binary.BigEndian.AppendUint16(nil, mx.Preference), mx.Exchange.Bytes()...)
+}
+
+// CanonicalBytes returns the wire format with the exchange lowercased
+// (RFC 4034 Section 6.2)
+func (mx *MXRecord) CanonicalBytes() []byte {
+	return NewMXRecord(mx.Preference, mx.Exchange.Canonical()).Bytes()
+}
+
+// String returns the string representation of the MX record
+func (mx *MXRecord) String() string {
+	return fmt.Sprintf("PREFERENCE: %d\tEXCHANGE: %s", mx.Preference, mx.Exchange.DisplayString())
+}
+
+// Type returns the DNS record type
+func (mx *MXRecord) Type() dns.QType {
+	return dns.TypeMX
+}
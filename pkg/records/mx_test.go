@@ -0,0 +1,61 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNewMXRecord(t *testing.T) {
+	exchange := dns.MustParseName("mail.example.com")
+	record := NewMXRecord(10, exchange)
+
+	if record.Preference != 10 || !record.Exchange.Equal(exchange) {
+		t.Errorf("MXRecord = %d %s, want 10 %s", record.Preference, record.Exchange, exchange)
+	}
+}
+
+func TestMXRecordString(t *testing.T) {
+	record := NewMXRecord(10, dns.MustParseName("Mail.Example.com"))
+
+	expected := "PREFERENCE: 10\tEXCHANGE: Mail.Example.com"
+	if result := record.String(); result != expected {
+		t.Errorf("MXRecord.String() = %q, want %q", result, expected)
+	}
+	// A-labels are followed by their Unicode form
+	record = NewMXRecord(10, dns.MustParseName("mail.xn--mnchen-3ya.de"))
+	expected = "PREFERENCE: 10\tEXCHANGE: mail.xn--mnchen-3ya.de (mail.münchen.de)"
+	if result := record.String(); result != expected {
+		t.Errorf("MXRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestMXRecordBytes(t *testing.T) {
+	record := NewMXRecord(10, dns.MustParseName("Mail.Example.com"))
+
+	expected := []byte("\x00\x0a\x04Mail\x07Example\x03com\x00")
+	if result := record.Bytes(); !bytes.Equal(result, expected) {
+		t.Errorf("MXRecord.Bytes() = %q, want %q", result, expected)
+	}
+}
+
+func TestMXRecordType(t *testing.T) {
+	record := NewMXRecord(10, dns.MustParseName("mail.example.com"))
+
+	if record.Type() != dns.TypeMX {
+		t.Errorf("MXRecord.Type() = %v, want %v", record.Type(), dns.TypeMX)
+	}
+}
+
+func TestMXRecordCanonicalBytes(t *testing.T) {
+	record := NewMXRecord(10, dns.MustParseName("Mail.Example.com"))
+
+	expected := []byte("\x00\x0a\x04mail\x07example\x03com\x00")
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("MXRecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+	if record.Exchange.String() != "Mail.Example.com" {
+		t.Error("MXRecord.CanonicalBytes() modified the record")
+	}
+}
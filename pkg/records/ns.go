@@ -9,17 +9,22 @@ import (
 
 // NSRecord represents an NS (name server) record
 type NSRecord struct {
-	NameServer []dns.Label
+	NameServer dns.Name
 }
 
 // NewNSRecord creates a new NS record from domain labels
-func NewNSRecord(nameserver []dns.Label) *NSRecord {
+func NewNSRecord(nameserver dns.Name) *NSRecord {
 	return &NSRecord{NameServer: nameserver}
 }
 
-// NewNSRecordFromString creates a new NS record from a string
-func NewNSRecordFromString(nameserver string) *NSRecord {
-	return &NSRecord{NameServer: dns.StringToLabels(nameserver)}
+// NewNSRecordFromString creates a new NS record from a name in
+// presentation format
+func NewNSRecordFromString(nameserver string) (*NSRecord, error) {
+	name, err := dns.ParseName(nameserver)
+	if err != nil {
+		return nil, err
+	}
+	return &NSRecord{NameServer: name}, nil
 }
 
 // Bytes returns the wire format representation of the NS record
@@ -31,9 +36,15 @@ func (ns *NSRecord) Bytes() []byte {
 	return buf.Bytes()
 }
 
+// CanonicalBytes returns the wire format with the name server lowercased
+// (RFC 4034 Section 6.2)
+func (ns *NSRecord) CanonicalBytes() []byte {
+	return NewNSRecord(ns.NameServer.Canonical()).Bytes()
+}
+
 // String returns the string representation of the NS record
 func (ns *NSRecord) String() string {
-	return fmt.Sprintf("NAME: %s", dns.LabelsToString(ns.NameServer))
+	return fmt.Sprintf("NAME: %s", ns.NameServer.DisplayString())
 }
 
 // Type returns the DNS record type
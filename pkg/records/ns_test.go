@@ -1,13 +1,14 @@
 package records
 
 import (
+	"bytes"
 	"testing"
 	
 	"dklbreitling/goDNS/pkg/dns"
 )
 
 func TestNewNSRecord(t *testing.T) {
-	labels := dns.StringToLabels("ns.example.com")
+	labels := dns.MustParseName("ns.example.com")
 	record := NewNSRecord(labels)
 	
 	if record == nil {
@@ -21,21 +22,24 @@ func TestNewNSRecord(t *testing.T) {
 
 func TestNewNSRecordFromString(t *testing.T) {
 	domain := "ns.example.com"
-	record := NewNSRecordFromString(domain)
-	
-	if record == nil {
-		t.Fatal("NewNSRecordFromString returned nil")
+	record, err := NewNSRecordFromString(domain)
+	if err != nil {
+		t.Fatalf("NewNSRecordFromString returned error: %v", err)
 	}
 	
 	result := dns.LabelsToString(record.NameServer)
 	if result != domain {
 		t.Errorf("NSRecord domain = %q, want %q", result, domain)
 	}
+
+	if _, err := NewNSRecordFromString("ns..example.com"); err == nil {
+		t.Error("NewNSRecordFromString should return error for an empty label")
+	}
 }
 
 func TestNSRecordString(t *testing.T) {
 	domain := "ns.example.com"
-	record := NewNSRecordFromString(domain)
+	record := NewNSRecord(dns.MustParseName(domain))
 	
 	expected := "NAME: ns.example.com"
 	result := record.String()
@@ -47,7 +51,7 @@ func TestNSRecordString(t *testing.T) {
 
 func TestNSRecordBytes(t *testing.T) {
 	domain := "ns.example.com"
-	record := NewNSRecordFromString(domain)
+	record := NewNSRecord(dns.MustParseName(domain))
 	
 	result := record.Bytes()
 	
@@ -62,9 +66,21 @@ func TestNSRecordBytes(t *testing.T) {
 }
 
 func TestNSRecordType(t *testing.T) {
-	record := NewNSRecordFromString("ns.example.com")
+	record := NewNSRecord(dns.MustParseName("ns.example.com"))
 	
 	if record.Type() != dns.TypeNS {
 		t.Errorf("NSRecord.Type() = %v, want %v", record.Type(), dns.TypeNS)
 	}
 }
+
+func TestNSRecordCanonicalBytes(t *testing.T) {
+	record := NewNSRecord(dns.MustParseName("NS.Example.COM"))
+
+	expected := NewNSRecord(dns.MustParseName("ns.example.com")).Bytes()
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("NSRecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+	if dns.LabelsToString(record.NameServer) != "NS.Example.COM" {
+		t.Error("NSRecord.CanonicalBytes() modified the record")
+	}
+}
@@ -0,0 +1,54 @@
+package records
+
+import (
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// NSECRecord represents an NSEC (next secure) record
+type NSECRecord struct {
+	NextDomain dns.Name
+	Types      []dns.QType // Types present at the owner name
+}
+
+// NewNSECRecord creates a new NSEC record
+func NewNSECRecord(next dns.Name, types []dns.QType) *NSECRecord {
+	return &NSECRecord{NextDomain: next, Types: types}
+}
+
+// ParseNSECRecord decodes an NSEC record from its wire format resource data
+func ParseNSECRecord(data []byte) (*NSECRecord, error) {
+	next, index, err := readName(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NSEC next domain: %w", err)
+	}
+	types, err := decodeTypeBitmap(data[index:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NSEC type bitmap: %w", err)
+	}
+	return &NSECRecord{NextDomain: next, Types: types}, nil
+}
+
+// Bytes returns the wire format representation of the NSEC record
+func (n *NSECRecord) Bytes() []byte {
+	return append(n.NextDomain.Bytes(), encodeTypeBitmap(n.Types)...)
+}
+
+// String returns the presentation format of the NSEC record
+func (n *NSECRecord) String() string {
+	if len(n.Types) == 0 {
+		return fqdn(n.NextDomain)
+	}
+	return fqdn(n.NextDomain) + " " + typeListString(n.Types)
+}
+
+// Type returns the DNS record type
+func (n *NSECRecord) Type() dns.QType {
+	return dns.TypeNSEC
+}
+
+// HasType reports whether the type bitmap contains the type
+func (n *NSECRecord) HasType(t dns.QType) bool {
+	return hasType(n.Types, t)
+}
@@ -0,0 +1,105 @@
+package records
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// NSEC3FlagOptOut marks an NSEC3 record that may cover unsigned
+// delegations (RFC 5155 Section 3.1.2.1)
+const NSEC3FlagOptOut uint8 = 1
+
+// NSEC3HashSHA1 is the only NSEC3 hash algorithm defined by RFC 5155
+const NSEC3HashSHA1 uint8 = 1
+
+// Base32Hex is the unpadded "Extended Hex" base32 encoding used for
+// hashed owner names (RFC 5155 Section 3.3)
+var Base32Hex = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// NSEC3Record represents an NSEC3 (hashed next secure) record
+type NSEC3Record struct {
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+	NextHashed    []byte      // Raw hash of the next owner name
+	Types         []dns.QType // Types present at the original owner name
+}
+
+// ParseNSEC3Record decodes an NSEC3 record from its wire format resource data
+func ParseNSEC3Record(data []byte) (*NSEC3Record, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("NSEC3 record too short: %d bytes", len(data))
+	}
+	saltLength := int(data[4])
+	index := 5 + saltLength
+	if len(data) < index+1 {
+		return nil, fmt.Errorf("NSEC3 salt truncated")
+	}
+	hashLength := int(data[index])
+	if len(data) < index+1+hashLength {
+		return nil, fmt.Errorf("NSEC3 next hashed owner name truncated")
+	}
+	types, err := decodeTypeBitmap(data[index+1+hashLength:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NSEC3 type bitmap: %w", err)
+	}
+
+	return &NSEC3Record{
+		HashAlgorithm: data[0],
+		Flags:         data[1],
+		Iterations:    binary.BigEndian.Uint16(data[2:4]),
+		Salt:          append([]byte(nil), data[5:5+saltLength]...),
+		NextHashed:    append([]byte(nil), data[index+1:index+1+hashLength]...),
+		Types:         types,
+	}, nil
+}
+
+// Bytes returns the wire format representation of the NSEC3 record
+func (n *NSEC3Record) Bytes() []byte {
+	result := []byte{n.HashAlgorithm, n.Flags}
+	result = appendUint16(result, n.Iterations)
+	result = append(result, byte(len(n.Salt)))
+	result = append(result, n.Salt...)
+	result = append(result, byte(len(n.NextHashed)))
+	result = append(result, n.NextHashed...)
+	return append(result, encodeTypeBitmap(n.Types)...)
+}
+
+// String returns the presentation format of the NSEC3 record
+func (n *NSEC3Record) String() string {
+	s := fmt.Sprintf("%d %d %d %s %s", n.HashAlgorithm, n.Flags, n.Iterations,
+		saltString(n.Salt), Base32Hex.EncodeToString(n.NextHashed))
+	if len(n.Types) > 0 {
+		s += " " + typeListString(n.Types)
+	}
+	return s
+}
+
+// Type returns the DNS record type
+func (n *NSEC3Record) Type() dns.QType {
+	return dns.TypeNSEC3
+}
+
+// OptOut reports whether the Opt-Out flag is set
+func (n *NSEC3Record) OptOut() bool {
+	return n.Flags&NSEC3FlagOptOut != 0
+}
+
+// HasType reports whether the type bitmap contains the type
+func (n *NSEC3Record) HasType(t dns.QType) bool {
+	return hasType(n.Types, t)
+}
+
+// saltString returns the presentation form of a salt, "-" when empty
+func saltString(salt []byte) string {
+	if len(salt) == 0 {
+		return "-"
+	}
+	return strings.ToUpper(hex.EncodeToString(salt))
+}
@@ -0,0 +1,65 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func testNSEC3Record() *NSEC3Record {
+	next, _ := Base32Hex.DecodeString("2VPTU5TIMAMQTTGL4LUU9KG21E0AOR3S")
+	return &NSEC3Record{
+		HashAlgorithm: NSEC3HashSHA1,
+		Flags:         NSEC3FlagOptOut,
+		Iterations:    12,
+		Salt:          []byte{0xAA, 0xBB, 0xCC, 0xDD},
+		NextHashed:    next,
+		Types:         []dns.QType{dns.TypeA, dns.TypeRRSIG},
+	}
+}
+
+func TestNSEC3RecordRoundTrip(t *testing.T) {
+	nsec3 := testNSEC3Record()
+
+	parsed, err := ParseNSEC3Record(nsec3.Bytes())
+	if err != nil {
+		t.Fatalf("ParseNSEC3Record() returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), nsec3.Bytes()) {
+		t.Errorf("ParseNSEC3Record() round trip = %v, want %v", parsed, nsec3)
+	}
+
+	if _, err := ParseNSEC3Record(nsec3.Bytes()[:7]); err == nil {
+		t.Error("ParseNSEC3Record() should return error for truncated data")
+	}
+}
+
+func TestNSEC3RecordString(t *testing.T) {
+	expected := "1 1 12 AABBCCDD 2VPTU5TIMAMQTTGL4LUU9KG21E0AOR3S A RRSIG"
+	if result := testNSEC3Record().String(); result != expected {
+		t.Errorf("NSEC3Record.String() = %q, want %q", result, expected)
+	}
+
+	noSalt := testNSEC3Record()
+	noSalt.Salt = nil
+	noSalt.Types = nil
+	expected = "1 1 12 - 2VPTU5TIMAMQTTGL4LUU9KG21E0AOR3S"
+	if result := noSalt.String(); result != expected {
+		t.Errorf("NSEC3Record.String() without salt = %q, want %q", result, expected)
+	}
+}
+
+func TestNSEC3RecordFlags(t *testing.T) {
+	nsec3 := testNSEC3Record()
+
+	if !nsec3.OptOut() {
+		t.Error("NSEC3Record.OptOut() = false, want true")
+	}
+	if !nsec3.HasType(dns.TypeA) || nsec3.HasType(dns.TypeNS) {
+		t.Error("NSEC3Record.HasType() disagrees with the type list")
+	}
+	if nsec3.Type() != dns.TypeNSEC3 {
+		t.Errorf("NSEC3Record.Type() = %v, want %v", nsec3.Type(), dns.TypeNSEC3)
+	}
+}
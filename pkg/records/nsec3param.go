@@ -0,0 +1,62 @@
+package records
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// NSEC3PARAMRecord represents an NSEC3PARAM record, which publishes the
+// parameters of a zone's NSEC3 chain at its apex (RFC 5155 Section 4)
+type NSEC3PARAMRecord struct {
+	HashAlgorithm uint8
+	Flags         uint8 // Always 0 in published records
+	Iterations    uint16
+	Salt          []byte
+}
+
+// NewNSEC3PARAMRecord creates a new NSEC3PARAM record
+func NewNSEC3PARAMRecord(hashAlgorithm uint8, iterations uint16, salt []byte) *NSEC3PARAMRecord {
+	return &NSEC3PARAMRecord{
+		HashAlgorithm: hashAlgorithm,
+		Iterations:    iterations,
+		Salt:          salt,
+	}
+}
+
+// ParseNSEC3PARAMRecord decodes an NSEC3PARAM record from its wire format
+// resource data
+func ParseNSEC3PARAMRecord(data []byte) (*NSEC3PARAMRecord, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("NSEC3PARAM record too short: %d bytes", len(data))
+	}
+	saltLength := int(data[4])
+	if len(data) != 5+saltLength {
+		return nil, fmt.Errorf("invalid NSEC3PARAM salt length %d", saltLength)
+	}
+	return &NSEC3PARAMRecord{
+		HashAlgorithm: data[0],
+		Flags:         data[1],
+		Iterations:    binary.BigEndian.Uint16(data[2:4]),
+		Salt:          append([]byte(nil), data[5:]...),
+	}, nil
+}
+
+// Bytes returns the wire format representation of the NSEC3PARAM record
+func (n *NSEC3PARAMRecord) Bytes() []byte {
+	result := []byte{n.HashAlgorithm, n.Flags}
+	result = appendUint16(result, n.Iterations)
+	result = append(result, byte(len(n.Salt)))
+	return append(result, n.Salt...)
+}
+
+// String returns the presentation format of the NSEC3PARAM record
+func (n *NSEC3PARAMRecord) String() string {
+	return fmt.Sprintf("%d %d %d %s", n.HashAlgorithm, n.Flags, n.Iterations, saltString(n.Salt))
+}
+
+// Type returns the DNS record type
+func (n *NSEC3PARAMRecord) Type() dns.QType {
+	return dns.TypeNSEC3PARAM
+}
@@ -0,0 +1,46 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNSEC3PARAMRecordRoundTrip(t *testing.T) {
+	param := NewNSEC3PARAMRecord(NSEC3HashSHA1, 12, []byte{0xAA, 0xBB, 0xCC, 0xDD})
+
+	parsed, err := ParseNSEC3PARAMRecord(param.Bytes())
+	if err != nil {
+		t.Fatalf("ParseNSEC3PARAMRecord() returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), param.Bytes()) {
+		t.Errorf("ParseNSEC3PARAMRecord() round trip = %v, want %v", parsed, param)
+	}
+
+	if _, err := ParseNSEC3PARAMRecord(param.Bytes()[:6]); err == nil {
+		t.Error("ParseNSEC3PARAMRecord() should return error for truncated salt")
+	}
+	if _, err := ParseNSEC3PARAMRecord([]byte{1, 0}); err == nil {
+		t.Error("ParseNSEC3PARAMRecord() should return error for truncated data")
+	}
+}
+
+func TestNSEC3PARAMRecordString(t *testing.T) {
+	tests := []struct {
+		param    *NSEC3PARAMRecord
+		expected string
+	}{
+		{NewNSEC3PARAMRecord(NSEC3HashSHA1, 12, []byte{0xAA, 0xBB, 0xCC, 0xDD}), "1 0 12 AABBCCDD"},
+		{NewNSEC3PARAMRecord(NSEC3HashSHA1, 0, nil), "1 0 0 -"},
+	}
+
+	for _, test := range tests {
+		if result := test.param.String(); result != test.expected {
+			t.Errorf("NSEC3PARAMRecord.String() = %q, want %q", result, test.expected)
+		}
+	}
+	if (&NSEC3PARAMRecord{}).Type() != dns.TypeNSEC3PARAM {
+		t.Error("NSEC3PARAMRecord.Type() should be NSEC3PARAM")
+	}
+}
@@ -0,0 +1,72 @@
+package records
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNSECRecordRoundTrip(t *testing.T) {
+	// Example from RFC 4034 Section 4.3
+	nsec := NewNSECRecord(dns.MustParseName("host.example.com"),
+		[]dns.QType{dns.TypeA, dns.TypeMX, dns.TypeRRSIG, dns.TypeNSEC, dns.QType(1234)})
+
+	expectedBitmap := []byte{
+		0x00, 0x06, 0x40, 0x01, 0x00, 0x00, 0x00, 0x03,
+		0x04, 0x1b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x20,
+	}
+	wire := nsec.Bytes()
+	if !bytes.Equal(wire[18:], expectedBitmap) {
+		t.Errorf("NSEC type bitmap = % 02x, want % 02x", wire[18:], expectedBitmap)
+	}
+
+	parsed, err := ParseNSECRecord(wire)
+	if err != nil {
+		t.Fatalf("ParseNSECRecord() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Types, nsec.Types) {
+		t.Errorf("ParseNSECRecord() types = %v, want %v", parsed.Types, nsec.Types)
+	}
+}
+
+func TestNSECRecordString(t *testing.T) {
+	nsec := NewNSECRecord(dns.MustParseName("host.example.com"),
+		[]dns.QType{dns.TypeA, dns.TypeMX, dns.TypeRRSIG, dns.TypeNSEC, dns.QType(1234)})
+
+	expected := "host.example.com. A MX RRSIG NSEC TYPE1234"
+	if result := nsec.String(); result != expected {
+		t.Errorf("NSECRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestNSECRecordHasType(t *testing.T) {
+	nsec := NewNSECRecord(dns.MustParseName("b.example.com"), []dns.QType{dns.TypeA, dns.TypeNSEC})
+
+	if !nsec.HasType(dns.TypeA) || nsec.HasType(dns.TypeAAAA) {
+		t.Error("NSECRecord.HasType() disagrees with the type list")
+	}
+	if nsec.Type() != dns.TypeNSEC {
+		t.Errorf("NSECRecord.Type() = %v, want %v", nsec.Type(), dns.TypeNSEC)
+	}
+}
+
+func TestDecodeTypeBitmapErrors(t *testing.T) {
+	tests := [][]byte{
+		{0x00},                               // truncated window header
+		{0x00, 0x00},                         // zero-length bitmap
+		{0x00, 0x21},                         // bitmap longer than 32 bytes
+		{0x00, 0x02, 0x40},                   // bitmap truncated
+		{0x01, 0x01, 0x40, 0x00, 0x01, 0x40}, // windows out of order
+	}
+
+	for _, data := range tests {
+		if _, err := decodeTypeBitmap(data); err == nil {
+			t.Errorf("decodeTypeBitmap(% 02x) should return error", data)
+		}
+	}
+}
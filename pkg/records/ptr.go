@@ -0,0 +1,39 @@
+package records
+
+import (
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// PTRRecord represents a PTR (domain name pointer) record, as used for
+// reverse lookups
+type PTRRecord struct {
+	Target dns.Name
+}
+
+// NewPTRRecord creates a new PTR record pointing to target
+func NewPTRRecord(target dns.Name) *PTRRecord {
+	return &PTRRecord{Target: target}
+}
+
+// Bytes returns the wire format representation of the PTR record
+func (ptr *PTRRecord) Bytes() []byte {
+	return ptr.Target.Bytes()
+}
+
+// CanonicalBytes returns the wire format with the target lowercased
+// (RFC 4034 Section 6.2)
+func (ptr *PTRRecord) CanonicalBytes() []byte {
+	return ptr.Target.Canonical().Bytes()
+}
+
+// String returns the string representation of the PTR record
+func (ptr *PTRRecord) String() string {
+	return fmt.Sprintf("NAME: %s", ptr.Target.DisplayString())
+}
+
+// Type returns the DNS record type
+func (ptr *PTRRecord) Type() dns.QType {
+	return dns.TypePTR
+}
@@ -0,0 +1,60 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNewPTRRecord(t *testing.T) {
+	target := dns.MustParseName("host.example.com")
+	record := NewPTRRecord(target)
+
+	if !record.Target.Equal(target) {
+		t.Errorf("PTRRecord.Target = %s, want %s", record.Target, target)
+	}
+}
+
+func TestPTRRecordString(t *testing.T) {
+	record := NewPTRRecord(dns.MustParseName("Host.Example.com"))
+
+	expected := "NAME: Host.Example.com"
+	if result := record.String(); result != expected {
+		t.Errorf("PTRRecord.String() = %q, want %q", result, expected)
+	}
+	record = NewPTRRecord(dns.MustParseName("xn--bcher-kva.example"))
+	expected = "NAME: xn--bcher-kva.example (bücher.example)"
+	if result := record.String(); result != expected {
+		t.Errorf("PTRRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestPTRRecordBytes(t *testing.T) {
+	record := NewPTRRecord(dns.MustParseName("Host.Example.com"))
+
+	expected := []byte("\x04Host\x07Example\x03com\x00")
+	if result := record.Bytes(); !bytes.Equal(result, expected) {
+		t.Errorf("PTRRecord.Bytes() = %q, want %q", result, expected)
+	}
+}
+
+func TestPTRRecordType(t *testing.T) {
+	record := NewPTRRecord(dns.MustParseName("host.example.com"))
+
+	if record.Type() != dns.TypePTR {
+		t.Errorf("PTRRecord.Type() = %v, want %v", record.Type(), dns.TypePTR)
+	}
+}
+
+func TestPTRRecordCanonicalBytes(t *testing.T) {
+	record := NewPTRRecord(dns.MustParseName("Host.Example.com"))
+
+	expected := []byte("\x04host\x07example\x03com\x00")
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("PTRRecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+	if record.Target.String() != "Host.Example.com" {
+		t.Error("PTRRecord.CanonicalBytes() modified the record")
+	}
+}
@@ -0,0 +1,97 @@
+package records
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// rrsigTimeFormat is the presentation format of signature times
+const rrsigTimeFormat = "20060102150405"
+
+// RRSIGRecord represents an RRSIG (RRset signature) record
+type RRSIGRecord struct {
+	TypeCovered dns.QType
+	Algorithm   uint8
+	Labels      uint8  // Number of labels in the original owner name
+	OriginalTTL uint32 // TTL of the covered RRset as signed
+	Expiration  uint32 // Seconds since the epoch, in serial number arithmetic
+	Inception   uint32 // Seconds since the epoch, in serial number arithmetic
+	KeyTag      uint16
+	SignerName  dns.Name
+	Signature   []byte
+}
+
+// ParseRRSIGRecord decodes an RRSIG record from its wire format resource data
+func ParseRRSIGRecord(data []byte) (*RRSIGRecord, error) {
+	if len(data) < 18 {
+		return nil, fmt.Errorf("RRSIG record too short: %d bytes", len(data))
+	}
+
+	signer, index, err := readName(data, 18)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RRSIG signer name: %w", err)
+	}
+
+	return &RRSIGRecord{
+		TypeCovered: dns.QType(binary.BigEndian.Uint16(data[0:2])),
+		Algorithm:   data[2],
+		Labels:      data[3],
+		OriginalTTL: binary.BigEndian.Uint32(data[4:8]),
+		Expiration:  binary.BigEndian.Uint32(data[8:12]),
+		Inception:   binary.BigEndian.Uint32(data[12:16]),
+		KeyTag:      binary.BigEndian.Uint16(data[16:18]),
+		SignerName:  signer,
+		Signature:   append([]byte(nil), data[index:]...),
+	}, nil
+}
+
+// Bytes returns the wire format representation of the RRSIG record
+func (sig *RRSIGRecord) Bytes() []byte {
+	return append(sig.SignedBytes(), sig.Signature...)
+}
+
+// SignedBytes returns the resource data without the signature field, which
+// is the prefix covered by the signature itself (RFC 4034 Section 3.1.8.1)
+func (sig *RRSIGRecord) SignedBytes() []byte {
+	result := appendUint16(nil, uint16(sig.TypeCovered))
+	result = append(result, sig.Algorithm, sig.Labels)
+	result = appendUint32(result, sig.OriginalTTL)
+	result = appendUint32(result, sig.Expiration)
+	result = appendUint32(result, sig.Inception)
+	result = appendUint16(result, sig.KeyTag)
+	return append(result, sig.SignerName.Bytes()...)
+}
+
+// CanonicalBytes returns the wire format with the signer name lowercased
+// (RFC 4034 Section 6.2)
+func (sig *RRSIGRecord) CanonicalBytes() []byte {
+	canonical := *sig
+	canonical.SignerName = sig.SignerName.Canonical()
+	return canonical.Bytes()
+}
+
+// String returns the presentation format of the RRSIG record
+func (sig *RRSIGRecord) String() string {
+	return fmt.Sprintf("%s %d %d %d %s %s %d %s %s",
+		typeName(sig.TypeCovered), sig.Algorithm, sig.Labels, sig.OriginalTTL,
+		time.Unix(int64(sig.Expiration), 0).UTC().Format(rrsigTimeFormat),
+		time.Unix(int64(sig.Inception), 0).UTC().Format(rrsigTimeFormat),
+		sig.KeyTag, fqdn(sig.SignerName),
+		base64.StdEncoding.EncodeToString(sig.Signature))
+}
+
+// Type returns the DNS record type
+func (sig *RRSIGRecord) Type() dns.QType {
+	return dns.TypeRRSIG
+}
+
+// ValidAt reports whether the time lies within the validity period of the
+// signature, using serial number arithmetic (RFC 4034 Section 3.1.5)
+func (sig *RRSIGRecord) ValidAt(t time.Time) bool {
+	now := uint32(t.Unix())
+	return int32(now-sig.Inception) >= 0 && int32(sig.Expiration-now) >= 0
+}
@@ -0,0 +1,87 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func testRRSIGRecord() *RRSIGRecord {
+	return &RRSIGRecord{
+		TypeCovered: dns.TypeA,
+		Algorithm:   AlgorithmECDSAP256SHA256,
+		Labels:      2,
+		OriginalTTL: 3600,
+		Expiration:  uint32(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		Inception:   uint32(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		KeyTag:      12345,
+		SignerName:  dns.MustParseName("example.com"),
+		Signature:   []byte{0xDE, 0xAD, 0xBE, 0xEF},
+	}
+}
+
+func TestRRSIGRecordRoundTrip(t *testing.T) {
+	sig := testRRSIGRecord()
+
+	parsed, err := ParseRRSIGRecord(sig.Bytes())
+	if err != nil {
+		t.Fatalf("ParseRRSIGRecord() returned error: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), sig.Bytes()) {
+		t.Errorf("ParseRRSIGRecord() round trip = %v, want %v", parsed, sig)
+	}
+	if dns.LabelsToString(parsed.SignerName) != "example.com" {
+		t.Errorf("SignerName = %q, want %q", dns.LabelsToString(parsed.SignerName), "example.com")
+	}
+
+	if _, err := ParseRRSIGRecord(sig.Bytes()[:10]); err == nil {
+		t.Error("ParseRRSIGRecord() should return error for truncated data")
+	}
+}
+
+func TestRRSIGRecordCompressedSigner(t *testing.T) {
+	data := append(testRRSIGRecord().SignedBytes()[:18], 0xC0, 0x0C)
+
+	if _, err := ParseRRSIGRecord(data); err == nil {
+		t.Error("ParseRRSIGRecord() should reject a compressed signer name")
+	}
+}
+
+func TestRRSIGRecordString(t *testing.T) {
+	expected := "A 13 2 3600 20240201000000 20240101000000 12345 example.com. 3q2+7w=="
+	if result := testRRSIGRecord().String(); result != expected {
+		t.Errorf("RRSIGRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestRRSIGRecordValidAt(t *testing.T) {
+	sig := testRRSIGRecord()
+
+	tests := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		if result := sig.ValidAt(test.at); result != test.expected {
+			t.Errorf("RRSIGRecord.ValidAt(%v) = %v, want %v", test.at, result, test.expected)
+		}
+	}
+}
+
+func TestRRSIGRecordCanonicalBytes(t *testing.T) {
+	sig := testRRSIGRecord()
+	sig.SignerName = dns.MustParseName("EXAMPLE.com")
+
+	expected := testRRSIGRecord().Bytes()
+	if result := sig.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("RRSIGRecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+}
@@ -0,0 +1,69 @@
+package records
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// SOARecord represents an SOA (start of authority) record
+type SOARecord struct {
+	MName   dns.Name // Primary name server of the zone
+	RName   dns.Name // Mailbox of the person responsible for the zone
+	Serial  uint32   // Version number of the zone
+	Refresh uint32   // Seconds before the zone should be refreshed
+	Retry   uint32   // Seconds before a failed refresh should be retried
+	Expire  uint32   // Seconds after which the zone is no longer authoritative
+	Minimum uint32   // Negative caching TTL (RFC 2308)
+}
+
+// NewSOARecord creates a new SOA record
+func NewSOARecord(mname, rname dns.Name, serial, refresh, retry, expire, minimum uint32) *SOARecord {
+	return &SOARecord{
+		MName:   mname,
+		RName:   rname,
+		Serial:  serial,
+		Refresh: refresh,
+		Retry:   retry,
+		Expire:  expire,
+		Minimum: minimum,
+	}
+}
+
+// Bytes returns the wire format representation of the SOA record
+func (soa *SOARecord) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	for _, label := range soa.MName {
+		buf.Write(label.ToBytes())
+	}
+	for _, label := range soa.RName {
+		buf.Write(label.ToBytes())
+	}
+	for _, field := range []uint32{soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.Minimum} {
+		binary.Write(buf, binary.BigEndian, field)
+	}
+	return buf.Bytes()
+}
+
+// CanonicalBytes returns the wire format with MNAME and RNAME lowercased
+// (RFC 4034 Section 6.2)
+func (soa *SOARecord) CanonicalBytes() []byte {
+	canonical := *soa
+	canonical.MName = soa.MName.Canonical()
+	canonical.RName = soa.RName.Canonical()
+	return canonical.Bytes()
+}
+
+// String returns the string representation of the SOA record
+func (soa *SOARecord) String() string {
+	return fmt.Sprintf("MNAME: %s\tRNAME: %s\tSERIAL: %d\tREFRESH: %d\tRETRY: %d\tEXPIRE: %d\tMINIMUM: %d",
+		soa.MName, soa.RName,
+		soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.Minimum)
+}
+
+// Type returns the DNS record type
+func (soa *SOARecord) Type() dns.QType {
+	return dns.TypeSOA
+}
@@ -0,0 +1,56 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func testSOARecord() *SOARecord {
+	return NewSOARecord(
+		dns.MustParseName("ns.example.com"),
+		dns.MustParseName("hostmaster.example.com"),
+		2024010101, 7200, 3600, 1209600, 300,
+	)
+}
+
+func TestSOARecordBytes(t *testing.T) {
+	result := testSOARecord().Bytes()
+
+	// ns.example.com (16) + hostmaster.example.com (24) + 5 * 4 bytes
+	if len(result) != 16+24+20 {
+		t.Fatalf("SOARecord.Bytes() length = %d, want %d", len(result), 16+24+20)
+	}
+
+	// Minimum is the last field
+	if result[len(result)-1] != 0x2C || result[len(result)-2] != 0x01 {
+		t.Errorf("SOARecord.Bytes() minimum = [%02x %02x], want [01 2c]", result[len(result)-2], result[len(result)-1])
+	}
+}
+
+func TestSOARecordString(t *testing.T) {
+	expected := "MNAME: ns.example.com\tRNAME: hostmaster.example.com\tSERIAL: 2024010101\tREFRESH: 7200\tRETRY: 3600\tEXPIRE: 1209600\tMINIMUM: 300"
+	if result := testSOARecord().String(); result != expected {
+		t.Errorf("SOARecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestSOARecordType(t *testing.T) {
+	if testSOARecord().Type() != dns.TypeSOA {
+		t.Errorf("SOARecord.Type() = %v, want %v", testSOARecord().Type(), dns.TypeSOA)
+	}
+}
+
+func TestSOARecordCanonicalBytes(t *testing.T) {
+	record := NewSOARecord(
+		dns.MustParseName("NS.Example.com"),
+		dns.MustParseName("HostMaster.example.COM"),
+		2024010101, 7200, 3600, 1209600, 300,
+	)
+
+	expected := testSOARecord().Bytes()
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("SOARecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+}
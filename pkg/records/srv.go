@@ -0,0 +1,45 @@
+package records
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// SRVRecord represents an SRV (service location) record (RFC 2782)
+type SRVRecord struct {
+	Priority uint16 // Lower values are tried first
+	Weight   uint16 // Relative share among records of equal priority
+	Port     uint16
+	Target   dns.Name
+}
+
+// NewSRVRecord creates a new SRV record
+func NewSRVRecord(priority, weight, port uint16, target dns.Name) *SRVRecord {
+	return &SRVRecord{Priority: priority, Weight: weight, Port: port, Target: target}
+}
+
+// Bytes returns the wire format representation of the SRV record
+func (srv *SRVRecord) Bytes() []byte {
+	buf := binary.BigEndian.AppendUint16(nil, srv.Priority)
+	buf = binary.BigEndian.AppendUint16(buf, srv.Weight)
+	buf = binary.BigEndian.AppendUint16(buf, srv.Port)
+	return append(buf, srv.Target.Bytes()...)
+}
+
+// CanonicalBytes returns the wire format with the target lowercased
+// (RFC 4034 Section 6.2)
+func (srv *SRVRecord) CanonicalBytes() []byte {
+	return NewSRVRecord(srv.Priority, srv.Weight, srv.Port, srv.Target.Canonical()).Bytes()
+}
+
+// String returns the string representation of the SRV record
+func (srv *SRVRecord) String() string {
+	return fmt.Sprintf("PRIORITY: %d\tWEIGHT: %d\tPORT: %d\tTARGET: %s", srv.Priority, srv.Weight, srv.Port, srv.Target.DisplayString())
+}
+
+// Type returns the DNS record type
+func (srv *SRVRecord) Type() dns.QType {
+	return dns.TypeSRV
+}
@@ -0,0 +1,55 @@
+package records
+
+import (
+	"bytes"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestNewSRVRecord(t *testing.T) {
+	target := dns.MustParseName("sip.example.com")
+	record := NewSRVRecord(1, 20, 5060, target)
+
+	if record.Priority != 1 || record.Weight != 20 || record.Port != 5060 || !record.Target.Equal(target) {
+		t.Errorf("SRVRecord = %d %d %d %s, want 1 20 5060 %s", record.Priority, record.Weight, record.Port, record.Target, target)
+	}
+}
+
+func TestSRVRecordString(t *testing.T) {
+	record := NewSRVRecord(1, 20, 5060, dns.MustParseName("SIP.example.com"))
+
+	expected := "PRIORITY: 1\tWEIGHT: 20\tPORT: 5060\tTARGET: SIP.example.com"
+	if result := record.String(); result != expected {
+		t.Errorf("SRVRecord.String() = %q, want %q", result, expected)
+	}
+}
+
+func TestSRVRecordBytes(t *testing.T) {
+	record := NewSRVRecord(1, 20, 5060, dns.MustParseName("SIP.example.com"))
+
+	expected := []byte("\x00\x01\x00\x14\x13\xc4\x03SIP\x07example\x03com\x00")
+	if result := record.Bytes(); !bytes.Equal(result, expected) {
+		t.Errorf("SRVRecord.Bytes() = %q, want %q", result, expected)
+	}
+}
+
+func TestSRVRecordType(t *testing.T) {
+	record := NewSRVRecord(1, 20, 5060, dns.MustParseName("sip.example.com"))
+
+	if record.Type() != dns.TypeSRV {
+		t.Errorf("SRVRecord.Type() = %v, want %v", record.Type(), dns.TypeSRV)
+	}
+}
+
+func TestSRVRecordCanonicalBytes(t *testing.T) {
+	record := NewSRVRecord(1, 20, 5060, dns.MustParseName("SIP.example.com"))
+
+	expected := []byte("\x00\x01\x00\x14\x13\xc4\x03sip\x07example\x03com\x00")
+	if result := record.CanonicalBytes(); !bytes.Equal(result, expected) {
+		t.Errorf("SRVRecord.CanonicalBytes() = %q, want %q", result, expected)
+	}
+	if record.Target.String() != "SIP.example.com" {
+		t.Error("SRVRecord.CanonicalBytes() modified the record")
+	}
+}
@@ -0,0 +1,130 @@
+package records
+
+import (
+	"fmt"
+	"strings"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// TXTRecord represents a TXT record: one or more character-strings of up
+// to 255 bytes each
+type TXTRecord struct {
+	Strings []string
+}
+
+// NewTXTRecord creates a new TXT record. Strings longer than 255 bytes are
+// split into several character-strings.
+func NewTXTRecord(texts ...string) *TXTRecord {
+	var chunks []string
+	for _, text := range texts {
+		for len(text) > 255 {
+			chunks = append(chunks, text[:255])
+			text = text[255:]
+		}
+		chunks = append(chunks, text)
+	}
+	return &TXTRecord{Strings: chunks}
+}
+
+// ParseTXTRecord decodes a TXT record from its wire format resource data
+func ParseTXTRecord(data []byte) (*TXTRecord, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("TXT record has no character-strings")
+	}
+	record := &TXTRecord{}
+	for len(data) > 0 {
+		length := int(data[0])
+		if 1+length > len(data) {
+			return nil, fmt.Errorf("TXT character-string truncated")
+		}
+		record.Strings = append(record.Strings, string(data[1:1+length]))
+		data = data[1+length:]
+	}
+	return record, nil
+}
+
+// Bytes returns the wire format representation of the TXT record
+func (txt *TXTRecord) Bytes() []byte {
+	var buf []byte
+	for _, s := range txt.Strings {
+		buf = append(buf, byte(len(s)))
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+// Text returns the character-strings joined together, which is how
+// records such as SPF and DKIM split long values
+func (txt *TXTRecord) Text() string {
+	return strings.Join(txt.Strings, "")
+}
+
+// String returns the character-strings in presentation format
+func (txt *TXTRecord) String() string {
+	quoted := make([]string, len(txt.Strings))
+	for i, s := range txt.Strings {
+		quoted[i] = QuoteCharacterString(s)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Type returns the DNS record type
+func (txt *TXTRecord) Type() dns.QType {
+	return dns.TypeTXT
+}
+
+// QuoteCharacterString returns a character-string in quotes, escaping
+// quotes and backslashes as \X and unprintable bytes as \DDD
+// (RFC 1035 Section 5.1)
+func QuoteCharacterString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c >= 0x7F:
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// UnescapeCharacterString resolves the \X and \DDD escapes of a
+// character-string in presentation format, without surrounding quotes
+func UnescapeCharacterString(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c != '\\':
+			b.WriteByte(c)
+		case i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]):
+			value := int(s[i+1]-'0')*100 + int(s[i+2]-'0')*10 + int(s[i+3]-'0')
+			if value > 255 {
+				return "", fmt.Errorf("escaped value out of range in %q", s)
+			}
+			b.WriteByte(byte(value))
+			i += 3
+		case i+1 < len(s) && !isDigit(s[i+1]):
+			b.WriteByte(s[i+1])
+			i++
+		default:
+			return "", fmt.Errorf("invalid escape sequence in %q", s)
+		}
+	}
+	if b.Len() > 255 {
+		return "", fmt.Errorf("character-string longer than 255 bytes")
+	}
+	return b.String(), nil
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,68 @@
+package records
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestTXTRecord(t *testing.T) {
+	record := NewTXTRecord("v=spf1 -all", `say "hi"`)
+
+	if record.Type() != dns.TypeTXT {
+		t.Errorf("TXTRecord.Type() = %v, want %v", record.Type(), dns.TypeTXT)
+	}
+	if expected := `"v=spf1 -all" "say \"hi\""`; record.String() != expected {
+		t.Errorf("TXTRecord.String() = %q, want %q", record.String(), expected)
+	}
+	expected := []byte("\x0bv=spf1 -all\x08say \"hi\"")
+	if !bytes.Equal(record.Bytes(), expected) {
+		t.Errorf("TXTRecord.Bytes() = %q, want %q", record.Bytes(), expected)
+	}
+
+	parsed, err := ParseTXTRecord(expected)
+	if err != nil || len(parsed.Strings) != 2 || parsed.Text() != `v=spf1 -allsay "hi"` {
+		t.Errorf("ParseTXTRecord() = %v, %v", parsed, err)
+	}
+	for _, data := range [][]byte{nil, []byte("\x05abc")} {
+		if _, err := ParseTXTRecord(data); err == nil {
+			t.Errorf("ParseTXTRecord(%q) should return error", data)
+		}
+	}
+}
+
+func TestNewTXTRecordSplitsLongStrings(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	record := NewTXTRecord(long)
+	if len(record.Strings) != 2 || len(record.Strings[0]) != 255 || record.Text() != long {
+		t.Errorf("NewTXTRecord() split %d bytes into %d strings", len(long), len(record.Strings))
+	}
+}
+
+func TestCharacterStringEscapes(t *testing.T) {
+	tests := []struct {
+		raw, quoted string
+	}{
+		{"plain", `"plain"`},
+		{`back\slash`, `"back\\slash"`},
+		{"tab\there", `"tab\009here"`},
+		{"", `""`},
+	}
+	for _, test := range tests {
+		if result := QuoteCharacterString(test.raw); result != test.quoted {
+			t.Errorf("QuoteCharacterString(%q) = %q, want %q", test.raw, result, test.quoted)
+		}
+		inner := test.quoted[1 : len(test.quoted)-1]
+		if result, err := UnescapeCharacterString(inner); err != nil || result != test.raw {
+			t.Errorf("UnescapeCharacterString(%q) = %q, %v, want %q", inner, result, err, test.raw)
+		}
+	}
+
+	for _, input := range []string{`\256`, `trailing\`, strings.Repeat("a", 256)} {
+		if _, err := UnescapeCharacterString(input); err == nil {
+			t.Errorf("UnescapeCharacterString(%q) should return error", input)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package zone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// ParseError reports a syntax error in a master file
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parser holds the state carried from one entry of a master file to the next
+type parser struct {
+	zone      *Zone
+	origin    dns.Name
+	ttl       int32 // From $TTL
+	hasTTL    bool
+	lastOwner dns.Name
+	lastTTL   int32
+	hasLast   bool
+	lastClass dns.QClass
+}
+
+// entry is one logical line of a master file, which parentheses may
+// spread over several physical lines
+type entry struct {
+	line       int
+	blankOwner bool // Line starts with whitespace: reuse the previous owner
+	tokens     []string
+}
+
+// Parse reads a zone in master file format. Relative names are completed
+// with origin until a $ORIGIN directive changes it. Records without a TTL
+// use the $TTL value or the TTL of the previous record. $INCLUDE is not
+// supported.
+func Parse(r io.Reader, origin string) (*Zone, error) {
+	originLabels, err := parseName(origin, dns.RootName())
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+
+	p := &parser{
+		zone:      &Zone{Origin: originLabels},
+		origin:    originLabels,
+		lastClass: dns.ClassIN,
+	}
+
+	scanner := bufio.NewScanner(r)
+	var current *entry
+	depth, lineNumber := 0, 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		tokens, delta, err := tokenize(line)
+		if err != nil {
+			return nil, &ParseError{Line: lineNumber, Err: err}
+		}
+		if depth == 0 {
+			if len(tokens) == 0 && delta == 0 {
+				continue
+			}
+			current = &entry{
+				line:       lineNumber,
+				blankOwner: strings.IndexFunc(line, func(r rune) bool { return !unicode.IsSpace(r) }) > 0,
+			}
+		}
+		current.tokens = append(current.tokens, tokens...)
+
+		depth += delta
+		if depth < 0 {
+			return nil, &ParseError{Line: lineNumber, Err: fmt.Errorf("unbalanced parentheses")}
+		}
+		if depth == 0 && len(current.tokens) > 0 {
+			if err := p.parseEntry(current); err != nil {
+				return nil, &ParseError{Line: current.line, Err: err}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read zone: %w", err)
+	}
+	if depth != 0 {
+		return nil, &ParseError{Line: current.line, Err: fmt.Errorf("unterminated parentheses")}
+	}
+
+	return p.zone, nil
+}
+
+// tokenize splits a line into tokens, dropping comments. Quoted strings
+// form a single token without their quotes; escaped characters are kept
+// with their backslash. It also returns the change in parenthesis depth.
+func tokenize(line string) ([]string, int, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken, quoted, depth := false, false, 0
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, token.String())
+			token.Reset()
+			inToken = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, 0, fmt.Errorf("dangling escape at end of line")
+			}
+			token.WriteByte(c)
+			token.WriteByte(line[i+1])
+			inToken = true
+			i++
+		case quoted:
+			if c == '"' {
+				quoted = false
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			} else {
+				token.WriteByte(c)
+			}
+		case c == '"':
+			flush()
+			quoted, inToken = true, true
+		case c == ';':
+			flush()
+			return tokens, depth, nil
+		case c == '(':
+			flush()
+			depth++
+		case c == ')':
+			flush()
+			depth--
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, 0, fmt.Errorf("unterminated quoted string")
+	}
+	flush()
+	return tokens, depth, nil
+}
+
+// parseEntry handles a directive or a resource record
+func (p *parser) parseEntry(e *entry) error {
+	tokens := e.tokens
+	if !e.blankOwner && strings.HasPrefix(tokens[0], "$") {
+		return p.parseDirective(tokens)
+	}
+
+	// Owner name
+	var owner dns.Name
+	if e.blankOwner {
+		if p.lastOwner == nil {
+			return fmt.Errorf("no previous owner name")
+		}
+		owner = p.lastOwner
+	} else {
+		var err error
+		if owner, err = parseName(tokens[0], p.origin); err != nil {
+			return err
+		}
+		tokens = tokens[1:]
+	}
+
+	// Optional TTL and class, in either order
+	var ttl int32
+	hasTTL := false
+	class := p.lastClass
+	for i := 0; i < 2 && len(tokens) > 0; i++ {
+		if value, err := parseTTL(tokens[0]); err == nil && !hasTTL {
+			ttl, hasTTL = value, true
+			tokens = tokens[1:]
+		} else if qc, err := dns.ParseQClass(tokens[0]); err == nil && tokens[0] != "*" {
+			class = qc
+			tokens = tokens[1:]
+		}
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("missing record type")
+	}
+	qtype, err := dns.ParseQType(tokens[0])
+	if err != nil {
+		return err
+	}
+	rdata, err := parseRData(qtype, tokens[1:], p.origin)
+	if err != nil {
+		return fmt.Errorf("invalid %s record: %w", qtype, err)
+	}
+
+	if !hasTTL {
+		switch {
+		case p.hasTTL:
+			ttl = p.ttl
+		case p.hasLast:
+			ttl = p.lastTTL
+		case qtype == dns.TypeSOA:
+			ttl = int32(rdata.(*records.SOARecord).Minimum)
+		default:
+			return fmt.Errorf("no TTL specified and no $TTL directive")
+		}
+	}
+
+	p.zone.Records = append(p.zone.Records, dns.ResourceRecord{
+		Name:     owner,
+		Type:     qtype,
+		Class:    class,
+		TTL:      ttl,
+		RDLength: uint16(len(rdata.Bytes())),
+		RData:    rdata,
+	})
+	p.lastOwner, p.lastTTL, p.hasLast, p.lastClass = owner, ttl, true, class
+	return nil
+}
+
+// parseDirective handles $ORIGIN and $TTL
+func (p *parser) parseDirective(tokens []string) error {
+	if len(tokens) != 2 {
+		return fmt.Errorf("%s expects one argument", tokens[0])
+	}
+
+	switch strings.ToUpper(tokens[0]) {
+	case "$ORIGIN":
+		origin, err := parseName(tokens[1], p.origin)
+		if err != nil {
+			return err
+		}
+		p.origin = origin
+	case "$TTL":
+		ttl, err := parseTTL(tokens[1])
+		if err != nil {
+			return err
+		}
+		p.ttl, p.hasTTL = ttl, true
+	default:
+		return fmt.Errorf("unsupported directive %s", tokens[0])
+	}
+	return nil
+}
+
+// parseName converts a name in master file form to labels. "@" stands for
+// the origin and names without a trailing dot are relative to it.
+func parseName(s string, origin dns.Name) (dns.Name, error) {
+	if s == "@" {
+		return append(dns.Name(nil), origin...), nil
+	}
+	if !isAbsolute(s) && !origin.IsRoot() {
+		s += "." + origin.String()
+	}
+	return dns.ParseName(s)
+}
+
+// isAbsolute reports whether a name ends with an unescaped dot
+func isAbsolute(s string) bool {
+	if !strings.HasSuffix(s, ".") {
+		return false
+	}
+	escapes := 0
+	for i := len(s) - 2; i >= 0 && s[i] == '\\'; i-- {
+		escapes++
+	}
+	return escapes%2 == 0
+}
+
+// parseTTL parses a TTL in seconds, optionally written with the units
+// s, m, h, d and w as in "1h30m"
+func parseTTL(s string) (int32, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+
+	var total, value uint64
+	hasValue := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			value = value*10 + uint64(c-'0')
+			hasValue = true
+			if value > math.MaxInt32 {
+				return 0, fmt.Errorf("TTL %q out of range", s)
+			}
+			continue
+		}
+
+		unit, ok := map[byte]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c|0x20]
+		if !ok || !hasValue {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += value * unit
+		value, hasValue = 0, false
+	}
+	total += value
+
+	if total > math.MaxInt32 {
+		return 0, fmt.Errorf("TTL %q out of range", s)
+	}
+	return int32(total), nil
+}
+
+// parseUint parses a decimal number of at most bits bits
+func parseUint(s string, bits int) (uint64, error) {
+	value, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return value, nil
+}
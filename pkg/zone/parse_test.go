@@ -0,0 +1,165 @@
+package zone
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+const testZoneFile = `; Example zone
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		2h 15m 1w 300 )
+	IN	NS	ns1
+	IN	NS	ns2.example.net.
+ns1	300	A	192.0.2.53
+www	IN 600	A	192.0.2.1
+	AAAA	2001:db8::1
+sub	NS	ns.sub
+ns.sub	A	192.0.2.54
+$ORIGIN deep.example.com.
+host	A	192.0.2.2
+raw	TYPE999	\# 2 abcd
+`
+
+func TestParse(t *testing.T) {
+	zone, err := Parse(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	expected := []struct {
+		name  string
+		ttl   int32
+		qtype dns.QType
+	}{
+		{"example.com", 3600, dns.TypeSOA},
+		{"example.com", 3600, dns.TypeNS},
+		{"example.com", 3600, dns.TypeNS},
+		{"ns1.example.com", 300, dns.TypeA},
+		{"www.example.com", 600, dns.TypeA},
+		{"www.example.com", 3600, dns.TypeAAAA},
+		{"sub.example.com", 3600, dns.TypeNS},
+		{"ns.sub.example.com", 3600, dns.TypeA},
+		{"host.deep.example.com", 3600, dns.TypeA},
+		{"raw.deep.example.com", 3600, dns.QType(999)},
+	}
+	if len(zone.Records) != len(expected) {
+		t.Fatalf("Parse() returned %d records, want %d", len(zone.Records), len(expected))
+	}
+	for i, e := range expected {
+		rr := zone.Records[i]
+		if dns.LabelsToString(rr.Name) != e.name || rr.TTL != e.ttl || rr.Type != e.qtype || rr.Class != dns.ClassIN {
+			t.Errorf("record %d = %s %d %s, want %s %d %s", i, dns.LabelsToString(rr.Name), rr.TTL, rr.Type, e.name, e.ttl, e.qtype)
+		}
+		if int(rr.RDLength) != len(rr.RData.Bytes()) {
+			t.Errorf("record %d RDLength = %d, want %d", i, rr.RDLength, len(rr.RData.Bytes()))
+		}
+	}
+
+	soa := zone.SOA().RData.(*records.SOARecord)
+	if dns.LabelsToString(soa.MName) != "ns1.example.com" || soa.Serial != 2024010101 || soa.Refresh != 7200 ||
+		soa.Retry != 900 || soa.Expire != 604800 || soa.Minimum != 300 {
+		t.Errorf("SOA = %v", soa)
+	}
+	if ns := zone.Records[2].RData.(*records.NSRecord); dns.LabelsToString(ns.NameServer) != "ns2.example.net" {
+		t.Errorf("absolute NS name = %s, want ns2.example.net", dns.LabelsToString(ns.NameServer))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{"missing TTL", "www A 192.0.2.1\n", 1},
+		{"bad address", "$TTL 60\nwww A 2001:db8::1\n", 2},
+		{"unknown type", "$TTL 60\nwww FOO bar\n", 2},
+		{"unsupported directive", "$INCLUDE other.zone\n", 1},
+		{"unbalanced parentheses", "$TTL 60\nwww A 192.0.2.1 )\n", 2},
+		{"unterminated parentheses", "$TTL 60\n@ SOA ns hostmaster ( 1 2 3 4\n", 2},
+		{"no previous owner", "  60 A 192.0.2.1\n", 1},
+		{"bad generic length", "$TTL 60\nx TYPE999 \\# 3 abcd\n", 2},
+		{"empty label", "$TTL 60\na..b A 192.0.2.1\n", 2},
+		{"bad escape", "$TTL 60\na\\256 A 192.0.2.1\n", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input), "example.com")
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want *ParseError", err)
+			}
+			if parseErr.Line != test.line {
+				t.Errorf("ParseError.Line = %d, want %d", parseErr.Line, test.line)
+			}
+		})
+	}
+}
+
+func TestParseEscapedNames(t *testing.T) {
+	input := "$TTL 60\na\\.b A 192.0.2.1\nabs\\. A 192.0.2.2\nfqdn. A 192.0.2.3\n"
+	zone, err := Parse(strings.NewReader(input), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	// An escaped trailing dot does not make a name absolute
+	expected := []string{`a\.b.example.com`, `abs\..example.com`, "fqdn"}
+	for i, name := range expected {
+		if result := zone.Records[i].Name.String(); result != name {
+			t.Errorf("record %d owner = %q, want %q", i, result, name)
+		}
+	}
+	if first := zone.Records[0].Name[0]; string(first.Data) != "a.b" {
+		t.Errorf("first label = %q, want %q", first.Data, "a.b")
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int32
+	}{
+		{"0", 0},
+		{"3600", 3600},
+		{"1h", 3600},
+		{"1h30m", 5400},
+		{"1W2D", 777600},
+		{"90s", 90},
+	}
+
+	for _, test := range tests {
+		result, err := parseTTL(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("parseTTL(%q) = %d, %v, want %d", test.input, result, err, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "h", "1x", "4294967296", "IN"} {
+		if _, err := parseTTL(input); err == nil {
+			t.Errorf("parseTTL(%q) should return error", input)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, depth, err := tokenize(`www A ( "quoted text" \; ) ; comment`)
+	if err != nil {
+		t.Fatalf("tokenize() returned error: %v", err)
+	}
+	expected := []string{"www", "A", "quoted text", `\;`}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") || depth != 0 {
+		t.Errorf("tokenize() = %q, %d, want %q, 0", tokens, depth, expected)
+	}
+
+	if _, _, err := tokenize(`"open`); err == nil {
+		t.Error("tokenize() should return error for unterminated quote")
+	}
+}
@@ -0,0 +1,427 @@
+package zone
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// rrsigTimeFormat is the presentation format of RRSIG validity times
+// (RFC 4034 Section 3.2)
+const rrsigTimeFormat = "20060102150405"
+
+// parseRData parses the presentation form of resource data. Any type may
+// use the generic "\# length hex" form of RFC 3597 Section 5.
+func parseRData(qtype dns.QType, tokens []string, origin dns.Name) (dns.ResourceData, error) {
+	if len(tokens) > 0 && tokens[0] == `\#` {
+		return parseGeneric(qtype, tokens[1:])
+	}
+
+	switch qtype {
+	case dns.TypeA:
+		if err := expectFields(tokens, 1); err != nil {
+			return nil, err
+		}
+		return records.NewARecordFromString(tokens[0])
+
+	case dns.TypeAAAA:
+		if err := expectFields(tokens, 1); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(tokens[0], ":") {
+			return nil, fmt.Errorf("invalid IPv6 address: %s", tokens[0])
+		}
+		return records.NewAAAARecordFromString(tokens[0])
+
+	case dns.TypeNS:
+		if err := expectFields(tokens, 1); err != nil {
+			return nil, err
+		}
+		name, err := parseName(tokens[0], origin)
+		if err != nil {
+			return nil, err
+		}
+		return records.NewNSRecord(name), nil
+
+	case dns.TypePTR, dns.TypeCNAME, dns.TypeDNAME:
+		if err := expectFields(tokens, 1); err != nil {
+			return nil, err
+		}
+		name, err := parseName(tokens[0], origin)
+		if err != nil {
+			return nil, err
+		}
+		switch qtype {
+		case dns.TypeCNAME:
+			return records.NewCNAMERecord(name), nil
+		case dns.TypeDNAME:
+			return records.NewDNAMERecord(name), nil
+		}
+		return records.NewPTRRecord(name), nil
+
+	case dns.TypeSOA:
+		return parseSOA(tokens, origin)
+
+	case dns.TypeMX:
+		if err := expectFields(tokens, 2); err != nil {
+			return nil, err
+		}
+		preference, err := parseUint(tokens[0], 16)
+		if err != nil {
+			return nil, err
+		}
+		exchange, err := parseName(tokens[1], origin)
+		if err != nil {
+			return nil, err
+		}
+		return records.NewMXRecord(uint16(preference), exchange), nil
+
+	case dns.TypeSRV:
+		return parseSRV(tokens, origin)
+
+	case dns.TypeTXT:
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("TXT record needs at least one string")
+		}
+		texts := make([]string, len(tokens))
+		for i, token := range tokens {
+			text, err := records.UnescapeCharacterString(token)
+			if err != nil {
+				return nil, err
+			}
+			texts[i] = text
+		}
+		return &records.TXTRecord{Strings: texts}, nil
+
+	case dns.TypeDNSKEY, dns.TypeCDNSKEY:
+		key, err := parseDNSKEY(tokens)
+		if err != nil || qtype == dns.TypeDNSKEY {
+			return key, err
+		}
+		return &records.CDNSKEYRecord{DNSKEYRecord: *key}, nil
+
+	case dns.TypeDS, dns.TypeCDS:
+		ds, err := records.NewDSRecordFromString(strings.Join(tokens, " "))
+		if err != nil || qtype == dns.TypeDS {
+			return ds, err
+		}
+		return &records.CDSRecord{DSRecord: *ds}, nil
+
+	case dns.TypeRRSIG:
+		return parseRRSIG(tokens, origin)
+
+	case dns.TypeNSEC:
+		if len(tokens) < 1 {
+			return nil, fmt.Errorf("missing next domain name")
+		}
+		next, err := parseName(tokens[0], origin)
+		if err != nil {
+			return nil, err
+		}
+		types, err := parseTypeList(tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		return records.NewNSECRecord(next, types), nil
+
+	case dns.TypeNSEC3:
+		return parseNSEC3(tokens)
+
+	case dns.TypeNSEC3PARAM:
+		if err := expectFields(tokens, 4); err != nil {
+			return nil, err
+		}
+		hashAlgorithm, flags, iterations, salt, err := parseNSEC3Params(tokens)
+		if err != nil {
+			return nil, err
+		}
+		param := records.NewNSEC3PARAMRecord(hashAlgorithm, iterations, salt)
+		param.Flags = flags
+		return param, nil
+
+	default:
+		return nil, fmt.Errorf(`unsupported record type %s, use the generic \# form`, qtype)
+	}
+}
+
+// expectFields checks the number of fields of resource data
+func expectFields(tokens []string, n int) error {
+	if len(tokens) != n {
+		return fmt.Errorf("expected %d fields, got %d", n, len(tokens))
+	}
+	return nil
+}
+
+// parseGeneric parses the "length hex..." part of the generic form
+func parseGeneric(qtype dns.QType, tokens []string) (dns.ResourceData, error) {
+	if len(tokens) < 1 {
+		return nil, fmt.Errorf(`missing length after \#`)
+	}
+	length, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(strings.Join(tokens[1:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data: %w", err)
+	}
+	if uint64(len(data)) != length {
+		return nil, fmt.Errorf("data length %d does not match %d", len(data), length)
+	}
+	return records.NewGenericRecord(qtype, data), nil
+}
+
+// parseSOA parses "mname rname serial refresh retry expire minimum"
+func parseSOA(tokens []string, origin dns.Name) (*records.SOARecord, error) {
+	if err := expectFields(tokens, 7); err != nil {
+		return nil, err
+	}
+	mname, err := parseName(tokens[0], origin)
+	if err != nil {
+		return nil, err
+	}
+	rname, err := parseName(tokens[1], origin)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := parseUint(tokens[2], 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// The timers may use TTL units
+	var timers [4]uint32
+	for i, token := range tokens[3:] {
+		value, err := parseTTL(token)
+		if err != nil {
+			return nil, err
+		}
+		timers[i] = uint32(value)
+	}
+
+	return records.NewSOARecord(mname, rname, uint32(serial), timers[0], timers[1], timers[2], timers[3]), nil
+}
+
+// parseSRV parses "priority weight port target"
+func parseSRV(tokens []string, origin dns.Name) (*records.SRVRecord, error) {
+	if err := expectFields(tokens, 4); err != nil {
+		return nil, err
+	}
+	var fields [3]uint16
+	for i, token := range tokens[:3] {
+		value, err := parseUint(token, 16)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = uint16(value)
+	}
+	target, err := parseName(tokens[3], origin)
+	if err != nil {
+		return nil, err
+	}
+	return records.NewSRVRecord(fields[0], fields[1], fields[2], target), nil
+}
+
+// parseDNSKEY parses "flags protocol algorithm base64-key"
+func parseDNSKEY(tokens []string) (*records.DNSKEYRecord, error) {
+	if len(tokens) < 4 {
+		return nil, fmt.Errorf("expected flags, protocol, algorithm and key")
+	}
+	flags, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return nil, err
+	}
+	protocol, err := parseUint(tokens[1], 8)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := parseUint(tokens[2], 8)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.Join(tokens[3:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	record := records.NewDNSKEYRecord(uint16(flags), uint8(algorithm), key)
+	record.Protocol = uint8(protocol)
+	return record, nil
+}
+
+// parseRRSIG parses "type algorithm labels ttl expiration inception
+// keytag signer base64-signature"
+func parseRRSIG(tokens []string, origin dns.Name) (*records.RRSIGRecord, error) {
+	if len(tokens) < 9 {
+		return nil, fmt.Errorf("expected 9 fields, got %d", len(tokens))
+	}
+	covered, err := dns.ParseQType(tokens[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var numbers [3]uint64
+	for i, bits := range []int{8, 8, 32} {
+		if numbers[i], err = parseUint(tokens[1+i], bits); err != nil {
+			return nil, err
+		}
+	}
+	expiration, err := parseRRSIGTime(tokens[4])
+	if err != nil {
+		return nil, err
+	}
+	inception, err := parseRRSIGTime(tokens[5])
+	if err != nil {
+		return nil, err
+	}
+	keyTag, err := parseUint(tokens[6], 16)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parseName(tokens[7], origin)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.Join(tokens[8:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return &records.RRSIGRecord{
+		TypeCovered: covered,
+		Algorithm:   uint8(numbers[0]),
+		Labels:      uint8(numbers[1]),
+		OriginalTTL: uint32(numbers[2]),
+		Expiration:  expiration,
+		Inception:   inception,
+		KeyTag:      uint16(keyTag),
+		SignerName:  signer,
+		Signature:   signature,
+	}, nil
+}
+
+// parseRRSIGTime accepts YYYYMMDDHHmmSS or seconds since the epoch
+func parseRRSIGTime(s string) (uint32, error) {
+	if len(s) == len(rrsigTimeFormat) {
+		t, err := time.Parse(rrsigTimeFormat, s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid signature time %q", s)
+		}
+		return uint32(t.Unix()), nil
+	}
+	value, err := parseUint(s, 32)
+	return uint32(value), err
+}
+
+// parseNSEC3 parses "algorithm flags iterations salt next-hash types..."
+func parseNSEC3(tokens []string) (*records.NSEC3Record, error) {
+	if len(tokens) < 5 {
+		return nil, fmt.Errorf("expected at least 5 fields, got %d", len(tokens))
+	}
+	hashAlgorithm, flags, iterations, salt, err := parseNSEC3Params(tokens[:4])
+	if err != nil {
+		return nil, err
+	}
+	next, err := records.Base32Hex.DecodeString(strings.ToUpper(tokens[4]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid next hashed owner name: %w", err)
+	}
+	types, err := parseTypeList(tokens[5:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &records.NSEC3Record{
+		HashAlgorithm: hashAlgorithm,
+		Flags:         flags,
+		Iterations:    iterations,
+		Salt:          salt,
+		NextHashed:    next,
+		Types:         types,
+	}, nil
+}
+
+// parseNSEC3Params parses "algorithm flags iterations salt", where the
+// salt "-" is empty
+func parseNSEC3Params(tokens []string) (uint8, uint8, uint16, []byte, error) {
+	hashAlgorithm, err := parseUint(tokens[0], 8)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	flags, err := parseUint(tokens[1], 8)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	iterations, err := parseUint(tokens[2], 16)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	var salt []byte
+	if tokens[3] != "-" {
+		if salt, err = hex.DecodeString(tokens[3]); err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("invalid salt: %w", err)
+		}
+	}
+	return uint8(hashAlgorithm), uint8(flags), uint16(iterations), salt, nil
+}
+
+// parseTypeList parses the type mnemonics of an NSEC or NSEC3 bitmap
+func parseTypeList(tokens []string) ([]dns.QType, error) {
+	types := make([]dns.QType, 0, len(tokens))
+	for _, token := range tokens {
+		t, err := dns.ParseQType(token)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// formatRData returns the presentation form of resource data
+func formatRData(rdata dns.ResourceData) string {
+	switch rd := rdata.(type) {
+	case *records.ARecord:
+		return rd.Address.String()
+	case *records.AAAARecord:
+		return rd.Address.String()
+	case *records.NSRecord:
+		return formatName(rd.NameServer)
+	case *records.PTRRecord:
+		return formatName(rd.Target)
+	case *records.MXRecord:
+		return fmt.Sprintf("%d %s", rd.Preference, formatName(rd.Exchange))
+	case *records.SRVRecord:
+		return fmt.Sprintf("%d %d %d %s", rd.Priority, rd.Weight, rd.Port, formatName(rd.Target))
+	case *records.CNAMERecord:
+		return formatName(rd.Target)
+	case *records.DNAMERecord:
+		return formatName(rd.Target)
+	case *records.SOARecord:
+		return fmt.Sprintf("%s %s %d %d %d %d %d", formatName(rd.MName), formatName(rd.RName),
+			rd.Serial, rd.Refresh, rd.Retry, rd.Expire, rd.Minimum)
+	case *records.GenericRecord:
+		if len(rd.Data) == 0 {
+			return `\# 0`
+		}
+		return `\# ` + strconv.Itoa(len(rd.Data)) + " " + strings.ToUpper(hex.EncodeToString(rd.Data))
+	case *records.TXTRecord, *records.DNSKEYRecord, *records.CDNSKEYRecord, *records.DSRecord, *records.CDSRecord,
+		*records.RRSIGRecord, *records.NSECRecord, *records.NSEC3Record, *records.NSEC3PARAMRecord:
+		// These records already print in presentation format
+		return rdata.String()
+	default:
+		data := rdata.Bytes()
+		return `\# ` + strconv.Itoa(len(data)) + " " + strings.ToUpper(hex.EncodeToString(data))
+	}
+}
+
+// formatName returns the absolute form of a name
+func formatName(labels dns.Name) string {
+	return dns.LabelsToString(labels) + "."
+}
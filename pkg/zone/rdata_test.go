@@ -0,0 +1,81 @@
+package zone
+
+import (
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+func TestRDataRoundTrip(t *testing.T) {
+	origin := dns.MustParseName("example.com")
+	tests := []struct {
+		qtype dns.QType
+		text  string
+	}{
+		{dns.TypeA, "192.0.2.1"},
+		{dns.TypeAAAA, "2001:db8::1"},
+		{dns.TypeNS, "ns1.example.com."},
+		{dns.TypePTR, "host.example.com."},
+		{dns.TypeCNAME, "www.example.net."},
+		{dns.TypeDNAME, "example.net."},
+		{dns.TypeMX, "10 mail.example.com."},
+		{dns.TypeSRV, "0 5 5060 sip.example.com."},
+		{dns.TypeTXT, `"v=spf1 -all" "a \"quoted\" \\ \009"`},
+		{dns.TypeSOA, "ns1.example.com. hostmaster.example.com. 1 7200 900 604800 300"},
+		{dns.TypeDNSKEY, "257 3 15 AQIDBA=="},
+		{dns.TypeCDNSKEY, "0 3 0 AA=="},
+		{dns.TypeDS, "20326 8 2 E06D"},
+		{dns.TypeCDS, "0 0 0 00"},
+		{dns.TypeRRSIG, "A 13 3 300 20240201000000 20240101000000 12345 example.com. AQID"},
+		{dns.TypeNSEC, "b.example.com. A RRSIG NSEC TYPE999"},
+		{dns.TypeNSEC3, "1 1 12 AABBCCDD 2VPTU5TIMAMQTTGL4LUU9KG21E0AOR3S A RRSIG"},
+		{dns.TypeNSEC3PARAM, "1 0 0 -"},
+		{dns.QType(999), `\# 2 ABCD`},
+		{dns.TypeTXT, `\# 0`},
+	}
+
+	for _, test := range tests {
+		tokens, _, err := tokenize(test.text)
+		if err != nil {
+			t.Fatalf("tokenize(%q) returned error: %v", test.text, err)
+		}
+		rdata, err := parseRData(test.qtype, tokens, origin)
+		if err != nil {
+			t.Errorf("parseRData(%s, %q) returned error: %v", test.qtype, test.text, err)
+			continue
+		}
+		if rdata.Type() != test.qtype {
+			t.Errorf("parseRData(%s) type = %s", test.qtype, rdata.Type())
+		}
+		if result := formatRData(rdata); result != test.text {
+			t.Errorf("formatRData(parseRData(%q)) = %q", test.text, result)
+		}
+	}
+}
+
+func TestParseRDataErrors(t *testing.T) {
+	origin := dns.MustParseName("example.com")
+	tests := []struct {
+		qtype dns.QType
+		text  string
+	}{
+		{dns.TypeA, "192.0.2.1 192.0.2.2"},
+		{dns.TypeAAAA, "192.0.2.1"},
+		{dns.TypeSOA, "ns1 hostmaster 1 2 3"},
+		{dns.TypeDNSKEY, "257 3 15 !!"},
+		{dns.TypeRRSIG, "A 13 3 300 2024-02-01 20240101000000 12345 example.com. AQID"},
+		{dns.TypeNSEC, "b.example.com. FOO"},
+		{dns.TypeNSEC3PARAM, "1 0 0 XY"},
+		{dns.TypeHINFO, `"cpu" "os"`},
+		{dns.TypeTXT, `"\256"`},
+		{dns.TypeMX, "mail.example.com."},
+		{dns.TypeSRV, "0 5 70000 sip.example.com."},
+	}
+
+	for _, test := range tests {
+		tokens, _, _ := tokenize(test.text)
+		if _, err := parseRData(test.qtype, tokens, origin); err == nil {
+			t.Errorf("parseRData(%s, %q) should return error", test.qtype, test.text)
+		}
+	}
+}
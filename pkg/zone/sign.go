@@ -0,0 +1,388 @@
+package zone
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnssec"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// SignOptions controls how a zone is signed
+type SignOptions struct {
+	Inception  time.Time // Start of signature validity; one hour ago if zero
+	Expiration time.Time // End of signature validity; 30 days after Inception if zero
+
+	NSEC3      bool   // Build an NSEC3 chain instead of an NSEC chain
+	Iterations uint16 // Additional NSEC3 hash iterations; RFC 9276 recommends 0
+	Salt       []byte // NSEC3 salt; RFC 9276 recommends none
+	OptOut     bool   // Leave unsigned delegations out of the NSEC3 chain
+}
+
+// Sign returns a signed copy of the zone. Existing signatures, NSEC, NSEC3,
+// CDS and CDNSKEY records are replaced. The DNSKEY RRset gets the keys, and
+// a CDS (SHA-256) and CDNSKEY record is published for every key signing
+// key. Keys with the SEP flag sign the DNSKEY, CDS and CDNSKEY RRsets, the
+// other keys sign the rest; with only one kind of key it signs everything.
+// Delegation NS records and glue stay unsigned (RFC 4035 Section 2.2).
+func Sign(z *Zone, keys []*dnssec.PrivateKey, opts SignOptions) (*Zone, error) {
+	soaRR := z.SOA()
+	if soaRR == nil {
+		return nil, fmt.Errorf("zone %s has no SOA record", formatName(z.Origin))
+	}
+	soa := soaRR.RData.(*records.SOARecord)
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys")
+	}
+	var ksks, zsks []*dnssec.PrivateKey
+	for _, key := range keys {
+		if !dnssec.SupportedAlgorithm(key.DNSKEY.Algorithm) {
+			return nil, fmt.Errorf("%w: %d", dnssec.ErrUnsupportedAlgorithm, key.DNSKEY.Algorithm)
+		}
+		if !key.DNSKEY.IsZoneKey() || key.DNSKEY.IsRevoked() {
+			return nil, fmt.Errorf("key %d is not a usable zone key", key.DNSKEY.KeyTag())
+		}
+		if key.DNSKEY.IsSEP() {
+			ksks = append(ksks, key)
+		} else {
+			zsks = append(zsks, key)
+		}
+	}
+	if len(ksks) == 0 {
+		ksks = zsks
+	}
+	if len(zsks) == 0 {
+		zsks = ksks
+	}
+
+	if opts.Inception.IsZero() {
+		opts.Inception = time.Now().Add(-time.Hour)
+	}
+	if opts.Expiration.IsZero() {
+		opts.Expiration = opts.Inception.Add(30 * 24 * time.Hour)
+	}
+	if !opts.Expiration.After(opts.Inception) {
+		return nil, fmt.Errorf("signature expiration must be after inception")
+	}
+	if opts.NSEC3 && opts.Iterations > dnssec.MaxNSEC3Iterations {
+		return nil, fmt.Errorf("NSEC3 iterations %d exceed %d", opts.Iterations, dnssec.MaxNSEC3Iterations)
+	}
+
+	signed := &Zone{Origin: z.Origin}
+	if err := signed.addUnsigned(z, keys, soaRR.TTL, opts); err != nil {
+		return nil, err
+	}
+
+	// Negative answers are cached for the smaller of the SOA TTL and
+	// minimum (RFC 9077 Section 3)
+	negativeTTL := soaRR.TTL
+	if int64(soa.Minimum) < int64(negativeTTL) {
+		negativeTTL = int32(soa.Minimum)
+	}
+
+	nodes := signed.nodes()
+	byName := make(map[string]*node, len(nodes))
+	for _, n := range nodes {
+		byName[n.name.Key()] = n
+	}
+	if opts.NSEC3 {
+		signed.Records = append(signed.Records, nsec3Chain(nodes, z.Origin, negativeTTL, opts)...)
+	} else {
+		signed.Records = append(signed.Records, nsecChain(nodes, negativeTTL)...)
+	}
+
+	// RRSIGs cover whole RRsets with one TTL (RFC 2181 Section 5.2), so
+	// TTLs are lowered to the RRset minimum and duplicates dropped first
+	grouped := dns.GroupRRsets(signed.Records)
+	signed.Records = nil
+	var sigs []dns.ResourceRecord
+	for _, rrset := range grouped {
+		rrset.NormalizeTTL()
+		rrset.Dedupe()
+		signed.Records = append(signed.Records, rrset.Records...)
+		if !needsSignature(rrset.Records[0], byName) {
+			continue
+		}
+		signers := zsks
+		switch rrset.Type {
+		case dns.TypeDNSKEY, dns.TypeCDS, dns.TypeCDNSKEY:
+			signers = ksks
+		}
+		for _, key := range signers {
+			sig, err := dnssec.SignRRset(rrset.Records, z.Origin, key.DNSKEY, key.Signer, opts.Inception, opts.Expiration)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign %s %s: %w", formatName(rrset.Name), rrset.Type, err)
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	signed.Records = append(signed.Records, sigs...)
+
+	signed.Sort()
+	return signed, nil
+}
+
+// addUnsigned copies the zone data without DNSSEC records and adds the
+// DNSKEY, CDS, CDNSKEY and NSEC3PARAM records of the apex
+func (z *Zone) addUnsigned(source *Zone, keys []*dnssec.PrivateKey, ttl int32, opts SignOptions) error {
+	apex := func(rdata dns.ResourceData, ttl int32) {
+		z.Records = append(z.Records, dns.ResourceRecord{
+			Name:     z.Origin,
+			Type:     rdata.Type(),
+			Class:    dns.ClassIN,
+			TTL:      ttl,
+			RDLength: uint16(len(rdata.Bytes())),
+			RData:    rdata,
+		})
+	}
+
+	for _, key := range keys {
+		apex(key.DNSKEY, ttl)
+	}
+	for _, rr := range source.Records {
+		if !rr.Name.IsSubdomainOf(z.Origin) {
+			return fmt.Errorf("record %s %s is outside zone %s", formatName(rr.Name), rr.Type, formatName(z.Origin))
+		}
+		switch rr.Type {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3, dns.TypeNSEC3PARAM, dns.TypeCDS, dns.TypeCDNSKEY:
+			continue
+		case dns.TypeDNSKEY:
+			if hasKey(keys, rr.RData) {
+				continue
+			}
+		}
+		z.Records = append(z.Records, rr)
+	}
+
+	for _, key := range keys {
+		if !key.DNSKEY.IsSEP() {
+			continue
+		}
+		ds, err := dnssec.ComputeDS(z.Origin, key.DNSKEY, records.DigestSHA256)
+		if err != nil {
+			return err
+		}
+		apex(&records.CDSRecord{DSRecord: *ds}, ttl)
+		apex(&records.CDNSKEYRecord{DNSKEYRecord: *key.DNSKEY}, ttl)
+	}
+
+	if opts.NSEC3 {
+		// Resolvers do not need to cache NSEC3PARAM
+		apex(records.NewNSEC3PARAMRecord(records.NSEC3HashSHA1, opts.Iterations, opts.Salt), 0)
+	}
+	return nil
+}
+
+// hasKey reports whether rdata is the DNSKEY of one of the keys
+func hasKey(keys []*dnssec.PrivateKey, rdata dns.ResourceData) bool {
+	for _, key := range keys {
+		if bytes.Equal(key.DNSKEY.Bytes(), rdata.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// node is an owner name of the zone with the types present there
+type node struct {
+	name       dns.Name
+	types      []dns.QType
+	delegation bool // Name below the apex with NS records
+	occluded   bool // Name below a delegation: glue, not authoritative
+}
+
+// nodes returns the owner names of the zone in canonical order
+func (z *Zone) nodes() []*node {
+	byName := make(map[string]*node)
+	var nodes []*node
+	for _, rr := range z.Records {
+		key := rr.Name.Key()
+		n, ok := byName[key]
+		if !ok {
+			n = &node{name: rr.Name}
+			byName[key] = n
+			nodes = append(nodes, n)
+		}
+		if !hasType(n.types, rr.Type) {
+			n.types = append(n.types, rr.Type)
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name.Compare(nodes[j].name) < 0 })
+
+	var cuts []dns.Name
+	for _, n := range nodes {
+		for _, cut := range cuts {
+			if n.name.IsSubdomainOf(cut) {
+				n.occluded = true
+				break
+			}
+		}
+		if !n.occluded && hasType(n.types, dns.TypeNS) && n.name.Compare(z.Origin) != 0 {
+			n.delegation = true
+			cuts = append(cuts, n.name)
+		}
+	}
+	return nodes
+}
+
+// needsSignature reports whether an RRset is authoritative signed data:
+// at a delegation point only DS and NSEC are, below it nothing is
+func needsSignature(rr dns.ResourceRecord, nodes map[string]*node) bool {
+	switch rr.Type {
+	case dns.TypeRRSIG:
+		return false
+	case dns.TypeNSEC3:
+		return true
+	}
+
+	n, ok := nodes[rr.Name.Key()]
+	switch {
+	case !ok:
+		return true
+	case n.occluded:
+		return false
+	case n.delegation:
+		return rr.Type == dns.TypeDS || rr.Type == dns.TypeNSEC
+	default:
+		return true
+	}
+}
+
+// chainTypes returns the type bitmap of a node: at a delegation point
+// only NS and DS are authoritative
+func chainTypes(n *node) []dns.QType {
+	if !n.delegation {
+		return append([]dns.QType(nil), n.types...)
+	}
+	var types []dns.QType
+	for _, t := range n.types {
+		if t == dns.TypeNS || t == dns.TypeDS {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// sortTypes orders a type list numerically, as the bitmap encodes it
+func sortTypes(types []dns.QType) []dns.QType {
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// nsecChain links the authoritative names and delegation points of the
+// zone with NSEC records (RFC 4035 Section 2.3)
+func nsecChain(nodes []*node, ttl int32) []dns.ResourceRecord {
+	var chain []*node
+	for _, n := range nodes {
+		if !n.occluded {
+			chain = append(chain, n)
+		}
+	}
+
+	result := make([]dns.ResourceRecord, 0, len(chain))
+	for i, n := range chain {
+		next := chain[(i+1)%len(chain)]
+		types := sortTypes(append(chainTypes(n), dns.TypeRRSIG, dns.TypeNSEC))
+		nsec := records.NewNSECRecord(next.name, types)
+		result = append(result, dns.ResourceRecord{
+			Name:     n.name,
+			Type:     dns.TypeNSEC,
+			Class:    dns.ClassIN,
+			TTL:      ttl,
+			RDLength: uint16(len(nsec.Bytes())),
+			RData:    nsec,
+		})
+	}
+	return result
+}
+
+// nsec3Chain links the hashes of the authoritative names, delegation
+// points and empty non-terminals of the zone with NSEC3 records
+// (RFC 5155 Section 7.1). With opt-out, unsigned delegations are skipped.
+func nsec3Chain(nodes []*node, origin dns.Name, ttl int32, opts SignOptions) []dns.ResourceRecord {
+	type hashed struct {
+		hash  []byte
+		types []dns.QType
+	}
+
+	seen := make(map[string]bool)
+	var chain []hashed
+	add := func(name dns.Name, types []dns.QType) {
+		key := name.Key()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		chain = append(chain, hashed{hash: dnssec.NSEC3Hash(name, opts.Iterations, opts.Salt), types: sortTypes(types)})
+	}
+
+	for _, n := range nodes {
+		if n.occluded {
+			continue
+		}
+		types := chainTypes(n)
+		if n.delegation && !hasType(types, dns.TypeDS) {
+			if opts.OptOut {
+				continue
+			}
+		} else {
+			types = append(types, dns.TypeRRSIG)
+		}
+		add(n.name, types)
+	}
+
+	// Empty non-terminals between the names and the apex
+	for _, n := range nodes {
+		if n.occluded || (opts.OptOut && n.delegation && !hasType(n.types, dns.TypeDS)) {
+			continue
+		}
+		for parent := n.name.Parent(); parent.LabelCount() > origin.LabelCount(); parent = parent.Parent() {
+			add(parent, nil)
+		}
+	}
+
+	sort.Slice(chain, func(i, j int) bool { return bytes.Compare(chain[i].hash, chain[j].hash) < 0 })
+
+	var flags uint8
+	if opts.OptOut {
+		flags = records.NSEC3FlagOptOut
+	}
+
+	result := make([]dns.ResourceRecord, 0, len(chain))
+	for i, h := range chain {
+		nsec3 := &records.NSEC3Record{
+			HashAlgorithm: records.NSEC3HashSHA1,
+			Flags:         flags,
+			Iterations:    opts.Iterations,
+			Salt:          opts.Salt,
+			NextHashed:    chain[(i+1)%len(chain)].hash,
+			Types:         h.types,
+		}
+		owner := strings.ToLower(records.Base32Hex.EncodeToString(h.hash))
+		result = append(result, dns.ResourceRecord{
+			Name:     append(dns.Name{{Length: byte(len(owner)), Data: []byte(owner)}}, origin...),
+			Type:     dns.TypeNSEC3,
+			Class:    dns.ClassIN,
+			TTL:      ttl,
+			RDLength: uint16(len(nsec3.Bytes())),
+			RData:    nsec3,
+		})
+	}
+	return result
+}
+
+// hasType reports whether the type list contains the type
+func hasType(types []dns.QType, t dns.QType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
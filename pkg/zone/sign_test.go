@@ -0,0 +1,235 @@
+package zone
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/dnssec"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+var (
+	testInception  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testExpiration = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// testSigningKeys returns a key signing key and a zone signing key
+func testSigningKeys(t *testing.T) []*dnssec.PrivateKey {
+	t.Helper()
+	ksk, err := dnssec.GenerateKey(records.AlgorithmECDSAP256SHA256, records.DNSKEYFlagZone|records.DNSKEYFlagSEP)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	zsk, err := dnssec.GenerateKey(records.AlgorithmED25519, records.DNSKEYFlagZone)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	return []*dnssec.PrivateKey{ksk, zsk}
+}
+
+// signTestZone signs testZoneFile extended by a secure delegation
+func signTestZone(t *testing.T, keys []*dnssec.PrivateKey, opts SignOptions) *Zone {
+	t.Helper()
+	input := testZoneFile + "$ORIGIN example.com.\nsecure NS ns.example.net.\nsecure DS 1 13 2 ABCD\n"
+	zone, err := Parse(strings.NewReader(input), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	opts.Inception, opts.Expiration = testInception, testExpiration
+	signed, err := Sign(zone, keys, opts)
+	if err != nil {
+		t.Fatalf("Sign() returned error: %v", err)
+	}
+	return signed
+}
+
+// groupBy returns the RRsets and the signatures covering them
+func groupBy(rrs []dns.ResourceRecord) (map[string][]dns.ResourceRecord, map[string][]dns.ResourceRecord) {
+	rrsets := make(map[string][]dns.ResourceRecord)
+	sigs := make(map[string][]dns.ResourceRecord)
+	for _, rr := range rrs {
+		if sig, ok := rr.RData.(*records.RRSIGRecord); ok {
+			key := rr.Name.Canonical().String() + " " + sig.TypeCovered.String()
+			sigs[key] = append(sigs[key], rr)
+			continue
+		}
+		key := rr.Name.Canonical().String() + " " + rr.Type.String()
+		rrsets[key] = append(rrsets[key], rr)
+	}
+	return rrsets, sigs
+}
+
+func TestSignNSEC(t *testing.T) {
+	keys := testSigningKeys(t)
+	signed := signTestZone(t, keys, SignOptions{})
+	rrsets, sigs := groupBy(signed.Records)
+
+	validator := dnssec.NewValidator(rrsets["example.com DNSKEY"])
+	validator.SetClock(func() time.Time { return testInception.Add(time.Hour) })
+
+	unsigned := map[string]bool{
+		"sub.example.com NS":    true,
+		"ns.sub.example.com A":  true,
+		"secure.example.com NS": true,
+	}
+	for key, rrset := range rrsets {
+		if unsigned[key] {
+			if len(sigs[key]) != 0 {
+				t.Errorf("%s should not be signed", key)
+			}
+			continue
+		}
+		status, err := validator.VerifyRRset(rrset, sigs[key])
+		if status != dnssec.Secure {
+			t.Errorf("%s status = %v (%v), want SECURE", key, status, err)
+		}
+	}
+
+	// The key signing key alone signs the key RRsets
+	for _, key := range []string{"example.com DNSKEY", "example.com CDS", "example.com CDNSKEY"} {
+		if len(sigs[key]) != 1 || sigs[key][0].RData.(*records.RRSIGRecord).KeyTag != keys[0].DNSKEY.KeyTag() {
+			t.Errorf("%s should be signed by the key signing key only", key)
+		}
+	}
+	if len(rrsets["example.com CDS"]) != 1 || len(rrsets["example.com DNSKEY"]) != 2 {
+		t.Errorf("zone should publish 2 DNSKEY and 1 CDS records")
+	}
+
+	// The NSEC chain skips glue and loops back to the apex
+	var chain []string
+	for _, rr := range signed.Records {
+		if nsec, ok := rr.RData.(*records.NSECRecord); ok {
+			chain = append(chain, formatName(rr.Name)+" "+nsec.String())
+		}
+	}
+	expected := []string{
+		"example.com. host.deep.example.com. NS SOA RRSIG NSEC DNSKEY CDS CDNSKEY",
+		"host.deep.example.com. raw.deep.example.com. A RRSIG NSEC",
+		"raw.deep.example.com. ns1.example.com. RRSIG NSEC TYPE999",
+		"ns1.example.com. secure.example.com. A RRSIG NSEC",
+		"secure.example.com. sub.example.com. NS DS RRSIG NSEC",
+		"sub.example.com. www.example.com. NS RRSIG NSEC",
+		"www.example.com. example.com. A AAAA RRSIG NSEC",
+	}
+	if strings.Join(chain, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("NSEC chain = %q, want %q", chain, expected)
+	}
+	if _, ok := rrsets["ns.sub.example.com NSEC"]; ok {
+		t.Error("glue below a delegation should not get an NSEC record")
+	}
+}
+
+func TestSignNSEC3OptOut(t *testing.T) {
+	keys := testSigningKeys(t)
+	signed := signTestZone(t, keys, SignOptions{NSEC3: true, OptOut: true, Salt: []byte{0xAB}})
+	rrsets, sigs := groupBy(signed.Records)
+
+	hashes := make(map[string]*records.NSEC3Record)
+	for _, rr := range signed.Records {
+		if nsec3, ok := rr.RData.(*records.NSEC3Record); ok {
+			hashes[string(rr.Name[0].Data)] = nsec3
+			if !nsec3.OptOut() {
+				t.Errorf("NSEC3 %s should have the opt-out flag", dns.LabelsToString(rr.Name))
+			}
+			if len(sigs[rr.Name.Canonical().String()+" NSEC3"]) == 0 {
+				t.Errorf("NSEC3 %s is not signed", dns.LabelsToString(rr.Name))
+			}
+		}
+	}
+
+	hashOf := func(name string) string {
+		hash := dnssec.NSEC3Hash(dns.MustParseName(name), 0, []byte{0xAB})
+		return strings.ToLower(records.Base32Hex.EncodeToString(hash))
+	}
+	for _, name := range []string{"example.com", "deep.example.com", "host.deep.example.com", "secure.example.com", "www.example.com"} {
+		if _, ok := hashes[hashOf(name)]; !ok {
+			t.Errorf("NSEC3 chain lacks %s", name)
+		}
+	}
+	// Unsigned delegations and glue are opted out
+	for _, name := range []string{"sub.example.com", "ns.sub.example.com"} {
+		if _, ok := hashes[hashOf(name)]; ok {
+			t.Errorf("NSEC3 chain should not contain %s", name)
+		}
+	}
+	if apex := hashes[hashOf("example.com")]; apex == nil || !apex.HasType(dns.TypeNSEC3PARAM) {
+		t.Error("apex NSEC3 should list NSEC3PARAM")
+	}
+	if ent := hashes[hashOf("deep.example.com")]; ent == nil || len(ent.Types) != 0 {
+		t.Error("empty non-terminal NSEC3 should have an empty type bitmap")
+	}
+	if _, ok := rrsets["example.com NSEC3PARAM"]; !ok {
+		t.Error("signed zone lacks NSEC3PARAM")
+	}
+
+	// The signed zone survives a round trip through master file format
+	var buf bytes.Buffer
+	if _, err := signed.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() returned error: %v", err)
+	}
+	reparsed, err := Parse(&buf, "example.com")
+	if err != nil {
+		t.Fatalf("Parse() of signed zone returned error: %v", err)
+	}
+	if len(reparsed.Records) != len(signed.Records) {
+		t.Errorf("reparsed zone has %d records, want %d", len(reparsed.Records), len(signed.Records))
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	keys := testSigningKeys(t)
+	zone, err := Parse(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	if _, err := Sign(zone, nil, SignOptions{}); err == nil {
+		t.Error("Sign() without keys should return error")
+	}
+	if _, err := Sign(&Zone{Origin: zone.Origin}, keys, SignOptions{}); err == nil {
+		t.Error("Sign() without SOA should return error")
+	}
+	if _, err := Sign(zone, keys, SignOptions{Inception: testExpiration, Expiration: testInception}); err == nil {
+		t.Error("Sign() with expiration before inception should return error")
+	}
+
+	outside := &Zone{Origin: zone.Origin, Records: append(append([]dns.ResourceRecord{}, zone.Records...), dns.ResourceRecord{
+		Name: dns.MustParseName("example.org"), Type: dns.TypeA, Class: dns.ClassIN, RData: zone.Records[3].RData,
+	})}
+	if _, err := Sign(outside, keys, SignOptions{}); err == nil {
+		t.Error("Sign() with out-of-zone data should return error")
+	}
+}
+
+func TestSignNormalizesRRsets(t *testing.T) {
+	input := testZoneFile + "$ORIGIN example.com.\nwww 60 A 192.0.2.1\nwww 600 A 192.0.2.1\n"
+	zone, err := Parse(strings.NewReader(input), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	signed, err := Sign(zone, testSigningKeys(t), SignOptions{Inception: testInception, Expiration: testExpiration})
+	if err != nil {
+		t.Fatalf("Sign() returned error: %v", err)
+	}
+
+	rrsets, sigs := groupBy(signed.Records)
+	rrset, err := dns.NewRRset(rrsets["www.example.com A"])
+	if err != nil {
+		t.Fatalf("NewRRset() returned error: %v", err)
+	}
+	if rrset.Len() != 1 {
+		t.Errorf("www.example.com A has %d records, want duplicates removed", rrset.Len())
+	}
+	if !rrset.ConsistentTTL() || rrset.TTL() != 60 {
+		t.Errorf("www.example.com A TTL = %d (consistent %v), want 60 for every record", rrset.TTL(), rrset.ConsistentTTL())
+	}
+	for _, sig := range sigs["www.example.com A"] {
+		if ttl := sig.RData.(*records.RRSIGRecord).OriginalTTL; ttl != 60 {
+			t.Errorf("RRSIG original TTL = %d, want 60", ttl)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package zone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"dklbreitling/goDNS/pkg/dns"
+)
+
+// WriteTo writes the zone in master file format: a $ORIGIN directive
+// followed by one record per line with absolute owner names
+func (z *Zone) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+
+	n, err := fmt.Fprintf(bw, "$ORIGIN %s\n", formatName(z.Origin))
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for _, rr := range z.Records {
+		n, err := fmt.Fprintf(bw, "%s\t%d\t%s\t%s\t%s\n",
+			formatName(rr.Name), rr.TTL, className(rr.Class), typeName(rr.Type), formatRData(rr.RData))
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, bw.Flush()
+}
+
+// typeName returns the mnemonic of a type, or TYPEnnn for unknown types
+func typeName(t dns.QType) string {
+	if name := t.String(); name != "UNKNOWN" {
+		return name
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// className returns the mnemonic of a class, or CLASSnnn for unknown classes
+func className(c dns.QClass) string {
+	if name := c.String(); name != "UNKNOWN" {
+		return name
+	}
+	return "CLASS" + strconv.Itoa(int(c))
+}
@@ -0,0 +1,51 @@
+package zone
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	zone, err := Parse(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := zone.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() returned error: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, buf.Len())
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	expected := []string{
+		"$ORIGIN example.com.",
+		"example.com.\t3600\tIN\tSOA\tns1.example.com. hostmaster.example.com. 2024010101 7200 900 604800 300",
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], line)
+		}
+	}
+	if !strings.Contains(buf.String(), "raw.deep.example.com.\t3600\tIN\tTYPE999\t\\# 2 ABCD\n") {
+		t.Errorf("WriteTo() output lacks generic record:\n%s", buf.String())
+	}
+
+	// The output parses back to the same records
+	reparsed, err := Parse(&buf, "invalid.")
+	if err != nil {
+		t.Fatalf("Parse() of written zone returned error: %v", err)
+	}
+	if len(reparsed.Records) != len(zone.Records) {
+		t.Fatalf("reparsed zone has %d records, want %d", len(reparsed.Records), len(zone.Records))
+	}
+	for i, rr := range reparsed.Records {
+		if !bytes.Equal(rr.RData.Bytes(), zone.Records[i].RData.Bytes()) || rr.TTL != zone.Records[i].TTL {
+			t.Errorf("reparsed record %d = %v, want %v", i, rr, zone.Records[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package zone reads, writes and signs DNS zones in master file format
+// (RFC 1035 Section 5)
+package zone
+
+import (
+	"sort"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+// Zone is the set of records of one zone below its origin
+type Zone struct {
+	Origin  dns.Name
+	Records []dns.ResourceRecord
+}
+
+// SOA returns the SOA record at the zone origin, or nil if there is none
+func (z *Zone) SOA() *dns.ResourceRecord {
+	for i, rr := range z.Records {
+		if rr.Type == dns.TypeSOA && rr.Name.Compare(z.Origin) == 0 {
+			return &z.Records[i]
+		}
+	}
+	return nil
+}
+
+// Sort orders the records canonically by owner name (RFC 4034 Section 6.1),
+// then by type with the SOA first. Signatures follow the RRset they cover.
+func (z *Zone) Sort() {
+	sort.SliceStable(z.Records, func(i, j int) bool {
+		a, b := z.Records[i], z.Records[j]
+		if c := a.Name.Compare(b.Name); c != 0 {
+			return c < 0
+		}
+		if ka, kb := sortType(a), sortType(b); ka != kb {
+			return ka < kb
+		}
+		return a.Type != dns.TypeRRSIG && b.Type == dns.TypeRRSIG
+	})
+}
+
+// sortType returns the type a record is sorted by: signatures sort with
+// the type they cover and the SOA sorts before everything else
+func sortType(rr dns.ResourceRecord) int {
+	t := rr.Type
+	if sig, ok := rr.RData.(*records.RRSIGRecord); ok {
+		t = sig.TypeCovered
+	}
+	if t == dns.TypeSOA {
+		return -1
+	}
+	return int(t)
+}
@@ -0,0 +1,54 @@
+package zone
+
+import (
+	"strings"
+	"testing"
+
+	"dklbreitling/goDNS/pkg/dns"
+	"dklbreitling/goDNS/pkg/records"
+)
+
+func TestZoneSOA(t *testing.T) {
+	zone, err := Parse(strings.NewReader(testZoneFile), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if soa := zone.SOA(); soa == nil || soa.Type != dns.TypeSOA {
+		t.Errorf("SOA() = %v, want the SOA record", soa)
+	}
+
+	empty := &Zone{Origin: dns.MustParseName("example.com")}
+	if soa := empty.SOA(); soa != nil {
+		t.Errorf("SOA() of empty zone = %v, want nil", soa)
+	}
+}
+
+func TestZoneSort(t *testing.T) {
+	rr := func(name string, qtype dns.QType, rdata dns.ResourceData) dns.ResourceRecord {
+		return dns.ResourceRecord{Name: dns.MustParseName(name), Type: qtype, Class: dns.ClassIN, RData: rdata}
+	}
+	sig := func(covered dns.QType) dns.ResourceData {
+		return &records.RRSIGRecord{TypeCovered: covered}
+	}
+	generic := records.NewGenericRecord(dns.TypeNULL, nil)
+
+	zone := &Zone{Records: []dns.ResourceRecord{
+		rr("b.example.com", dns.TypeA, generic),
+		rr("example.com", dns.TypeRRSIG, sig(dns.TypeNS)),
+		rr("example.com", dns.TypeNS, generic),
+		rr("A.example.com", dns.TypeA, generic),
+		rr("example.com", dns.TypeRRSIG, sig(dns.TypeSOA)),
+		rr("example.com", dns.TypeSOA, generic),
+	}}
+	zone.Sort()
+
+	expected := []string{
+		"example.com SOA", "example.com RRSIG", "example.com NS", "example.com RRSIG",
+		"A.example.com A", "b.example.com A",
+	}
+	for i, e := range expected {
+		if result := dns.LabelsToString(zone.Records[i].Name) + " " + zone.Records[i].Type.String(); result != e {
+			t.Errorf("record %d = %q, want %q", i, result, e)
+		}
+	}
+}